@@ -0,0 +1,111 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// accumulatorKeySize is the size of the keys (indexes) of an Accumulator
+const accumulatorKeySize = 8
+
+// Accumulator is an authenticated append-only log over a sum trie, assigning
+// every appended value the next index as its key. The leaves are indexed
+// directly by their 64-bit index, see NewIndexPathHasher, so the trie stays
+// balanced, and as the root of a sum trie commits to its number of leaves the
+// root also commits to the length of the log.
+type Accumulator struct {
+	trie *SMST
+}
+
+// NewAccumulator returns a new, empty Accumulator using the node store
+// provided. Any PathHasher provided in the options is ignored.
+func NewAccumulator(nodes kvstore.MapStore, hasher hash.Hash, options ...TrieSpecOption) *Accumulator {
+	return &Accumulator{
+		trie: NewSparseMerkleSumTrie(nodes, hasher, accumulatorOptions(options)...),
+	}
+}
+
+// ImportAccumulator returns an Accumulator with the root hash provided
+func ImportAccumulator(nodes kvstore.MapStore, hasher hash.Hash, root []byte, options ...TrieSpecOption) *Accumulator {
+	return &Accumulator{
+		trie: ImportSparseMerkleSumTrie(nodes, hasher, root, accumulatorOptions(options)...),
+	}
+}
+
+// accumulatorOptions appends the index PathHasher to the options provided
+func accumulatorOptions(options []TrieSpecOption) []TrieSpecOption {
+	return append(append([]TrieSpecOption{}, options...), WithPathHasher(NewIndexPathHasher(accumulatorKeySize)))
+}
+
+// Len returns the number of values appended to the accumulator
+func (acc *Accumulator) Len() uint64 {
+	return acc.trie.Count()
+}
+
+// Append appends the value to the accumulator, returning its index. Empty
+// values cannot be appended as they could not be proven.
+func (acc *Accumulator) Append(value []byte) (uint64, error) {
+	if len(value) == 0 {
+		return 0, errors.New("cannot append an empty value")
+	}
+	index := acc.Len()
+	if err := acc.trie.Update(IndexKey(index, accumulatorKeySize), value, 0); err != nil {
+		return 0, err
+	}
+	return index, nil
+}
+
+// Get returns the value hash of the value at the given index, or
+// ErrKeyNotFound if there is no value at the index.
+func (acc *Accumulator) Get(index uint64) ([]byte, error) {
+	if index >= acc.Len() {
+		return nil, ErrKeyNotFound
+	}
+	valueHash, _, err := acc.trie.Get(IndexKey(index, accumulatorKeySize))
+	return valueHash, err
+}
+
+// Prove generates a proof of the value at the given index, or returns
+// ErrKeyNotFound if there is no value at the index.
+func (acc *Accumulator) Prove(index uint64) (*SparseMerkleProof, error) {
+	if index >= acc.Len() {
+		return nil, ErrKeyNotFound
+	}
+	return acc.trie.Prove(IndexKey(index, accumulatorKeySize))
+}
+
+// Root returns the root hash of the accumulator, committing to its length
+func (acc *Accumulator) Root() MerkleRoot {
+	return acc.trie.Root()
+}
+
+// Commit persists the accumulator's nodes to its node store
+func (acc *Accumulator) Commit() error {
+	return acc.trie.Commit()
+}
+
+// Spec returns the TrieSpec proofs of the accumulator are verified with
+func (acc *Accumulator) Spec() *TrieSpec {
+	return acc.trie.Spec()
+}
+
+// Close closes the accumulator and its node store, see SMT.Close
+func (acc *Accumulator) Close() error {
+	return acc.trie.Close()
+}
+
+// VerifyAccumulatorProof verifies a proof that the value is at the given
+// index of the accumulator with the root provided, rejecting indexes beyond
+// the length of the log the root commits to.
+func VerifyAccumulatorProof(proof *SparseMerkleProof, root []byte, index uint64, value []byte, spec *TrieSpec) (bool, error) {
+	if len(root) != spec.hashSize() || bytes.Equal(root, spec.placeholder()) {
+		return false, nil
+	}
+	if _, count := parseSumAndCount(root); index >= count {
+		return false, nil
+	}
+	return VerifySumProof(proof, root, IndexKey(index, accumulatorKeySize), value, 0, 1, spec)
+}
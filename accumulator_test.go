@@ -0,0 +1,67 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestAccumulator(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	acc := NewAccumulator(nodes, sha256.New())
+	require.Zero(t, acc.Len())
+	_, err := acc.Prove(0)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = acc.Append(nil)
+	require.Error(t, err)
+
+	const n = 100
+	for i := uint64(0); i < n; i++ {
+		index, err := acc.Append([]byte(fmt.Sprintf("entry-%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, i, index)
+	}
+	require.Equal(t, uint64(n), acc.Len())
+	require.NoError(t, acc.Commit())
+	root := acc.Root()
+
+	// Appending resumes at the imported length
+	acc = ImportAccumulator(nodes, sha256.New(), root)
+	require.Equal(t, uint64(n), acc.Len())
+	for _, index := range []uint64{0, 1, 63, n - 1} {
+		value := []byte(fmt.Sprintf("entry-%d", index))
+		valueHash, err := acc.Get(index)
+		require.NoError(t, err)
+		require.Equal(t, acc.Spec().valueHash(value), valueHash)
+
+		proof, err := acc.Prove(index)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(proof.SideNodes), 64)
+		valid, err := VerifyAccumulatorProof(proof, root, index, value, acc.Spec())
+		require.NoError(t, err)
+		require.True(t, valid, "index %d", index)
+		valid, err = VerifyAccumulatorProof(proof, root, index, []byte("forged"), acc.Spec())
+		require.NoError(t, err)
+		require.False(t, valid)
+	}
+	_, err = acc.Get(n)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	index, err := acc.Append([]byte("next"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(n), index)
+
+	// Proofs beyond the length the root commits to are rejected
+	proof, err := acc.Prove(n)
+	require.NoError(t, err)
+	valid, err := VerifyAccumulatorProof(proof, root, n, []byte("next"), acc.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+	valid, err = VerifyAccumulatorProof(proof, acc.Root(), n, []byte("next"), acc.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+}
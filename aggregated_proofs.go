@@ -0,0 +1,175 @@
+package smt
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+)
+
+func init() {
+	gob.Register(SparseMerkleAggregatedProof{})
+}
+
+// ErrKeyPresent is returned when proving the absence of a key which is
+// present in the trie.
+var ErrKeyPresent = errors.New("key present")
+
+// SparseMerkleAggregatedProof bundles several SparseMerkleProofs generated
+// against the same root, storing every side node shared between the proofs
+// once. It is a lightweight alternative to multiproofs for transferring
+// proofs which have already been generated.
+type SparseMerkleAggregatedProof struct {
+	// SideNodes holds every unique side node of the aggregated proofs
+	SideNodes [][]byte
+
+	// Proofs holds the per-key data of the aggregated proofs, in the order
+	// they were aggregated in.
+	Proofs []AggregatedProofEntry
+}
+
+// AggregatedProofEntry is the data of a single proof within a
+// SparseMerkleAggregatedProof.
+type AggregatedProofEntry struct {
+	// SideNodeIndices are the indices into the aggregated proof's side nodes
+	// of the side nodes of the proof, in the order of the original proof.
+	SideNodeIndices []uint32
+
+	// NonMembershipLeafData is the NonMembershipLeafData of the original proof
+	NonMembershipLeafData []byte
+
+	// SiblingData is the SiblingData of the original proof
+	SiblingData []byte
+}
+
+// AggregateProofs aggregates the proofs provided into a single
+// SparseMerkleAggregatedProof, deduplicating their side nodes.
+func AggregateProofs(proofs []*SparseMerkleProof) (*SparseMerkleAggregatedProof, error) {
+	aggregated := &SparseMerkleAggregatedProof{
+		Proofs: make([]AggregatedProofEntry, 0, len(proofs)),
+	}
+	indices := make(map[string]uint32)
+	for i, proof := range proofs {
+		if proof == nil {
+			return nil, fmt.Errorf("nil proof at index %d", i)
+		}
+		entry := AggregatedProofEntry{
+			SideNodeIndices:       make([]uint32, 0, len(proof.SideNodes)),
+			NonMembershipLeafData: proof.NonMembershipLeafData,
+			SiblingData:           proof.SiblingData,
+		}
+		for _, sideNode := range proof.SideNodes {
+			idx, ok := indices[string(sideNode)]
+			if !ok {
+				idx = uint32(len(aggregated.SideNodes))
+				indices[string(sideNode)] = idx
+				aggregated.SideNodes = append(aggregated.SideNodes, sideNode)
+			}
+			entry.SideNodeIndices = append(entry.SideNodeIndices, idx)
+		}
+		aggregated.Proofs = append(aggregated.Proofs, entry)
+	}
+	return aggregated, nil
+}
+
+// Marshal serialises the SparseMerkleAggregatedProof to bytes
+func (proof *SparseMerkleAggregatedProof) Marshal() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(proof); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal deserialises the SparseMerkleAggregatedProof from bytes
+func (proof *SparseMerkleAggregatedProof) Unmarshal(bz []byte) error {
+	buf := bytes.NewBuffer(bz)
+	dec := gob.NewDecoder(buf)
+	return dec.Decode(proof)
+}
+
+// Split returns the individual proofs aggregated in the proof, in the order
+// they were aggregated in. An error is returned if a side node index is out
+// of range.
+func (proof *SparseMerkleAggregatedProof) Split() ([]*SparseMerkleProof, error) {
+	proofs := make([]*SparseMerkleProof, 0, len(proof.Proofs))
+	for i, entry := range proof.Proofs {
+		var sideNodes [][]byte
+		for _, idx := range entry.SideNodeIndices {
+			if int(idx) >= len(proof.SideNodes) {
+				return nil, fmt.Errorf(
+					"side node index %d of proof %d out of range: have %d side nodes",
+					idx, i, len(proof.SideNodes),
+				)
+			}
+			sideNodes = append(sideNodes, proof.SideNodes[idx])
+		}
+		proofs = append(proofs, &SparseMerkleProof{
+			SideNodes:             sideNodes,
+			NonMembershipLeafData: entry.NonMembershipLeafData,
+			SiblingData:           entry.SiblingData,
+		})
+	}
+	return proofs, nil
+}
+
+// VerifyAggregatedProof verifies every proof aggregated in the proof against
+// the root, for the key and value at the same index as the proof, returning
+// false if any of them is invalid.
+func VerifyAggregatedProof(
+	proof *SparseMerkleAggregatedProof,
+	root []byte,
+	keys, values [][]byte,
+	spec *TrieSpec,
+) (bool, error) {
+	if len(keys) != len(proof.Proofs) || len(values) != len(proof.Proofs) {
+		return false, errors.Join(ErrBadProof, fmt.Errorf(
+			"got %d keys and %d values for %d proofs", len(keys), len(values), len(proof.Proofs),
+		))
+	}
+	proofs, err := proof.Split()
+	if err != nil {
+		return false, errors.Join(ErrBadProof, err)
+	}
+	for i, p := range proofs {
+		valid, err := VerifyProof(p, root, keys[i], values[i], spec)
+		if err != nil || !valid {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// ProveAbsent generates a single SparseMerkleAggregatedProof that none of the
+// keys provided are present in the trie, sharing the side nodes of their
+// non-membership proofs. An error wrapping ErrKeyPresent is returned if any
+// of the keys is present.
+func (smt *SMT) ProveAbsent(keys [][]byte) (*SparseMerkleAggregatedProof, error) {
+	proofs := make([]*SparseMerkleProof, 0, len(keys))
+	for _, key := range keys {
+		value, err := smt.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(value, defaultEmptyValue) {
+			return nil, errors.Join(ErrKeyPresent, fmt.Errorf("key %x", key))
+		}
+		proof, err := smt.Prove(key)
+		if err != nil {
+			return nil, err
+		}
+		proofs = append(proofs, proof)
+	}
+	return AggregateProofs(proofs)
+}
+
+// VerifyAbsentProof verifies a proof generated by ProveAbsent, that none of
+// the keys provided are present under the root.
+func VerifyAbsentProof(proof *SparseMerkleAggregatedProof, root []byte, keys [][]byte, spec *TrieSpec) (bool, error) {
+	values := make([][]byte, len(keys))
+	for i := range values {
+		values[i] = defaultEmptyValue
+	}
+	return VerifyAggregatedProof(proof, root, keys, values, spec)
+}
@@ -0,0 +1,117 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestAggregateProofs(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := 0; i < 50; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		require.NoError(t, trie.Update(key, []byte(fmt.Sprintf("value-%d", i))))
+	}
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+
+	// Prove a mix of present and absent keys
+	var keys, values [][]byte
+	var proofs []*SparseMerkleProof
+	totalSideNodes := 0
+	for i := 0; i < 60; i += 3 {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		value := []byte(fmt.Sprintf("value-%d", i))
+		if i >= 50 {
+			value = defaultEmptyValue
+		}
+		proof, err := trie.Prove(key)
+		require.NoError(t, err)
+		keys, values, proofs = append(keys, key), append(values, value), append(proofs, proof)
+		totalSideNodes += len(proof.SideNodes)
+	}
+
+	aggregated, err := AggregateProofs(proofs)
+	require.NoError(t, err)
+	require.Len(t, aggregated.Proofs, len(proofs))
+	require.Less(t, len(aggregated.SideNodes), totalSideNodes)
+
+	bz, err := aggregated.Marshal()
+	require.NoError(t, err)
+	decoded := new(SparseMerkleAggregatedProof)
+	require.NoError(t, decoded.Unmarshal(bz))
+
+	split, err := decoded.Split()
+	require.NoError(t, err)
+	require.Equal(t, proofs, split)
+
+	valid, err := VerifyAggregatedProof(decoded, root, keys, values, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// A wrong value invalidates the aggregated proof
+	values[0] = []byte("wrong")
+	valid, err = VerifyAggregatedProof(decoded, root, keys, values, trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+
+	// Mismatched inputs and out of range indices are rejected
+	_, err = VerifyAggregatedProof(decoded, root, keys[1:], values, trie.Spec())
+	require.ErrorIs(t, err, ErrBadProof)
+	decoded.Proofs[0].SideNodeIndices = append(decoded.Proofs[0].SideNodeIndices, uint32(len(decoded.SideNodes)))
+	_, err = decoded.Split()
+	require.Error(t, err)
+	_, err = VerifyAggregatedProof(decoded, root, keys, values, trie.Spec())
+	require.ErrorIs(t, err, ErrBadProof)
+
+	_, err = AggregateProofs([]*SparseMerkleProof{nil})
+	require.Error(t, err)
+}
+
+func TestSMT_ProveAbsent(t *testing.T) {
+	for _, sumTrie := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sumTrie=%t", sumTrie), func(t *testing.T) {
+			var trie *SMT
+			if sumTrie {
+				smst := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+				for i := 0; i < 50; i++ {
+					key := []byte(fmt.Sprintf("allowed-%d", i))
+					require.NoError(t, smst.Update(key, key, uint64(i)))
+				}
+				trie = smst.SMT
+			} else {
+				trie = NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+				for i := 0; i < 50; i++ {
+					key := []byte(fmt.Sprintf("allowed-%d", i))
+					require.NoError(t, trie.Update(key, key))
+				}
+			}
+			root := trie.Root()
+
+			var denied [][]byte
+			for i := 0; i < 20; i++ {
+				denied = append(denied, []byte(fmt.Sprintf("denied-%d", i)))
+			}
+			proof, err := trie.ProveAbsent(denied)
+			require.NoError(t, err)
+			valid, err := VerifyAbsentProof(proof, root, denied, trie.Spec())
+			require.NoError(t, err)
+			require.True(t, valid)
+
+			// The proof does not verify for present keys
+			keys := append([][]byte{}, denied...)
+			keys[3] = []byte("allowed-3")
+			valid, err = VerifyAbsentProof(proof, root, keys, trie.Spec())
+			require.NoError(t, err)
+			require.False(t, valid)
+
+			// Present keys cannot be proven absent
+			_, err = trie.ProveAbsent(keys)
+			require.ErrorIs(t, err, ErrKeyPresent)
+		})
+	}
+}
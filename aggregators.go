@@ -0,0 +1,53 @@
+package smt
+
+// AggregateFunc combines the 8 byte values of the two non-empty children of
+// an inner node of a sum trie into the value committed to by the inner node.
+// It must be associative (i.e. the combination of a monoid) for the value of
+// the root to be independent of the shape of the trie. Empty subtries are the
+// identity and are never passed to the function.
+type AggregateFunc func(left, right uint64) uint64
+
+// SumAggregate is the default AggregateFunc of a sum trie, making every node
+// commit to the total weight of the leaves beneath it.
+func SumAggregate(left, right uint64) uint64 { return left + right }
+
+// MinAggregate is an AggregateFunc making every node commit to the minimum
+// weight of the leaves beneath it.
+func MinAggregate(left, right uint64) uint64 {
+	if right < left {
+		return right
+	}
+	return left
+}
+
+// MaxAggregate is an AggregateFunc making every node commit to the maximum
+// weight of the leaves beneath it.
+func MaxAggregate(left, right uint64) uint64 {
+	if right > left {
+		return right
+	}
+	return left
+}
+
+// WithAggregator returns an Option that sets the AggregateFunc used by a sum
+// trie to combine the weights of its nodes' children, replacing the default
+// SumAggregate. The weight committed to by the root, returned by Sum, is then
+// the aggregate of all the leaves in the trie. Proofs must be verified with a
+// TrieSpec using the same AggregateFunc.
+func WithAggregator(agg AggregateFunc) TrieSpecOption {
+	return func(ts *TrieSpec) { ts.agg = agg }
+}
+
+// aggregate combines the weights of the two children of an inner node, where
+// a child with a count of zero is empty and so the identity.
+func (agg AggregateFunc) aggregate(leftSum, leftCount, rightSum, rightCount uint64) uint64 {
+	switch {
+	case agg == nil:
+		return leftSum + rightSum
+	case leftCount == 0:
+		return rightSum
+	case rightCount == 0:
+		return leftSum
+	}
+	return agg(leftSum, rightSum)
+}
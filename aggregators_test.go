@@ -0,0 +1,75 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMST_Aggregators(t *testing.T) {
+	weights := map[string]uint64{"a": 7, "b": 3, "c": 9, "d": 5}
+	tests := []struct {
+		name     string
+		agg      AggregateFunc
+		expected uint64
+		deleted  uint64 // the aggregate after deleting "b"
+	}{
+		{"sum", SumAggregate, 24, 21},
+		{"min", MinAggregate, 3, 5},
+		{"max", MaxAggregate, 9, 9},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nodes := simplemap.NewSimpleMap()
+			trie := NewSparseMerkleSumTrie(nodes, sha256.New(), WithAggregator(test.agg))
+			for key, weight := range weights {
+				require.NoError(t, trie.Update([]byte(key), []byte(key), weight))
+			}
+			require.Equal(t, test.expected, trie.Sum())
+			require.Equal(t, uint64(len(weights)), trie.Count())
+			require.NoError(t, trie.Commit())
+
+			// Proofs verify against the aggregate root with the same aggregator
+			root := trie.Root()
+			proof, err := trie.Prove([]byte("b"))
+			require.NoError(t, err)
+			valid, err := VerifySumProof(proof, root, []byte("b"), []byte("b"), 3, 1, trie.Spec())
+			require.NoError(t, err)
+			require.True(t, valid)
+			valid, err = VerifySumProof(proof, root, []byte("b"), []byte("b"), 4, 1, trie.Spec())
+			require.NoError(t, err)
+			require.False(t, valid)
+
+			// The aggregate is maintained when resolving persisted nodes
+			imported := ImportSparseMerkleSumTrie(nodes, sha256.New(), root, WithAggregator(test.agg))
+			require.NoError(t, imported.Delete([]byte("b")))
+			require.Equal(t, test.deleted, imported.Sum())
+		})
+	}
+}
+
+func TestSMST_MinAggregate_ArgMin(t *testing.T) {
+	trie := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New(), WithAggregator(MinAggregate))
+	require.Equal(t, uint64(0), trie.Sum())
+	for i := uint64(1); i <= 32; i++ {
+		require.NoError(t, trie.Update([]byte{byte(i)}, []byte{byte(i)}, 100-i))
+	}
+	require.Equal(t, uint64(68), trie.Sum())
+
+	// A proof of the leaf holding the root's aggregate authenticates the minimum
+	root := trie.Root()
+	proof, err := trie.Prove([]byte{32})
+	require.NoError(t, err)
+	valid, err := VerifySumProof(proof, root, []byte{32}, []byte{32}, root.Sum(), 1, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// Verifying with a spec using a different aggregator fails
+	sumSpec := NewTrieSpec(sha256.New(), true)
+	valid, err = VerifySumProof(proof, root, []byte{32}, []byte{32}, root.Sum(), 1, &sumSpec)
+	require.NoError(t, err)
+	require.False(t, valid)
+}
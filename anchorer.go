@@ -0,0 +1,186 @@
+package smt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrAlreadyAnchored is returned when submitting an event whose ID has
+	// already been anchored or is pending.
+	ErrAlreadyAnchored = errors.New("event already anchored")
+	// ErrAnchorPending is returned when requesting a receipt for an event
+	// which has not been folded into an anchored root yet.
+	ErrAnchorPending = errors.New("event pending anchoring")
+)
+
+// AnchorEvent is an event from an external stream to be anchored, identified
+// by its ID and committing to its payload by hash.
+type AnchorEvent struct {
+	ID          []byte
+	PayloadHash []byte
+}
+
+// AnchorReceipt proves an event was anchored in the root published at the
+// given height.
+type AnchorReceipt struct {
+	Height uint64
+	Root   MerkleRoot
+	Proof  *SparseMerkleProof
+}
+
+// Verify checks the receipt proves the event provided is included in the
+// receipt's root, the caller must check the root itself is trusted (e.g.
+// against the Anchorer's RootOracle or wherever its roots are published).
+func (receipt *AnchorReceipt) Verify(event AnchorEvent, spec *TrieSpec) (bool, error) {
+	return VerifyProof(receipt.Proof, receipt.Root, event.ID, event.PayloadHash, spec)
+}
+
+// Anchorer folds an external stream of events into a trie, committing and
+// publishing a new root at every flush, turning the trie into an anchoring
+// (timestamping) service: once an event is anchored its receipt proves it
+// existed no later than the root it was anchored in was published.
+//
+// Events are append-only, an ID can only be anchored once. The roots are
+// published to the Anchorer's RootOracle, at consecutive heights starting
+// from one.
+type Anchorer struct {
+	mu      sync.Mutex
+	trie    *SMT
+	pending []AnchorEvent
+	ids     map[string]struct{} // IDs of the pending events
+	height  uint64
+	roots   *MemoryRootOracle
+}
+
+// NewAnchorer returns a new Anchorer folding events into the trie provided,
+// which should not be modified by anything else.
+func NewAnchorer(trie *SMT) *Anchorer {
+	return &Anchorer{
+		trie:  trie,
+		ids:   make(map[string]struct{}),
+		roots: NewMemoryRootOracle(MonotonicRootPolicy),
+	}
+}
+
+// Oracle returns the RootOracle the anchored roots are published to
+func (a *Anchorer) Oracle() RootOracle {
+	return a.roots
+}
+
+// Submit queues the event to be anchored on the next flush, returning
+// ErrAlreadyAnchored if its ID was already submitted.
+func (a *Anchorer) Submit(event AnchorEvent) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.ids[string(event.ID)]; ok {
+		return ErrAlreadyAnchored
+	}
+	anchored, err := a.trie.Get(event.ID)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(anchored, defaultEmptyValue) {
+		return ErrAlreadyAnchored
+	}
+	a.ids[string(event.ID)] = struct{}{}
+	a.pending = append(a.pending, AnchorEvent{
+		ID:          bytes.Clone(event.ID),
+		PayloadHash: bytes.Clone(event.PayloadHash),
+	})
+	return nil
+}
+
+// Flush folds all pending events into the trie, commits it and publishes its
+// root at the next height. If no events are pending the latest published root
+// is returned, or ErrRootNotFound if none was published yet.
+func (a *Anchorer) Flush() (RootUpdate, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.pending) == 0 {
+		return a.roots.LatestRoot()
+	}
+	for _, event := range a.pending {
+		if err := a.trie.Update(event.ID, event.PayloadHash); err != nil {
+			return RootUpdate{}, err
+		}
+	}
+	if err := a.trie.Commit(); err != nil {
+		return RootUpdate{}, err
+	}
+	a.pending = nil
+	a.ids = make(map[string]struct{})
+	a.height++
+	if err := a.roots.Update(a.height, a.trie.Root()); err != nil {
+		return RootUpdate{}, err
+	}
+	return a.roots.LatestRoot()
+}
+
+// Receipt returns the receipt proving the event with the given ID is included
+// in the latest published root. ErrAnchorPending is returned if the event has
+// not been flushed yet and ErrKeyNotFound if it was never submitted.
+func (a *Anchorer) Receipt(id []byte) (*AnchorReceipt, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.ids[string(id)]; ok {
+		return nil, ErrAnchorPending
+	}
+	anchored, err := a.trie.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(anchored, defaultEmptyValue) {
+		return nil, ErrKeyNotFound
+	}
+	proof, err := a.trie.Prove(id)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := a.roots.LatestRoot()
+	if err != nil {
+		return nil, err
+	}
+	return &AnchorReceipt{Height: latest.Height, Root: latest.Root, Proof: proof}, nil
+}
+
+// Run submits every event received on the channel provided and flushes the
+// pending events every interval, until the channel is closed or the context
+// is cancelled. Events whose ID was already submitted are dropped. Pending
+// events are flushed before returning when the channel is closed.
+func (a *Anchorer) Run(ctx context.Context, events <-chan AnchorEvent, interval time.Duration) error {
+	ticker := a.trie.Clock().NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return a.flushPending()
+			}
+			if err := a.Submit(event); err != nil && !errors.Is(err, ErrAlreadyAnchored) {
+				return err
+			}
+		case <-ticker.C():
+			if err := a.flushPending(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// flushPending flushes the pending events, if any
+func (a *Anchorer) flushPending() error {
+	a.mu.Lock()
+	empty := len(a.pending) == 0
+	a.mu.Unlock()
+	if empty {
+		return nil
+	}
+	_, err := a.Flush()
+	return err
+}
@@ -0,0 +1,84 @@
+package smt
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestAnchorer_FlushAndReceipt(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	anchorer := NewAnchorer(trie)
+
+	_, err := anchorer.Flush()
+	require.ErrorIs(t, err, ErrRootNotFound)
+
+	first := AnchorEvent{ID: []byte("note1"), PayloadHash: []byte("hash1")}
+	require.NoError(t, anchorer.Submit(first))
+	require.ErrorIs(t, anchorer.Submit(first), ErrAlreadyAnchored)
+	_, err = anchorer.Receipt(first.ID)
+	require.ErrorIs(t, err, ErrAnchorPending)
+
+	update, err := anchorer.Flush()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), update.Height)
+	require.ErrorIs(t, anchorer.Submit(first), ErrAlreadyAnchored)
+
+	second := AnchorEvent{ID: []byte("note2"), PayloadHash: []byte("hash2")}
+	require.NoError(t, anchorer.Submit(second))
+	update, err = anchorer.Flush()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), update.Height)
+
+	receipt, err := anchorer.Receipt(first.ID)
+	require.NoError(t, err)
+	require.Equal(t, update, RootUpdate{Height: receipt.Height, Root: receipt.Root})
+	trusted, err := anchorer.Oracle().RootAt(receipt.Height)
+	require.NoError(t, err)
+	require.Equal(t, trusted, receipt.Root)
+	valid, err := receipt.Verify(first, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+	valid, err = receipt.Verify(AnchorEvent{ID: first.ID, PayloadHash: []byte("forged")}, trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+
+	_, err = anchorer.Receipt([]byte("unknown"))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestAnchorer_Run(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	anchorer := NewAnchorer(trie)
+	roots, cancel := anchorer.Oracle().Subscribe()
+	defer cancel()
+
+	events := make(chan AnchorEvent)
+	done := make(chan error)
+	go func() { done <- anchorer.Run(context.Background(), events, time.Millisecond) }()
+	for i := 0; i < 10; i++ {
+		events <- AnchorEvent{ID: []byte(fmt.Sprintf("id%d", i)), PayloadHash: []byte{byte(i)}}
+	}
+	events <- AnchorEvent{ID: []byte("id0"), PayloadHash: []byte("duplicate")}
+	close(events)
+	require.NoError(t, <-done)
+
+	require.NotEmpty(t, roots)
+	for i := 0; i < 10; i++ {
+		receipt, err := anchorer.Receipt([]byte(fmt.Sprintf("id%d", i)))
+		require.NoError(t, err)
+		valid, err := receipt.Verify(AnchorEvent{ID: []byte(fmt.Sprintf("id%d", i)), PayloadHash: []byte{byte(i)}}, trie.Spec())
+		require.NoError(t, err)
+		require.True(t, valid)
+	}
+
+	ctx, cancelRun := context.WithCancel(context.Background())
+	cancelRun()
+	require.ErrorIs(t, anchorer.Run(ctx, make(chan AnchorEvent), time.Hour), context.Canceled)
+}
@@ -0,0 +1,181 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+const (
+	// inlineValueSize is the maximum size of values inlined into the leaves of
+	// an AuthenticatedMap, larger values are stored by hash.
+	inlineValueSize = sha256.Size
+	// inlineValuePrefix prefixes the leaf data of inlined values
+	inlineValuePrefix = 0
+	// hashedValuePrefix prefixes the leaf data of values stored by hash
+	hashedValuePrefix = 1
+)
+
+var (
+	// ErrEmptyValue is returned when putting an empty value in an
+	// AuthenticatedMap, keys are removed with Delete.
+	ErrEmptyValue = errors.New("empty value")
+
+	// mapValuePrefix prefixes the keys the values of an AuthenticatedMap too
+	// large to be inlined are stored under, so they cannot collide with the
+	// digests the trie's nodes are stored under.
+	mapValuePrefix = reservePrefix("authenticated map", []byte("map/value/"))
+)
+
+// AuthenticatedMap is a key-value map authenticated by a sparse Merkle trie,
+// whose root commits to every key-value pair and which proves the value (or
+// absence) of any key against that root. It hides the configuration of the
+// underlying trie behind sane defaults: SHA-256 hashing, and both the trie's
+// nodes and values stored in a single store.
+//
+// Values up to 32 bytes are inlined into the leaves of the trie, so they are
+// read without a second lookup, while larger values are committed to by hash
+// and stored alongside the nodes. Changes are buffered in memory until the
+// map is committed. AuthenticatedMap is not safe for concurrent use.
+type AuthenticatedMap struct {
+	trie  *SMT
+	store kvstore.MapStore
+	// pending maps the paths of the keys changed since the last commit to
+	// their values too large to be inlined, or nil if they were inlined or
+	// deleted.
+	pending map[string][]byte
+}
+
+// NewAuthenticatedMap returns a new, empty AuthenticatedMap stored in the
+// store provided, which should not be used for anything else.
+func NewAuthenticatedMap(store kvstore.MapStore) *AuthenticatedMap {
+	return &AuthenticatedMap{
+		trie:    NewSparseMerkleTrie(store, sha256.New(), WithValueHasher(nil)),
+		store:   store,
+		pending: make(map[string][]byte),
+	}
+}
+
+// ImportAuthenticatedMap returns the AuthenticatedMap committed to the store
+// provided with the given root.
+func ImportAuthenticatedMap(store kvstore.MapStore, root MerkleRoot) *AuthenticatedMap {
+	return &AuthenticatedMap{
+		trie:    ImportSparseMerkleTrie(store, sha256.New(), root, WithValueHasher(nil)),
+		store:   store,
+		pending: make(map[string][]byte),
+	}
+}
+
+// Put sets the value of the key, returning ErrEmptyValue if the value is
+// empty.
+func (m *AuthenticatedMap) Put(key, value []byte) error {
+	if len(value) == 0 {
+		return ErrEmptyValue
+	}
+	if err := m.trie.Update(key, encodeMapValue(value)); err != nil {
+		return err
+	}
+	var stored []byte
+	if len(value) > inlineValueSize {
+		stored = bytes.Clone(value)
+	}
+	m.pending[string(m.trie.ph.Path(key))] = stored
+	return nil
+}
+
+// Get returns the value of the key, returning ErrKeyNotFound if it is not
+// set.
+func (m *AuthenticatedMap) Get(key []byte) ([]byte, error) {
+	leaf, err := m.trie.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(leaf) == 0 {
+		return nil, ErrKeyNotFound
+	}
+	if leaf[0] == inlineValuePrefix {
+		return bytes.Clone(leaf[1:]), nil
+	}
+	path := m.trie.ph.Path(key)
+	value, ok := m.pending[string(path)]
+	if !ok {
+		if value, err = m.store.Get(mapValueKey(path)); err != nil {
+			return nil, err
+		}
+	}
+	// Guard against values written by a commit that did not complete
+	if !bytes.Equal(encodeMapValue(value), leaf) {
+		return nil, fmt.Errorf("stored value for key %x does not match its hash", key)
+	}
+	return value, nil
+}
+
+// Delete removes the key from the map, returning ErrKeyNotFound if it is not
+// set.
+func (m *AuthenticatedMap) Delete(key []byte) error {
+	if err := m.trie.Delete(key); err != nil {
+		return err
+	}
+	m.pending[string(m.trie.ph.Path(key))] = nil
+	return nil
+}
+
+// Commit persists the changes made to the map since the last commit
+func (m *AuthenticatedMap) Commit() error {
+	if err := m.trie.Commit(); err != nil {
+		return err
+	}
+	for path, value := range m.pending {
+		var err error
+		if value != nil {
+			err = m.store.Set(mapValueKey([]byte(path)), value)
+		} else if !isNotFound(m.store, mapValueKey([]byte(path))) {
+			err = m.store.Delete(mapValueKey([]byte(path)))
+		}
+		if err != nil {
+			return err
+		}
+		delete(m.pending, path)
+	}
+	return nil
+}
+
+// Root returns the root of the map, committing to every key-value pair
+func (m *AuthenticatedMap) Root() MerkleRoot {
+	return m.trie.Root()
+}
+
+// ProofFor returns a proof of the value of the key, or its absence, against
+// the current root of the map, verified with VerifyMapProof.
+func (m *AuthenticatedMap) ProofFor(key []byte) (*SparseMerkleProof, error) {
+	return m.trie.Prove(key)
+}
+
+// VerifyMapProof verifies a proof that the key has the value provided in the
+// AuthenticatedMap with the given root, or that it is absent if the value is
+// nil.
+func VerifyMapProof(proof *SparseMerkleProof, root MerkleRoot, key, value []byte) (bool, error) {
+	spec := NewTrieSpec(sha256.New(), false, WithValueHasher(nil))
+	if len(value) == 0 {
+		return VerifyProof(proof, root, key, defaultEmptyValue, &spec)
+	}
+	return VerifyProof(proof, root, key, encodeMapValue(value), &spec)
+}
+
+// encodeMapValue encodes a value of an AuthenticatedMap into the data of its
+// leaf, inlining small values and hashing larger ones.
+func encodeMapValue(value []byte) []byte {
+	if len(value) <= inlineValueSize {
+		return append([]byte{inlineValuePrefix}, value...)
+	}
+	digest := sha256.Sum256(value)
+	return append([]byte{hashedValuePrefix}, digest[:]...)
+}
+
+// mapValueKey returns the key the value of the path is stored under
+func mapValueKey(path []byte) []byte {
+	return append(bytes.Clone(mapValuePrefix), path...)
+}
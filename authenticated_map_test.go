@@ -0,0 +1,67 @@
+package smt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestAuthenticatedMap(t *testing.T) {
+	store := simplemap.NewSimpleMap()
+	m := NewAuthenticatedMap(store)
+
+	small := []byte("small value")
+	large := bytes.Repeat([]byte("large value "), 10)
+	require.NoError(t, m.Put([]byte("small"), small))
+	require.NoError(t, m.Put([]byte("large"), large))
+	require.ErrorIs(t, m.Put([]byte("empty"), nil), ErrEmptyValue)
+
+	// Values are readable before being committed
+	value, err := m.Get([]byte("large"))
+	require.NoError(t, err)
+	require.Equal(t, large, value)
+	_, err = m.Get([]byte("missing"))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	require.NoError(t, m.Commit())
+
+	// Values are proven against the root
+	root := m.Root()
+	for key, value := range map[string][]byte{"small": small, "large": large, "missing": nil} {
+		proof, err := m.ProofFor([]byte(key))
+		require.NoError(t, err)
+		valid, err := VerifyMapProof(proof, root, []byte(key), value)
+		require.NoError(t, err)
+		require.True(t, valid, key)
+		valid, err = VerifyMapProof(proof, root, []byte(key), []byte("wrong"))
+		require.NoError(t, err)
+		require.False(t, valid, key)
+	}
+
+	// The map is restored from its store and root
+	imported := ImportAuthenticatedMap(store, root)
+	for key, want := range map[string][]byte{"small": small, "large": large} {
+		value, err := imported.Get([]byte(key))
+		require.NoError(t, err)
+		require.Equal(t, want, value)
+	}
+
+	// Deleting a large value removes it from the store on commit
+	storedBefore := store.Len()
+	require.NoError(t, imported.Delete([]byte("large")))
+	require.ErrorIs(t, imported.Delete([]byte("large")), ErrKeyNotFound)
+	_, err = imported.Get([]byte("large"))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	require.NoError(t, imported.Commit())
+	require.Less(t, store.Len(), storedBefore)
+
+	// Replacing a large value with a small one inlines it
+	require.NoError(t, imported.Put([]byte("small"), large))
+	require.NoError(t, imported.Put([]byte("small"), small))
+	require.NoError(t, imported.Commit())
+	value, err = imported.Get([]byte("small"))
+	require.NoError(t, err)
+	require.Equal(t, small, value)
+}
@@ -0,0 +1,380 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrBatchMismatch is returned when the keys and values of a batch differ in
+// length.
+var ErrBatchMismatch = errors.New("batch keys and values differ in length")
+
+// UpdateBatch inserts every value for the key at the same index into the
+// trie in a single pass: the leaves are sorted by path and split by the bit
+// at each depth as the trie is descended, so every node shared by their paths
+// is visited, read from the node store and marked dirty once, and new
+// subtries are built directly from the leaves under them. As digests are only
+// recomputed for dirty nodes the root is hashed once, on the next call to
+// Root or Commit. If a key appears more than once its last value is inserted.
+//
+// The batch is atomic: the keys are validated, the depth of every leaf is
+// checked against the trie's depth limit, accounting for the other leaves of
+// the batch, and the nodes along all of their paths are read before the trie
+// is modified, so an invalid key, a leaf over the depth limit or a failure to
+// read the node store leaves the trie unchanged.
+func (smt *SMT) UpdateBatch(keys, values [][]byte) error {
+	if smt.closed {
+		return ErrClosed
+	}
+	if len(keys) != len(values) {
+		return errors.Join(ErrBatchMismatch, fmt.Errorf("%d keys and %d values", len(keys), len(values)))
+	}
+	type entry struct {
+		key  []byte
+		leaf *leafNode
+	}
+	entries := make([]entry, len(keys))
+	for i, key := range keys {
+		path, err := smt.path(key)
+		if err != nil {
+			return err
+		}
+		entries[i] = entry{key, &leafNode{path: path, valueHash: smt.valueHash(values[i])}}
+	}
+	// Sorting is stable so that the last value of a duplicate key is kept
+	sort.SliceStable(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].leaf.path, entries[j].leaf.path) < 0
+	})
+	leafKeys, leaves := make([][]byte, 0, len(entries)), make([]*leafNode, 0, len(entries))
+	for i, e := range entries {
+		if i+1 < len(entries) && bytes.Equal(e.leaf.path, entries[i+1].leaf.path) {
+			continue
+		}
+		leafKeys, leaves = append(leafKeys, e.key), append(leaves, e.leaf)
+	}
+
+	if err := smt.checkBatchDepths(leafKeys, leaves); err != nil {
+		return err
+	}
+	for _, leaf := range leaves {
+		smt.recordAccess(leaf.path)
+		if err := smt.resolvePath(leaf.path, false); err != nil {
+			return err
+		}
+	}
+	// Every node the batch descends through is resolved, so it cannot fail to
+	// read the node store
+	var orphans orphanNodes
+	newRoot, err := smt.updateBatch(smt.root, 0, leaves, &orphans)
+	if err != nil {
+		return err
+	}
+	smt.root = newRoot
+	if len(orphans) > 0 {
+		smt.orphans = append(smt.orphans, orphans)
+	}
+	for _, e := range entries {
+		smt.updates++
+		smt.metrics.Updates++
+		smt.emit(UpdateEvent{Key: bytes.Clone(e.key), ValueHash: bytes.Clone(e.leaf.valueHash)})
+	}
+	return nil
+}
+
+// checkBatchDepths checks the depth every leaf of a batch, sorted by path
+// without duplicates, would be inserted at against the trie's depth limit,
+// given the key of each leaf at the same index.
+// A leaf lands below both the leaves already in the trie and the other leaves
+// of the batch sharing its path prefix, the longest of which are its
+// neighbours in path order.
+func (smt *SMT) checkBatchDepths(keys [][]byte, leaves []*leafNode) error {
+	if smt.depthLimit <= 0 {
+		return nil
+	}
+	for i, leaf := range leaves {
+		depth, err := smt.insertDepth(leaf.path)
+		if err != nil {
+			return err
+		}
+		for _, j := range [2]int{i - 1, i + 1} {
+			if j < 0 || j >= len(leaves) {
+				continue
+			}
+			if d := countCommonPrefixBits(leaf.path, leaves[j].path, 0) + 1; d > depth {
+				depth = d
+			}
+		}
+		if err := smt.checkLeafDepth(keys[i], depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateBatch inserts the leaves provided, sorted by path without duplicates
+// and sharing the path prefix up to the given depth, into the subtrie rooted
+// at the node at that depth, descending each node once.
+func (smt *SMT) updateBatch(
+	node trieNode,
+	depth int,
+	leaves []*leafNode,
+	orphans *orphanNodes,
+) (trieNode, error) {
+	if len(leaves) == 1 {
+		return smt.update(node, depth, leaves[0].path, leaves[0].valueHash, orphans)
+	}
+	node, err := smt.resolveLazy(node)
+	if err != nil {
+		return node, err
+	}
+
+	switch n := node.(type) {
+	case nil:
+		return buildSubtrie(leaves, depth), nil
+	case *leafNode:
+		// The existing leaf is kept alongside the new ones, unless one of them
+		// replaces it
+		i := sort.Search(len(leaves), func(i int) bool {
+			return bytes.Compare(leaves[i].path, n.path) >= 0
+		})
+		if i < len(leaves) && bytes.Equal(leaves[i].path, n.path) {
+			smt.addOrphan(orphans, n)
+		} else {
+			merged := make([]*leafNode, 0, len(leaves)+1)
+			merged = append(append(append(merged, leaves[:i]...), n), leaves[i:]...)
+			leaves = merged
+		}
+		return buildSubtrie(leaves, depth), nil
+	case *extensionNode:
+		smt.addOrphan(orphans, n)
+		// Split the extension where the leaf diverging from it first does, so
+		// that every leaf follows it up to the branch below the split
+		split, matchLen := leaves[0], n.length()
+		for _, leaf := range leaves {
+			if m, _ := n.boundsMatch(leaf.path, depth); m < matchLen {
+				split, matchLen = leaf, m
+			}
+		}
+		head, branch, branchDepth := n.split(split.path)
+		*branch, err = smt.updateBatch(*branch, branchDepth, leaves, orphans)
+		if err != nil {
+			return head, err
+		}
+		n.setDirty()
+		return head, nil
+	}
+
+	inner := node.(*innerNode)
+	smt.addOrphan(orphans, inner)
+	// The leaves are sorted, so those branching left precede the others
+	i := sort.Search(len(leaves), func(i int) bool {
+		return getPathBit(leaves[i].path, depth) != leftChildBit
+	})
+	if i > 0 {
+		if inner.leftChild, err = smt.updateBatch(inner.leftChild, depth+1, leaves[:i], orphans); err != nil {
+			return node, err
+		}
+	}
+	if i < len(leaves) {
+		if inner.rightChild, err = smt.updateBatch(inner.rightChild, depth+1, leaves[i:], orphans); err != nil {
+			return node, err
+		}
+	}
+	inner.setDirty()
+	return node, nil
+}
+
+// buildSubtrie returns a new subtrie at the given depth holding the leaves
+// provided, sorted by path without duplicates and sharing the path prefix up
+// to that depth.
+func buildSubtrie(leaves []*leafNode, depth int) trieNode {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	// The leaves branch at the first bit where the first and last differ
+	first, last := leaves[0], leaves[len(leaves)-1]
+	split := countCommonPrefixBits(first.path, last.path, depth)
+	i := sort.Search(len(leaves), func(i int) bool {
+		return getPathBit(leaves[i].path, split) != leftChildBit
+	})
+	inner := &innerNode{
+		leftChild:  buildSubtrie(leaves[:i], split+1),
+		rightChild: buildSubtrie(leaves[i:], split+1),
+	}
+	return seal(first.path, inner, split, depth)
+}
+
+// UpdateBatch inserts every value for the key at the same index into the
+// trie, see SMT.UpdateBatch. Every value is validated and checked against the
+// quota of its namespace before the trie is modified, so either all values
+// are inserted or none are. The batch holds the commit lock exclusively, so
+// it never interleaves with other operations, and its values are persisted
+// atomically along with the trie's nodes by the next Commit.
+func (smt *SMTWithStorage) UpdateBatch(keys, values [][]byte) error {
+	if len(keys) != len(values) {
+		return errors.Join(ErrBatchMismatch, fmt.Errorf("%d keys and %d values", len(keys), len(values)))
+	}
+	if err := smt.admit(); err != nil {
+		return err
+	}
+	smt.commitMu.Lock()
+	defer smt.commitMu.Unlock()
+
+	// Only the last value of each key is checked against its namespace's quota
+	last := make(map[string]int, len(keys))
+	for i, key := range keys {
+		last[string(key)] = i
+	}
+	usages := make(map[*Namespace]NamespaceUsage)
+	for i, key := range keys {
+		if err := smt.validate(key, values[i]); err != nil {
+			return err
+		}
+		if last[string(key)] != i {
+			continue
+		}
+		ns, before, after, err := smt.namespaceDelta(key, values[i])
+		if err != nil {
+			return err
+		}
+		if ns == nil {
+			continue
+		}
+		usage, ok := usages[ns]
+		if !ok {
+			usage = ns.usage
+		}
+		if usages[ns], err = ns.checkQuota(key, usage, before, after); err != nil {
+			return err
+		}
+	}
+
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	var size uint64
+	for i, value := range values {
+		size += uint64(len(keys[i]) + len(value))
+	}
+	if err := smt.checkStaging(uint64(len(keys)), size); err != nil {
+		return err
+	}
+	leafValues := make([][]byte, len(values))
+	for i, value := range values {
+		leafValues[i] = smt.leafValue(keys[i], value)
+	}
+	if err := smt.trie.UpdateBatch(keys, leafValues); err != nil {
+		return err
+	}
+	for i, value := range values {
+		smt.addPending(keys[i], leafValues[i], value)
+	}
+	for ns, usage := range usages {
+		ns.usage = usage
+	}
+	return nil
+}
+
+// DeleteBatch removes the leaves for every key provided from the trie. Every
+// key is checked to be present, and the nodes along all of their paths are
+// read, before the trie is modified, returning an error wrapping
+// ErrKeyNotFound if a key is absent and leaving the trie unchanged on any
+// error. The keys are removed in path order so that the root is hashed once,
+// on the next call to Root or Commit.
+func (smt *SMT) DeleteBatch(keys [][]byte) error {
+	if smt.closed {
+		return ErrClosed
+	}
+	type entry struct{ key, path []byte }
+	entries := make([]entry, 0, len(keys))
+	seen := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		path, err := smt.path(key)
+		if err != nil {
+			return err
+		}
+		if _, ok := seen[string(path)]; ok {
+			continue
+		}
+		seen[string(path)] = struct{}{}
+		leaf, err := smt.findLeaf(path)
+		if err != nil {
+			return err
+		}
+		if leaf == nil {
+			return errors.Join(ErrKeyNotFound, fmt.Errorf("key %x", key))
+		}
+		entries = append(entries, entry{key, path})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].path, entries[j].path) < 0
+	})
+
+	for _, e := range entries {
+		smt.recordAccess(e.path)
+		if err := smt.resolvePath(e.path, true); err != nil {
+			return err
+		}
+	}
+	var orphans orphanNodes
+	for _, e := range entries {
+		newRoot, err := smt.delete(smt.root, 0, e.path, &orphans)
+		if err != nil {
+			return err
+		}
+		smt.root = newRoot
+		smt.updates++
+		smt.metrics.Deletes++
+		smt.emit(DeleteEvent{Key: bytes.Clone(e.key)})
+	}
+	if len(orphans) > 0 {
+		smt.orphans = append(smt.orphans, orphans)
+	}
+	return nil
+}
+
+// DeleteBatch removes every key provided from the trie, releasing their usage
+// of their namespaces' quotas, see SMT.DeleteBatch. Like UpdateBatch it holds
+// the commit lock exclusively.
+func (smt *SMTWithStorage) DeleteBatch(keys [][]byte) error {
+	if err := smt.admit(); err != nil {
+		return err
+	}
+	smt.commitMu.Lock()
+	defer smt.commitMu.Unlock()
+
+	usages := make(map[*Namespace]NamespaceUsage)
+	seen := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		if _, ok := seen[string(key)]; ok {
+			continue
+		}
+		seen[string(key)] = struct{}{}
+		ns, before, _, err := smt.namespaceDelta(key, nil)
+		if err != nil {
+			return err
+		}
+		if ns == nil {
+			continue
+		}
+		usage, ok := usages[ns]
+		if !ok {
+			usage = ns.usage
+		}
+		usages[ns], _ = ns.checkQuota(key, usage, before, NamespaceUsage{})
+	}
+
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	if err := smt.checkStaging(uint64(len(keys)), 0); err != nil {
+		return err
+	}
+	if err := smt.trie.DeleteBatch(keys); err != nil {
+		return err
+	}
+	for ns, usage := range usages {
+		ns.usage = usage
+	}
+	return nil
+}
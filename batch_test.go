@@ -0,0 +1,240 @@
+package smt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_UpdateBatch(t *testing.T) {
+	batched := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	sequential := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+
+	keys, values := make([][]byte, 100), make([][]byte, 100)
+	for i := range keys {
+		keys[i], values[i] = make([]byte, 16), make([]byte, 8)
+		_, _ = rand.Read(keys[i])
+		_, _ = rand.Read(values[i])
+	}
+	// Duplicate keys keep their last value
+	keys = append(keys, keys[0])
+	values = append(values, []byte("last"))
+
+	require.NoError(t, batched.UpdateBatch(keys, values))
+	for i, key := range keys {
+		require.NoError(t, sequential.Update(key, values[i]))
+	}
+	require.Equal(t, sequential.Root(), batched.Root())
+	valueHash, err := batched.Get(keys[0])
+	require.NoError(t, err)
+	require.Equal(t, batched.valueHash([]byte("last")), valueHash)
+
+	// Batches over persisted nodes orphan them like individual updates
+	require.NoError(t, batched.Commit())
+	require.NoError(t, sequential.Commit())
+	require.NoError(t, batched.UpdateBatch(keys[:10], values[10:20]))
+	for i, key := range keys[:10] {
+		require.NoError(t, sequential.Update(key, values[10+i]))
+	}
+	require.NoError(t, batched.Commit())
+	require.NoError(t, sequential.Commit())
+	require.Equal(t, sequential.Root(), batched.Root())
+	require.Equal(t, sequential.nodes.Len(), batched.nodes.Len())
+
+	err = batched.UpdateBatch(keys, values[:1])
+	require.ErrorIs(t, err, ErrBatchMismatch)
+}
+
+func TestSMT_UpdateBatchShape(t *testing.T) {
+	// Keys are used as paths directly and only differ in their first bits, so
+	// that batches split and extend many extension nodes
+	nilPathHasher := WithPathHasher(newNilPathHasher(sha256.Size))
+	batched := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), nilPathHasher)
+	sequential := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), nilPathHasher)
+
+	for round := 0; round < 10; round++ {
+		keys, values := make([][]byte, round*5+1), make([][]byte, round*5+1)
+		for i := range keys {
+			keys[i], values[i] = make([]byte, sha256.Size), make([]byte, 8)
+			_, _ = rand.Read(keys[i][:2])
+			_, _ = rand.Read(values[i])
+		}
+		require.NoError(t, batched.UpdateBatch(keys, values))
+		for i, key := range keys {
+			require.NoError(t, sequential.Update(key, values[i]))
+		}
+		require.Equal(t, sequential.Root(), batched.Root())
+		if round%2 == 0 {
+			require.NoError(t, batched.Commit())
+			require.NoError(t, sequential.Commit())
+			require.Equal(t, sequential.nodes.Len(), batched.nodes.Len())
+		}
+	}
+}
+
+// limitedStore is a MapStore failing every read after its first limit reads
+type limitedStore struct {
+	kvstore.MapStore
+	limit int
+}
+
+func (store *limitedStore) Get(key []byte) ([]byte, error) {
+	if store.limit == 0 {
+		return nil, errCrash
+	}
+	store.limit--
+	return store.MapStore.Get(key)
+}
+
+func TestSMT_UpdateBatchAtomic(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleTrie(nodes, sha256.New())
+	keys, values := make([][]byte, 50), make([][]byte, 50)
+	for i := range keys {
+		keys[i], values[i] = make([]byte, 16), []byte("value")
+		_, _ = rand.Read(keys[i])
+		require.NoError(t, trie.Update(keys[i], values[i]))
+	}
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+
+	// A batch failing to read the node store partway through its keys leaves
+	// the trie unchanged
+	store := &limitedStore{MapStore: nodes, limit: 10}
+	imported := ImportSparseMerkleTrie(store, sha256.New(), root)
+	err := imported.UpdateBatch(keys, make([][]byte, len(keys)))
+	require.ErrorIs(t, err, errCrash)
+	store.limit = -1
+	require.Equal(t, root, imported.Root())
+	require.NoError(t, imported.Commit())
+	require.Equal(t, root, imported.Root())
+	for i, key := range keys {
+		valueHash, err := imported.Get(key)
+		require.NoError(t, err)
+		require.Equal(t, imported.valueHash(values[i]), valueHash)
+	}
+}
+
+func TestSMTWithStorage_UpdateBatch(t *testing.T) {
+	nodes, preimages := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	trie := NewSMTWithStorage(nodes, preimages, sha256.New())
+	require.NoError(t, trie.RegisterNamespace(Namespace{
+		Name:   "tenant",
+		Prefix: []byte("t/"),
+		Quota:  NamespaceQuota{MaxKeys: 2},
+	}))
+
+	keys := [][]byte{[]byte("foo"), []byte("t/1"), []byte("t/2"), []byte("t/1")}
+	values := [][]byte{[]byte("bar"), []byte("one"), []byte("two"), []byte("uno")}
+	require.NoError(t, trie.UpdateBatch(keys, values))
+	require.NoError(t, trie.Commit())
+	value, err := trie.GetValue([]byte("t/1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("uno"), value)
+	usage, err := trie.NamespaceUsage("tenant")
+	require.NoError(t, err)
+	require.Equal(t, NamespaceUsage{Keys: 2, Bytes: 12}, usage)
+
+	// A batch exceeding a quota is rejected as a whole
+	root := trie.Root()
+	err = trie.UpdateBatch(
+		[][]byte{[]byte("baz"), []byte("t/3")},
+		[][]byte{[]byte("qux"), []byte("three")},
+	)
+	require.ErrorIs(t, err, ErrQuotaExceeded)
+	require.Equal(t, root, trie.Root())
+	has, err := trie.Has([]byte("baz"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// Values are persisted by the next commit
+	imported, err := ImportSMTWithStorage(nodes, preimages, sha256.New(), root)
+	require.NoError(t, err)
+	value, err = imported.GetValue([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), value)
+}
+
+func TestSMT_DeleteBatch(t *testing.T) {
+	batched := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	sequential := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+
+	keys := make([][]byte, 50)
+	for i := range keys {
+		keys[i] = make([]byte, 16)
+		_, _ = rand.Read(keys[i])
+		require.NoError(t, batched.Update(keys[i], []byte("value")))
+		require.NoError(t, sequential.Update(keys[i], []byte("value")))
+	}
+	require.NoError(t, batched.Commit())
+	require.NoError(t, sequential.Commit())
+
+	// Missing keys are rejected before the trie is modified
+	root := batched.Root()
+	err := batched.DeleteBatch([][]byte{keys[0], []byte("missing")})
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	require.Equal(t, root, batched.Root())
+
+	require.NoError(t, batched.DeleteBatch(append(keys[:30:30], keys[0])))
+	for _, key := range keys[:30] {
+		require.NoError(t, sequential.Delete(key))
+	}
+	require.Equal(t, sequential.Root(), batched.Root())
+	require.NoError(t, batched.Commit())
+	require.NoError(t, sequential.Commit())
+	require.Equal(t, sequential.nodes.Len(), batched.nodes.Len())
+
+	// Deleting every remaining key empties the trie
+	require.NoError(t, batched.DeleteBatch(keys[30:]))
+	require.Equal(t, batched.placeholder(), []byte(batched.Root()))
+}
+
+func TestSMT_DeleteWithPrevious(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+
+	previous, existed, err := trie.DeleteWithPrevious([]byte("foo"))
+	require.NoError(t, err)
+	require.True(t, existed)
+	require.Equal(t, trie.valueHash([]byte("bar")), previous)
+
+	previous, existed, err = trie.DeleteWithPrevious([]byte("foo"))
+	require.NoError(t, err)
+	require.False(t, existed)
+	require.Nil(t, previous)
+}
+
+func TestSMTWithStorage_DeleteBatch(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.RegisterNamespace(Namespace{
+		Name:   "tenant",
+		Prefix: []byte("t/"),
+		Quota:  NamespaceQuota{MaxKeys: 2},
+	}))
+	require.NoError(t, trie.UpdateBatch(
+		[][]byte{[]byte("foo"), []byte("t/1"), []byte("t/2")},
+		[][]byte{[]byte("bar"), []byte("one"), []byte("two")},
+	))
+	require.NoError(t, trie.Commit())
+
+	previous, existed, err := trie.DeleteWithPrevious([]byte("t/1"))
+	require.NoError(t, err)
+	require.True(t, existed)
+	require.Equal(t, []byte("one"), previous)
+	_, existed, err = trie.DeleteWithPrevious([]byte("t/1"))
+	require.NoError(t, err)
+	require.False(t, existed)
+
+	require.NoError(t, trie.DeleteBatch([][]byte{[]byte("foo"), []byte("t/2")}))
+	usage, err := trie.NamespaceUsage("tenant")
+	require.NoError(t, err)
+	require.Equal(t, NamespaceUsage{}, usage)
+	has, err := trie.Has([]byte("foo"))
+	require.NoError(t, err)
+	require.False(t, has)
+}
@@ -0,0 +1,273 @@
+package bench
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"math/rand"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// ErrUnknownWorkload is returned when running a workload which is not defined
+var ErrUnknownWorkload = errors.New("unknown workload")
+
+// Workload is a standardised mix of operations
+type Workload string
+
+const (
+	// WorkloadInsert updates keys in 90% of its operations and reads them in
+	// the rest, as when ingesting state
+	WorkloadInsert Workload = "insert-heavy"
+	// WorkloadRead reads keys in 90% of its operations and updates them in
+	// the rest, as when serving state
+	WorkloadRead Workload = "read-heavy"
+	// WorkloadProof proves and verifies keys in 90% of its operations and
+	// updates them in the rest, as when serving light clients
+	WorkloadProof Workload = "proof-heavy"
+)
+
+// workloadUpdateRates is the fraction of the operations of every workload
+// which are updates
+var workloadUpdateRates = map[Workload]float64{
+	WorkloadInsert: 0.9,
+	WorkloadRead:   0.1,
+	WorkloadProof:  0.1,
+}
+
+// Workloads returns every standardised workload
+func Workloads() []Workload {
+	return []Workload{WorkloadInsert, WorkloadRead, WorkloadProof}
+}
+
+// Config configures a benchmark run, its zero fields taking their defaults.
+type Config struct {
+	// Workloads are the workloads run, every workload by default
+	Workloads []Workload
+	// Keys is the number of keys the trie is preloaded with before every
+	// workload, and then operated on, 10000 by default
+	Keys int
+	// Operations is the number of operations of every workload, 10000 by
+	// default
+	Operations int
+	// ValueSize is the size in bytes of the values stored, 64 by default
+	ValueSize int
+	// CommitEvery is the number of operations between commits, 1000 by
+	// default
+	CommitEvery int
+	// Seed seeds the keys, values and operations, so that runs are
+	// reproducible
+	Seed int64
+	// NewHasher returns the hasher of the trie, sha256 by default
+	NewHasher func() hash.Hash
+	// NewStore returns the empty node store every workload runs against, an
+	// in-memory store by default. Stores with a Stop() error method, such as
+	// the badger store, are stopped once their workload completes.
+	NewStore func() (kvstore.MapStore, error)
+	// Options are the options the trie is created with
+	Options []smt.TrieSpecOption
+}
+
+// withDefaults returns the config with its zero fields set to their defaults
+func (config Config) withDefaults() Config {
+	if len(config.Workloads) == 0 {
+		config.Workloads = Workloads()
+	}
+	if config.Keys <= 0 {
+		config.Keys = 10000
+	}
+	if config.Operations <= 0 {
+		config.Operations = 10000
+	}
+	if config.ValueSize <= 0 {
+		config.ValueSize = 64
+	}
+	if config.CommitEvery <= 0 {
+		config.CommitEvery = 1000
+	}
+	if config.NewHasher == nil {
+		config.NewHasher = sha256.New
+	}
+	if config.NewStore == nil {
+		config.NewStore = func() (kvstore.MapStore, error) { return simplemap.NewSimpleMap(), nil }
+	}
+	return config
+}
+
+// Report is the result of a benchmark run along with the environment it was
+// measured in.
+type Report struct {
+	GoVersion string `json:"go_version"`
+	GOOS      string `json:"goos"`
+	GOARCH    string `json:"goarch"`
+	NumCPU    int    `json:"num_cpu"`
+	// Spec is the hex encoded fingerprint of the spec of the tries
+	Spec    string   `json:"spec"`
+	Keys    int      `json:"keys"`
+	Seed    int64    `json:"seed"`
+	Results []Result `json:"results"`
+}
+
+// Result is the result of a workload. Durations are in nanoseconds.
+type Result struct {
+	Workload   Workload `json:"workload"`
+	Operations int      `json:"operations"`
+	Commits    int      `json:"commits"`
+	// Duration is the total duration of the workload, including commits but
+	// not preloading the trie
+	Duration     time.Duration `json:"duration_ns"`
+	OpsPerSecond float64       `json:"ops_per_second"`
+	// The percentiles of the latencies of the operations, excluding commits
+	LatencyP50 time.Duration `json:"latency_p50_ns"`
+	LatencyP90 time.Duration `json:"latency_p90_ns"`
+	LatencyP99 time.Duration `json:"latency_p99_ns"`
+	LatencyMax time.Duration `json:"latency_max_ns"`
+	// CommitAvg is the average duration of a commit
+	CommitAvg time.Duration `json:"commit_avg_ns"`
+	// NodesWritten is the number of nodes written by the workload's commits
+	NodesWritten int `json:"nodes_written"`
+	// TrieBytes is the serialised size of the trie once the workload completes
+	TrieBytes uint64 `json:"trie_bytes"`
+}
+
+// Run runs the workloads of the config, each against a trie in a new node
+// store, and returns their results. Running stops at the first error, or when
+// the context is done.
+func Run(ctx context.Context, config Config) (*Report, error) {
+	config = config.withDefaults()
+	spec := smt.NewTrieSpec(config.NewHasher(), false, config.Options...)
+	report := &Report{
+		GoVersion: runtime.Version(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		NumCPU:    runtime.NumCPU(),
+		Spec:      hex.EncodeToString(spec.Fingerprint()),
+		Keys:      config.Keys,
+		Seed:      config.Seed,
+	}
+	for _, workload := range config.Workloads {
+		if _, ok := workloadUpdateRates[workload]; !ok {
+			return nil, errors.Join(ErrUnknownWorkload, fmt.Errorf("%q", workload))
+		}
+		store, err := config.NewStore()
+		if err != nil {
+			return nil, err
+		}
+		result, err := run(ctx, config, workload, store)
+		if stopper, ok := store.(interface{ Stop() error }); ok {
+			if stopErr := stopper.Stop(); err == nil {
+				err = stopErr
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("workload %s: %w", workload, err)
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// run runs the workload against a trie in the store provided
+func run(ctx context.Context, config Config, workload Workload, store kvstore.MapStore) (Result, error) {
+	rng := rand.New(rand.NewSource(config.Seed))
+	trie := smt.NewSparseMerkleTrie(store, config.NewHasher(), config.Options...)
+	keys := make([][]byte, config.Keys)
+	values := make([][]byte, config.Keys)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("key-%d", i))
+		values[i] = make([]byte, config.ValueSize)
+		rng.Read(values[i])
+		if err := trie.Update(keys[i], values[i]); err != nil {
+			return Result{}, err
+		}
+	}
+	if err := trie.Commit(); err != nil {
+		return Result{}, err
+	}
+
+	result := Result{Workload: workload, Operations: config.Operations}
+	latencies := make([]time.Duration, 0, config.Operations)
+	var committing time.Duration
+	updateRate := workloadUpdateRates[workload]
+	start := time.Now()
+	for op := 0; op < config.Operations; op++ {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		i := rng.Intn(len(keys))
+		update := rng.Float64() < updateRate
+		if update {
+			rng.Read(values[i])
+		}
+
+		opStart := time.Now()
+		var err error
+		switch {
+		case update:
+			err = trie.Update(keys[i], values[i])
+		case workload == WorkloadProof:
+			err = prove(trie, keys[i], values[i])
+		default:
+			_, err = trie.Get(keys[i])
+		}
+		latencies = append(latencies, time.Since(opStart))
+		if err != nil {
+			return Result{}, err
+		}
+
+		if (op+1)%config.CommitEvery == 0 || op == config.Operations-1 {
+			commitStart := time.Now()
+			if err := trie.Commit(); err != nil {
+				return Result{}, err
+			}
+			committing += time.Since(commitStart)
+			result.Commits++
+			result.NodesWritten += trie.LastCommitStats().Written
+		}
+	}
+	result.Duration = time.Since(start)
+	result.OpsPerSecond = float64(config.Operations) / result.Duration.Seconds()
+	if result.Commits > 0 {
+		result.CommitAvg = committing / time.Duration(result.Commits)
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p float64) time.Duration {
+		return latencies[int(p*float64(len(latencies)-1))]
+	}
+	result.LatencyP50 = percentile(0.5)
+	result.LatencyP90 = percentile(0.9)
+	result.LatencyP99 = percentile(0.99)
+	result.LatencyMax = latencies[len(latencies)-1]
+
+	stats, err := trie.Stats()
+	if err != nil {
+		return Result{}, err
+	}
+	result.TrieBytes = stats.Bytes
+	return result, nil
+}
+
+// prove proves the key and verifies its proof
+func prove(trie *smt.SMT, key, value []byte) error {
+	proof, err := trie.Prove(key)
+	if err != nil {
+		return err
+	}
+	valid, err := smt.VerifyProof(proof, trie.Root(), key, value, trie.Spec())
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("proof of key %q does not verify", key)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestRun(t *testing.T) {
+	var stores int
+	config := Config{
+		Keys:        200,
+		Operations:  500,
+		CommitEvery: 100,
+		NewStore: func() (kvstore.MapStore, error) {
+			stores++
+			return simplemap.NewSimpleMap(), nil
+		},
+	}
+	report, err := Run(context.Background(), config)
+	require.NoError(t, err)
+	require.Equal(t, len(Workloads()), stores)
+	require.Len(t, report.Results, len(Workloads()))
+	require.NotEmpty(t, report.Spec)
+	for i, result := range report.Results {
+		require.Equal(t, Workloads()[i], result.Workload)
+		require.Equal(t, 500, result.Operations)
+		require.Equal(t, 5, result.Commits)
+		require.Greater(t, result.OpsPerSecond, 0.0)
+		require.LessOrEqual(t, result.LatencyP50, result.LatencyP99)
+		require.LessOrEqual(t, result.LatencyP99, result.LatencyMax)
+		require.Greater(t, result.TrieBytes, uint64(0))
+	}
+	// Insert heavy workloads write more nodes than read heavy ones
+	require.Greater(t, report.Results[0].NodesWritten, report.Results[1].NodesWritten)
+
+	// Reports are machine-readable
+	bz, err := json.Marshal(report)
+	require.NoError(t, err)
+	var decoded Report
+	require.NoError(t, json.Unmarshal(bz, &decoded))
+	require.Equal(t, *report, decoded)
+	require.Contains(t, string(bz), `"workload":"proof-heavy"`)
+
+	// Runs with the same seed perform the same operations
+	again, err := Run(context.Background(), config)
+	require.NoError(t, err)
+	for i := range again.Results {
+		require.Equal(t, report.Results[i].NodesWritten, again.Results[i].NodesWritten)
+		require.Equal(t, report.Results[i].TrieBytes, again.Results[i].TrieBytes)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	_, err := Run(context.Background(), Config{Workloads: []Workload{"write-only"}})
+	require.ErrorIs(t, err, ErrUnknownWorkload)
+
+	storeErr := errors.New("store unavailable")
+	_, err = Run(context.Background(), Config{NewStore: func() (kvstore.MapStore, error) { return nil, storeErr }})
+	require.ErrorIs(t, err, storeErr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Run(ctx, Config{Keys: 10})
+	require.ErrorIs(t, err, context.Canceled)
+}
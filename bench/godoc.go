@@ -0,0 +1,11 @@
+// Package bench runs standardised workloads against a trie with a given spec
+// and node store, reporting machine-readable results, so that users can
+// compare backends and settings on their own hardware without writing
+// bespoke benchmarks, and track performance regressions across releases.
+//
+// Every workload preloads the trie with a fixed set of keys, then runs a
+// deterministic mix of operations on them, committing periodically. Results
+// report throughput, operation latency percentiles and the size of the
+// resulting trie, and serialise to JSON along with the environment they were
+// measured in.
+package bench
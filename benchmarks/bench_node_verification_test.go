@@ -0,0 +1,56 @@
+//go:build benchmark
+
+package smt
+
+import (
+	"crypto/sha256"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// BenchmarkSparseMerkleTrie_NodeVerification measures the cost of verifying
+// every node read from the node store against its digest, by iterating over a
+// committed trie, which reads every node without caching them.
+func BenchmarkSparseMerkleTrie_NodeVerification(b *testing.B) {
+	testCases := []struct {
+		desc string
+		opts []smt.TrieSpecOption
+	}{
+		{
+			desc: "Iterate (Unverified)",
+		},
+		{
+			desc: "Iterate (Verified)",
+			opts: []smt.TrieSpecOption{smt.WithNodeVerification()},
+		},
+	}
+
+	nodes := simplemap.NewSimpleMap()
+	trie := smt.NewSparseMerkleTrie(nodes, sha256.New())
+	for i := 0; i < 100000; i++ {
+		s := strconv.Itoa(i)
+		require.NoError(b, trie.Update([]byte(s), []byte(s)))
+	}
+	require.NoError(b, trie.Commit())
+	root := trie.Root()
+
+	for _, tc := range testCases {
+		b.ResetTimer()
+		b.Run(tc.desc, func(b *testing.B) {
+			imported := smt.ImportSparseMerkleTrie(nodes, sha256.New(), root, tc.opts...)
+			b.ResetTimer()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				it := imported.Iterator()
+				for it.Next() {
+				}
+				require.NoError(b, it.Err())
+			}
+		})
+	}
+}
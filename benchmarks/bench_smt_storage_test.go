@@ -0,0 +1,52 @@
+//go:build benchmark
+
+package smt
+
+import (
+	"crypto/sha256"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// BenchmarkSMTWithStorage_ConcurrentUpdate measures the throughput of updates
+// to unrelated keys from an increasing number of goroutines, with and without
+// periodic commits interleaved.
+func BenchmarkSMTWithStorage_ConcurrentUpdate(b *testing.B) {
+	testCases := []struct {
+		desc        string
+		parallelism int
+		commitEvery int64
+	}{
+		{desc: "1 goroutine", parallelism: 1},
+		{desc: "4 goroutines", parallelism: 4},
+		{desc: "16 goroutines", parallelism: 16},
+		{desc: "16 goroutines, commit every 1000", parallelism: 16, commitEvery: 1000},
+	}
+	for _, tc := range testCases {
+		b.Run(tc.desc, func(b *testing.B) {
+			trie := smt.NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+			var counter int64
+			b.SetParallelism(tc.parallelism)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					n := atomic.AddInt64(&counter, 1)
+					key := []byte(strconv.FormatInt(n, 10))
+					if err := trie.Update(key, key); err != nil {
+						b.Error(err)
+					}
+					if tc.commitEvery > 0 && n%tc.commitEvery == 0 {
+						if err := trie.Commit(); err != nil {
+							b.Error(err)
+						}
+					}
+				}
+			})
+			b.StopTimer()
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// maxImportPartitionBits bounds the number of top path bits the leaves
+	// of a parallel bulk load are partitioned by
+	maxImportPartitionBits = 8
+	// importPartitionsPerWorker is the number of partitions per worker of a
+	// parallel bulk load, so that partitions of uneven sizes are balanced
+	importPartitionsPerWorker = 4
+)
+
+// ErrUnsortedKeys is returned when bulk loading keys which are not in
+// strictly ascending path order.
+var ErrUnsortedKeys = errors.New("keys are not sorted by path")
+
+// KVIterator iterates over key-value pairs, e.g. to bulk load them into a
+// trie. Key and Value are only called after Next returns true, and their
+// results may be reused by the following call to Next.
+type KVIterator interface {
+	// Next advances the iterator to the next pair, returning false when there
+	// are no more pairs or an error occurred
+	Next() bool
+	// Key returns the key of the current pair
+	Key() []byte
+	// Value returns the value of the current pair
+	Value() []byte
+	// Err returns the error encountered while iterating, if any
+	Err() error
+}
+
+// WithImportWorkers returns an Option building the subtries of a BulkLoad
+// concurrently with n workers. The leaves are partitioned by the top bits of
+// their paths, each partition's subtrie is built by a worker, and the roots
+// of the subtries are then combined. As each worker needs its own hasher,
+// the trie's hasher must produce the digests of a registered hasher
+// implementation (see RegisterHasherImplementation), otherwise the trie is
+// bulk loaded by a single worker.
+func WithImportWorkers(n int) TrieSpecOption {
+	return func(ts *TrieSpec) { ts.importWorkers = n }
+}
+
+// BulkLoad builds the trie bottom-up from the key-value pairs of the
+// iterator, which must be sorted in strictly ascending order of their paths
+// (i.e. of their hashed keys), and commits it. Unlike inserting the pairs one
+// at a time every node is built and hashed exactly once, and each subtrie is
+// written to the node store as soon as it is complete, so only a single path
+// of the trie is held in memory, or a single partition per worker if the trie
+// is configured WithImportWorkers.
+//
+// The trie must be empty. If the pairs are out of order an error wrapping
+// ErrUnsortedKeys is returned and the trie is left empty, though the subtries
+// already written remain in the node store. Only the trie is loaded, not the
+// values stored by an SMTWithStorage, and sum tries cannot be bulk loaded.
+func (smt *SMT) BulkLoad(iter KVIterator) error {
+	if smt.closed {
+		return ErrClosed
+	}
+	if smt.sumTrie {
+		return errors.New("cannot bulk load a sum trie")
+	}
+	if !bytes.Equal(smt.Root(), smt.placeholder()) {
+		return errors.New("cannot bulk load a non-empty trie")
+	}
+	reader := &leafReader{smt: smt, iter: iter}
+	var root trieNode
+	var err error
+	if smt.importWorkers > 1 && smt.hasherConstructor() != nil {
+		root, err = smt.bulkLoadParallel(reader)
+	} else {
+		builder := &bulkBuilder{smt: smt, read: reader.next}
+		if err = builder.advance(); err == nil && builder.next != nil {
+			root, err = builder.build(0)
+		}
+	}
+	if err != nil || root == nil {
+		return err
+	}
+	smt.root = root
+	smt.updates += reader.loaded
+	smt.metrics.Updates += uint64(reader.loaded)
+	return smt.Commit()
+}
+
+// bulkLoadParallel builds the trie from the leaves of the reader, building
+// the subtries of the partitions of the leaves by their top path bits with
+// the trie's import workers, and returns its root.
+func (smt *SMT) bulkLoadParallel(reader *leafReader) (trieNode, error) {
+	workers, newHasher := smt.importWorkers, smt.hasherConstructor()
+	bits := 0
+	for 1<<bits < workers*importPartitionsPerWorker && bits < maxImportPartitionBits && bits < smt.depth() {
+		bits++
+	}
+	type partition struct {
+		index  int
+		leaves []*leafNode
+	}
+	partitions := make(chan partition, workers)
+	parts := make([]bulkPart, 1<<bits)
+	errs := make([]error, workers)
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// Every worker hashes with its own hasher
+			spec := smt.TrieSpec
+			spec.th = *NewTrieHasher(newHasher())
+			worker := &SMT{TrieSpec: spec, nodes: smt.nodes}
+			for p := range partitions {
+				if errs[w] != nil {
+					continue
+				}
+				leaves := p.leaves
+				builder := &bulkBuilder{smt: worker, read: func() (*leafNode, error) {
+					if len(leaves) == 0 {
+						return nil, nil
+					}
+					leaf := leaves[0]
+					leaves = leaves[1:]
+					return leaf, nil
+				}}
+				if errs[w] = builder.advance(); errs[w] == nil {
+					parts[p.index].path = p.leaves[0].path
+					parts[p.index].node, errs[w] = builder.build(bits)
+				}
+				if errs[w] != nil {
+					failed.Store(true)
+				}
+			}
+		}(w)
+	}
+
+	// Partitions are contiguous as the leaves are sorted
+	var current partition
+	var err error
+	for !failed.Load() {
+		var leaf *leafNode
+		if leaf, err = reader.next(); err != nil || leaf == nil {
+			break
+		}
+		index := 0
+		if bits > 0 {
+			index = int(leaf.path[0] >> (8 - bits))
+		}
+		if len(current.leaves) > 0 && index != current.index {
+			partitions <- current
+			current = partition{}
+		}
+		current.index = index
+		current.leaves = append(current.leaves, leaf)
+	}
+	if err == nil && len(current.leaves) > 0 {
+		partitions <- current
+	}
+	close(partitions)
+	wg.Wait()
+	if err := errors.Join(append(errs, err)...); err != nil {
+		return nil, err
+	}
+	builder := &bulkBuilder{smt: smt}
+	return builder.combine(parts, 0)
+}
+
+// leafReader reads the leaves of a bulk load from a KVIterator, checking
+// their order. As the leaves are sorted the depth of a leaf is known once the
+// one following it is read, so each leaf is returned after reading the next
+// and its depth is checked against the trie's depth limit.
+type leafReader struct {
+	smt  *SMT
+	iter KVIterator
+	// pending is the leaf read but not yet returned and pendingKey its key
+	pending    *leafNode
+	pendingKey []byte
+	// pendingPrefix is the length of the common prefix of the pending leaf's
+	// path and that of the leaf before it, or -1 if it is the first leaf
+	pendingPrefix int
+	// loaded is the number of leaves read
+	loaded int
+	done   bool
+}
+
+// next returns the next leaf, or nil once every leaf was read
+func (reader *leafReader) next() (*leafNode, error) {
+	for !reader.done {
+		if !reader.iter.Next() {
+			reader.done = true
+			if err := reader.iter.Err(); err != nil {
+				return nil, err
+			}
+			if reader.pending == nil {
+				return nil, nil
+			}
+			return reader.pending, reader.smt.checkLeafDepth(reader.pendingKey, reader.pendingPrefix+1)
+		}
+		key := bytes.Clone(reader.iter.Key())
+		path, err := reader.smt.path(key)
+		if err != nil {
+			return nil, err
+		}
+		leaf := &leafNode{
+			path:      bytes.Clone(path),
+			valueHash: bytes.Clone(reader.smt.valueHash(reader.iter.Value())),
+		}
+		reader.loaded++
+		previous := reader.pending
+		if previous == nil {
+			reader.pending, reader.pendingKey, reader.pendingPrefix = leaf, key, -1
+			continue
+		}
+		if bytes.Compare(previous.path, leaf.path) >= 0 {
+			return nil, errors.Join(ErrUnsortedKeys, fmt.Errorf("path %x follows %x", leaf.path, previous.path))
+		}
+		prefix := countCommonPrefixBits(previous.path, leaf.path, 0)
+		depth := prefix + 1
+		if reader.pendingPrefix > prefix {
+			depth = reader.pendingPrefix + 1
+		}
+		if err := reader.smt.checkLeafDepth(reader.pendingKey, depth); err != nil {
+			return nil, err
+		}
+		reader.pending, reader.pendingKey, reader.pendingPrefix = leaf, key, prefix
+		return previous, nil
+	}
+	return nil, nil
+}
+
+// bulkBuilder builds a trie from a stream of leaves sorted by path, looking a
+// single leaf ahead.
+type bulkBuilder struct {
+	smt  *SMT
+	read func() (*leafNode, error)
+	// next is the next leaf to place in the trie, nil once they are all read
+	next *leafNode
+	// written is the number of nodes written to the node store
+	written int
+}
+
+// bulkPart is the subtrie of the leaves of a partition of a parallel bulk
+// load, along with the path of one of its leaves
+type bulkPart struct {
+	node trieNode
+	path []byte
+}
+
+// advance reads the next leaf
+func (builder *bulkBuilder) advance() (err error) {
+	builder.next, err = builder.read()
+	return err
+}
+
+// build builds the subtrie at the given depth holding the next leaf, and
+// every following leaf sharing its path up to the depth. The subtries below
+// its root are written to the node store and replaced by lazy nodes.
+func (builder *bulkBuilder) build(depth int) (trieNode, error) {
+	first := builder.next
+	if err := builder.advance(); err != nil {
+		return nil, err
+	}
+	// node is the root of the subtrie built so far, branching at split
+	var node trieNode = first
+	split := builder.smt.depth()
+	for builder.next != nil {
+		// The following leaves branch off the subtrie at decreasing depths,
+		// becoming the right children of the inner nodes it is placed under
+		prefix := countCommonPrefixBits(first.path, builder.next.path, 0)
+		if prefix < depth {
+			break
+		}
+		left, err := builder.flush(seal(first.path, node, split, prefix+1))
+		if err != nil {
+			return nil, err
+		}
+		right, err := builder.build(prefix + 1)
+		if err != nil {
+			return nil, err
+		}
+		if right, err = builder.flush(right); err != nil {
+			return nil, err
+		}
+		node, split = &innerNode{leftChild: left, rightChild: right}, prefix
+	}
+	return seal(first.path, node, split, depth), nil
+}
+
+// combine combines the subtries of consecutive partitions, placed at the
+// given depth plus the number of bits the partitions are indexed by, into the
+// subtrie at the given depth.
+func (builder *bulkBuilder) combine(parts []bulkPart, depth int) (trieNode, error) {
+	if len(parts) == 1 {
+		return parts[0].node, nil
+	}
+	half := len(parts) / 2
+	left, err := builder.combine(parts[:half], depth+1)
+	if err != nil {
+		return nil, err
+	}
+	right, err := builder.combine(parts[half:], depth+1)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case left == nil && right == nil:
+		return nil, nil
+	case left == nil:
+		return lift(right, firstPath(parts[half:]), depth), nil
+	case right == nil:
+		return lift(left, firstPath(parts[:half]), depth), nil
+	}
+	if left, err = builder.flush(left); err != nil {
+		return nil, err
+	}
+	if right, err = builder.flush(right); err != nil {
+		return nil, err
+	}
+	return &innerNode{leftChild: left, rightChild: right}, nil
+}
+
+// flush writes the node and its children to the node store, returning it as
+// a lazy node so that it can be released from memory.
+func (builder *bulkBuilder) flush(node trieNode) (trieNode, error) {
+	if err := builder.smt.commit(node, func(trieNode) { builder.written++ }); err != nil {
+		return nil, err
+	}
+	return &lazyNode{builder.smt.digest(node)}, nil
+}
+
+// seal places the node branching at split at the given depth, under an
+// extension node covering the depths in between if it is an inner node. The
+// path provided is that of any leaf below the node.
+func seal(path []byte, node trieNode, split, depth int) trieNode {
+	if _, ok := node.(*leafNode); ok || split == depth {
+		return node
+	}
+	return &extensionNode{
+		path:       path,
+		pathBounds: [2]byte{byte(depth), byte(split)},
+		child:      node,
+	}
+}
+
+// lift places the unflushed node placed at the depth below the one given at
+// the given depth instead, as its sibling is empty. The path provided is that
+// of any leaf below the node.
+func lift(node trieNode, path []byte, depth int) trieNode {
+	switch n := node.(type) {
+	case *extensionNode:
+		n.pathBounds[0] = byte(depth)
+	case *innerNode:
+		return seal(path, n, depth+1, depth)
+	}
+	return node
+}
+
+// firstPath returns the path of the first non-empty partition
+func firstPath(parts []bulkPart) []byte {
+	for _, part := range parts {
+		if part.node != nil {
+			return part.path
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// sliceKVIterator is a KVIterator over slices of keys and values
+type sliceKVIterator struct {
+	keys, values [][]byte
+	i            int
+}
+
+func (it *sliceKVIterator) Next() bool    { it.i++; return it.i <= len(it.keys) }
+func (it *sliceKVIterator) Key() []byte   { return it.keys[it.i-1] }
+func (it *sliceKVIterator) Value() []byte { return it.values[it.i-1] }
+func (it *sliceKVIterator) Err() error    { return nil }
+
+// sortedKVs returns n key-value pairs sorted by the paths of the spec
+func sortedKVs(spec *TrieSpec, n int) *sliceKVIterator {
+	it := &sliceKVIterator{}
+	for i := 0; i < n; i++ {
+		it.keys = append(it.keys, []byte(fmt.Sprintf("key%d", i)))
+		it.values = append(it.values, []byte(fmt.Sprintf("value%d", i)))
+	}
+	sort.Sort(byPath{it, spec})
+	return it
+}
+
+type byPath struct {
+	*sliceKVIterator
+	spec *TrieSpec
+}
+
+func (s byPath) Len() int { return len(s.keys) }
+func (s byPath) Less(i, j int) bool {
+	return bytes.Compare(s.spec.ph.Path(s.keys[i]), s.spec.ph.Path(s.keys[j])) < 0
+}
+func (s byPath) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.values[i], s.values[j] = s.values[j], s.values[i]
+}
+
+func TestSMT_BulkLoad(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 100, 1000} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			nodes := simplemap.NewSimpleMap()
+			loaded := NewSparseMerkleTrie(nodes, sha256.New())
+			kvs := sortedKVs(loaded.Spec(), n)
+			require.NoError(t, loaded.BulkLoad(kvs))
+
+			updated := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+			for i, key := range kvs.keys {
+				require.NoError(t, updated.Update(key, kvs.values[i]))
+			}
+			require.NoError(t, updated.Commit())
+			require.Equal(t, updated.Root(), loaded.Root())
+			require.Equal(t, updated.nodes.Len(), nodes.Len())
+
+			// The loaded trie is committed and can be reopened
+			reopened := ImportSparseMerkleTrie(nodes, sha256.New(), loaded.Root())
+			for i, key := range kvs.keys {
+				got, err := reopened.Get(key)
+				require.NoError(t, err)
+				require.Equal(t, reopened.valueHash(kvs.values[i]), got)
+				proof, err := reopened.Prove(key)
+				require.NoError(t, err)
+				valid, err := VerifyProof(proof, loaded.Root(), key, kvs.values[i], loaded.Spec())
+				require.NoError(t, err)
+				require.True(t, valid)
+			}
+			metrics, err := loaded.Metrics()
+			require.NoError(t, err)
+			require.Equal(t, uint64(n), metrics.Updates)
+		})
+	}
+}
+
+func TestSMT_BulkLoad_IndexPaths(t *testing.T) {
+	loaded := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithPathHasher(NewIndexPathHasher(8)))
+	updated := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithPathHasher(NewIndexPathHasher(8)))
+	kvs := &sliceKVIterator{}
+	for i := uint64(0); i < 300; i += 3 {
+		key := binary.BigEndian.AppendUint64(nil, i)
+		kvs.keys = append(kvs.keys, key)
+		kvs.values = append(kvs.values, key)
+		require.NoError(t, updated.Update(key, key))
+	}
+	require.NoError(t, loaded.BulkLoad(kvs))
+	require.Equal(t, updated.Root(), loaded.Root())
+}
+
+func TestSMT_BulkLoad_Errors(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	kvs := sortedKVs(trie.Spec(), 10)
+	kvs.keys[3], kvs.keys[4] = kvs.keys[4], kvs.keys[3]
+	require.ErrorIs(t, trie.BulkLoad(kvs), ErrUnsortedKeys)
+	require.Equal(t, MerkleRoot(trie.placeholder()), trie.Root())
+
+	// Duplicate keys are out of order
+	kvs = sortedKVs(trie.Spec(), 10)
+	kvs.keys[4] = kvs.keys[3]
+	require.ErrorIs(t, trie.BulkLoad(kvs), ErrUnsortedKeys)
+
+	// Only empty tries can be loaded
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	require.Error(t, trie.BulkLoad(sortedKVs(trie.Spec(), 10)))
+
+	sumTrie := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.Error(t, sumTrie.BulkLoad(sortedKVs(sumTrie.Spec(), 10)))
+}
+
+func TestSMT_BulkLoad_DepthLimit(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithDepthLimit(4, nil))
+	require.ErrorIs(t, trie.BulkLoad(sortedKVs(trie.Spec(), 1000)), ErrDepthLimitExceeded)
+
+	// The alarm is called with the depth of every leaf over the limit
+	var deepest int
+	alarm := func(key []byte, depth int) error {
+		if depth > deepest {
+			deepest = depth
+		}
+		return nil
+	}
+	loaded := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithDepthLimit(4, alarm))
+	require.NoError(t, loaded.BulkLoad(sortedKVs(loaded.Spec(), 1000)))
+	var maxDepth int
+	it := loaded.Iterator()
+	for it.Next() {
+		depth, err := loaded.insertDepth(it.Path())
+		require.NoError(t, err)
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+	}
+	require.NoError(t, it.Err())
+	require.Greater(t, maxDepth, 4)
+	require.Equal(t, maxDepth, deepest)
+}
+
+func TestSMT_BulkLoad_ImportWorkers(t *testing.T) {
+	for _, n := range []int{1, 2, 10, 1000, 5000} {
+		for _, workers := range []int{2, 3, 8} {
+			t.Run(fmt.Sprintf("%d/%d", n, workers), func(t *testing.T) {
+				sequential := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+				require.NoError(t, sequential.BulkLoad(sortedKVs(sequential.Spec(), n)))
+
+				nodes := simplemap.NewSimpleMap()
+				parallel := NewSparseMerkleTrie(nodes, sha256.New(), WithImportWorkers(workers))
+				require.NotNil(t, parallel.hasherConstructor())
+				kvs := sortedKVs(parallel.Spec(), n)
+				require.NoError(t, parallel.BulkLoad(kvs))
+				require.Equal(t, sequential.Root(), parallel.Root())
+				require.Equal(t, sequential.nodes.Len(), nodes.Len())
+
+				reopened := ImportSparseMerkleTrie(nodes, sha256.New(), parallel.Root())
+				for i, key := range kvs.keys {
+					got, err := reopened.Get(key)
+					require.NoError(t, err)
+					require.Equal(t, reopened.valueHash(kvs.values[i]), got)
+				}
+			})
+		}
+	}
+}
+
+func TestSMT_BulkLoad_ImportWorkersErrors(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithImportWorkers(4))
+	kvs := sortedKVs(trie.Spec(), 1000)
+	kvs.keys[500], kvs.keys[501] = kvs.keys[501], kvs.keys[500]
+	require.ErrorIs(t, trie.BulkLoad(kvs), ErrUnsortedKeys)
+	require.Equal(t, MerkleRoot(trie.placeholder()), trie.Root())
+
+	// Tries whose hasher is not registered are loaded by a single worker
+	unregistered := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha512.New(), WithImportWorkers(4))
+	require.Nil(t, unregistered.hasherConstructor())
+	sequential := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha512.New())
+	require.NoError(t, unregistered.BulkLoad(sortedKVs(unregistered.Spec(), 100)))
+	require.NoError(t, sequential.BulkLoad(sortedKVs(sequential.Spec(), 100)))
+	require.Equal(t, sequential.Root(), unregistered.Root())
+}
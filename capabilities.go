@@ -0,0 +1,61 @@
+package smt
+
+import "sort"
+
+// Capability identifies an optional subsystem of the library, so that tools
+// and servers built on different versions of it can negotiate the features
+// they share.
+type Capability string
+
+const (
+	// CapabilitySumTrie is the sparse merkle sum trie, see SMST
+	CapabilitySumTrie Capability = "sum-trie"
+	// CapabilitySnapshots is the retention of committed roots, see
+	// SMTWithSnapshots
+	CapabilitySnapshots Capability = "snapshots"
+	// CapabilityProofCompression is the compression of proofs, see
+	// CompressProofBytes
+	CapabilityProofCompression Capability = "proof-compression"
+	// CapabilityMultiproofs is proving several keys at once, see
+	// SparseMerkleMultiProof
+	CapabilityMultiproofs Capability = "multiproofs"
+	// CapabilityNamespaces is the partitioning of keys into namespaces with
+	// their own validators and quotas, see Namespace
+	CapabilityNamespaces Capability = "namespaces"
+	// CapabilitySimpleMapStore is the in-memory node store, see
+	// kvstore/simplemap
+	CapabilitySimpleMapStore Capability = "backend/simplemap"
+
+	// capabilityHasherPrefix prefixes the capabilities of the registered
+	// hasher implementations, followed by their algorithm and name
+	capabilityHasherPrefix = "hasher/"
+)
+
+// Capabilities returns the capabilities of the library, sorted, including one
+// per registered hasher implementation (e.g. "hasher/sha256/stdlib"), so that
+// hashers registered at runtime, such as SIMD implementations, are reported.
+func Capabilities() []Capability {
+	capabilities := []Capability{
+		CapabilitySumTrie,
+		CapabilitySnapshots,
+		CapabilityProofCompression,
+		CapabilityMultiproofs,
+		CapabilityNamespaces,
+		CapabilitySimpleMapStore,
+	}
+	for _, impl := range defaultHasherRegistry.list() {
+		capabilities = append(capabilities, Capability(capabilityHasherPrefix+impl.Algorithm+"/"+impl.Name))
+	}
+	sort.Slice(capabilities, func(i, j int) bool { return capabilities[i] < capabilities[j] })
+	return capabilities
+}
+
+// HasCapability returns true if the library has the capability provided
+func HasCapability(capability Capability) bool {
+	for _, c := range Capabilities() {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
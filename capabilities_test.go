@@ -0,0 +1,28 @@
+package smt
+
+import (
+	"crypto/sha512"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilities(t *testing.T) {
+	capabilities := Capabilities()
+	require.True(t, sort.SliceIsSorted(capabilities, func(i, j int) bool {
+		return capabilities[i] < capabilities[j]
+	}))
+	require.Contains(t, capabilities, CapabilitySumTrie)
+	require.Contains(t, capabilities, Capability("hasher/sha256/stdlib"))
+	require.True(t, HasCapability(CapabilitySnapshots))
+	require.False(t, HasCapability("hasher/sha512_256/capabilities-test"))
+
+	// Hasher implementations registered at runtime are reported
+	require.NoError(t, RegisterHasherImplementation(HasherImplementation{
+		Algorithm: "sha512_256",
+		Name:      "capabilities-test",
+		New:       sha512.New512_256,
+	}))
+	require.True(t, HasCapability("hasher/sha512_256/capabilities-test"))
+}
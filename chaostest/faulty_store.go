@@ -0,0 +1,117 @@
+package chaostest
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Ensure the FaultyStore can be used as an SMT node store
+var _ kvstore.MapStore = (*FaultyStore)(nil)
+
+// ErrInjected is returned by the operations of a FaultyStore which were made
+// to fail.
+var ErrInjected = errors.New("injected fault")
+
+// Faults configures the faults injected by a FaultyStore, the zero value
+// injecting none.
+type Faults struct {
+	// Latency is the maximum delay added to every operation, each being
+	// delayed by a random duration up to it
+	Latency time.Duration
+	// ErrorRate is the probability an operation fails with ErrInjected
+	// without reaching the store
+	ErrorRate float64
+	// PartialWriteRate is the probability a write stores only the first half
+	// of its value before failing with ErrInjected
+	PartialWriteRate float64
+}
+
+// FaultyStore is a MapStore injecting faults into the operations it forwards
+// to the store it wraps. Len and ClearAll are never faulted.
+type FaultyStore struct {
+	kvstore.MapStore
+	faults   Faults
+	mu       sync.Mutex
+	rand     *rand.Rand
+	disabled atomic.Bool
+	injected atomic.Uint64
+}
+
+// NewFaultyStore returns a store injecting the faults provided into the
+// operations on the store it wraps, drawn from a source with the given seed.
+func NewFaultyStore(store kvstore.MapStore, faults Faults, seed int64) *FaultyStore {
+	return &FaultyStore{
+		MapStore: store,
+		faults:   faults,
+		rand:     rand.New(rand.NewSource(seed)),
+	}
+}
+
+// SetEnabled enables or disables the injection of faults
+func (store *FaultyStore) SetEnabled(enabled bool) {
+	store.disabled.Store(!enabled)
+}
+
+// Injected returns the number of faults injected, excluding latency
+func (store *FaultyStore) Injected() uint64 {
+	return store.injected.Load()
+}
+
+// Get satisfies the MapStore#Get interface
+func (store *FaultyStore) Get(key []byte) ([]byte, error) {
+	if store.fault(false) {
+		return nil, ErrInjected
+	}
+	return store.MapStore.Get(key)
+}
+
+// Set satisfies the MapStore#Set interface
+func (store *FaultyStore) Set(key, value []byte) error {
+	if store.fault(false) {
+		return ErrInjected
+	}
+	if store.fault(true) {
+		if err := store.MapStore.Set(key, value[:len(value)/2]); err != nil {
+			return err
+		}
+		return ErrInjected
+	}
+	return store.MapStore.Set(key, value)
+}
+
+// Delete satisfies the MapStore#Delete interface
+func (store *FaultyStore) Delete(key []byte) error {
+	if store.fault(false) {
+		return ErrInjected
+	}
+	return store.MapStore.Delete(key)
+}
+
+// fault delays the operation and returns true if it must fail, with a
+// partial write if partial is true
+func (store *FaultyStore) fault(partial bool) bool {
+	if store.disabled.Load() {
+		return false
+	}
+	store.mu.Lock()
+	var delay time.Duration
+	if !partial && store.faults.Latency > 0 {
+		delay = time.Duration(store.rand.Int63n(int64(store.faults.Latency)))
+	}
+	rate := store.faults.ErrorRate
+	if partial {
+		rate = store.faults.PartialWriteRate
+	}
+	failed := store.rand.Float64() < rate
+	store.mu.Unlock()
+	time.Sleep(delay)
+	if failed {
+		store.injected.Add(1)
+	}
+	return failed
+}
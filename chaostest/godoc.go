@@ -0,0 +1,12 @@
+// Package chaostest provides a soak test harness for tries, driving
+// randomised concurrent workloads against an SMTWithStorage whose stores
+// inject faults (latency, errors and partial writes), so that integrators can
+// soak-test their own backends and configurations before production.
+//
+// The harness checks the guarantees the library makes when its stores fail:
+// values read from the trie are those last written, commits are atomic, and
+// recovering the trie from its stores (see smt.ImportSMTWithStorage) restores
+// exactly its last committed state, neither losing committed changes nor
+// leaking uncommitted ones. Once the workload completes, every key is read
+// back and proven against the final root.
+package chaostest
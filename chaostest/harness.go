@@ -0,0 +1,397 @@
+package chaostest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// ErrInvariantViolated is returned when the trie breaks one of the guarantees
+// checked by the harness.
+var ErrInvariantViolated = errors.New("invariant violated")
+
+// Config configures a soak test, its zero fields taking their defaults.
+type Config struct {
+	// Workers is the number of goroutines operating on the trie concurrently,
+	// 4 by default
+	Workers int
+	// Operations is the number of updates, deletes and reads made across all
+	// workers, 10000 by default
+	Operations int
+	// Keys is the number of distinct keys operated on, 1000 by default
+	Keys int
+	// CommitEvery is the number of operations between commits, 100 by default
+	CommitEvery int
+	// Faults are the faults injected into both stores of the trie
+	Faults Faults
+	// Seed seeds the workload and the faults, so that runs with a single
+	// worker are reproducible
+	Seed int64
+	// NewHasher returns the hasher of the trie, sha256 by default
+	NewHasher func() hash.Hash
+	// Options are the options the trie is created with
+	Options []smt.TrieSpecOption
+}
+
+// withDefaults returns the config with its zero fields set to their defaults
+func (config Config) withDefaults() Config {
+	if config.Workers <= 0 {
+		config.Workers = 4
+	}
+	if config.Operations <= 0 {
+		config.Operations = 10000
+	}
+	if config.Keys <= 0 {
+		config.Keys = 1000
+	}
+	if config.CommitEvery <= 0 {
+		config.CommitEvery = 100
+	}
+	if config.NewHasher == nil {
+		config.NewHasher = sha256.New
+	}
+	return config
+}
+
+// Report summarises a soak test
+type Report struct {
+	// Operations is the number of updates, deletes and reads made
+	Operations uint64
+	// Failed is the number of operations failed by an injected fault
+	Failed uint64
+	// Commits is the number of commits attempted
+	Commits uint64
+	// FailedCommits is the number of commits failed by an injected fault
+	FailedCommits uint64
+	// Recoveries is the number of times the trie was recovered from its
+	// stores after a failure
+	Recoveries uint64
+	// Injected is the number of faults injected into the stores
+	Injected uint64
+	// Root is the root of the trie once the workload completed
+	Root smt.MerkleRoot
+}
+
+// Run soak tests an SMTWithStorage backed by the empty stores provided, into
+// which the configured faults are injected. Workers update, delete and read
+// disjoint sets of keys concurrently, and the trie is committed every
+// CommitEvery operations. After an operation or commit fails the trie is
+// recovered from its stores, as an application restarting would, with faults
+// disabled. Once the workload completes, or ctx is done, faults are disabled,
+// the trie is committed and reopened, and every key is read and proven.
+//
+// An error wrapping ErrInvariantViolated is returned if a read returns a value
+// other than the last written, a recovered trie is not at its last committed
+// root (or that of the commit which failed, if recovery completed it), or a
+// key's proof does not verify. Errors other than injected faults are also
+// reported as violations.
+func Run(ctx context.Context, nodes, preimages kvstore.MapStore, config Config) (Report, error) {
+	config = config.withDefaults()
+	h := &harness{
+		config:    config,
+		faulty:    true,
+		nodes:     NewFaultyStore(nodes, config.Faults, config.Seed),
+		preimages: NewFaultyStore(preimages, config.Faults, config.Seed+1),
+		reference: smt.NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), config.NewHasher(), config.Options...),
+		committed: make(map[string][]byte),
+		pending:   make(map[string][]byte),
+	}
+	h.trie = smt.NewSMTWithStorage(h.nodes, h.preimages, config.NewHasher(), config.Options...)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make([]error, config.Workers)
+	var wg sync.WaitGroup
+	for w := 0; w < config.Workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			if errs[w] = h.work(ctx, w); errs[w] != nil {
+				cancel()
+			}
+		}(w)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return h.report(), err
+	}
+	err := h.validate()
+	return h.report(), err
+}
+
+// harness is the state of a soak test
+type harness struct {
+	config           Config
+	nodes, preimages *FaultyStore
+	// faulty is false once faults are disabled for the final validation
+	faulty bool
+
+	// mu is held for reading while operating on the trie, and for writing
+	// while committing or recovering it
+	mu   sync.RWMutex
+	trie *smt.SMTWithStorage
+	// reference is a fault-free trie holding the committed state
+	reference *smt.SMTWithStorage
+
+	// modelMu guards the expected state of the trie: its committed values
+	// and those written since, nil for deleted keys
+	modelMu   sync.Mutex
+	committed map[string][]byte
+	pending   map[string][]byte
+
+	operations, failed                 atomic.Uint64
+	commits, failedCommits, recoveries uint64
+}
+
+// work runs the operations of a worker on the keys it owns
+func (h *harness) work(ctx context.Context, w int) error {
+	r := rand.New(rand.NewSource(h.config.Seed + int64(w) + 2))
+	var owned [][]byte
+	for i := w; i < h.config.Keys; i += h.config.Workers {
+		owned = append(owned, key(i))
+	}
+	if len(owned) == 0 {
+		return nil
+	}
+	for ctx.Err() == nil {
+		n := h.operations.Add(1)
+		if n > uint64(h.config.Operations) {
+			h.operations.Add(^uint64(0))
+			return nil
+		}
+		if err := h.operate(r, owned[r.Intn(len(owned))]); err != nil {
+			return err
+		}
+		if n%uint64(h.config.CommitEvery) == 0 {
+			if err := h.commit(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// operate updates, deletes or reads the key, recovering the trie if the
+// operation fails
+func (h *harness) operate(r *rand.Rand, key []byte) error {
+	h.mu.RLock()
+	trie := h.trie
+	err := h.apply(r, trie, key)
+	h.mu.RUnlock()
+	if err == nil || errors.Is(err, ErrInvariantViolated) {
+		return err
+	}
+	if !errors.Is(err, ErrInjected) {
+		return violation("operating on %q: %w", key, err)
+	}
+	h.failed.Add(1)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.trie != trie {
+		// The trie was already recovered
+		return nil
+	}
+	return h.recover(nil)
+}
+
+// apply applies a random operation on the key to the trie, checking reads
+// against the expected state
+func (h *harness) apply(r *rand.Rand, trie *smt.SMTWithStorage, key []byte) error {
+	expected, exists := h.expected(key)
+	switch op := r.Intn(10); {
+	case op < 6:
+		value := make([]byte, 1+r.Intn(32))
+		r.Read(value)
+		if err := trie.Update(key, value); err != nil {
+			return err
+		}
+		h.record(key, value)
+	case op < 8:
+		err := trie.Delete(key)
+		if errors.Is(err, smt.ErrKeyNotFound) && !exists {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return violation("deleted %q which was not present", key)
+		}
+		h.record(key, nil)
+	default:
+		value, err := trie.GetValue(key)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(value, expected) {
+			return violation("read %x from %q, expected %x", value, key, expected)
+		}
+	}
+	return nil
+}
+
+// commit commits the trie, recovering it if the commit fails
+func (h *harness) commit() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.commits++
+	attempted := h.trie.Root()
+	if err := h.trie.Commit(); err != nil {
+		if !errors.Is(err, ErrInjected) {
+			return violation("committing: %w", err)
+		}
+		h.failedCommits++
+		return h.recover(attempted)
+	}
+	if err := h.settle(); err != nil {
+		return err
+	}
+	if root := h.trie.Root(); !bytes.Equal(root, h.reference.Root()) {
+		return violation("committed root %x, expected %x", root, h.reference.Root())
+	}
+	return nil
+}
+
+// recover reopens the trie from its stores with faults disabled, checking it
+// is at its last committed root or, if recovery completed a failed commit,
+// at the root of the commit. The caller must hold mu for writing.
+func (h *harness) recover(attempted smt.MerkleRoot) error {
+	h.setFaults(false)
+	defer h.setFaults(h.faulty)
+	trie, err := smt.ImportSMTWithStorage(
+		h.nodes, h.preimages, h.config.NewHasher(), h.reference.Root(), h.config.Options...,
+	)
+	if err != nil {
+		return violation("recovering: %w", err)
+	}
+	h.recoveries++
+	h.trie = trie
+	root := trie.Root()
+	if attempted != nil && bytes.Equal(root, attempted) {
+		return h.settle()
+	}
+	if !bytes.Equal(root, h.reference.Root()) {
+		return violation("recovered root %x, expected %x", root, h.reference.Root())
+	}
+	h.modelMu.Lock()
+	h.pending = make(map[string][]byte)
+	h.modelMu.Unlock()
+	return nil
+}
+
+// settle moves the pending writes to the committed state, and commits them
+// to the reference trie. The caller must hold mu for writing.
+func (h *harness) settle() error {
+	h.modelMu.Lock()
+	defer h.modelMu.Unlock()
+	for k, value := range h.pending {
+		var err error
+		if value == nil {
+			delete(h.committed, k)
+			if err = h.reference.Delete([]byte(k)); errors.Is(err, smt.ErrKeyNotFound) {
+				err = nil
+			}
+		} else {
+			h.committed[k] = value
+			err = h.reference.Update([]byte(k), value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	h.pending = make(map[string][]byte)
+	return h.reference.Commit()
+}
+
+// validate commits the trie with faults disabled and reopens it, checking
+// every key reads its expected value and is proven against the root
+func (h *harness) validate() error {
+	h.faulty = false
+	h.setFaults(false)
+	if err := h.commit(); err != nil {
+		return err
+	}
+	root := h.reference.Root()
+	trie, err := smt.ImportSMTWithStorage(h.nodes, h.preimages, h.config.NewHasher(), root, h.config.Options...)
+	if err != nil {
+		return violation("reopening: %w", err)
+	}
+	for i := 0; i < h.config.Keys; i++ {
+		key := key(i)
+		expected := h.committed[string(key)]
+		value, err := trie.GetValue(key)
+		if err != nil {
+			return violation("reading %q: %w", key, err)
+		}
+		if !bytes.Equal(value, expected) {
+			return violation("read %x from %q, expected %x", value, key, expected)
+		}
+		proof, err := trie.Prove(key)
+		if err != nil {
+			return violation("proving %q: %w", key, err)
+		}
+		if valid, err := smt.VerifyProof(proof, root, key, expected, trie.Spec()); err != nil || !valid {
+			return violation("proof of %q does not verify: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// expected returns the expected value of the key, and whether it is present
+func (h *harness) expected(key []byte) ([]byte, bool) {
+	h.modelMu.Lock()
+	defer h.modelMu.Unlock()
+	value, ok := h.pending[string(key)]
+	if !ok {
+		value = h.committed[string(key)]
+	}
+	return value, value != nil
+}
+
+// record records the value written to the key, nil if it was deleted
+func (h *harness) record(key, value []byte) {
+	h.modelMu.Lock()
+	defer h.modelMu.Unlock()
+	h.pending[string(key)] = value
+}
+
+// setFaults enables or disables the faults of both stores
+func (h *harness) setFaults(enabled bool) {
+	h.nodes.SetEnabled(enabled)
+	h.preimages.SetEnabled(enabled)
+}
+
+// report returns the report of the soak test so far
+func (h *harness) report() Report {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return Report{
+		Operations:    h.operations.Load(),
+		Failed:        h.failed.Load(),
+		Commits:       h.commits,
+		FailedCommits: h.failedCommits,
+		Recoveries:    h.recoveries,
+		Injected:      h.nodes.Injected() + h.preimages.Injected(),
+		Root:          h.trie.Root(),
+	}
+}
+
+// key returns the i-th key of the workload
+func key(i int) []byte {
+	return []byte(fmt.Sprintf("key-%d", i))
+}
+
+// violation returns an error wrapping ErrInvariantViolated
+func violation(format string, args ...any) error {
+	return errors.Join(ErrInvariantViolated, fmt.Errorf(format, args...))
+}
@@ -0,0 +1,76 @@
+package chaostest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestRun(t *testing.T) {
+	report, err := Run(context.Background(), simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), Config{
+		Operations:  2000,
+		Keys:        200,
+		CommitEvery: 20,
+		Faults: Faults{
+			ErrorRate:        0.005,
+			PartialWriteRate: 0.005,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2000), report.Operations)
+	require.Equal(t, uint64(100), report.Commits-1)
+	require.NotZero(t, report.Injected)
+	require.NotZero(t, report.FailedCommits)
+	require.NotZero(t, report.Recoveries)
+	require.NotEmpty(t, report.Root)
+}
+
+func TestRun_NoFaults(t *testing.T) {
+	report, err := Run(context.Background(), simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), Config{
+		Workers:    1,
+		Operations: 500,
+	})
+	require.NoError(t, err)
+	require.Zero(t, report.Injected)
+	require.Zero(t, report.Failed)
+	require.Zero(t, report.Recoveries)
+
+	// Runs with a single worker are reproducible
+	again, err := Run(context.Background(), simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), Config{
+		Workers:    1,
+		Operations: 500,
+	})
+	require.NoError(t, err)
+	require.Equal(t, report, again)
+}
+
+func TestFaultyStore(t *testing.T) {
+	store := simplemap.NewSimpleMap()
+	faulty := NewFaultyStore(store, Faults{PartialWriteRate: 1}, 1)
+	require.ErrorIs(t, faulty.Set([]byte("key"), []byte("value")), ErrInjected)
+	value, err := store.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("va"), value)
+	require.Equal(t, uint64(1), faulty.Injected())
+
+	faulty.SetEnabled(false)
+	require.NoError(t, faulty.Set([]byte("key"), []byte("value")))
+	value, err = faulty.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), value)
+
+	faulty = NewFaultyStore(store, Faults{Latency: time.Millisecond}, 1)
+	_, err = faulty.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Zero(t, faulty.Injected())
+
+	faulty = NewFaultyStore(store, Faults{ErrorRate: 1}, 1)
+	_, err = faulty.Get([]byte("key"))
+	require.ErrorIs(t, err, ErrInjected)
+	require.ErrorIs(t, faulty.Delete([]byte("key")), ErrInjected)
+	require.Equal(t, 1, faulty.Len())
+}
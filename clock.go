@@ -0,0 +1,208 @@
+package smt
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Ensure the clocks satisfy the Clock interface
+var (
+	_ Clock = systemClock{}
+	_ Clock = (*ManualClock)(nil)
+)
+
+// SystemClock is the Clock of the system, used unless another is configured
+var SystemClock Clock = systemClock{}
+
+// Clock is the source of time and timers of every time-based feature of the
+// library: snapshot retention, proof archive expiry, root publication retries,
+// anchoring intervals and health check latencies. Replacing the SystemClock
+// with a ManualClock lets deterministic simulations (and tests) fast-forward
+// time.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// NewTimer returns a timer delivering the time on its channel once d has
+	// elapsed
+	NewTimer(d time.Duration) Timer
+	// NewTicker returns a timer delivering the time on its channel every time
+	// d elapses, dropping ticks if its receiver falls behind
+	NewTicker(d time.Duration) Timer
+}
+
+// Timer is a timer or ticker created by a Clock
+type Timer interface {
+	// C returns the channel the time is delivered on when the timer fires
+	C() <-chan time.Time
+	// Stop stops the timer, after which it no longer fires
+	Stop()
+}
+
+// WithClock returns an Option making the time-based features of the trie use
+// the clock provided.
+func WithClock(clock Clock) TrieSpecOption {
+	return func(ts *TrieSpec) { ts.clock = clock }
+}
+
+// Clock returns the clock of the trie, the SystemClock unless configured
+// WithClock.
+func (spec *TrieSpec) Clock() Clock {
+	if spec.clock == nil {
+		return SystemClock
+	}
+	return spec.clock
+}
+
+// systemClock is a Clock reading the system's time
+type systemClock struct{}
+
+// Now satisfies the Clock#Now interface
+func (systemClock) Now() time.Time { return time.Now() }
+
+// NewTimer satisfies the Clock#NewTimer interface
+func (systemClock) NewTimer(d time.Duration) Timer { return systemTimer{time.NewTimer(d)} }
+
+// NewTicker satisfies the Clock#NewTicker interface
+func (systemClock) NewTicker(d time.Duration) Timer { return systemTicker{time.NewTicker(d)} }
+
+// systemTimer is a Timer backed by a time.Timer
+type systemTimer struct{ timer *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time { return t.timer.C }
+func (t systemTimer) Stop()               { t.timer.Stop() }
+
+// systemTicker is a Timer backed by a time.Ticker
+type systemTicker struct{ ticker *time.Ticker }
+
+func (t systemTicker) C() <-chan time.Time { return t.ticker.C }
+func (t systemTicker) Stop()               { t.ticker.Stop() }
+
+// ManualClock is a Clock whose time only moves when it is advanced, firing
+// the timers and tickers whose deadlines it passes. ManualClock is safe for
+// concurrent use.
+type ManualClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+}
+
+// NewManualClock returns a ManualClock set to the time provided
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now satisfies the Clock#Now interface
+func (clock *ManualClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return clock.now
+}
+
+// NewTimer satisfies the Clock#NewTimer interface
+func (clock *ManualClock) NewTimer(d time.Duration) Timer {
+	return clock.add(d, 0)
+}
+
+// NewTicker satisfies the Clock#NewTicker interface, panicking if d is not
+// positive as time.NewTicker does
+func (clock *ManualClock) NewTicker(d time.Duration) Timer {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return clock.add(d, d)
+}
+
+// Advance moves the clock forward by d, firing every timer whose deadline is
+// reached in deadline order. Tickers fire at most once per call.
+func (clock *ManualClock) Advance(d time.Duration) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	clock.now = clock.now.Add(d)
+	clock.fire()
+}
+
+// Set moves the clock to the time provided, see Advance. The clock cannot be
+// moved backwards, earlier times are ignored.
+func (clock *ManualClock) Set(now time.Time) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	if now.After(clock.now) {
+		clock.now = now
+		clock.fire()
+	}
+}
+
+// Timers returns the number of timers and tickers which have not fired or
+// been stopped, so that simulations can wait for a component to set its
+// timer before advancing the clock.
+func (clock *ManualClock) Timers() int {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return len(clock.timers)
+}
+
+// add registers a timer firing after d, and every period after if positive
+func (clock *ManualClock) add(d, period time.Duration) *manualTimer {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	timer := &manualTimer{
+		clock:    clock,
+		c:        make(chan time.Time, 1),
+		deadline: clock.now.Add(d),
+		period:   period,
+	}
+	clock.timers = append(clock.timers, timer)
+	clock.fire()
+	return timer
+}
+
+// fire fires the timers whose deadline is reached, the caller must hold mu
+func (clock *ManualClock) fire() {
+	sort.SliceStable(clock.timers, func(i, j int) bool {
+		return clock.timers[i].deadline.Before(clock.timers[j].deadline)
+	})
+	remaining := clock.timers[:0]
+	for _, timer := range clock.timers {
+		if timer.deadline.After(clock.now) {
+			remaining = append(remaining, timer)
+			continue
+		}
+		// As with the system's timers, ticks are dropped if the receiver has
+		// not received the previous one
+		select {
+		case timer.c <- clock.now:
+		default:
+		}
+		if timer.period > 0 {
+			for !timer.deadline.After(clock.now) {
+				timer.deadline = timer.deadline.Add(timer.period)
+			}
+			remaining = append(remaining, timer)
+		}
+	}
+	clock.timers = remaining
+}
+
+// remove unregisters the timer
+func (clock *ManualClock) remove(timer *manualTimer) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	for i, t := range clock.timers {
+		if t == timer {
+			clock.timers = append(clock.timers[:i], clock.timers[i+1:]...)
+			return
+		}
+	}
+}
+
+// manualTimer is a Timer of a ManualClock
+type manualTimer struct {
+	clock    *ManualClock
+	c        chan time.Time
+	deadline time.Time
+	period   time.Duration
+}
+
+func (t *manualTimer) C() <-chan time.Time { return t.c }
+func (t *manualTimer) Stop()               { t.clock.remove(t) }
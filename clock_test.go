@@ -0,0 +1,95 @@
+package smt
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestManualClock(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := NewManualClock(start)
+	require.Equal(t, start, clock.Now())
+
+	timer := clock.NewTimer(time.Minute)
+	ticker := clock.NewTicker(time.Second)
+	stopped := clock.NewTimer(time.Second)
+	stopped.Stop()
+	require.Equal(t, 2, clock.Timers())
+
+	// Only the ticker fires before the timer's deadline
+	clock.Advance(time.Second)
+	require.Equal(t, start.Add(time.Second), <-ticker.C())
+	require.Len(t, timer.C(), 0)
+	require.Len(t, stopped.C(), 0)
+
+	// Ticks are dropped until the previous one is received
+	clock.Advance(time.Second)
+	clock.Advance(time.Second)
+	require.Equal(t, start.Add(2*time.Second), <-ticker.C())
+	require.Len(t, ticker.C(), 0)
+
+	// Timers fire once and are then unregistered
+	clock.Set(start.Add(time.Hour))
+	require.Equal(t, start.Add(time.Hour), <-timer.C())
+	require.Equal(t, 1, clock.Timers())
+	ticker.Stop()
+	require.Equal(t, 0, clock.Timers())
+
+	// The clock cannot be moved backwards, and expired timers fire at once
+	clock.Set(start)
+	require.Equal(t, start.Add(time.Hour), clock.Now())
+	require.Equal(t, start.Add(time.Hour), <-clock.NewTimer(0).C())
+	require.Panics(t, func() { clock.NewTicker(0) })
+}
+
+func TestWithClock_Snapshots(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	trie := NewSMTWithSnapshots(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New(), WithClock(clock))
+	require.Equal(t, clock, trie.Clock())
+	require.Equal(t, SystemClock, NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New()).Clock())
+
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, trie.Update([]byte(key), []byte("value")))
+		require.NoError(t, trie.Commit())
+		clock.Advance(time.Hour)
+	}
+	snapshots := trie.Snapshots()
+	require.Len(t, snapshots, 3)
+	require.Equal(t, time.Unix(0, 0), snapshots[0].Time)
+
+	// Fast-forwarding the clock ages the snapshots
+	clock.Advance(24 * time.Hour)
+	report, err := trie.Prune(PrunePolicy{MaxAge: 26*time.Hour + time.Minute})
+	require.NoError(t, err)
+	require.Len(t, report.Dropped, 1)
+	require.Equal(t, 2, report.Retained)
+}
+
+func TestRootPublication_SetClock(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	publisher := &flakyPublisher{failures: 2, attempts: make(map[string]int)}
+	publication := NewRootPublication(publisher, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour})
+	publication.SetClock(clock)
+
+	done := make(chan PublicationReceipt)
+	go func() {
+		receipt, err := publication.Publish(context.Background(), MerkleRoot("root"))
+		require.NoError(t, err)
+		done <- receipt
+	}()
+
+	// Retries wait for the clock to reach their backoff rather than sleeping
+	for _, backoff := range []time.Duration{time.Hour, 2 * time.Hour} {
+		require.Eventually(t, func() bool { return clock.Timers() == 1 }, time.Second, time.Millisecond)
+		clock.Advance(backoff)
+	}
+	receipt := <-done
+	require.Equal(t, 3, receipt.Attempts)
+	require.Equal(t, time.Unix(0, 0).Add(3*time.Hour), receipt.PublishedAt)
+}
@@ -0,0 +1,150 @@
+package smt
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Ensure the cowStore can be used as an SMT node store
+var _ kvstore.MapStore = (*cowStore)(nil)
+
+// Clone returns an independent copy of the trie, including its uncommitted
+// changes, sharing its node store copy-on-write: the clone reads the nodes of
+// the trie from its store, but its own writes and deletes (e.g. when it is
+// committed) are kept in memory and never reach the shared store. This allows
+// a speculative block of updates to be applied to the clone and its root
+// computed, then discarded without touching the trie.
+//
+// Only the uncommitted nodes of the trie are copied, so cloning is cheap. The
+// clone publishes no events and starts with no metrics. As the clone shares
+// the trie's hashers, the two must not be used concurrently.
+func (smt *SMT) Clone() *SMT {
+	spec := smt.TrieSpec
+	spec.events = nil
+	spec.borrowStores = true
+	spec.persistMetrics = false
+	clone := &SMT{
+		TrieSpec: spec,
+		nodes:    newCowStore(smt.nodes),
+		rootHash: smt.rootHash,
+		root:     cloneNode(smt.root),
+		closed:   smt.closed,
+		updates:  smt.updates,
+	}
+	for _, orphans := range smt.orphans {
+		clone.orphans = append(clone.orphans, append(orphanNodes(nil), orphans...))
+	}
+	return clone
+}
+
+// Clone returns an independent copy of the sum trie sharing its node store
+// copy-on-write, see SMT.Clone.
+func (smst *SMST) Clone() *SMST {
+	clone := smst.SMT.Clone()
+	return &SMST{TrieSpec: clone.TrieSpec, SMT: clone}
+}
+
+// cloneNode returns a copy of the node in which persisted nodes are replaced
+// by lazy nodes, as the nodes of a trie are modified in place when it is
+// updated, and dirty nodes are copied along with their children.
+func cloneNode(node trieNode) trieNode {
+	if node == nil {
+		return nil
+	}
+	if node.Persisted() {
+		return &lazyNode{node.CachedDigest()}
+	}
+	switch n := node.(type) {
+	case *innerNode:
+		return &innerNode{leftChild: cloneNode(n.leftChild), rightChild: cloneNode(n.rightChild)}
+	case *extensionNode:
+		return &extensionNode{path: bytes.Clone(n.path), pathBounds: n.pathBounds, child: cloneNode(n.child)}
+	case *leafNode:
+		return &leafNode{path: bytes.Clone(n.path), valueHash: bytes.Clone(n.valueHash)}
+	}
+	return node
+}
+
+// cowStore is a copy-on-write overlay of a node store, reading through to
+// the underlying store the keys it has not written or deleted itself.
+type cowStore struct {
+	mu      sync.RWMutex
+	base    kvstore.MapStore
+	writes  map[string][]byte
+	deletes map[string]bool
+	// cleared is true if the underlying store is hidden after ClearAll
+	cleared bool
+}
+
+// newCowStore returns a copy-on-write overlay of the store provided
+func newCowStore(base kvstore.MapStore) *cowStore {
+	return &cowStore{
+		base:    base,
+		writes:  make(map[string][]byte),
+		deletes: make(map[string]bool),
+	}
+}
+
+// Get satisfies the MapStore#Get interface
+func (store *cowStore) Get(key []byte) ([]byte, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	if value, ok := store.writes[string(key)]; ok {
+		return value, nil
+	}
+	if store.cleared || store.deletes[string(key)] {
+		return nil, ErrKeyNotFound
+	}
+	return store.base.Get(key)
+}
+
+// Set satisfies the MapStore#Set interface
+func (store *cowStore) Set(key, value []byte) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.writes[string(key)] = bytes.Clone(value)
+	delete(store.deletes, string(key))
+	return nil
+}
+
+// Delete satisfies the MapStore#Delete interface
+func (store *cowStore) Delete(key []byte) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.writes, string(key))
+	store.deletes[string(key)] = true
+	return nil
+}
+
+// Len satisfies the MapStore#Len interface
+func (store *cowStore) Len() int {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	if store.cleared {
+		return len(store.writes)
+	}
+	n := store.base.Len()
+	for key := range store.writes {
+		if _, err := store.base.Get([]byte(key)); err != nil {
+			n++
+		}
+	}
+	for key := range store.deletes {
+		if _, err := store.base.Get([]byte(key)); err == nil {
+			n--
+		}
+	}
+	return n
+}
+
+// ClearAll satisfies the MapStore#ClearAll interface
+func (store *cowStore) ClearAll() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.writes = make(map[string][]byte)
+	store.deletes = make(map[string]bool)
+	store.cleared = true
+	return nil
+}
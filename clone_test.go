@@ -0,0 +1,97 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_Clone(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleTrie(nodes, sha256.New())
+	for i := 0; i < 50; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte("v1")))
+	}
+	require.NoError(t, trie.Commit())
+	// Uncommitted changes are cloned too
+	require.NoError(t, trie.Update([]byte("dirty"), []byte("v1")))
+	root := trie.Root()
+	storeLen := nodes.Len()
+
+	// Speculative updates, deletes and commits of the clone do not affect the
+	// trie or its store
+	clone := trie.Clone()
+	require.Equal(t, root, clone.Root())
+	for i := 0; i < 25; i++ {
+		require.NoError(t, clone.Update([]byte(fmt.Sprintf("key-%d", i)), []byte("v2")))
+	}
+	require.NoError(t, clone.Delete([]byte("key-30")))
+	require.NoError(t, clone.Delete([]byte("dirty")))
+	require.NoError(t, clone.Commit())
+	require.NotEqual(t, root, clone.Root())
+	require.Equal(t, root, trie.Root())
+	require.Equal(t, storeLen, nodes.Len())
+
+	got, err := clone.Get([]byte("key-3"))
+	require.NoError(t, err)
+	require.Equal(t, clone.valueHash([]byte("v2")), got)
+	has, err := clone.Has([]byte("key-30"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// The trie is unaffected, and applying the same updates yields the same
+	// root as the clone
+	got, err = trie.Get([]byte("key-3"))
+	require.NoError(t, err)
+	require.Equal(t, trie.valueHash([]byte("v1")), got)
+	for i := 0; i < 25; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte("v2")))
+	}
+	require.NoError(t, trie.Delete([]byte("key-30")))
+	require.NoError(t, trie.Delete([]byte("dirty")))
+	require.NoError(t, trie.Commit())
+	require.Equal(t, clone.Root(), trie.Root())
+}
+
+func TestSMST_Clone(t *testing.T) {
+	trie := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar"), 5))
+	require.NoError(t, trie.Commit())
+	clone := trie.Clone()
+	require.NoError(t, clone.Update([]byte("baz"), []byte("qux"), 7))
+	require.Equal(t, uint64(12), clone.Sum())
+	require.Equal(t, uint64(5), trie.Sum())
+}
+
+func TestCowStore(t *testing.T) {
+	base := simplemap.NewSimpleMap()
+	require.NoError(t, base.Set([]byte("a"), []byte("1")))
+	require.NoError(t, base.Set([]byte("b"), []byte("2")))
+	store := newCowStore(base)
+
+	require.NoError(t, store.Set([]byte("c"), []byte("3")))
+	require.NoError(t, store.Delete([]byte("a")))
+	require.NoError(t, store.Set([]byte("b"), []byte("4")))
+	_, err := store.Get([]byte("a"))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	value, err := store.Get([]byte("b"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("4"), value)
+	require.Equal(t, 2, store.Len())
+
+	// The underlying store is untouched
+	value, err = base.Get([]byte("b"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("2"), value)
+	require.Equal(t, 2, base.Len())
+
+	require.NoError(t, store.ClearAll())
+	require.Equal(t, 0, store.Len())
+	_, err = store.Get([]byte("b"))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	require.Equal(t, 2, base.Len())
+}
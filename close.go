@@ -0,0 +1,136 @@
+package smt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Ensure the closedStore can be used as an SMT node store
+var _ kvstore.MapStore = closedStore{}
+
+// Ensure the tries can be closed
+var (
+	_ io.Closer = (*SMT)(nil)
+	_ io.Closer = (*SMST)(nil)
+	_ io.Closer = (*SMTWithStorage)(nil)
+	_ io.Closer = (*DecayingSMST)(nil)
+)
+
+var (
+	// ErrClosed is returned when using a trie after it was closed
+	ErrClosed = errors.New("trie closed")
+	// ErrUncommittedChanges is returned when closing a trie with changes that
+	// were neither committed nor discarded.
+	ErrUncommittedChanges = errors.New("trie has uncommitted changes")
+)
+
+// WithBorrowedStores returns an Option declaring the trie borrows its stores
+// rather than owning them, so they are left open when the trie is closed.
+// This should be used when stores are shared between tries, or outlive them.
+func WithBorrowedStores() TrieSpecOption {
+	return func(ts *TrieSpec) { ts.borrowStores = true }
+}
+
+// Close closes the trie and stops its node store if the trie owns it and it
+// can be stopped (i.e. it has a Stop or Close method, like the badger store).
+// Tries own their stores unless created with WithBorrowedStores. Tries
+// configured WithPersistentMetrics persist their metrics first. Every
+// subsequent use of the trie returns ErrClosed. Closing a closed trie is a
+// no-op.
+//
+// Uncommitted changes are never lost silently: if there are any an error
+// wrapping ErrUncommittedChanges is returned and the trie is left open, to be
+// committed or discarded before closing it again.
+func (smt *SMT) Close() error {
+	if smt.closed {
+		return nil
+	}
+	if err := smt.checkUncommitted(); err != nil {
+		return err
+	}
+	saveErr := smt.saveMetrics()
+	nodes := smt.nodes
+	smt.closed = true
+	smt.nodes = closedStore{}
+	smt.root, smt.orphans = nil, nil
+	return errors.Join(saveErr, smt.releaseStore(nodes))
+}
+
+// Close closes the trie and stops both of its stores if it owns them, see
+// SMT.Close. As for the trie's nodes, pending values must be committed or
+// discarded before closing it.
+func (smt *SMTWithStorage) Close() error {
+	smt.commitMu.Lock()
+	defer smt.commitMu.Unlock()
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	if smt.trie.closed {
+		return nil
+	}
+	if err := smt.trie.checkUncommitted(); err != nil {
+		return err
+	}
+	preimages := smt.preimages
+	smt.preimages = closedStore{}
+	smt.clearPending()
+	return errors.Join(smt.trie.Close(), smt.trie.releaseStore(preimages))
+}
+
+// Close closes the trie and stops both its node and weights stores if it
+// owns them, see SMT.Close.
+func (trie *DecayingSMST) Close() error {
+	if trie.closed {
+		return nil
+	}
+	if err := trie.checkUncommitted(); err != nil {
+		return err
+	}
+	weights := trie.weights
+	trie.weights = closedStore{}
+	return errors.Join(trie.SMST.Close(), trie.releaseStore(weights))
+}
+
+// checkUncommitted returns an error wrapping ErrUncommittedChanges if the
+// trie was updated since it was last committed, discarded or repointed.
+func (smt *SMT) checkUncommitted() error {
+	if smt.updates == 0 && len(smt.orphans) == 0 {
+		return nil
+	}
+	return errors.Join(ErrUncommittedChanges, fmt.Errorf("%d uncommitted updates", smt.updates))
+}
+
+// releaseStore stops the store provided if it is owned by the trie and can be
+// stopped
+func (spec *TrieSpec) releaseStore(store kvstore.MapStore) error {
+	if spec.borrowStores {
+		return nil
+	}
+	switch s := store.(type) {
+	case interface{ Stop() error }:
+		return s.Stop()
+	case io.Closer:
+		return s.Close()
+	}
+	return nil
+}
+
+// closedStore is the store of a closed trie, failing every operation
+type closedStore struct{}
+
+// Get satisfies the MapStore#Get interface
+func (closedStore) Get([]byte) ([]byte, error) { return nil, ErrClosed }
+
+// Set satisfies the MapStore#Set interface
+func (closedStore) Set([]byte, []byte) error { return ErrClosed }
+
+// Delete satisfies the MapStore#Delete interface
+func (closedStore) Delete([]byte) error { return ErrClosed }
+
+// Len satisfies the MapStore#Len interface
+func (closedStore) Len() int { return 0 }
+
+// ClearAll satisfies the MapStore#ClearAll interface
+func (closedStore) ClearAll() error { return ErrClosed }
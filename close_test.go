@@ -0,0 +1,99 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// stoppableStore is a MapStore counting the times it was stopped
+type stoppableStore struct {
+	kvstore.MapStore
+	stops int
+}
+
+func (store *stoppableStore) Stop() error {
+	store.stops++
+	return nil
+}
+
+func TestSMT_Close(t *testing.T) {
+	nodes := &stoppableStore{MapStore: simplemap.NewSimpleMap()}
+	trie := NewSparseMerkleTrie(nodes, sha256.New())
+	require.NoError(t, trie.Update([]byte("key"), []byte("value")))
+	require.NoError(t, trie.Commit())
+
+	require.NoError(t, trie.Close())
+	require.Equal(t, 1, nodes.stops)
+	// Closing is idempotent
+	require.NoError(t, trie.Close())
+	require.Equal(t, 1, nodes.stops)
+
+	_, err := trie.Get([]byte("key"))
+	require.ErrorIs(t, err, ErrClosed)
+	require.ErrorIs(t, trie.Update([]byte("key"), []byte("value")), ErrClosed)
+	require.ErrorIs(t, trie.Delete([]byte("key")), ErrClosed)
+	_, err = trie.Prove([]byte("key"))
+	require.ErrorIs(t, err, ErrClosed)
+	_, err = trie.ProveClosest(make([]byte, trie.ph.PathSize()))
+	require.ErrorIs(t, err, ErrClosed)
+	require.ErrorIs(t, trie.Commit(), ErrClosed)
+	_, _, err = trie.ListKeys(nil, 10)
+	require.NoError(t, err, "closed trie holds no leaves")
+}
+
+func TestSMTWithStorage_Close(t *testing.T) {
+	nodes := &stoppableStore{MapStore: simplemap.NewSimpleMap()}
+	preimages := &stoppableStore{MapStore: simplemap.NewSimpleMap()}
+	smt := NewSMTWithStorage(nodes, preimages, sha256.New())
+	require.NoError(t, smt.Update([]byte("key"), []byte("value")))
+
+	// Uncommitted changes must be committed or discarded before closing
+	require.ErrorIs(t, smt.Close(), ErrUncommittedChanges)
+	require.Zero(t, nodes.stops)
+	value, err := smt.GetValue([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), value)
+	require.NoError(t, smt.Discard())
+
+	require.NoError(t, smt.Close())
+	require.NoError(t, smt.Close())
+	require.Equal(t, 1, nodes.stops)
+	require.Equal(t, 1, preimages.stops)
+	_, err = smt.GetValue([]byte("key"))
+	require.ErrorIs(t, err, ErrClosed)
+	require.ErrorIs(t, smt.Commit(), ErrClosed)
+	require.Nil(t, smt.Root())
+
+	weights := &stoppableStore{MapStore: simplemap.NewSimpleMap()}
+	decaying := NewDecayingSparseMerkleSumTrie(simplemap.NewSimpleMap(), weights, sha256.New(), LinearDecay(1))
+	require.NoError(t, decaying.Close())
+	require.NoError(t, decaying.Close())
+	require.Equal(t, 1, weights.stops)
+	require.ErrorIs(t, decaying.Update([]byte("key"), []byte("value"), 1), ErrClosed)
+}
+
+func TestSMT_CloseBorrowedStores(t *testing.T) {
+	nodes := &stoppableStore{MapStore: simplemap.NewSimpleMap()}
+	preimages := &stoppableStore{MapStore: simplemap.NewSimpleMap()}
+
+	// Two tries sharing the same stores
+	first := NewSMTWithStorage(nodes, preimages, sha256.New(), WithBorrowedStores())
+	second := NewSMTWithStorage(nodes, preimages, sha256.New(), WithBorrowedStores())
+	require.NoError(t, first.Update([]byte("key"), []byte("value")))
+	require.NoError(t, first.Commit())
+	require.NoError(t, first.Close())
+	require.Zero(t, nodes.stops)
+	require.Zero(t, preimages.stops)
+
+	// The stores remain usable by the other trie
+	require.NoError(t, second.Update([]byte("key2"), []byte("value2")))
+	require.NoError(t, second.Commit())
+	require.NoError(t, second.Close())
+	require.Zero(t, nodes.stops)
+	require.Zero(t, preimages.stops)
+}
@@ -0,0 +1,189 @@
+package smt
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Ensure the journalStore can be used as an SMT node store
+var _ kvstore.MapStore = (*journalStore)(nil)
+
+// commitJournalKey is the key the journal of an in-progress commit of an
+// SMTWithStorage is stored under in its preimages store, it cannot collide
+// with the value hashes the preimages are stored under as long as the value
+// hasher's size differs from its length.
+var commitJournalKey = reserveKey("commit journal", []byte("smt/commit-journal"))
+
+// journalOp is a single write to a store recorded in a commitJournal
+type journalOp struct {
+	Key    []byte
+	Value  []byte
+	Delete bool
+}
+
+// commitJournal is the write-ahead log of a commit of an SMTWithStorage,
+// holding every write to both of its stores along with the committed root.
+type commitJournal struct {
+	Root      []byte
+	Nodes     []journalOp
+	Preimages []journalOp
+}
+
+// apply applies every write of the journal to the stores provided, as the
+// writes are idempotent it can safely be reapplied after a partial apply.
+func (journal *commitJournal) apply(nodes, preimages kvstore.MapStore) error {
+	for _, op := range journal.Preimages {
+		if err := preimages.Set(op.Key, op.Value); err != nil {
+			return err
+		}
+	}
+	for _, op := range journal.Nodes {
+		var err error
+		if op.Delete {
+			err = nodes.Delete(op.Key)
+		} else {
+			err = nodes.Set(op.Key, op.Value)
+		}
+		// Orphans may already have been deleted by a previous partial apply
+		if err != nil && !(op.Delete && isNotFound(nodes, op.Key)) {
+			return err
+		}
+	}
+	return nil
+}
+
+// isNotFound returns true if the key is absent from the store
+func isNotFound(store kvstore.MapStore, key []byte) bool {
+	_, err := store.Get(key)
+	return err != nil
+}
+
+// isKeyNotFound returns true if the error was returned by a store getting a
+// key which is not present, rather than failing to read it
+func isKeyNotFound(err error) bool {
+	return errors.Is(err, kvstore.ErrKeyNotFound) || errors.Is(err, ErrKeyNotFound)
+}
+
+// journalStore is a node store buffering every write made through it, so
+// that they can be journaled before being applied.
+type journalStore struct {
+	kvstore.MapStore
+	ops     []journalOp
+	pending map[string]journalOp
+}
+
+// Get satisfies the MapStore#Get interface, reading buffered writes first
+func (store *journalStore) Get(key []byte) ([]byte, error) {
+	if op, ok := store.pending[string(key)]; ok {
+		if op.Delete {
+			return nil, ErrKeyNotFound
+		}
+		return op.Value, nil
+	}
+	return store.MapStore.Get(key)
+}
+
+// Set satisfies the MapStore#Set interface, buffering the write
+func (store *journalStore) Set(key, value []byte) error {
+	store.record(journalOp{Key: bytes.Clone(key), Value: bytes.Clone(value)})
+	return nil
+}
+
+// Delete satisfies the MapStore#Delete interface, buffering the delete
+func (store *journalStore) Delete(key []byte) error {
+	store.record(journalOp{Key: bytes.Clone(key), Delete: true})
+	return nil
+}
+
+func (store *journalStore) record(op journalOp) {
+	if store.pending == nil {
+		store.pending = make(map[string]journalOp)
+	}
+	store.ops = append(store.ops, op)
+	store.pending[string(op.Key)] = op
+}
+
+// commitJournaled commits the trie and the pending preimages atomically: the
+// node writes of the commit are buffered and written along with the pending
+// preimages to a journal in a single write, before being applied to the
+// stores. A commit interrupted after the journal was written is completed by
+// RecoverSMTWithStorage, otherwise neither store was modified. The trie only
+// considers the commit done once the journal was applied, so a failed commit
+// can be retried. The caller must hold the commit and trie locks.
+func (smt *SMTWithStorage) commitJournaled() error {
+	if smt.trie.closed {
+		return ErrClosed
+	}
+	store := &journalStore{MapStore: smt.trie.nodes}
+	smt.trie.nodes = store
+	pending, err := smt.trie.writeCommit()
+	smt.trie.nodes = store.MapStore
+	if err != nil {
+		return err
+	}
+	if err := smt.writeJournal(store.ops); err != nil {
+		smt.trie.revertCommit(pending)
+		return err
+	}
+	smt.trie.finishCommit(pending)
+	smt.clearPending()
+	return nil
+}
+
+// writeJournal journals the node writes provided along with the pending
+// preimages, applies them to the stores and then deletes the journal.
+func (smt *SMTWithStorage) writeJournal(nodes []journalOp) error {
+	journal := &commitJournal{Root: smt.trie.Root(), Nodes: nodes}
+	for _, valueHash := range smt.pendingOrder {
+		journal.Preimages = append(journal.Preimages, journalOp{
+			Key:   []byte(valueHash),
+			Value: smt.pending[valueHash],
+		})
+	}
+	if len(journal.Nodes) == 0 && len(journal.Preimages) == 0 {
+		return nil
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := gob.NewEncoder(buf).Encode(journal); err != nil {
+		return err
+	}
+	if err := smt.preimages.Set(commitJournalKey, buf.Bytes()); err != nil {
+		return err
+	}
+	if err := journal.apply(smt.trie.nodes, smt.preimages); err != nil {
+		return err
+	}
+	return smt.preimages.Delete(commitJournalKey)
+}
+
+// RecoverSMTWithStorage completes the commit of an SMTWithStorage using the
+// stores provided if it was interrupted (e.g. by a crash) after its journal
+// was written, returning the root of the recovered commit. A nil root is
+// returned if there was no interrupted commit to recover, or if its journal
+// was only partially written, in which case it is discarded.
+func RecoverSMTWithStorage(nodes, preimages kvstore.MapStore) (MerkleRoot, error) {
+	bz, err := preimages.Get(commitJournalKey)
+	if isKeyNotFound(err) || (err == nil && bz == nil) {
+		// No journal was found
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	journal := new(commitJournal)
+	if err := gob.NewDecoder(bytes.NewBuffer(bz)).Decode(journal); err != nil {
+		// The journal was only partially written, as its commit fails before
+		// applying it if writing it fails, so none of the commit was applied
+		return nil, preimages.Delete(commitJournalKey)
+	}
+	if err := journal.apply(nodes, preimages); err != nil {
+		return nil, err
+	}
+	if err := preimages.Delete(commitJournalKey); err != nil {
+		return nil, err
+	}
+	return journal.Root, nil
+}
@@ -0,0 +1,171 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+var errCrash = errors.New("crash")
+
+// crashingStore is a MapStore failing every write after the first n
+type crashingStore struct {
+	kvstore.MapStore
+	writes int
+}
+
+func (store *crashingStore) Set(key, value []byte) error {
+	if store.writes == 0 {
+		return errCrash
+	}
+	store.writes--
+	return store.MapStore.Set(key, value)
+}
+
+func (store *crashingStore) Delete(key []byte) error {
+	if store.writes == 0 {
+		return errCrash
+	}
+	store.writes--
+	return store.MapStore.Delete(key)
+}
+
+func TestSMTWithStorage_CommitAtomic(t *testing.T) {
+	nodes, preimages := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	smt := NewSMTWithStorage(nodes, preimages, sha256.New())
+	for i := 0; i < 10; i++ {
+		require.NoError(t, smt.Update([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i))))
+	}
+	// Values are only written on commit
+	value, err := smt.GetValue([]byte("key-1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value-1"), value)
+	require.Zero(t, preimages.Len())
+	require.NoError(t, smt.Commit())
+	// Every key is stored along with its value
+	require.Equal(t, 20, preimages.Len())
+	root := smt.Root()
+
+	// Crash while applying the journal of the next commit
+	crashingNodes := &crashingStore{MapStore: nodes, writes: 3}
+	smt, err = ImportSMTWithStorage(crashingNodes, preimages, sha256.New(), root)
+	require.NoError(t, err)
+	for i := 10; i < 20; i++ {
+		require.NoError(t, smt.Update([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i))))
+	}
+	require.NoError(t, smt.Delete([]byte("key-0")))
+	newRoot := smt.Root()
+	require.ErrorIs(t, smt.Commit(), errCrash)
+
+	// Recovery completes the interrupted commit
+	smt, err = ImportSMTWithStorage(nodes, preimages, sha256.New(), root)
+	require.NoError(t, err)
+	require.Equal(t, newRoot, smt.Root())
+	for i := 1; i < 20; i++ {
+		value, err := smt.GetValue([]byte(fmt.Sprintf("key-%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value-%d", i)), value)
+	}
+	recovered, err := RecoverSMTWithStorage(nodes, preimages)
+	require.NoError(t, err)
+	require.Nil(t, recovered)
+}
+
+func TestSMTWithStorage_CommitCrashBeforeJournal(t *testing.T) {
+	nodes, preimages := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	smt := NewSMTWithStorage(nodes, preimages, sha256.New())
+	require.NoError(t, smt.Update([]byte("key"), []byte("value")))
+	require.NoError(t, smt.Commit())
+	root := smt.Root()
+	nodeCount, preimageCount := nodes.Len(), preimages.Len()
+
+	// Failing to write the journal leaves both stores untouched
+	crashingPreimages := &crashingStore{MapStore: preimages}
+	smt, err := ImportSMTWithStorage(nodes, crashingPreimages, sha256.New(), root)
+	require.NoError(t, err)
+	require.NoError(t, smt.Update([]byte("key"), []byte("value2")))
+	require.NoError(t, smt.Update([]byte("key2"), []byte("value")))
+	require.ErrorIs(t, smt.Commit(), errCrash)
+	require.Equal(t, nodeCount, nodes.Len())
+	require.Equal(t, preimageCount, preimages.Len())
+
+	smt, err = ImportSMTWithStorage(nodes, preimages, sha256.New(), root)
+	require.NoError(t, err)
+	require.Equal(t, root, smt.Root())
+	value, err := smt.GetValue([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), value)
+}
+
+func TestSMTWithStorage_CommitTornJournal(t *testing.T) {
+	nodes, preimages := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	smt := NewSMTWithStorage(nodes, preimages, sha256.New())
+	require.NoError(t, smt.Update([]byte("key"), []byte("value")))
+	require.NoError(t, smt.Commit())
+	root := smt.Root()
+
+	// A partially written journal was never applied, so it is discarded
+	require.NoError(t, preimages.Set(commitJournalKey, []byte("torn")))
+	recovered, err := RecoverSMTWithStorage(nodes, preimages)
+	require.NoError(t, err)
+	require.Nil(t, recovered)
+	_, err = preimages.Get(commitJournalKey)
+	require.Error(t, err)
+
+	smt, err = ImportSMTWithStorage(nodes, preimages, sha256.New(), root)
+	require.NoError(t, err)
+	require.Equal(t, root, smt.Root())
+}
+
+func TestSMTWithStorage_CommitRetry(t *testing.T) {
+	nodes, preimages := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	crashingPreimages := &crashingStore{MapStore: preimages}
+	smt := NewSMTWithStorage(nodes, crashingPreimages, sha256.New())
+	for i := 0; i < 10; i++ {
+		require.NoError(t, smt.Update([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i))))
+	}
+	root := smt.Root()
+
+	// A commit failing to write its journal is not considered done
+	require.ErrorIs(t, smt.Commit(), errCrash)
+	require.Zero(t, nodes.Len())
+	require.Equal(t, root, smt.Root())
+	metrics, err := smt.Metrics()
+	require.NoError(t, err)
+	require.Zero(t, metrics.Commits)
+
+	// So retrying it writes every node and value
+	crashingPreimages.writes = 100
+	require.NoError(t, smt.Commit())
+	require.Equal(t, 10, smt.LastCommitStats().Updates)
+	require.NotZero(t, smt.LastCommitStats().Written)
+
+	smt, err = ImportSMTWithStorage(nodes, preimages, sha256.New(), root)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		value, err := smt.GetValue([]byte(fmt.Sprintf("key-%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value-%d", i)), value)
+	}
+}
+
+// unreadableStore is a MapStore failing every read
+type unreadableStore struct {
+	kvstore.MapStore
+}
+
+func (store *unreadableStore) Get([]byte) ([]byte, error) {
+	return nil, errCrash
+}
+
+func TestRecoverSMTWithStorage_ReadError(t *testing.T) {
+	// Failing to read the journal is not mistaken for there being none
+	_, err := RecoverSMTWithStorage(simplemap.NewSimpleMap(), &unreadableStore{simplemap.NewSimpleMap()})
+	require.ErrorIs(t, err, errCrash)
+}
@@ -0,0 +1,49 @@
+package smt
+
+// CommitStats are the statistics of a single commit of a trie.
+type CommitStats struct {
+	// Root is the root committed
+	Root MerkleRoot
+	// Updates is the number of updates and deletes committed
+	Updates int
+	// Written is the number of nodes written to the node store
+	Written int
+	// Orphaned is the number of orphaned nodes deleted from the node store
+	Orphaned int
+}
+
+// NodesPerUpdate returns the average number of nodes written per update, or
+// the number of nodes written if the commit had no updates.
+func (stats CommitStats) NodesPerUpdate() float64 {
+	if stats.Updates == 0 {
+		return float64(stats.Written)
+	}
+	return float64(stats.Written) / float64(stats.Updates)
+}
+
+// WithCommitAnomalyThreshold returns an Option flagging commits writing more
+// than maxNodesPerUpdate nodes per update on average as anomalous. Updates of
+// uniformly distributed paths write at most around log2(n) nodes each, fewer
+// when committed in batches sharing nodes, so many more indicate a
+// pathological workload. Anomalous commits are counted in the trie's Metrics
+// and published to its EventBus as a CommitAnomalyEvent, which operators
+// subscribe to with EventCommitAnomaly to be alerted.
+func WithCommitAnomalyThreshold(maxNodesPerUpdate int) TrieSpecOption {
+	return func(ts *TrieSpec) { ts.maxNodesPerUpdate = maxNodesPerUpdate }
+}
+
+// LastCommitStats returns the statistics of the last commit of the trie, the
+// zero value if it was not committed yet.
+func (smt *SMT) LastCommitStats() CommitStats {
+	return smt.lastCommit
+}
+
+// checkCommit flags the commit as anomalous if it exceeds the trie's anomaly
+// threshold, if any, returning true if it was flagged
+func (spec *TrieSpec) checkCommit(stats CommitStats) bool {
+	if spec.maxNodesPerUpdate <= 0 || stats.NodesPerUpdate() <= float64(spec.maxNodesPerUpdate) {
+		return false
+	}
+	spec.emit(CommitAnomalyEvent{Stats: stats})
+	return true
+}
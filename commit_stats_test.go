@@ -0,0 +1,65 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestCommitStats(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(16, EventCommit)
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleTrie(nodes, sha256.New(), WithEventBus(bus))
+	require.Zero(t, trie.LastCommitStats())
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+	}
+	require.NoError(t, trie.Commit())
+	stats := trie.LastCommitStats()
+	require.Equal(t, trie.Root(), stats.Root)
+	require.Equal(t, 10, stats.Updates)
+	require.Equal(t, nodes.Len(), stats.Written)
+	require.Zero(t, stats.Orphaned)
+	require.Equal(t, stats, (<-sub.Events()).(CommitEvent).Stats)
+
+	require.NoError(t, trie.Delete([]byte("key-0")))
+	require.NoError(t, trie.Commit())
+	stats = trie.LastCommitStats()
+	require.Equal(t, 1, stats.Updates)
+	require.Positive(t, stats.Orphaned)
+
+	// Committing without changes writes nothing
+	require.NoError(t, trie.Commit())
+	require.Zero(t, trie.LastCommitStats().Written)
+	require.Zero(t, trie.LastCommitStats().Updates)
+}
+
+func TestCommitAnomalyThreshold(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(16, EventCommitAnomaly)
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(),
+		WithEventBus(bus), WithCommitAnomalyThreshold(3))
+
+	for i := 0; i < 16; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%02d", i)), []byte("value")))
+	}
+	require.NoError(t, trie.Commit())
+	require.Empty(t, sub.Events())
+
+	// A single update rewrites the whole path to its leaf
+	require.NoError(t, trie.Update([]byte("key-16"), []byte("value")))
+	require.NoError(t, trie.Commit())
+	require.Len(t, sub.Events(), 1)
+	flagged := (<-sub.Events()).(CommitAnomalyEvent).Stats
+	require.Equal(t, trie.LastCommitStats(), flagged)
+	require.Greater(t, flagged.NodesPerUpdate(), 3.0)
+	metrics, err := trie.Metrics()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), metrics.Anomalies)
+}
@@ -0,0 +1,73 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrConflict is returned when a conditional update is rejected as the key
+// no longer holds the value it was expected to.
+var ErrConflict = errors.New("update conflict")
+
+// ConflictError is returned when a conditional update is rejected as the
+// value hash of its key is not the one expected. It matches ErrConflict with
+// errors.Is.
+type ConflictError struct {
+	Key []byte
+	// Expected is the value hash the update expected, and Actual the value
+	// hash of the key, either is empty if the key is absent
+	Expected []byte
+	Actual   []byte
+}
+
+// Error satisfies the error interface
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: key %x has value hash %x, expected %x", ErrConflict, e.Key, e.Actual, e.Expected)
+}
+
+// Is allows the ConflictError to match ErrConflict
+func (e *ConflictError) Is(target error) bool { return target == ErrConflict }
+
+// UpdateIf updates the key with the new value only if its current value hash,
+// as returned by Get, is the one expected, or if the key is absent when the
+// expected value hash is empty. A ConflictError is returned otherwise, so that
+// writers sharing a trie can apply optimistic updates, retrying on conflicts
+// with the value they read again.
+func (smt *SMT) UpdateIf(key, value, expectedValueHash []byte) error {
+	if err := smt.checkValueHash(key, expectedValueHash); err != nil {
+		return err
+	}
+	return smt.Update(key, value)
+}
+
+// UpdateIf updates the key with the new value only if its current value hash,
+// as returned by Get, is the one expected, see SMT.UpdateIf. The key is locked
+// between the comparison and the update, so concurrent conditional updates of
+// a key expecting the same value hash succeed at most once.
+func (smt *SMTWithStorage) UpdateIf(key, value, expectedValueHash []byte) error {
+	if err := smt.admit(); err != nil {
+		return err
+	}
+	defer smt.lockKey(key)()
+	smt.trieMu.Lock()
+	err := smt.trie.checkValueHash(key, expectedValueHash)
+	smt.trieMu.Unlock()
+	if err != nil {
+		return err
+	}
+	return smt.update(key, value)
+}
+
+// checkValueHash returns a ConflictError if the value hash of the key is not
+// the one expected
+func (smt *SMT) checkValueHash(key, expectedValueHash []byte) error {
+	actual, err := smt.Get(key)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(actual, expectedValueHash) {
+		return &ConflictError{Key: bytes.Clone(key), Expected: bytes.Clone(expectedValueHash), Actual: bytes.Clone(actual)}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_UpdateIf(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+
+	// An empty expectation requires the key to be absent
+	require.NoError(t, trie.UpdateIf([]byte("key"), []byte("one"), nil))
+	err := trie.UpdateIf([]byte("key"), []byte("two"), nil)
+	require.ErrorIs(t, err, ErrConflict)
+	var conflict *ConflictError
+	require.True(t, errors.As(err, &conflict))
+	require.Equal(t, []byte("key"), conflict.Key)
+	require.Empty(t, conflict.Expected)
+	require.Equal(t, trie.valueHash([]byte("one")), conflict.Actual)
+
+	require.NoError(t, trie.UpdateIf([]byte("key"), []byte("two"), trie.valueHash([]byte("one"))))
+	root := trie.Root()
+	require.ErrorIs(t, trie.UpdateIf([]byte("key"), []byte("three"), trie.valueHash([]byte("one"))), ErrConflict)
+	require.Equal(t, root, trie.Root())
+	valueHash, err := trie.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, trie.valueHash([]byte("two")), valueHash)
+}
+
+func TestSMTWithStorage_UpdateIf(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	key := []byte("counter")
+	encode := func(n uint64) []byte { return binary.BigEndian.AppendUint64(nil, n) }
+	require.NoError(t, trie.Update(key, encode(0)))
+
+	// Concurrent writers increment the counter optimistically, retrying on
+	// conflicts, so that no increment is lost
+	const writers, increments = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increments; {
+				value, err := trie.GetValue(key)
+				require.NoError(t, err)
+				next := encode(binary.BigEndian.Uint64(value) + 1)
+				err = trie.UpdateIf(key, next, trie.trie.valueHash(value))
+				if errors.Is(err, ErrConflict) {
+					continue
+				}
+				require.NoError(t, err)
+				i++
+			}
+		}()
+	}
+	wg.Wait()
+	value, err := trie.GetValue(key)
+	require.NoError(t, err)
+	require.Equal(t, uint64(writers*increments), binary.BigEndian.Uint64(value))
+}
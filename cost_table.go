@@ -0,0 +1,143 @@
+package smt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownOperation is returned when looking up the cost of an operation
+// which is not in a CostTable.
+var ErrUnknownOperation = errors.New("unknown operation")
+
+// The operations priced by a CostTable
+const (
+	CostGet    = "get"
+	CostUpdate = "update"
+	CostDelete = "delete"
+	CostProve  = "prove"
+	CostVerify = "verify"
+)
+
+// CostFormula is a cost growing linearly with the depth of the leaf an
+// operation reaches: Base + PerLevel*depth.
+type CostFormula struct {
+	Base     uint64 `json:"base"`
+	PerLevel uint64 `json:"per_level"`
+}
+
+// At returns the cost at the given depth
+func (formula CostFormula) At(depth int) uint64 {
+	return formula.Base + formula.PerLevel*uint64(depth)
+}
+
+// OperationCost is the cost of an operation on a single key, as formulas of
+// the depth of the key's leaf. Batches of an operation cost at most the sum
+// of the costs of their keys, as nodes shared between the keys are only read
+// and hashed once.
+type OperationCost struct {
+	Operation string `json:"operation"`
+	// NodeReads is the number of nodes read from the node store
+	NodeReads CostFormula `json:"node_reads"`
+	// NodeWrites is the number of nodes written to or deleted from the node
+	// store when the trie is next committed
+	NodeWrites CostFormula `json:"node_writes"`
+	// Hashes is the number of invocations of the spec's hashers, including
+	// hashing keys into paths and values into value hashes
+	Hashes CostFormula `json:"hashes"`
+}
+
+// CostTable is a machine-readable table of the costs of the operations on a
+// trie, computed from its spec alone, so that virtual machines embedding a
+// trie can derive deterministic gas schedules from it. The costs are upper
+// bounds for a committed trie with no nodes cached in memory and are
+// independent of the trie's contents; they serialise to JSON.
+type CostTable struct {
+	// Fingerprint is the fingerprint of the spec the table was computed from
+	Fingerprint []byte `json:"fingerprint"`
+	// MaxDepth is the maximum depth of a leaf, i.e. the number of bits of a path
+	MaxDepth int `json:"max_depth"`
+	// HashSize is the size of the digests of the trie's nodes
+	HashSize   int             `json:"hash_size"`
+	Operations []OperationCost `json:"operations"`
+}
+
+// CostTable returns the table of the costs of the operations on a trie with
+// the spec.
+func (spec *TrieSpec) CostTable() *CostTable {
+	// Keys are hashed into paths unless the path hasher is the identity, as
+	// for index paths, and values are only hashed by a value hasher
+	var pathHashes, valueHashes uint64 = 1, 0
+	if _, ok := spec.ph.(*indexPathHasher); ok {
+		pathHashes = 0
+	}
+	if spec.vh != nil {
+		valueHashes = 1
+	}
+	table := &CostTable{
+		Fingerprint: spec.Fingerprint(),
+		MaxDepth:    spec.depth(),
+		HashSize:    spec.hashSize(),
+		Operations: []OperationCost{
+			{
+				// Every node from the root to the leaf is read
+				Operation: CostGet,
+				NodeReads: CostFormula{Base: 1, PerLevel: 1},
+				Hashes:    CostFormula{Base: pathHashes},
+			},
+			{
+				// The leaf and every node above it are rehashed, rewritten and
+				// the nodes they replace deleted
+				Operation:  CostUpdate,
+				NodeReads:  CostFormula{Base: 1, PerLevel: 1},
+				NodeWrites: CostFormula{Base: 2, PerLevel: 2},
+				Hashes:     CostFormula{Base: pathHashes + valueHashes + 1, PerLevel: 1},
+			},
+			{
+				// The leaf's sibling is also read, as it may be collapsed into
+				// its parent
+				Operation:  CostDelete,
+				NodeReads:  CostFormula{Base: 2, PerLevel: 1},
+				NodeWrites: CostFormula{Base: 1, PerLevel: 2},
+				Hashes:     CostFormula{Base: pathHashes, PerLevel: 1},
+			},
+			{
+				// The digests of the side nodes are known without hashing, but
+				// the leaf's sibling is read for the proof's sibling data
+				Operation: CostProve,
+				NodeReads: CostFormula{Base: 2, PerLevel: 1},
+				Hashes:    CostFormula{Base: pathHashes},
+			},
+			{
+				// The leaf and every node above it are recomputed
+				Operation: CostVerify,
+				Hashes:    CostFormula{Base: pathHashes + valueHashes + 1, PerLevel: 1},
+			},
+		},
+	}
+	if spec.verifyNodes {
+		// Every node read is hashed to verify it matches its digest
+		for i := range table.Operations {
+			cost := &table.Operations[i]
+			cost.Hashes.Base += cost.NodeReads.Base
+			cost.Hashes.PerLevel += cost.NodeReads.PerLevel
+		}
+	}
+	return table
+}
+
+// Cost returns the node reads, node writes and hash invocations of the
+// operation on a batch of keys whose leaves are at most at the given depth,
+// which is capped at the table's maximum depth.
+func (table *CostTable) Cost(operation string, depth, batchSize int) (reads, writes, hashes uint64, err error) {
+	if depth > table.MaxDepth {
+		depth = table.MaxDepth
+	}
+	for _, cost := range table.Operations {
+		if cost.Operation != operation {
+			continue
+		}
+		n := uint64(batchSize)
+		return n * cost.NodeReads.At(depth), n * cost.NodeWrites.At(depth), n * cost.Hashes.At(depth), nil
+	}
+	return 0, 0, 0, errors.Join(ErrUnknownOperation, fmt.Errorf("%q", operation))
+}
@@ -0,0 +1,77 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestCostTable(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	table := trie.Spec().CostTable()
+	require.Equal(t, trie.Spec().Fingerprint(), table.Fingerprint)
+	require.Equal(t, 256, table.MaxDepth)
+	require.Equal(t, sha256.Size, table.HashSize)
+
+	reads, writes, hashes, err := table.Cost(CostGet, 10, 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{11, 0, 1}, []uint64{reads, writes, hashes})
+
+	// Batches cost the sum of their keys
+	reads, writes, hashes, err = table.Cost(CostUpdate, 10, 4)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{44, 88, 52}, []uint64{reads, writes, hashes})
+
+	// Depths are capped at the maximum depth
+	_, _, capped, err := table.Cost(CostVerify, 1000, 1)
+	require.NoError(t, err)
+	_, _, max, err := table.Cost(CostVerify, table.MaxDepth, 1)
+	require.NoError(t, err)
+	require.Equal(t, max, capped)
+
+	_, _, _, err = table.Cost("scan", 10, 1)
+	require.ErrorIs(t, err, ErrUnknownOperation)
+
+	// The table is the same for every trie with the spec
+	bz, err := json.Marshal(table)
+	require.NoError(t, err)
+	other, err := json.Marshal(NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New()).Spec().CostTable())
+	require.NoError(t, err)
+	require.JSONEq(t, string(bz), string(other))
+
+	var decoded CostTable
+	require.NoError(t, json.Unmarshal(bz, &decoded))
+	require.Equal(t, *table, decoded)
+}
+
+func TestCostTable_Hashers(t *testing.T) {
+	// Index paths and raw values are not hashed
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(),
+		WithPathHasher(NewIndexPathHasher(8)), WithValueHasher(nil))
+	table := trie.Spec().CostTable()
+	require.Equal(t, 64, table.MaxDepth)
+
+	_, _, hashes, err := table.Cost(CostGet, 10, 1)
+	require.NoError(t, err)
+	require.Zero(t, hashes)
+	_, _, hashes, err = table.Cost(CostVerify, 10, 1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(11), hashes)
+}
+
+func TestCostTable_NodeVerification(t *testing.T) {
+	// Verifying nodes hashes every node read
+	plain := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New()).Spec().CostTable()
+	verified := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithNodeVerification()).Spec().CostTable()
+	for _, operation := range []string{CostGet, CostUpdate, CostDelete, CostProve, CostVerify} {
+		reads, _, hashes, err := plain.Cost(operation, 10, 1)
+		require.NoError(t, err)
+		_, _, verifiedHashes, err := verified.Cost(operation, 10, 1)
+		require.NoError(t, err)
+		require.Equal(t, hashes+reads, verifiedHashes)
+	}
+}
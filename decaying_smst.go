@@ -0,0 +1,307 @@
+package smt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// The number of bytes used to represent the epoch a leaf was inserted at
+const epochSizeBytes = 8
+
+var (
+	// ErrEpochRegression is returned when attempting to move the epoch of a
+	// DecayingSMST backwards.
+	ErrEpochRegression = errors.New("epoch cannot decrease")
+	// ErrInvalidHalfLife is returned when creating a half-life DecayFunc with
+	// a zero half-life.
+	ErrInvalidHalfLife = errors.New("half-life must be positive")
+
+	// decayEpochKey is the key the current epoch of a DecayingSMST is stored
+	// under in its weights store, it cannot collide with the fixed size paths
+	// the leaf weights are stored under.
+	decayEpochKey = reserveKey("decaying sum trie", []byte("decay/epoch"))
+)
+
+// DecayFunc returns the effective weight of a leaf inserted with the given
+// weight, age epochs ago. It must be deterministic as the effective weights
+// are committed into the root, and is expected to be non-increasing in age.
+type DecayFunc func(weight, age uint64) uint64
+
+// HalfLifeDecay returns a DecayFunc halving the weight of a leaf every
+// halfLife epochs, or ErrInvalidHalfLife if halfLife is zero.
+func HalfLifeDecay(halfLife uint64) (DecayFunc, error) {
+	if halfLife == 0 {
+		return nil, ErrInvalidHalfLife
+	}
+	return func(weight, age uint64) uint64 {
+		halvings := age / halfLife
+		if halvings >= 64 {
+			return 0
+		}
+		return weight >> halvings
+	}, nil
+}
+
+// LinearDecay returns a DecayFunc reducing the weight of a leaf by rate every
+// epoch, until it reaches zero.
+func LinearDecay(rate uint64) DecayFunc {
+	return func(weight, age uint64) uint64 {
+		if age != 0 && rate > weight/age {
+			return 0
+		}
+		return weight - rate*age
+	}
+}
+
+// DecayingSMST is a Sparse Merkle Sum Trie whose leaf weights decay with the
+// number of epochs since they were inserted. The leaves commit to the weight
+// and epoch they were inserted with, while their sum is their effective
+// weight, so the root's sum is the recency weighted total.
+//
+// Epochs default to the commit index: every Commit seals the current epoch and
+// advances to the next one. Callers wanting to weight by time instead should
+// call SetEpoch (e.g. with the current hour) before updating the trie.
+//
+// Effective weights are recomputed lazily: the sum of a leaf is its effective
+// weight at the epoch it was last updated or proven, as Prove first rewrites
+// the leaf with its effective weight at the current epoch. The root's sum is
+// thus the total of the leaves' weights as of their last refresh, and
+// verifiers recompute the effective weight of a proven leaf from its inserted
+// weight and age with VerifyDecayingProof.
+type DecayingSMST struct {
+	*SMST
+	decay DecayFunc
+	// weights maps leaf paths to the epoch and weight they were inserted with
+	weights kvstore.MapStore
+	// pending are the weights (by path) not yet written to the weights store,
+	// nil for deleted leaves, and pendingOrder the order they were staged in
+	pending      map[string][]byte
+	pendingOrder []string
+	epoch        uint64
+}
+
+// NewDecayingSparseMerkleSumTrie returns a pointer to a DecayingSMST at epoch
+// zero, using the node store provided for the trie and the weights store to
+// track the weight and epoch every leaf was inserted with.
+func NewDecayingSparseMerkleSumTrie(
+	nodes, weights kvstore.MapStore,
+	hasher hash.Hash,
+	decay DecayFunc,
+	options ...TrieSpecOption,
+) *DecayingSMST {
+	return &DecayingSMST{
+		SMST:    NewSparseMerkleSumTrie(nodes, hasher, options...),
+		decay:   decay,
+		weights: weights,
+	}
+}
+
+// ImportDecayingSparseMerkleSumTrie returns a pointer to a DecayingSMST with
+// the root hash provided, resuming the epoch stored in the weights store by
+// its last Commit. An error wrapping ErrReservedKeyspace is returned if either
+// store is a PrefixedStore whose prefix overlaps a reserved key.
+func ImportDecayingSparseMerkleSumTrie(
+	nodes, weights kvstore.MapStore,
+	hasher hash.Hash,
+	decay DecayFunc,
+	root []byte,
+	options ...TrieSpecOption,
+) (*DecayingSMST, error) {
+	if err := checkStoreKeyspaces(nodes, weights); err != nil {
+		return nil, err
+	}
+	epochBz, err := weights.Get(decayEpochKey)
+	if err != nil {
+		return nil, err
+	}
+	trie := NewDecayingSparseMerkleSumTrie(nodes, weights, hasher, decay, options...)
+	trie.root = &lazyNode{root}
+	trie.rootHash = root
+	trie.epoch = binary.BigEndian.Uint64(epochBz)
+	return trie, nil
+}
+
+// Epoch returns the current epoch of the trie, the epoch leaves updated now
+// are inserted at.
+func (trie *DecayingSMST) Epoch() uint64 {
+	return trie.epoch
+}
+
+// SetEpoch moves the trie to the epoch provided, returning ErrEpochRegression
+// if it is before the current epoch. The effective weights of existing leaves
+// are recomputed when they are next updated or proven.
+func (trie *DecayingSMST) SetEpoch(epoch uint64) error {
+	if epoch < trie.epoch {
+		return ErrEpochRegression
+	}
+	trie.epoch = epoch
+	return nil
+}
+
+// Update inserts the value into the trie for the given key, with the weight
+// provided at the current epoch. The weight is written to the weights store
+// on the next Commit.
+func (trie *DecayingSMST) Update(key, value []byte, weight uint64) error {
+	inserted := encodeDecayingWeight(trie.epoch, weight)
+	if err := trie.SMST.Update(key, encodeDecayingValue(value, inserted), trie.decay(weight, 0)); err != nil {
+		return err
+	}
+	trie.stageWeight(trie.ph.Path(key), inserted)
+	return nil
+}
+
+// Delete removes the leaf for the given key from the trie
+func (trie *DecayingSMST) Delete(key []byte) error {
+	if err := trie.SMST.Delete(key); err != nil {
+		return err
+	}
+	trie.stageWeight(trie.ph.Path(key), nil)
+	return nil
+}
+
+// Weight returns the weight and epoch the leaf for the given key was inserted
+// with, these are needed alongside the value to verify a proof for the leaf.
+// An error wrapping kvstore.ErrKeyNotFound is returned if the key is not
+// present in the trie.
+func (trie *DecayingSMST) Weight(key []byte) (weight, insertedAt uint64, err error) {
+	inserted, err := trie.insertedWeight(trie.ph.Path(key))
+	if err != nil {
+		return 0, 0, err
+	}
+	insertedAt, weight = parseDecayingWeight(inserted)
+	return weight, insertedAt, nil
+}
+
+// Prove rewrites the leaf for the given key with its effective weight at the
+// current epoch, if it decayed since it was last computed, and generates a
+// SparseMerkleProof for it against the resulting root, see Root. The proof is
+// verified with VerifyDecayingProof at the current epoch.
+func (trie *DecayingSMST) Prove(key []byte) (*SparseMerkleProof, error) {
+	if err := trie.refresh(key); err != nil {
+		return nil, err
+	}
+	return trie.SMST.Prove(key)
+}
+
+// Commit persists the trie and the weights of the leaves updated since the
+// last commit, and advances the trie to the next epoch.
+func (trie *DecayingSMST) Commit() error {
+	if err := trie.SMST.Commit(); err != nil {
+		return err
+	}
+	for _, path := range trie.pendingOrder {
+		var err error
+		if inserted := trie.pending[path]; inserted == nil {
+			err = trie.weights.Delete([]byte(path))
+		} else {
+			err = trie.weights.Set([]byte(path), inserted)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	trie.pending, trie.pendingOrder = nil, nil
+	trie.epoch++
+	var epochBz [epochSizeBytes]byte
+	binary.BigEndian.PutUint64(epochBz[:], trie.epoch)
+	return trie.weights.Set(decayEpochKey, epochBz[:])
+}
+
+// Discard throws away every change made since the trie was last committed,
+// including the staged weights, see SMT.Discard.
+func (trie *DecayingSMST) Discard() error {
+	if err := trie.SMST.Discard(); err != nil {
+		return err
+	}
+	trie.pending, trie.pendingOrder = nil, nil
+	return nil
+}
+
+// refresh rewrites the leaf for the given key, if it is present, with its
+// effective weight at the current epoch if it differs from the sum it holds.
+func (trie *DecayingSMST) refresh(key []byte) error {
+	valueHash, err := trie.SMT.Get(key)
+	if err != nil || bytes.Equal(valueHash, defaultEmptyValue) {
+		return err
+	}
+	inserted, err := trie.insertedWeight(trie.ph.Path(key))
+	if err != nil {
+		return err
+	}
+	insertedAt, weight := parseDecayingWeight(inserted)
+	effective := trie.decay(weight, trie.epoch-insertedAt)
+	firstSumByteIdx, firstCountByteIdx := getFirstMetaByteIdx(valueHash)
+	if binary.BigEndian.Uint64(valueHash[firstSumByteIdx:firstCountByteIdx]) == effective {
+		return nil
+	}
+	valueHash = bytes.Clone(valueHash)
+	binary.BigEndian.PutUint64(valueHash[firstSumByteIdx:firstCountByteIdx], effective)
+	return trie.SMT.Update(key, valueHash)
+}
+
+// stageWeight stages the weight a leaf was inserted with, or its deletion if
+// nil, to be written to the weights store on the next Commit.
+func (trie *DecayingSMST) stageWeight(path, inserted []byte) {
+	if trie.pending == nil {
+		trie.pending = make(map[string][]byte)
+	}
+	if _, ok := trie.pending[string(path)]; !ok {
+		trie.pendingOrder = append(trie.pendingOrder, string(path))
+	}
+	trie.pending[string(path)] = inserted
+}
+
+// insertedWeight returns the encoded epoch and weight the leaf with the given
+// path was inserted with, from the staged weights or the weights store.
+func (trie *DecayingSMST) insertedWeight(path []byte) ([]byte, error) {
+	if inserted, ok := trie.pending[string(path)]; ok {
+		if inserted == nil {
+			return nil, errors.Join(kvstore.ErrKeyNotFound, fmt.Errorf("no weight for path %x", path))
+		}
+		return inserted, nil
+	}
+	return trie.weights.Get(path)
+}
+
+// VerifyDecayingProof verifies a Merkle proof for a leaf of a DecayingSMST
+// committed at the given epoch, recomputing its effective weight from the
+// weight and epoch it was inserted with.
+func VerifyDecayingProof(
+	proof *SparseMerkleProof,
+	root, key, value []byte,
+	weight, insertedAt, epoch uint64,
+	decay DecayFunc,
+	spec *TrieSpec,
+) (bool, error) {
+	if insertedAt > epoch {
+		return false, nil
+	}
+	inserted := encodeDecayingWeight(insertedAt, weight)
+	return VerifySumProof(proof, root, key, encodeDecayingValue(value, inserted),
+		decay(weight, epoch-insertedAt), 1, spec)
+}
+
+// encodeDecayingWeight encodes the epoch and weight a leaf was inserted with
+func encodeDecayingWeight(epoch, weight uint64) []byte {
+	inserted := make([]byte, epochSizeBytes+sumSizeBytes)
+	binary.BigEndian.PutUint64(inserted[:epochSizeBytes], epoch)
+	binary.BigEndian.PutUint64(inserted[epochSizeBytes:], weight)
+	return inserted
+}
+
+// parseDecayingWeight parses the epoch and weight a leaf was inserted with
+func parseDecayingWeight(inserted []byte) (epoch, weight uint64) {
+	return binary.BigEndian.Uint64(inserted[:epochSizeBytes]),
+		binary.BigEndian.Uint64(inserted[epochSizeBytes:])
+}
+
+// encodeDecayingValue prepends the encoded epoch and weight a leaf was
+// inserted with to its value, binding them into the leaf's value hash.
+func encodeDecayingValue(value, inserted []byte) []byte {
+	return append(bytes.Clone(inserted), value...)
+}
@@ -0,0 +1,114 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestDecayFuncs(t *testing.T) {
+	halfLife, err := HalfLifeDecay(2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), halfLife(100, 0))
+	require.Equal(t, uint64(100), halfLife(100, 1))
+	require.Equal(t, uint64(50), halfLife(100, 2))
+	require.Equal(t, uint64(25), halfLife(100, 5))
+	require.Zero(t, halfLife(100, 200))
+	_, err = HalfLifeDecay(0)
+	require.ErrorIs(t, err, ErrInvalidHalfLife)
+
+	linear := LinearDecay(30)
+	require.Equal(t, uint64(100), linear(100, 0))
+	require.Equal(t, uint64(40), linear(100, 2))
+	require.Zero(t, linear(100, 4))
+	require.Zero(t, linear(100, 1<<62))
+}
+
+func TestDecayingSMST_Commit(t *testing.T) {
+	nodes, weights := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	decay, err := HalfLifeDecay(1)
+	require.NoError(t, err)
+	trie := NewDecayingSparseMerkleSumTrie(nodes, weights, sha256.New(), decay)
+
+	_, err = ImportDecayingSparseMerkleSumTrie(nodes, weights, sha256.New(), decay, nil)
+	require.ErrorIs(t, err, simplemap.ErrKVStoreKeyNotFound)
+
+	require.NoError(t, trie.Update([]byte("old"), []byte("a"), 8))
+	require.NoError(t, trie.Commit())
+	require.Equal(t, uint64(8), trie.Sum())
+	require.Equal(t, uint64(1), trie.Epoch())
+
+	require.NoError(t, trie.Update([]byte("new"), []byte("b"), 8))
+	require.NoError(t, trie.Commit())
+	// Leaves are not rewritten by commits
+	require.Equal(t, uint64(8+8), trie.Sum())
+
+	require.NoError(t, trie.SetEpoch(4))
+	require.ErrorIs(t, trie.SetEpoch(3), ErrEpochRegression)
+	require.Equal(t, uint64(8+8), trie.Sum())
+
+	// Proving a leaf recomputes its effective weight from its inserted weight
+	// and age
+	weight, insertedAt, err := trie.Weight([]byte("new"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), weight)
+	require.Equal(t, uint64(1), insertedAt)
+	proof, err := trie.Prove([]byte("new"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(8+1), trie.Sum())
+	root := trie.Root()
+	valid, err := VerifyDecayingProof(proof, root, []byte("new"), []byte("b"), weight, insertedAt, 4, decay, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+	valid, err = VerifyDecayingProof(proof, root, []byte("new"), []byte("b"), weight, insertedAt+1, 5, decay, trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+	valid, err = VerifyDecayingProof(proof, root, []byte("new"), []byte("b"), weight, insertedAt, 3, decay, trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+	_, err = trie.Prove([]byte("old"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(0+1), trie.Sum())
+	require.NoError(t, trie.Commit())
+	require.Equal(t, uint64(2), trie.Count())
+
+	// The epoch is resumed when importing the trie
+	imported, err := ImportDecayingSparseMerkleSumTrie(nodes, weights, sha256.New(), decay, trie.Root())
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), imported.Epoch())
+	require.NoError(t, imported.Delete([]byte("old")))
+	require.NoError(t, imported.Commit())
+	require.Equal(t, uint64(1), imported.Sum())
+	_, _, err = imported.Weight([]byte("old"))
+	require.ErrorIs(t, err, simplemap.ErrKVStoreKeyNotFound)
+}
+
+func TestDecayingSMST_StagedWeights(t *testing.T) {
+	nodes, weights := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	trie := NewDecayingSparseMerkleSumTrie(nodes, weights, sha256.New(), LinearDecay(1))
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar"), 10))
+	require.NoError(t, trie.Commit())
+	committed := weights.Len()
+
+	// Weights are only written to the weights store on commit
+	require.NoError(t, trie.Update([]byte("baz"), []byte("qux"), 5))
+	require.NoError(t, trie.Delete([]byte("foo")))
+	require.Equal(t, committed, weights.Len())
+	weight, _, err := trie.Weight([]byte("baz"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), weight)
+	_, _, err = trie.Weight([]byte("foo"))
+	require.ErrorIs(t, err, kvstore.ErrKeyNotFound)
+
+	// So discarding the changes leaves the weights store consistent
+	require.NoError(t, trie.Discard())
+	_, _, err = trie.Weight([]byte("baz"))
+	require.ErrorIs(t, err, kvstore.ErrKeyNotFound)
+	weight, _, err = trie.Weight([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), weight)
+}
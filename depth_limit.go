@@ -0,0 +1,95 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrDepthLimitExceeded is returned when an update would insert a leaf deeper
+// in the trie than its configured depth limit.
+var ErrDepthLimitExceeded = errors.New("depth limit exceeded")
+
+// DepthAlarm is called with the key and depth of a leaf whose insertion would
+// exceed the depth limit of the trie. Returning nil allows the update (only
+// flagging it), while returning an error rejects it.
+type DepthAlarm func(key []byte, depth int) error
+
+// WithDepthLimit returns an Option limiting the depth leaves can be inserted
+// at. Uniformly distributed paths place leaves around log2(n) deep, so leaves
+// close to the full path length indicate keys ground against the path hasher
+// to balloon proof sizes. If alarm is nil updates exceeding the limit are
+// rejected with ErrDepthLimitExceeded, otherwise the alarm decides.
+func WithDepthLimit(limit int, alarm DepthAlarm) TrieSpecOption {
+	return func(ts *TrieSpec) {
+		ts.depthLimit = limit
+		ts.depthAlarm = alarm
+	}
+}
+
+// checkDepth checks the depth the leaf for the key and path provided would be
+// inserted at against the trie's depth limit, if any
+func (smt *SMT) checkDepth(key, path []byte) error {
+	if smt.depthLimit <= 0 {
+		return nil
+	}
+	depth, err := smt.insertDepth(path)
+	if err != nil {
+		return err
+	}
+	return smt.checkLeafDepth(key, depth)
+}
+
+// checkLeafDepth checks the depth of the leaf for the key provided against
+// the trie's depth limit, if any
+func (smt *SMT) checkLeafDepth(key []byte, depth int) error {
+	if smt.depthLimit <= 0 || depth <= smt.depthLimit {
+		return nil
+	}
+	exceeded := fmt.Errorf("inserting leaf at depth %d over limit %d", depth, smt.depthLimit)
+	if smt.depthAlarm == nil {
+		return errors.Join(ErrDepthLimitExceeded, exceeded)
+	}
+	if err := smt.depthAlarm(key, depth); err != nil {
+		return errors.Join(ErrDepthLimitExceeded, exceeded, err)
+	}
+	return nil
+}
+
+// insertDepth returns the depth the leaf for the path provided would have if
+// it were inserted into the trie, without modifying the trie.
+func (smt *SMT) insertDepth(path []byte) (int, error) {
+	node := smt.root
+	depth := 0
+	for {
+		var err error
+		node, err = smt.resolveLazy(node)
+		if err != nil {
+			return 0, err
+		}
+		switch n := node.(type) {
+		case nil:
+			return depth, nil
+		case *leafNode:
+			if bytes.Equal(n.path, path) {
+				return depth, nil
+			}
+			// The leaves are split at their first differing bit
+			return countCommonPrefixBits(path, n.path, depth) + 1, nil
+		case *extensionNode:
+			matchLen, fullMatch := n.boundsMatch(path, depth)
+			if !fullMatch {
+				return depth + matchLen + 1, nil
+			}
+			depth += matchLen
+			node = n.child
+		case *innerNode:
+			if getPathBit(path, depth) == leftChildBit {
+				node = n.leftChild
+			} else {
+				node = n.rightChild
+			}
+			depth++
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_DepthLimit(t *testing.T) {
+	// Keys are used as paths directly to simulate ground keys
+	nilPathHasher := WithPathHasher(newNilPathHasher(sha256.Size))
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), nilPathHasher, WithDepthLimit(16, nil))
+
+	base := make([]byte, sha256.Size)
+	require.NoError(t, trie.Update(base, []byte("a")))
+	sibling := make([]byte, sha256.Size)
+	sibling[1] = 0x01 // differs at bit 15, so the leaves are split at depth 16
+	require.NoError(t, trie.Update(sibling, []byte("b")))
+
+	root := trie.Root()
+	deep := make([]byte, sha256.Size)
+	deep[2] = 0x80 // differs at bit 16, inserting at depth 17
+	err := trie.Update(deep, []byte("c"))
+	require.ErrorIs(t, err, ErrDepthLimitExceeded)
+	require.Equal(t, root, trie.Root())
+
+	// Replacing an existing leaf does not deepen the trie
+	require.NoError(t, trie.Update(base, []byte("d")))
+
+	// Random keys under the limit are accepted
+	for i := 0; i < 100; i++ {
+		key := sha256.Sum256([]byte(fmt.Sprintf("key%d", i)))
+		depth, err := trie.insertDepth(key[:])
+		require.NoError(t, err)
+		if depth <= 16 {
+			require.NoError(t, trie.Update(key[:], []byte("value")))
+		}
+	}
+}
+
+func TestSMT_DepthAlarm(t *testing.T) {
+	var flagged []int
+	errTooDeep := errors.New("too deep")
+	alarm := func(key []byte, depth int) error {
+		flagged = append(flagged, depth)
+		if depth > 200 {
+			return errTooDeep
+		}
+		return nil
+	}
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(),
+		WithPathHasher(newNilPathHasher(sha256.Size)), WithDepthLimit(8, alarm))
+
+	first := make([]byte, sha256.Size)
+	require.NoError(t, trie.Update(first, []byte("a")))
+	flag := make([]byte, sha256.Size)
+	flag[1] = 0x80 // inserting at depth 9 is flagged but allowed
+	require.NoError(t, trie.Update(flag, []byte("b")))
+	reject := make([]byte, sha256.Size)
+	reject[sha256.Size-1] = 0x01 // inserting at depth 256 is rejected
+	err := trie.Update(reject, []byte("c"))
+	require.ErrorIs(t, err, ErrDepthLimitExceeded)
+	require.ErrorIs(t, err, errTooDeep)
+	require.Equal(t, []int{9, 256}, flagged)
+
+	// The same checks apply to sum tries
+	smst := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New(),
+		WithPathHasher(newNilPathHasher(sha256.Size)), WithDepthLimit(8, nil))
+	require.NoError(t, smst.Update(first, []byte("a"), 1))
+	require.ErrorIs(t, smst.Update(flag, []byte("b"), 1), ErrDepthLimitExceeded)
+}
+
+func TestSMT_DepthLimitBatch(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(),
+		WithPathHasher(newNilPathHasher(sha256.Size)), WithDepthLimit(4, nil))
+
+	// Neither key is too deep on its own, but sharing 8 bits of their paths
+	// the second is inserted at depth 9
+	first, second := make([]byte, sha256.Size), make([]byte, sha256.Size)
+	second[1] = 0x80
+	root := trie.Root()
+	err := trie.UpdateBatch([][]byte{first, second}, [][]byte{[]byte("a"), []byte("b")})
+	require.ErrorIs(t, err, ErrDepthLimitExceeded)
+	require.Equal(t, root, trie.Root())
+
+	// As updating them in turn is
+	require.NoError(t, trie.Update(first, []byte("a")))
+	require.ErrorIs(t, trie.Update(second, []byte("b")), ErrDepthLimitExceeded)
+
+	// Leaves under the limit with both the trie and the batch are accepted
+	third, fourth := make([]byte, sha256.Size), make([]byte, sha256.Size)
+	third[0], fourth[0] = 0x80, 0xc0
+	require.NoError(t, trie.UpdateBatch([][]byte{third, fourth}, [][]byte{[]byte("c"), []byte("d")}))
+}
@@ -0,0 +1,158 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// LeafChange is a leaf which differs between two roots of a trie
+type LeafChange struct {
+	// Path is the path of the leaf
+	Path []byte
+	// Before is the value hash of the leaf at the first root, nil if the leaf
+	// was inserted
+	Before []byte
+	// After is the value hash of the leaf at the second root, nil if the leaf
+	// was deleted
+	After []byte
+}
+
+// Diff returns the leaves inserted, updated or deleted between the two roots
+// provided, both of which must be committed to the trie's node store, in
+// ascending path order. Both tries are walked side by side, skipping every
+// pair of subtries with identical digests, so the cost of the diff is
+// proportional to the number of changes rather than to the size of the tries.
+func (smt *SMT) Diff(from, to MerkleRoot) ([]LeafChange, error) {
+	if smt.closed {
+		return nil, ErrClosed
+	}
+	var changes []LeafChange
+	if err := smt.diff(&lazyNode{from}, &lazyNode{to}, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// diff appends the changes between the subtries rooted at the same depth to
+// the changes provided
+func (smt *SMT) diff(from, to trieNode, changes *[]LeafChange) (err error) {
+	if bytes.Equal(smt.digest(from), smt.digest(to)) {
+		return nil
+	}
+	if from, err = smt.resolveLazy(from); err != nil {
+		return err
+	}
+	if to, err = smt.resolveLazy(to); err != nil {
+		return err
+	}
+	fromInner, toInner := asInnerNode(from), asInnerNode(to)
+	if fromInner != nil && toInner != nil {
+		if err := smt.diff(fromInner.leftChild, toInner.leftChild, changes); err != nil {
+			return err
+		}
+		return smt.diff(fromInner.rightChild, toInner.rightChild, changes)
+	}
+	// One of the subtries is empty or a single leaf, so the leaves of both
+	// are compared directly
+	var fromLeaves, toLeaves []*leafNode
+	collect := func(leaves *[]*leafNode) func(path, valueHash []byte) bool {
+		return func(path, valueHash []byte) bool {
+			*leaves = append(*leaves, &leafNode{path: path, valueHash: valueHash})
+			return true
+		}
+	}
+	if _, err := smt.iterateLeaves(from, collect(&fromLeaves)); err != nil {
+		return err
+	}
+	if _, err := smt.iterateLeaves(to, collect(&toLeaves)); err != nil {
+		return err
+	}
+	for len(fromLeaves) > 0 || len(toLeaves) > 0 {
+		var cmp int
+		switch {
+		case len(fromLeaves) == 0:
+			cmp = 1
+		case len(toLeaves) == 0:
+			cmp = -1
+		default:
+			cmp = bytes.Compare(fromLeaves[0].path, toLeaves[0].path)
+		}
+		switch {
+		case cmp < 0:
+			*changes = append(*changes, LeafChange{Path: fromLeaves[0].path, Before: fromLeaves[0].valueHash})
+			fromLeaves = fromLeaves[1:]
+		case cmp > 0:
+			*changes = append(*changes, LeafChange{Path: toLeaves[0].path, After: toLeaves[0].valueHash})
+			toLeaves = toLeaves[1:]
+		default:
+			if !bytes.Equal(fromLeaves[0].valueHash, toLeaves[0].valueHash) {
+				*changes = append(*changes, LeafChange{
+					Path:   fromLeaves[0].path,
+					Before: fromLeaves[0].valueHash,
+					After:  toLeaves[0].valueHash,
+				})
+			}
+			fromLeaves, toLeaves = fromLeaves[1:], toLeaves[1:]
+		}
+	}
+	return nil
+}
+
+// asInnerNode returns the node as an inner node, expanding extension nodes,
+// or nil if it is empty or a leaf
+func asInnerNode(node trieNode) *innerNode {
+	switch n := node.(type) {
+	case *innerNode:
+		return n
+	case *extensionNode:
+		return n.expand().(*innerNode)
+	}
+	return nil
+}
+
+// ValueChange is a key whose value differs between two roots of an
+// SMTWithStorage
+type ValueChange struct {
+	// Key is the key of the leaf
+	Key []byte
+	// Before is the value of the key at the first root, nil if the key was
+	// inserted
+	Before []byte
+	// After is the value of the key at the second root, nil if the key was
+	// deleted
+	After []byte
+}
+
+// DiffValues returns the keys inserted, updated or deleted between the two
+// roots provided along with their values, see SMT.Diff. An error wrapping
+// ErrKeyNotFound is returned for changed leaves whose keys were not stored.
+func (smt *SMTWithStorage) DiffValues(from, to MerkleRoot) ([]ValueChange, error) {
+	smt.commitMu.RLock()
+	defer smt.commitMu.RUnlock()
+	smt.trieMu.Lock()
+	leaves, err := smt.trie.Diff(from, to)
+	smt.trieMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]ValueChange, len(leaves))
+	for i, leaf := range leaves {
+		key, err := smt.lookupPreimage(keyPreimageKey(leaf.Path))
+		if err != nil {
+			return nil, errors.Join(ErrKeyNotFound, fmt.Errorf("no key stored for path %x", leaf.Path), err)
+		}
+		changes[i].Key = key
+		if leaf.Before != nil {
+			if changes[i].Before, err = smt.lookupValue(leaf.Before); err != nil {
+				return nil, err
+			}
+		}
+		if leaf.After != nil {
+			if changes[i].After, err = smt.lookupValue(leaf.After); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return changes, nil
+}
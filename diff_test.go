@@ -0,0 +1,92 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_Diff(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithBorrowedStores())
+	for i := 0; i < 100; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte("v1")))
+	}
+	require.NoError(t, trie.Commit())
+	from := trie.Root()
+
+	// Identical roots have no changes
+	changes, err := trie.Diff(from, from)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	// Orphans are dropped before committing so that the old root survives
+	expected := map[string]LeafChange{}
+	for i := 0; i < 5; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		require.NoError(t, trie.Update(key, []byte("v2")))
+		expected[string(trie.ph.Path(key))] = LeafChange{
+			Path: trie.ph.Path(key), Before: trie.valueHash([]byte("v1")), After: trie.valueHash([]byte("v2")),
+		}
+	}
+	for i := 5; i < 8; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		require.NoError(t, trie.Delete(key))
+		expected[string(trie.ph.Path(key))] = LeafChange{Path: trie.ph.Path(key), Before: trie.valueHash([]byte("v1"))}
+	}
+	for i := 100; i < 103; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		require.NoError(t, trie.Update(key, []byte("v1")))
+		expected[string(trie.ph.Path(key))] = LeafChange{Path: trie.ph.Path(key), After: trie.valueHash([]byte("v1"))}
+	}
+	trie.orphans = nil
+	require.NoError(t, trie.Commit())
+	to := trie.Root()
+
+	changes, err = trie.Diff(from, to)
+	require.NoError(t, err)
+	require.Len(t, changes, len(expected))
+	require.True(t, sort.SliceIsSorted(changes, func(i, j int) bool {
+		return string(changes[i].Path) < string(changes[j].Path)
+	}))
+	for _, change := range changes {
+		require.Equal(t, expected[string(change.Path)], change)
+	}
+
+	// Diffing in reverse swaps the values
+	reversed, err := trie.Diff(to, from)
+	require.NoError(t, err)
+	require.Len(t, reversed, len(changes))
+	for i, change := range reversed {
+		require.Equal(t, changes[i].Before, change.After)
+		require.Equal(t, changes[i].After, change.Before)
+	}
+
+	// Diffing against the empty trie lists every leaf
+	changes, err = trie.Diff(trie.placeholder(), to)
+	require.NoError(t, err)
+	require.Len(t, changes, 100)
+}
+
+func TestSMTWithStorage_DiffValues(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, trie.Update([]byte("baz"), []byte("qux")))
+	require.NoError(t, trie.Commit())
+	from := trie.Root()
+	require.NoError(t, trie.Update([]byte("foo"), []byte("updated")))
+	require.NoError(t, trie.Update([]byte("new"), []byte("value")))
+	trie.trie.orphans = nil
+	require.NoError(t, trie.Commit())
+
+	changes, err := trie.DiffValues(from, trie.Root())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []ValueChange{
+		{Key: []byte("foo"), Before: []byte("bar"), After: []byte("updated")},
+		{Key: []byte("new"), After: []byte("value")},
+	}, changes)
+}
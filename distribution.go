@@ -0,0 +1,104 @@
+package smt
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// maxHistogramPrefixBits is the maximum number of prefix bits the density
+// histogram of a DistributionReport can bucket paths by.
+const maxHistogramPrefixBits = 16
+
+// PathCollision is a pair of adjacent leaves whose paths share an unusually
+// long common prefix.
+type PathCollision struct {
+	Left, Right []byte
+	CommonBits  int
+}
+
+// DistributionReport describes the distribution of the leaf paths of a trie.
+type DistributionReport struct {
+	// Leaves is the number of leaves in the trie
+	Leaves uint64
+	// PrefixBits is the number of leading path bits the density histogram
+	// buckets leaves by
+	PrefixBits int
+	// Density is the number of leaves per prefix, indexed by the prefix value
+	Density []uint64
+	// CommonPrefixes maps the number of bits of the longest prefix each leaf
+	// shares with another leaf to the number of such leaves
+	CommonPrefixes map[int]uint64
+	// CollisionBits is the common prefix length from which adjacent leaves
+	// are reported as collisions
+	CollisionBits int
+	// Collisions are the pairs of adjacent leaves sharing at least
+	// CollisionBits leading bits
+	Collisions []PathCollision
+}
+
+// AnalyzeDistribution scans the leaf paths of the trie and reports the
+// density of leaves per path prefix of prefixBits bits, along with any
+// adjacent leaves whose paths share at least collisionBits leading bits.
+//
+// For n uniformly distributed paths the longest common prefix between any two
+// is around 2*log2(n) bits, so leaves sharing much longer prefixes indicate
+// keys were ground against the path hasher to deepen the trie. If
+// collisionBits is not positive it defaults to 2*log2(n) + 16.
+func (smt *SMT) AnalyzeDistribution(prefixBits, collisionBits int) (*DistributionReport, error) {
+	if prefixBits < 0 || prefixBits > maxHistogramPrefixBits || prefixBits > smt.depth() {
+		return nil, fmt.Errorf("invalid prefix bits %d: must be between 0 and %d", prefixBits, maxHistogramPrefixBits)
+	}
+	report := &DistributionReport{
+		PrefixBits:     prefixBits,
+		Density:        make([]uint64, 1<<prefixBits),
+		CommonPrefixes: make(map[int]uint64),
+	}
+
+	// The longest common prefix of each leaf is with one of its neighbours in
+	// path order, so a single ordered scan suffices.
+	var paths [][]byte
+	it := smt.Iterator()
+	for it.Next() {
+		path := it.Path()
+		report.Density[pathPrefix(path, prefixBits)]++
+		paths = append(paths, path)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	report.Leaves = uint64(len(paths))
+
+	report.CollisionBits = collisionBits
+	if collisionBits <= 0 {
+		report.CollisionBits = 2*bits.Len64(report.Leaves) + 16
+	}
+	common := make([]int, len(paths))
+	for i := 1; i < len(paths); i++ {
+		n := countCommonPrefixBits(paths[i-1], paths[i], 0)
+		if n > common[i-1] {
+			common[i-1] = n
+		}
+		common[i] = n
+		if n >= report.CollisionBits {
+			report.Collisions = append(report.Collisions, PathCollision{
+				Left:       paths[i-1],
+				Right:      paths[i],
+				CommonBits: n,
+			})
+		}
+	}
+	if len(paths) > 1 {
+		for _, n := range common {
+			report.CommonPrefixes[n]++
+		}
+	}
+	return report, nil
+}
+
+// pathPrefix returns the value of the first n bits of the path
+func pathPrefix(path []byte, n int) (prefix int) {
+	for i := 0; i < n; i++ {
+		prefix = prefix<<1 | getPathBit(path, i)
+	}
+	return prefix
+}
@@ -0,0 +1,62 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_AnalyzeDistribution(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := 0; i < 500; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key%d", i)), []byte("value")))
+	}
+
+	report, err := trie.AnalyzeDistribution(4, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(500), report.Leaves)
+	require.Len(t, report.Density, 16)
+	var total uint64
+	for _, n := range report.Density {
+		total += n
+	}
+	require.Equal(t, uint64(500), total)
+	total = 0
+	for _, n := range report.CommonPrefixes {
+		total += n
+	}
+	require.Equal(t, uint64(500), total)
+	require.Equal(t, 2*9+16, report.CollisionBits)
+	require.Empty(t, report.Collisions)
+
+	// Simulate ground keys by inserting paths sharing a long prefix directly
+	ground := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(),
+		WithPathHasher(newNilPathHasher(sha256.Size)))
+	for i := 0; i < 100; i++ {
+		key := sha256.Sum256([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, ground.Update(key[:], []byte("value")))
+	}
+	left, right := make([]byte, sha256.Size), make([]byte, sha256.Size)
+	for i := range left {
+		left[i], right[i] = 0xAB, 0xAB
+	}
+	right[sha256.Size-1] = 0xAC
+	require.NoError(t, ground.Update(left, []byte("value")))
+	require.NoError(t, ground.Update(right, []byte("value")))
+
+	report, err = ground.AnalyzeDistribution(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{102}, report.Density)
+	require.Len(t, report.Collisions, 1)
+	require.Equal(t, left, report.Collisions[0].Left)
+	require.Equal(t, right, report.Collisions[0].Right)
+	require.Equal(t, 8*(sha256.Size-1)+5, report.Collisions[0].CommonBits)
+	require.Equal(t, uint64(2), report.CommonPrefixes[8*(sha256.Size-1)+5])
+
+	_, err = trie.AnalyzeDistribution(17, 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package smt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// dotHashBytes is the number of leading bytes of digests, paths and value
+// hashes shown in the labels of DOT nodes
+const dotHashBytes = 4
+
+// WriteDOT renders the structure of the trie, including its uncommitted
+// changes, as a Graphviz DOT graph to the writer provided, for debugging small
+// tries and illustrating the shape of proofs. Every node is labelled with its
+// type and truncated digest, along with the truncated path and value hash of
+// leaves, the path bits spanned by extension nodes and the sum of the nodes of
+// sum tries. Edges are labelled with the path bit they follow. Only maxDepth
+// levels of nodes are rendered, the subtries below are drawn as dashed nodes,
+// unless maxDepth is not positive in which case the whole trie is rendered.
+// Persisted nodes are read from the node store without being cached.
+//
+//	dot -Tsvg trie.dot -o trie.svg
+func (smt *SMT) WriteDOT(w io.Writer, maxDepth int) error {
+	if smt.closed {
+		return ErrClosed
+	}
+	dot := &dotWriter{smt: smt, w: bufio.NewWriter(w), maxDepth: maxDepth}
+	fmt.Fprintln(dot.w, "digraph smt {")
+	fmt.Fprintln(dot.w, "\tnode [shape=box, fontname=\"monospace\"];")
+	if _, err := dot.write(smt.root, 0); err != nil {
+		return err
+	}
+	fmt.Fprintln(dot.w, "}")
+	return dot.w.Flush()
+}
+
+// dotWriter writes the nodes of a trie as a DOT graph
+type dotWriter struct {
+	smt      *SMT
+	w        *bufio.Writer
+	maxDepth int
+	// ids is the number of DOT nodes written
+	ids int
+}
+
+// write writes the subtrie rooted at the node provided, depth levels below
+// the root, and returns the DOT identifier of the node
+func (dot *dotWriter) write(node trieNode, depth int) (string, error) {
+	id := fmt.Sprintf("n%d", dot.ids)
+	dot.ids++
+	if node == nil {
+		fmt.Fprintf(dot.w, "\t%s [label=\"empty\", shape=point];\n", id)
+		return id, nil
+	}
+	digest := dot.smt.digest(node)
+	if dot.maxDepth > 0 && depth >= dot.maxDepth {
+		fmt.Fprintf(dot.w, "\t%s [label=\"...\\n%s\", style=dashed];\n", id, dot.label(digest))
+		return id, nil
+	}
+	node, err := dot.smt.resolveLazy(node)
+	if err != nil {
+		return "", err
+	}
+
+	switch n := node.(type) {
+	case *leafNode:
+		fmt.Fprintf(dot.w, "\t%s [label=\"leaf\\n%s\\npath %x\\nvalue %x\"];\n",
+			id, dot.label(digest), truncateDOT(n.path), truncateDOT(n.valueHash))
+	case *extensionNode:
+		fmt.Fprintf(dot.w, "\t%s [label=\"extension\\n%s\\nbits %d-%d\", shape=cds];\n",
+			id, dot.label(digest), n.pathStart(), n.pathEnd())
+		child, err := dot.write(n.child, depth+1)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(dot.w, "\t%s -> %s;\n", id, child)
+	case *innerNode:
+		fmt.Fprintf(dot.w, "\t%s [label=\"inner\\n%s\", shape=ellipse];\n", id, dot.label(digest))
+		for bit, child := range []trieNode{n.leftChild, n.rightChild} {
+			childID, err := dot.write(child, depth+1)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(dot.w, "\t%s -> %s [label=\"%d\"];\n", id, childID, bit)
+		}
+	}
+	return id, nil
+}
+
+// label returns the label of a node with the digest provided, its truncated
+// hash followed by its sum for sum tries
+func (dot *dotWriter) label(digest []byte) string {
+	if dot.smt.sumTrie {
+		return fmt.Sprintf("%x\\nsum %d", truncateDOT(digest), MerkleRoot(digest).Sum())
+	}
+	return fmt.Sprintf("%x", truncateDOT(digest))
+}
+
+// truncateDOT returns the leading bytes of the data shown in DOT labels
+func truncateDOT(data []byte) []byte {
+	if len(data) > dotHashBytes {
+		return data[:dotHashBytes]
+	}
+	return data
+}
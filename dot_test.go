@@ -0,0 +1,49 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_WriteDOT(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	var buf bytes.Buffer
+	require.NoError(t, trie.WriteDOT(&buf, 0))
+	require.Equal(t, "digraph smt {\n\tnode [shape=box, fontname=\"monospace\"];\n\tn0 [label=\"empty\", shape=point];\n}\n", buf.String())
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key%d", i)), []byte("value")))
+	}
+	require.NoError(t, trie.Commit())
+	buf.Reset()
+	require.NoError(t, trie.WriteDOT(&buf, 0))
+	dot := buf.String()
+	require.True(t, strings.HasPrefix(dot, "digraph smt {\n"))
+	require.True(t, strings.HasSuffix(dot, "}\n"))
+	require.Equal(t, 20, strings.Count(dot, "[label=\"leaf\\n"))
+	require.Equal(t, 19, strings.Count(dot, "[label=\"inner\\n"))
+	require.Contains(t, dot, fmt.Sprintf("n0 [label=\"inner\\n%x\"", []byte(trie.Root())[:dotHashBytes]))
+	require.Contains(t, dot, "n0 -> n1 [label=\"0\"];")
+
+	// Subtries below the maximum depth are truncated
+	buf.Reset()
+	require.NoError(t, trie.WriteDOT(&buf, 1))
+	require.Equal(t, 2, strings.Count(buf.String(), "style=dashed"))
+	require.NotContains(t, buf.String(), "leaf")
+
+	// Sum tries label nodes with their sums
+	sumTrie := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, sumTrie.Update([]byte("a"), []byte("value"), 3))
+	require.NoError(t, sumTrie.Update([]byte("b"), []byte("value"), 4))
+	buf.Reset()
+	require.NoError(t, sumTrie.WriteDOT(&buf, 0))
+	require.Contains(t, buf.String(), "\\nsum 7\"")
+	require.Contains(t, buf.String(), "\\nsum 3\\n")
+}
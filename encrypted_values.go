@@ -0,0 +1,97 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrDecryption is returned when a value cannot be decrypted, as it was not
+// encrypted for its key with the cipher's secret or was tampered with.
+var ErrDecryption = errors.New("value decryption failed")
+
+// ValueCipher encrypts values client-side before they are inserted into a
+// trie, so that the trie commits to the hash of the ciphertext: anyone holding
+// the ciphertext can verify its proof against a public root with VerifyProof,
+// while only holders of the secret learn the value. Every ciphertext is bound
+// to its key, so it cannot be moved to another key without failing to
+// decrypt.
+type ValueCipher struct {
+	aead cipher.AEAD
+	rand io.Reader
+}
+
+// NewValueCipher returns a ValueCipher sealing values with the AEAD provided,
+// e.g. AES-GCM from crypto/cipher, under random nonces.
+func NewValueCipher(aead cipher.AEAD) *ValueCipher {
+	return &ValueCipher{aead: aead, rand: rand.Reader}
+}
+
+// Encrypt returns the ciphertext of the value for the key provided, the nonce
+// followed by the sealed value authenticated along with the key. As nonces are
+// random, encrypting the same value twice produces different ciphertexts.
+func (c *ValueCipher) Encrypt(key, value []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(value)+c.aead.Overhead())
+	if _, err := io.ReadFull(c.rand, nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, value, key), nil
+}
+
+// Decrypt returns the value of the ciphertext for the key provided, or an
+// error wrapping ErrDecryption if it was not encrypted for the key with the
+// cipher's secret.
+func (c *ValueCipher) Decrypt(key, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < c.aead.NonceSize()+c.aead.Overhead() {
+		return nil, errors.Join(ErrDecryption, fmt.Errorf("ciphertext of %d bytes too short", len(ciphertext)))
+	}
+	nonce, sealed := ciphertext[:c.aead.NonceSize()], ciphertext[c.aead.NonceSize():]
+	value, err := c.aead.Open(nil, nonce, sealed, key)
+	if err != nil {
+		return nil, errors.Join(ErrDecryption, fmt.Errorf("key %x", key), err)
+	}
+	return value, nil
+}
+
+// VerifyProof verifies the proof of the ciphertext for the key against the
+// root provided, as any third party would with VerifyProof, and returns its
+// decrypted value. An error wrapping ErrBadProof is returned if the proof does
+// not verify, or wrapping ErrDecryption if the ciphertext does not decrypt.
+func (c *ValueCipher) VerifyProof(proof *SparseMerkleProof, root, key, ciphertext []byte, spec *TrieSpec) ([]byte, error) {
+	valid, err := VerifyProof(proof, root, key, ciphertext, spec)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errors.Join(ErrBadProof, fmt.Errorf("proof for key %x does not verify against root %x", key, root))
+	}
+	return c.Decrypt(key, ciphertext)
+}
+
+// UpdateEncrypted encrypts the value with the cipher provided and stores the
+// ciphertext at the given key, see ValueCipher. Namespace validators and
+// quotas apply to the ciphertext.
+func (smt *SMTWithStorage) UpdateEncrypted(key, value []byte, c *ValueCipher) error {
+	ciphertext, err := c.Encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	return smt.Update(key, ciphertext)
+}
+
+// GetDecrypted retrieves the ciphertext stored at the given key and decrypts
+// it with the cipher provided. ErrKeyNotFound is returned if the key is not
+// present in the trie.
+func (smt *SMTWithStorage) GetDecrypted(key []byte, c *ValueCipher) ([]byte, error) {
+	ciphertext, err := smt.GetValue(key)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(ciphertext, defaultEmptyValue) {
+		return nil, ErrKeyNotFound
+	}
+	return c.Decrypt(key, ciphertext)
+}
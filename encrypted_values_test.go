@@ -0,0 +1,73 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func newTestValueCipher(t *testing.T, secret byte) *ValueCipher {
+	block, err := aes.NewCipher(bytes.Repeat([]byte{secret}, 32))
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	return NewValueCipher(aead)
+}
+
+func TestValueCipher_EncryptDecrypt(t *testing.T) {
+	c := newTestValueCipher(t, 1)
+	ciphertext, err := c.Encrypt([]byte("key"), []byte("secret value"))
+	require.NoError(t, err)
+	require.NotContains(t, string(ciphertext), "secret value")
+	value, err := c.Decrypt([]byte("key"), ciphertext)
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret value"), value)
+
+	// Ciphertexts use random nonces
+	other, err := c.Encrypt([]byte("key"), []byte("secret value"))
+	require.NoError(t, err)
+	require.NotEqual(t, ciphertext, other)
+
+	// Ciphertexts are bound to their key and secret
+	_, err = c.Decrypt([]byte("other key"), ciphertext)
+	require.ErrorIs(t, err, ErrDecryption)
+	_, err = newTestValueCipher(t, 2).Decrypt([]byte("key"), ciphertext)
+	require.ErrorIs(t, err, ErrDecryption)
+	_, err = c.Decrypt([]byte("key"), ciphertext[:4])
+	require.ErrorIs(t, err, ErrDecryption)
+}
+
+func TestSMTWithStorage_EncryptedValues(t *testing.T) {
+	c := newTestValueCipher(t, 1)
+	smt := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, smt.UpdateEncrypted([]byte("key"), []byte("secret value"), c))
+	require.NoError(t, smt.Commit())
+
+	value, err := smt.GetDecrypted([]byte("key"), c)
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret value"), value)
+	_, err = smt.GetDecrypted([]byte("missing"), c)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	// Third parties verify the proof of the ciphertext alone
+	ciphertext, proof, err := smt.GetWithProof([]byte("key"))
+	require.NoError(t, err)
+	valid, err := VerifyProof(proof, smt.Root(), []byte("key"), ciphertext, smt.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// Holders of the secret verify the proof and decrypt the value
+	value, err = c.VerifyProof(proof, smt.Root(), []byte("key"), ciphertext, smt.Spec())
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret value"), value)
+	forged, err := c.Encrypt([]byte("key"), []byte("forged value"))
+	require.NoError(t, err)
+	_, err = c.VerifyProof(proof, smt.Root(), []byte("key"), forged, smt.Spec())
+	require.ErrorIs(t, err, ErrBadProof)
+}
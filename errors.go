@@ -12,4 +12,7 @@ var (
 	// ErrInvalidClosestPath is returned when the path used in the ClosestProof
 	// method does not match the size of the trie's PathHasher
 	ErrInvalidClosestPath = errors.New("invalid path does not match path hasher size")
+	// ErrCorruptNode is returned when a node read from the node store cannot
+	// be decoded or does not match the digest it was read with.
+	ErrCorruptNode = errors.New("corrupt node")
 )
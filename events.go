@@ -0,0 +1,188 @@
+package smt
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// EventType identifies the kind of an Event emitted by a trie.
+type EventType int
+
+const (
+	// EventUpdate is emitted after a key is updated in the trie
+	EventUpdate EventType = iota
+	// EventDelete is emitted after a key is deleted from the trie
+	EventDelete
+	// EventCommit is emitted after the trie is committed to its node store
+	EventCommit
+	// EventPrune is emitted when orphaned nodes are deleted from the node store
+	EventPrune
+	// EventCommitAnomaly is emitted after a commit flagged as anomalous
+	EventCommitAnomaly
+)
+
+// Ensure the typed events satisfy the Event interface
+var (
+	_ Event = UpdateEvent{}
+	_ Event = DeleteEvent{}
+	_ Event = CommitEvent{}
+	_ Event = PruneEvent{}
+	_ Event = CommitAnomalyEvent{}
+)
+
+// Event is the interface implemented by all typed events published to an
+// EventBus. Consumers type switch on the concrete event to access its data.
+type Event interface {
+	// Type returns the EventType of the event
+	Type() EventType
+}
+
+// UpdateEvent is published after a key is updated in the trie.
+type UpdateEvent struct {
+	Key       []byte
+	ValueHash []byte
+}
+
+// Type satisfies the Event#Type interface
+func (UpdateEvent) Type() EventType { return EventUpdate }
+
+// DeleteEvent is published after a key is deleted from the trie.
+type DeleteEvent struct {
+	Key []byte
+}
+
+// Type satisfies the Event#Type interface
+func (DeleteEvent) Type() EventType { return EventDelete }
+
+// CommitEvent is published after the trie is committed to its node store.
+type CommitEvent struct {
+	Root  MerkleRoot
+	Stats CommitStats
+}
+
+// Type satisfies the Event#Type interface
+func (CommitEvent) Type() EventType { return EventCommit }
+
+// PruneEvent is published when orphaned nodes are deleted from the node store.
+type PruneEvent struct {
+	// Digests are the keys of the nodes deleted from the node store
+	Digests [][]byte
+}
+
+// Type satisfies the Event#Type interface
+func (PruneEvent) Type() EventType { return EventPrune }
+
+// CommitAnomalyEvent is published after a commit writing more nodes per update
+// than the trie's anomaly threshold, see WithCommitAnomalyThreshold.
+type CommitAnomalyEvent struct {
+	Stats CommitStats
+}
+
+// Type satisfies the Event#Type interface
+func (CommitAnomalyEvent) Type() EventType { return EventCommitAnomaly }
+
+// EventBus dispatches the events of one or more tries to any number of
+// subscribers. Publishing never blocks: each subscriber has its own buffer
+// and events that do not fit in it are dropped and counted for that
+// subscriber only, so a slow observer cannot stall the trie or other
+// observers. Events are delivered to subscribers in subscription order.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers []*Subscription
+	nextID      int
+}
+
+// Subscription is a subscriber's handle on an EventBus.
+type Subscription struct {
+	bus     *EventBus
+	id      int
+	events  chan Event
+	types   map[EventType]bool
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// NewEventBus returns a new EventBus with no subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// WithEventBus returns an Option that publishes the events of the trie to the
+// EventBus provided.
+func WithEventBus(bus *EventBus) TrieSpecOption {
+	return func(ts *TrieSpec) { ts.events = bus }
+}
+
+// Subscribe registers a new subscriber receiving the given event types, or
+// all events if none are provided, through a channel buffering up to
+// bufferSize events.
+func (bus *EventBus) Subscribe(bufferSize int, types ...EventType) *Subscription {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	sub := &Subscription{
+		bus:    bus,
+		id:     bus.nextID,
+		events: make(chan Event, bufferSize),
+	}
+	if len(types) > 0 {
+		sub.types = make(map[EventType]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+	bus.subscribers = append(bus.subscribers, sub)
+	bus.nextID++
+	return sub
+}
+
+// Publish delivers the event to every subscriber interested in its type
+// without blocking.
+func (bus *EventBus) Publish(event Event) {
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+	for _, sub := range bus.subscribers {
+		if sub.types != nil && !sub.types[event.Type()] {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Events returns the channel the subscription's events are delivered on, it
+// is closed when the subscription is cancelled.
+func (sub *Subscription) Events() <-chan Event {
+	return sub.events
+}
+
+// Dropped returns the number of events dropped because the subscription's
+// buffer was full.
+func (sub *Subscription) Dropped() uint64 {
+	return sub.dropped.Load()
+}
+
+// Cancel unregisters the subscription from its EventBus and closes its
+// channel, it is safe to call more than once.
+func (sub *Subscription) Cancel() {
+	sub.once.Do(func() {
+		sub.bus.mu.Lock()
+		defer sub.bus.mu.Unlock()
+		for i, s := range sub.bus.subscribers {
+			if s.id == sub.id {
+				sub.bus.subscribers = append(sub.bus.subscribers[:i], sub.bus.subscribers[i+1:]...)
+				break
+			}
+		}
+		close(sub.events)
+	})
+}
+
+// emit publishes the event provided if the trie has an EventBus configured
+func (spec *TrieSpec) emit(event Event) {
+	if spec.events != nil {
+		spec.events.Publish(event)
+	}
+}
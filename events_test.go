@@ -0,0 +1,133 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestEventBus_TrieEvents(t *testing.T) {
+	bus := NewEventBus()
+	all := bus.Subscribe(16)
+	commits := bus.Subscribe(16, EventCommit)
+
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithEventBus(bus))
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, trie.Commit())
+	require.NoError(t, trie.Delete([]byte("foo")))
+	require.NoError(t, trie.Commit())
+
+	var types []EventType
+	for len(all.Events()) > 0 {
+		types = append(types, (<-all.Events()).Type())
+	}
+	require.Equal(t, []EventType{EventUpdate, EventCommit, EventDelete, EventPrune, EventCommit}, types)
+
+	first := (<-commits.Events()).(CommitEvent)
+	second := (<-commits.Events()).(CommitEvent)
+	require.Len(t, commits.Events(), 0)
+	require.NotEqual(t, first.Root, second.Root)
+	require.Equal(t, trie.Root(), second.Root)
+}
+
+func TestEventBus_EventsOwnTheirKeys(t *testing.T) {
+	bus := NewEventBus()
+	events := bus.Subscribe(16)
+	trie := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New(), WithEventBus(bus))
+
+	// Reusing the key buffers after an update or delete does not alter
+	// their events
+	key := []byte("foo")
+	require.NoError(t, trie.Update(key, []byte("bar"), 5))
+	key[0] = 'g'
+	key = []byte("foo")
+	require.NoError(t, trie.Delete(key))
+	key[0] = 'g'
+	keys := [][]byte{[]byte("foo")}
+	require.NoError(t, trie.Update(keys[0], []byte("bar"), 5))
+	require.NoError(t, trie.SMT.DeleteBatch(keys))
+	keys[0][0] = 'g'
+
+	for _, typ := range []EventType{EventUpdate, EventDelete, EventUpdate, EventDelete} {
+		event := <-events.Events()
+		require.Equal(t, typ, event.Type())
+		switch e := event.(type) {
+		case UpdateEvent:
+			require.Equal(t, []byte("foo"), e.Key)
+		case DeleteEvent:
+			require.Equal(t, []byte("foo"), e.Key)
+		}
+	}
+
+	// Nor does modifying an event alter the trie
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar"), 5))
+	root := trie.Root()
+	(<-events.Events()).(UpdateEvent).ValueHash[0] ^= 0xff
+	require.Equal(t, root, trie.Root())
+	valueHash, _, err := trie.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, trie.valueHash([]byte("bar")), valueHash)
+}
+
+func TestEventBus_NonBlocking(t *testing.T) {
+	bus := NewEventBus()
+	slow := bus.Subscribe(1)
+	fast := bus.Subscribe(8)
+
+	for i := 0; i < 5; i++ {
+		bus.Publish(DeleteEvent{Key: []byte{byte(i)}})
+	}
+	require.Len(t, slow.Events(), 1)
+	require.Equal(t, uint64(4), slow.Dropped())
+	require.Len(t, fast.Events(), 5)
+	require.Zero(t, fast.Dropped())
+
+	slow.Cancel()
+	slow.Cancel()
+	bus.Publish(DeleteEvent{})
+	<-slow.Events()
+	_, ok := <-slow.Events()
+	require.False(t, ok)
+	require.Len(t, fast.Events(), 6)
+}
+
+func TestEventBus_SumTrie(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(4, EventUpdate)
+
+	trie := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New(), WithEventBus(bus))
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar"), 5))
+
+	event := (<-sub.Events()).(UpdateEvent)
+	require.Equal(t, []byte("foo"), event.Key)
+	valueHash, sum, err := trie.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), sum)
+	require.Equal(t, valueHash, event.ValueHash[:len(valueHash)])
+}
+
+func TestEventBus_DeliveryOrder(t *testing.T) {
+	bus := NewEventBus()
+	var subs []*Subscription
+	for i := 0; i < 5; i++ {
+		subs = append(subs, bus.Subscribe(1))
+	}
+	subs[2].Cancel()
+
+	// Subscribers are filled in subscription order, so with a shared event
+	// each buffer fills before the next event is delivered to anyone
+	bus.Publish(DeleteEvent{Key: []byte{1}})
+	for i, sub := range subs {
+		if i == 2 {
+			continue
+		}
+		require.Len(t, sub.Events(), 1)
+	}
+	require.Len(t, bus.subscribers, 4)
+	for i := 1; i < len(bus.subscribers); i++ {
+		require.Less(t, bus.subscribers[i-1].id, bus.subscribers[i].id)
+	}
+}
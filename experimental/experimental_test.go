@@ -0,0 +1,84 @@
+package experimental
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestTrie(t *testing.T) {
+	store := simplemap.NewSimpleMap()
+	trie := New(WithStore(store))
+	require.False(t, trie.IsSumTrie())
+	require.Nil(t, trie.SMST())
+
+	require.NoError(t, trie.Update([]byte("key"), []byte("value")))
+	require.ErrorIs(t, trie.Update([]byte("key"), []byte("value"), WithWeight(1)), ErrNotSumTrie)
+	require.NoError(t, trie.Commit())
+
+	proof, err := trie.Prove([]byte("key"))
+	require.NoError(t, err)
+	valid, err := Verify(proof, trie.Root(), []byte("key"), []byte("value"), trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// The trie is reopened at its root
+	reopened := New(WithStore(store), WithRoot(trie.Root()))
+	valueHash, weight, err := reopened.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Zero(t, weight)
+	expected, err := trie.SMT().Get([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, expected, valueHash)
+
+	require.NoError(t, reopened.Delete([]byte("key")))
+	require.Equal(t, smt.NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New()).Root(), reopened.Root())
+	require.NoError(t, reopened.Commit())
+	require.NoError(t, reopened.Close())
+}
+
+func TestTrie_Sum(t *testing.T) {
+	trie := New(WithSumTrie())
+	require.True(t, trie.IsSumTrie())
+	require.ErrorIs(t, trie.Update([]byte("key"), []byte("value")), ErrWeightRequired)
+	require.NoError(t, trie.Update([]byte("key"), []byte("value"), WithWeight(5)))
+	require.NoError(t, trie.Update([]byte("other"), []byte("value"), WithWeight(7)))
+	require.NoError(t, trie.Commit())
+	require.Equal(t, uint64(12), trie.Root().Sum())
+
+	_, weight, err := trie.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), weight)
+
+	proof, err := trie.Prove([]byte("key"))
+	require.NoError(t, err)
+	valid, err := Verify(proof, trie.Root(), []byte("key"), []byte("value"), trie.Spec(), WithWeight(5))
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	proof, err = trie.Prove([]byte("absent"))
+	require.NoError(t, err)
+	valid, err = Verify(proof, trie.Root(), []byte("absent"), nil, trie.Spec(), WithWeight(0))
+	require.NoError(t, err)
+	require.True(t, valid)
+}
+
+func TestAdapters(t *testing.T) {
+	// Tries created with the existing API are usable through both APIs
+	smst := smt.NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	trie := FromSMST(smst)
+	require.NoError(t, trie.Update([]byte("key"), []byte("value"), WithWeight(3)))
+	require.Equal(t, uint64(3), smst.Sum())
+	require.Equal(t, smst, trie.SMST())
+
+	require.NoError(t, smst.Update([]byte("other"), []byte("value"), 4))
+	require.Equal(t, smst.Root(), trie.Root())
+	require.Equal(t, uint64(7), trie.Root().Sum())
+
+	plain := smt.NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.Equal(t, plain, FromSMT(plain).SMT())
+}
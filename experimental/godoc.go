@@ -0,0 +1,13 @@
+// Package experimental stages the redesigned, option based API of the smt
+// package alongside the existing one, so that downstream users can migrate to
+// it incrementally while new features land behind it.
+//
+// A single Trie type replaces the SMT and SMST constructors, configured with
+// functional options with sane defaults instead of positional arguments. The
+// adapters FromSMT and FromSMST wrap existing tries, while the SMT and SMST
+// methods unwrap a Trie for code still using the existing API, so both APIs
+// can be used on the same trie during a migration.
+//
+// This package is experimental: its API may change in any release, including
+// patch releases, until it is promoted to replace the existing API.
+package experimental
@@ -0,0 +1,227 @@
+package experimental
+
+import (
+	"crypto/sha256"
+	"errors"
+	"hash"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+var (
+	// ErrNotSumTrie is returned when using sum trie features on a trie which
+	// is not a sum trie.
+	ErrNotSumTrie = errors.New("not a sum trie")
+	// ErrWeightRequired is returned when updating a sum trie without a weight.
+	ErrWeightRequired = errors.New("sum trie updates require a weight")
+)
+
+// config is the configuration of a Trie, built from its options
+type config struct {
+	store       kvstore.MapStore
+	hasher      hash.Hash
+	sumTrie     bool
+	root        smt.MerkleRoot
+	specOptions []smt.TrieSpecOption
+}
+
+// Option configures a Trie
+type Option func(*config)
+
+// WithStore stores the trie's nodes in the store provided, the default is a
+// new in-memory store.
+func WithStore(store kvstore.MapStore) Option {
+	return func(c *config) { c.store = store }
+}
+
+// WithHasher hashes the trie with the hasher provided, the default is SHA-256.
+func WithHasher(hasher hash.Hash) Option {
+	return func(c *config) { c.hasher = hasher }
+}
+
+// WithSumTrie makes the trie a sum trie, whose leaves are weighted.
+func WithSumTrie() Option {
+	return func(c *config) { c.sumTrie = true }
+}
+
+// WithRoot opens the trie committed to its store with the root provided,
+// rather than a new empty trie.
+func WithRoot(root smt.MerkleRoot) Option {
+	return func(c *config) { c.root = root }
+}
+
+// WithSpecOptions configures the trie's spec with the existing API's options
+func WithSpecOptions(opts ...smt.TrieSpecOption) Option {
+	return func(c *config) { c.specOptions = append(c.specOptions, opts...) }
+}
+
+// UpdateOption configures a single update of a Trie
+type UpdateOption func(*update)
+
+type update struct {
+	weight    uint64
+	hasWeight bool
+}
+
+// WithWeight sets the weight of the leaf updated in a sum trie
+func WithWeight(weight uint64) UpdateOption {
+	return func(u *update) { u.weight, u.hasWeight = weight, true }
+}
+
+// Trie is a sparse Merkle trie, or sum trie, configured with options.
+type Trie struct {
+	smt  *smt.SMT
+	smst *smt.SMST
+}
+
+// New returns a new Trie configured with the options provided
+func New(opts ...Option) *Trie {
+	c := config{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.store == nil {
+		c.store = simplemap.NewSimpleMap()
+	}
+	if c.hasher == nil {
+		c.hasher = sha256.New()
+	}
+	switch {
+	case c.sumTrie && c.root != nil:
+		return FromSMST(smt.ImportSparseMerkleSumTrie(c.store, c.hasher, c.root, c.specOptions...))
+	case c.sumTrie:
+		return FromSMST(smt.NewSparseMerkleSumTrie(c.store, c.hasher, c.specOptions...))
+	case c.root != nil:
+		return FromSMT(smt.ImportSparseMerkleTrie(c.store, c.hasher, c.root, c.specOptions...))
+	default:
+		return FromSMT(smt.NewSparseMerkleTrie(c.store, c.hasher, c.specOptions...))
+	}
+}
+
+// FromSMT adapts an existing SMT into a Trie, both can be used to access the
+// same trie.
+func FromSMT(trie *smt.SMT) *Trie {
+	return &Trie{smt: trie}
+}
+
+// FromSMST adapts an existing SMST into a Trie, both can be used to access the
+// same trie.
+func FromSMST(trie *smt.SMST) *Trie {
+	return &Trie{smt: trie.SMT, smst: trie}
+}
+
+// SMT returns the trie for use with the existing API. For sum tries this is
+// the SMST's underlying trie, whose values are the leaves' value hashes
+// followed by their weights, use SMST instead.
+func (t *Trie) SMT() *smt.SMT {
+	return t.smt
+}
+
+// SMST returns the sum trie for use with the existing API, or nil if the
+// trie is not a sum trie.
+func (t *Trie) SMST() *smt.SMST {
+	return t.smst
+}
+
+// IsSumTrie returns true if the trie is a sum trie
+func (t *Trie) IsSumTrie() bool {
+	return t.smst != nil
+}
+
+// Spec returns the trie's spec
+func (t *Trie) Spec() *smt.TrieSpec {
+	if t.smst != nil {
+		return t.smst.Spec()
+	}
+	return t.smt.Spec()
+}
+
+// Update sets the value of the key. Sum trie updates require a weight,
+// provided with WithWeight.
+func (t *Trie) Update(key, value []byte, opts ...UpdateOption) error {
+	u := update{}
+	for _, opt := range opts {
+		opt(&u)
+	}
+	if t.smst == nil {
+		if u.hasWeight {
+			return ErrNotSumTrie
+		}
+		return t.smt.Update(key, value)
+	}
+	if !u.hasWeight {
+		return ErrWeightRequired
+	}
+	return t.smst.Update(key, value, u.weight)
+}
+
+// Get returns the value hash of the key, or the default empty value if it is
+// not set. The weight of the leaf is returned for sum tries, zero otherwise.
+func (t *Trie) Get(key []byte) (valueHash []byte, weight uint64, err error) {
+	if t.smst != nil {
+		return t.smst.Get(key)
+	}
+	valueHash, err = t.smt.Get(key)
+	return valueHash, 0, err
+}
+
+// Delete removes the key from the trie
+func (t *Trie) Delete(key []byte) error {
+	if t.smst != nil {
+		return t.smst.Delete(key)
+	}
+	return t.smt.Delete(key)
+}
+
+// Prove returns a proof of the key's value, or absence, against the current
+// root of the trie.
+func (t *Trie) Prove(key []byte) (*smt.SparseMerkleProof, error) {
+	if t.smst != nil {
+		return t.smst.Prove(key)
+	}
+	return t.smt.Prove(key)
+}
+
+// Commit persists the trie to its store
+func (t *Trie) Commit() error {
+	if t.smst != nil {
+		return t.smst.Commit()
+	}
+	return t.smt.Commit()
+}
+
+// Root returns the root of the trie
+func (t *Trie) Root() smt.MerkleRoot {
+	if t.smst != nil {
+		return t.smst.Root()
+	}
+	return t.smt.Root()
+}
+
+// Close closes the trie, see smt.SMT.Close
+func (t *Trie) Close() error {
+	if t.smst != nil {
+		return t.smst.Close()
+	}
+	return t.smt.Close()
+}
+
+// Verify verifies a proof of the key's value, or absence if the value is
+// empty, against the root for a trie with the spec provided. The weight
+// option is required for sum tries, with a zero weight for absent keys.
+func Verify(proof *smt.SparseMerkleProof, root smt.MerkleRoot, key, value []byte, spec *smt.TrieSpec, opts ...UpdateOption) (bool, error) {
+	u := update{}
+	for _, opt := range opts {
+		opt(&u)
+	}
+	if !u.hasWeight {
+		return smt.VerifyProof(proof, root, key, value, spec)
+	}
+	count := uint64(1)
+	if len(value) == 0 {
+		count = 0
+	}
+	return smt.VerifySumProof(proof, root, key, value, u.weight, count, spec)
+}
@@ -0,0 +1,85 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// fingerprintVersion is the version of the encoding of the spec parameters
+// hashed into a fingerprint, it must be bumped whenever the encoding changes.
+const fingerprintVersion = 1
+
+var (
+	// ErrSpecMismatch is returned when a proof or peer was produced with a
+	// different TrieSpec to the verifier's.
+	ErrSpecMismatch = errors.New("trie spec fingerprint mismatch")
+
+	// fingerprintProbe is the input the hashers of a spec are probed with to
+	// identify them in its fingerprint.
+	fingerprintProbe = []byte("smt/fingerprint")
+	// fingerprintAggregatePairs are the inputs a sum trie's aggregator is
+	// probed with to identify it in its fingerprint.
+	fingerprintAggregatePairs = [][2]uint64{{3, 5}, {5, 3}, {0, 1<<64 - 1}}
+)
+
+// Fingerprint returns a sha256 digest over all the parameters of the spec
+// that affect the roots and proofs of a trie: the trie hasher (identified by
+// its size and digest of empty input), the path hasher (identified by its
+// size, and so the trie's depth, and the path of a fixed probe), the value
+// hashing mode, the node encoding prefixes and, for sum tries, the aggregator
+// (identified by its output for fixed inputs). Two systems with matching
+// fingerprints produce and verify the same roots and proofs, allowing them to
+// detect mismatched configurations upfront.
+func (spec *TrieSpec) Fingerprint() []byte {
+	buf := bytes.NewBuffer(nil)
+	writeUint := func(n uint64) {
+		var bz [8]byte
+		binary.BigEndian.PutUint64(bz[:], n)
+		buf.Write(bz[:])
+	}
+	writeBytes := func(data []byte) {
+		writeUint(uint64(len(data)))
+		buf.Write(data)
+	}
+
+	buf.WriteByte(fingerprintVersion)
+	writeUint(uint64(spec.th.hashSize()))
+	writeBytes(spec.th.digestData(nil))
+	writeUint(uint64(spec.ph.PathSize()))
+	// The probe key is path sized so path hashers that do not hash keys work
+	pathProbe := bytes.Repeat(fingerprintProbe, spec.ph.PathSize()/len(fingerprintProbe)+1)
+	writeBytes(spec.ph.Path(pathProbe[:spec.ph.PathSize()]))
+	if spec.vh == nil {
+		buf.WriteByte(0)
+	} else {
+		buf.WriteByte(1)
+		writeUint(uint64(spec.vh.ValueHashSize()))
+		writeBytes(spec.vh.HashValue(fingerprintProbe))
+	}
+	writeBytes(leafNodePrefix)
+	writeBytes(innerNodePrefix)
+	writeBytes(extNodePrefix)
+	if spec.sumTrie {
+		buf.WriteByte(1)
+		for _, pair := range fingerprintAggregatePairs {
+			writeUint(spec.agg.aggregate(pair[0], 1, pair[1], 1))
+		}
+	} else {
+		buf.WriteByte(0)
+	}
+
+	digest := sha256.Sum256(buf.Bytes())
+	return digest[:]
+}
+
+// checkFingerprint returns an error wrapping ErrSpecMismatch if the
+// fingerprint provided does not match the spec's
+func (spec *TrieSpec) checkFingerprint(fingerprint []byte) error {
+	if expected := spec.Fingerprint(); !bytes.Equal(fingerprint, expected) {
+		return errors.Join(ErrSpecMismatch, fmt.Errorf("got %x but want %x", fingerprint, expected))
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestTrieSpec_Fingerprint(t *testing.T) {
+	smtSpec := NewTrieSpec(sha256.New(), false)
+	fingerprints := [][]byte{
+		smtSpec.Fingerprint(),
+		NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha512.New()).Spec().Fingerprint(),
+		NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithValueHasher(nil)).Spec().Fingerprint(),
+		NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(),
+			WithPathHasher(newNilPathHasher(sha256.Size))).Spec().Fingerprint(),
+		NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New()).Spec().Fingerprint(),
+		NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New(), WithAggregator(MinAggregate)).Spec().Fingerprint(),
+		NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New(), WithAggregator(MaxAggregate)).Spec().Fingerprint(),
+	}
+	for i := range fingerprints {
+		require.Len(t, fingerprints[i], sha256.Size)
+		for j := i + 1; j < len(fingerprints); j++ {
+			require.NotEqual(t, fingerprints[i], fingerprints[j], "fingerprints %d and %d collide", i, j)
+		}
+	}
+
+	// Equivalent specs share a fingerprint
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.Equal(t, smtSpec.Fingerprint(), trie.Spec().Fingerprint())
+	sumTrie := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New(), WithAggregator(SumAggregate))
+	require.Equal(t, fingerprints[4], sumTrie.Spec().Fingerprint())
+}
+
+func TestFreshProof_SpecFingerprint(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	proof, err := ProveFresh(trie, []byte("foo"), 1, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, trie.Spec().Fingerprint(), proof.SpecFingerprint)
+
+	valid, err := VerifyFreshProof(proof, []byte("foo"), []byte("bar"), FreshnessPolicy{Root: trie.Root()}, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	other := NewTrieSpec(sha256.New(), false, WithValueHasher(nil))
+	_, err = VerifyFreshProof(proof, []byte("foo"), []byte("bar"), FreshnessPolicy{Root: trie.Root()}, &other)
+	require.ErrorIs(t, err, ErrSpecMismatch)
+}
@@ -0,0 +1,163 @@
+package smt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var (
+	// ErrRootMismatch is returned when a follower applying a batch of changes
+	// does not arrive at the root the leader committed.
+	ErrRootMismatch = errors.New("follower root does not match leader root")
+	// ErrEventsDropped is returned when a changefeed fell behind its trie and
+	// dropped events, so its batches would be incomplete.
+	ErrEventsDropped = errors.New("changefeed dropped events")
+)
+
+// CommitChange is an update or deletion of a leaf of a trie made by a commit.
+type CommitChange struct {
+	Key []byte
+	// ValueHash is the value hash of the updated leaf, or nil for a deletion
+	ValueHash []byte
+}
+
+// CommitBatch holds the changes committed to a trie by a single commit, in
+// the order they were made, along with the root they resulted in. Batches are
+// serialisable with gob, to be streamed to followers.
+type CommitBatch struct {
+	Changes []CommitChange
+	Root    MerkleRoot
+}
+
+// Changefeed groups the events a leader trie publishes to an EventBus into
+// the CommitBatch of each of its commits.
+type Changefeed struct {
+	sub     *Subscription
+	changes []CommitChange
+}
+
+// NewChangefeed returns a Changefeed of the trie publishing to the EventBus
+// provided, which the trie must be configured with using WithEventBus,
+// buffering up to bufferSize events. Only the commits made after the
+// changefeed was created are fed.
+func NewChangefeed(bus *EventBus, bufferSize int) *Changefeed {
+	return &Changefeed{sub: bus.Subscribe(bufferSize, EventUpdate, EventDelete, EventCommit)}
+}
+
+// Next returns the batch of the next commit of the trie, waiting for it until
+// the context is cancelled. An error wrapping ErrEventsDropped is returned if
+// the changefeed fell behind the trie, after which it must be recreated and
+// its followers resynchronised, and io.EOF once the changefeed is closed.
+func (feed *Changefeed) Next(ctx context.Context) (*CommitBatch, error) {
+	for {
+		if dropped := feed.sub.Dropped(); dropped > 0 {
+			return nil, errors.Join(ErrEventsDropped, fmt.Errorf("%d events dropped", dropped))
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case event, ok := <-feed.sub.Events():
+			if !ok {
+				return nil, io.EOF
+			}
+			switch e := event.(type) {
+			case UpdateEvent:
+				feed.changes = append(feed.changes, CommitChange{Key: e.Key, ValueHash: e.ValueHash})
+			case DeleteEvent:
+				feed.changes = append(feed.changes, CommitChange{Key: e.Key})
+			case CommitEvent:
+				batch := &CommitBatch{Changes: feed.changes, Root: e.Root}
+				feed.changes = nil
+				return batch, nil
+			}
+		}
+	}
+}
+
+// Close unsubscribes the changefeed from its EventBus
+func (feed *Changefeed) Close() {
+	feed.sub.Cancel()
+}
+
+// Follower runs a trie as a read replica of a leader trie, applying the
+// batches of the leader's commits, e.g. streamed from a Changefeed, and
+// verifying each results in the leader's root before committing it, so that
+// proofs can be served by any number of replicas. A Follower is safe for
+// concurrent use.
+type Follower struct {
+	mu   sync.Mutex
+	trie *SMT
+}
+
+// NewFollower returns a Follower replicating the leader into the trie
+// provided, which must have the leader's spec and be at the root of the
+// leader's commit preceding the first batch applied, e.g. by importing a copy
+// of the leader's node store. The trie must not be modified by anything else.
+func NewFollower(trie *SMT) *Follower {
+	return &Follower{trie: trie}
+}
+
+// Apply applies the changes of the batch to the trie and commits them if they
+// result in the batch's root. Otherwise the changes are discarded and an
+// error wrapping ErrRootMismatch is returned, leaving the trie at its last
+// committed root.
+func (f *Follower) Apply(batch *CommitBatch) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.trie.closed {
+		return ErrClosed
+	}
+	if err := f.apply(batch); err != nil {
+		return errors.Join(err, f.trie.Discard())
+	}
+	if root := f.trie.Root(); !bytes.Equal(root, batch.Root) {
+		mismatch := errors.Join(ErrRootMismatch, fmt.Errorf("got root %x, want %x", []byte(root), []byte(batch.Root)))
+		return errors.Join(mismatch, f.trie.Discard())
+	}
+	return f.trie.Commit()
+}
+
+// Root returns the root of the last batch applied
+func (f *Follower) Root() MerkleRoot {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.trie.Root()
+}
+
+// Get returns the value hash of the key at the root of the last batch applied
+func (f *Follower) Get(key []byte) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.trie.Get(key)
+}
+
+// Prove returns a proof of the key against the root of the last batch applied
+func (f *Follower) Prove(key []byte) (*SparseMerkleProof, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.trie.Prove(key)
+}
+
+// apply applies the changes of the batch to the trie
+func (f *Follower) apply(batch *CommitBatch) error {
+	for _, change := range batch.Changes {
+		if change.ValueHash == nil {
+			if err := f.trie.Delete(change.Key); err != nil {
+				return fmt.Errorf("deleting key %x: %w", change.Key, err)
+			}
+			continue
+		}
+		path, err := f.trie.path(change.Key)
+		if err != nil {
+			return err
+		}
+		if err := f.trie.updateLeaf(change.Key, path, change.ValueHash); err != nil {
+			return fmt.Errorf("updating key %x: %w", change.Key, err)
+		}
+	}
+	return nil
+}
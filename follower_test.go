@@ -0,0 +1,86 @@
+package smt
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestFollower_Apply(t *testing.T) {
+	bus := NewEventBus()
+	feed := NewChangefeed(bus, 64)
+	defer feed.Close()
+	leader := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithEventBus(bus))
+	follower := NewFollower(NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New()))
+
+	ctx := context.Background()
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 5; i++ {
+			require.NoError(t, leader.Update([]byte(fmt.Sprintf("key-%d-%d", round, i)), []byte(fmt.Sprintf("value-%d", i))))
+		}
+		if round > 0 {
+			require.NoError(t, leader.Delete([]byte(fmt.Sprintf("key-%d-0", round-1))))
+		}
+		require.NoError(t, leader.Commit())
+
+		batch, err := feed.Next(ctx)
+		require.NoError(t, err)
+		require.Equal(t, leader.Root(), batch.Root)
+
+		// Batches are streamed to followers serialised
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, gob.NewEncoder(buf).Encode(batch))
+		streamed := &CommitBatch{}
+		require.NoError(t, gob.NewDecoder(buf).Decode(streamed))
+		require.NoError(t, follower.Apply(streamed))
+		require.Equal(t, leader.Root(), follower.Root())
+	}
+
+	// Followers serve proofs against the leader's roots
+	key, value := []byte("key-2-1"), []byte("value-1")
+	proof, err := follower.Prove(key)
+	require.NoError(t, err)
+	valid, err := VerifyProof(proof, leader.Root(), key, value, leader.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+	valueHash, err := follower.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, leader.valueHash(value), valueHash)
+
+	// Batches which do not result in the leader's root are rejected
+	root := follower.Root()
+	require.NoError(t, leader.Update([]byte("key"), []byte("value")))
+	require.NoError(t, leader.Commit())
+	batch, err := feed.Next(ctx)
+	require.NoError(t, err)
+	batch.Changes[0].ValueHash = leader.valueHash([]byte("forged"))
+	require.ErrorIs(t, follower.Apply(batch), ErrRootMismatch)
+	require.Equal(t, root, follower.Root())
+	batch.Changes = append(batch.Changes, CommitChange{Key: []byte("missing")})
+	require.ErrorIs(t, follower.Apply(batch), ErrKeyNotFound)
+	require.Equal(t, root, follower.Root())
+}
+
+func TestChangefeed_Dropped(t *testing.T) {
+	bus := NewEventBus()
+	feed := NewChangefeed(bus, 1)
+	leader := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithEventBus(bus))
+	require.NoError(t, leader.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, leader.Commit())
+	_, err := feed.Next(context.Background())
+	require.ErrorIs(t, err, ErrEventsDropped)
+
+	feed.Close()
+	feed = NewChangefeed(bus, 1)
+	feed.Close()
+	_, err = feed.Next(context.Background())
+	require.ErrorIs(t, err, io.EOF)
+}
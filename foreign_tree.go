@@ -0,0 +1,132 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// foreignImportCommitEvery is the number of leaves imported from a foreign
+// tree between commits, bounding the uncommitted nodes held in memory
+const foreignImportCommitEvery = 10000
+
+var (
+	// ErrForeignTreeChanged is returned when the root of a foreign tree
+	// changes while it is imported.
+	ErrForeignTreeChanged = errors.New("foreign tree changed during import")
+	// ErrForeignMismatch is returned when a trie imported from a foreign tree
+	// does not hold the mappings of the foreign tree.
+	ErrForeignMismatch = errors.New("imported trie does not match foreign tree")
+)
+
+// ForeignTree adapts a tree of another implementation, such as
+// celestiaorg/smt or an IAVL-based store, so that its state can be migrated
+// into a trie of this package with ImportForeignTree. Its root is only
+// compared with itself, identifying the state of the foreign tree, so it may
+// be computed in any way.
+type ForeignTree interface {
+	// Root returns the current root of the foreign tree
+	Root() ([]byte, error)
+	// Leaves returns an iterator over the key-value pairs of the foreign tree,
+	// in any order
+	Leaves() (KVIterator, error)
+}
+
+// ForeignImport describes a foreign tree imported by ImportForeignTree.
+type ForeignImport struct {
+	// ForeignRoot is the root of the foreign tree imported
+	ForeignRoot []byte
+	// Root is the root of the trie rebuilt from the foreign tree
+	Root MerkleRoot
+	// Leaves is the number of key-value pairs imported
+	Leaves int
+}
+
+// ImportForeignTree rebuilds the state of the foreign tree in the trie, which
+// must be empty, under the trie's spec, and commits it. Once rebuilt, every
+// key-value pair of the foreign tree is read back from the trie with a proof
+// verified against the new root, and an error wrapping ErrForeignMismatch is
+// returned if any pair is missing or differs. The foreign tree may be live, but
+// must not be modified during the import: its root is compared before and
+// after, and an error wrapping ErrForeignTreeChanged is returned if it moved.
+// The trie is committed regularly while importing, so a failed import leaves
+// a partially imported trie which should be discarded.
+func ImportForeignTree(foreign ForeignTree, trie *SMTWithStorage) (*ForeignImport, error) {
+	if !IsEmptyRoot(trie.Root(), trie.Spec()) {
+		return nil, errors.New("cannot import a foreign tree into a non-empty trie")
+	}
+	foreignRoot, err := foreign.Root()
+	if err != nil {
+		return nil, err
+	}
+	result := &ForeignImport{ForeignRoot: foreignRoot}
+
+	leaves, err := foreign.Leaves()
+	if err != nil {
+		return nil, err
+	}
+	for leaves.Next() {
+		if err := trie.Update(leaves.Key(), leaves.Value()); err != nil {
+			return nil, fmt.Errorf("importing key %x: %w", leaves.Key(), err)
+		}
+		if result.Leaves++; result.Leaves%foreignImportCommitEvery == 0 {
+			if err := trie.Commit(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := leaves.Err(); err != nil {
+		return nil, err
+	}
+	if err := trie.Commit(); err != nil {
+		return nil, err
+	}
+	result.Root = trie.Root()
+
+	if err := verifyForeignTree(foreign, trie, result); err != nil {
+		return nil, err
+	}
+	after, err := foreign.Root()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(after, foreignRoot) {
+		return nil, errors.Join(ErrForeignTreeChanged, fmt.Errorf("root moved from %x to %x", foreignRoot, after))
+	}
+	return result, nil
+}
+
+// verifyForeignTree checks every key-value pair of the foreign tree is proven
+// by the trie at the root of the import.
+func verifyForeignTree(foreign ForeignTree, trie *SMTWithStorage, result *ForeignImport) error {
+	leaves, err := foreign.Leaves()
+	if err != nil {
+		return err
+	}
+	verified := 0
+	for leaves.Next() {
+		key, value := leaves.Key(), leaves.Value()
+		imported, proof, err := trie.GetWithProof(key)
+		if err != nil {
+			return errors.Join(ErrForeignMismatch, fmt.Errorf("reading key %x", key), err)
+		}
+		if !bytes.Equal(imported, value) {
+			return errors.Join(ErrForeignMismatch, fmt.Errorf("key %x has value %x, want %x", key, imported, value))
+		}
+		valid, err := VerifyProof(proof, result.Root, key, value, trie.Spec())
+		if err != nil {
+			return errors.Join(ErrForeignMismatch, err)
+		}
+		if !valid {
+			return errors.Join(ErrForeignMismatch, fmt.Errorf("proof for key %x does not verify", key))
+		}
+		verified++
+	}
+	if err := leaves.Err(); err != nil {
+		return err
+	}
+	if verified != result.Leaves {
+		return errors.Join(ErrForeignMismatch, fmt.Errorf("verified %d keys but imported %d", verified, result.Leaves))
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// sliceForeignTree is a ForeignTree over slices of pairs, whose root is the
+// number of times it was modified
+type sliceForeignTree struct {
+	keys, values [][]byte
+	version      int
+	// onIterate is called every time the leaves are iterated
+	onIterate func(tree *sliceForeignTree)
+}
+
+func (tree *sliceForeignTree) Root() ([]byte, error) {
+	return []byte(fmt.Sprintf("version-%d", tree.version)), nil
+}
+
+func (tree *sliceForeignTree) Leaves() (KVIterator, error) {
+	if tree.onIterate != nil {
+		tree.onIterate(tree)
+	}
+	return &sliceKVIterator{keys: tree.keys, values: tree.values}, nil
+}
+
+func TestImportForeignTree(t *testing.T) {
+	foreign := &sliceForeignTree{}
+	for i := 0; i < 100; i++ {
+		foreign.keys = append(foreign.keys, []byte(fmt.Sprintf("key%d", i)))
+		foreign.values = append(foreign.values, []byte(fmt.Sprintf("value%d", i)))
+	}
+	newTrie := func() *SMTWithStorage {
+		return NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	}
+
+	trie := newTrie()
+	result, err := ImportForeignTree(foreign, trie)
+	require.NoError(t, err)
+	require.Equal(t, []byte("version-0"), result.ForeignRoot)
+	require.Equal(t, 100, result.Leaves)
+	require.Equal(t, trie.Root(), result.Root)
+	value, err := trie.GetValue([]byte("key42"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value42"), value)
+
+	// The root is that of the same pairs inserted in any order
+	expected := newTrie()
+	for i := len(foreign.keys) - 1; i >= 0; i-- {
+		require.NoError(t, expected.Update(foreign.keys[i], foreign.values[i]))
+	}
+	require.Equal(t, expected.Root(), result.Root)
+
+	_, err = ImportForeignTree(foreign, trie)
+	require.ErrorContains(t, err, "non-empty trie")
+
+	// Trees modified during the import are rejected
+	foreign.onIterate = func(tree *sliceForeignTree) { tree.version++ }
+	_, err = ImportForeignTree(foreign, newTrie())
+	require.ErrorIs(t, err, ErrForeignTreeChanged)
+
+	// Trees whose leaves change between iterations are rejected
+	iterations := 0
+	foreign.onIterate = func(tree *sliceForeignTree) {
+		if iterations++; iterations == 2 {
+			tree.values[7] = []byte("changed")
+		}
+	}
+	_, err = ImportForeignTree(foreign, newTrie())
+	require.ErrorIs(t, err, ErrForeignMismatch)
+}
@@ -0,0 +1,227 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func init() {
+	gob.Register(FreshProof{})
+}
+
+var (
+	// ErrStaleProof is returned when a FreshProof is rejected by the freshness
+	// policy of the verifier.
+	ErrStaleProof = errors.New("stale proof")
+	// ErrBindingMismatch is returned when a FreshProof is bound to a different
+	// context than the verifier expects.
+	ErrBindingMismatch = errors.New("proof binding mismatch")
+	// ErrProofNotAuthenticated is returned when the signature of a FreshProof
+	// is missing or rejected by the authenticator of the verifier's policy.
+	ErrProofNotAuthenticated = errors.New("proof not authenticated")
+)
+
+// freshProofDigestTag domain separates the digests of FreshProofs
+var freshProofDigestTag = []byte("smt/fresh-proof/v1")
+
+// FreshProof binds a SparseMerkleProof to the root it was generated against,
+// along with the height (commit index) and time that root was committed at,
+// so verifiers can reject proofs against outdated roots.
+type FreshProof struct {
+	Proof  *SparseMerkleProof
+	Root   MerkleRoot
+	Height uint64
+	Time   time.Time
+	// SpecFingerprint is the fingerprint of the prover's TrieSpec
+	SpecFingerprint []byte
+	// Binding is an optional context string (e.g. a chain or application ID)
+	// the proof was generated for, so that it is not accepted by verifiers of
+	// other deployments holding the same data
+	Binding []byte
+	// Signature authenticates the proof's Digest, see Sign
+	Signature []byte
+}
+
+// ProveFresh generates a FreshProof for the given key against the current
+// root of the trie, which is expected to have been committed at the height
+// and time provided, along with the fingerprint of the trie's spec.
+func ProveFresh(trie SparseMerkleTrie, key []byte, height uint64, at time.Time) (*FreshProof, error) {
+	proof, err := trie.Prove(key)
+	if err != nil {
+		return nil, err
+	}
+	return &FreshProof{
+		Proof:           proof,
+		Root:            trie.Root(),
+		Height:          height,
+		Time:            at,
+		SpecFingerprint: trie.Spec().Fingerprint(),
+	}, nil
+}
+
+// Digest returns the digest binding the envelope of the proof, its binding,
+// root, height, time and spec fingerprint, hashed with the spec's hasher.
+// Provers sign (or otherwise authenticate) the digest so that none of these,
+// in particular the binding, can be altered without detection.
+func (proof *FreshProof) Digest(spec *TrieSpec) []byte {
+	buf := bytes.NewBuffer(nil)
+	writeBytes := func(data []byte) {
+		buf.Write(binary.AppendUvarint(nil, uint64(len(data))))
+		buf.Write(data)
+	}
+	writeBytes(freshProofDigestTag)
+	writeBytes(proof.Binding)
+	writeBytes(proof.Root)
+	buf.Write(binary.BigEndian.AppendUint64(nil, proof.Height))
+	buf.Write(binary.BigEndian.AppendUint64(nil, uint64(proof.Time.UnixNano())))
+	writeBytes(proof.SpecFingerprint)
+	return spec.th.digestData(buf.Bytes())
+}
+
+// Sign signs the digest of the proof with the ed25519 private key provided,
+// so that verifiers can authenticate its binding, root, height and time.
+func (proof *FreshProof) Sign(key ed25519.PrivateKey, spec *TrieSpec) {
+	proof.Signature = ed25519.Sign(key, proof.Digest(spec))
+}
+
+// FreshProofAuthenticator verifies the signature of the digest of a
+// FreshProof.
+type FreshProofAuthenticator func(digest, signature []byte) error
+
+// Ed25519FreshProofAuthenticator returns a FreshProofAuthenticator accepting
+// proofs signed by any of the ed25519 public keys provided.
+func Ed25519FreshProofAuthenticator(keys ...ed25519.PublicKey) FreshProofAuthenticator {
+	return func(digest, signature []byte) error {
+		for _, key := range keys {
+			if ed25519.Verify(key, digest, signature) {
+				return nil
+			}
+		}
+		return errors.New("proof signature does not match any trusted key")
+	}
+}
+
+// Marshal serialises the FreshProof to bytes
+func (proof *FreshProof) Marshal() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(proof); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal deserialises the FreshProof from bytes
+func (proof *FreshProof) Unmarshal(bz []byte) error {
+	buf := bytes.NewBuffer(bz)
+	dec := gob.NewDecoder(buf)
+	return dec.Decode(proof)
+}
+
+// FreshnessPolicy is the verifier-side policy deciding whether a FreshProof is
+// recent enough to be accepted. Zero valued fields are not enforced.
+type FreshnessPolicy struct {
+	// MaxAge is the maximum time elapsed since the proof's root was committed
+	MaxAge time.Duration
+	// MinHeight is the minimum height the proof's root must be committed at
+	MinHeight uint64
+	// Roots must trust the proof's root at the proof's height, as the height
+	// and time of a FreshProof are otherwise only claimed by the prover, unless
+	// Root is set instead
+	Roots RootOracle
+	// Root is the trusted root proofs must be generated against if the policy
+	// has no RootOracle
+	Root MerkleRoot
+	// Clock measures the age of proofs, defaulting to the SystemClock
+	Clock Clock
+	// Binding, if set, is the context proofs must be bound to. As the binding
+	// is otherwise only claimed by the prover, proofs are only verified
+	// against a binding if the policy authenticates them.
+	Binding []byte
+	// Authenticate, if set, must accept the proof's signature of its digest
+	Authenticate FreshProofAuthenticator
+}
+
+// Check returns an error wrapping ErrStaleProof if the proof does not satisfy
+// the policy, an error wrapping ErrBindingMismatch if it is not bound to the
+// policy's binding, or an error wrapping ErrRootNotFound if the policy does
+// not trust the proof's root, including when it has neither a RootOracle nor
+// a trusted Root. Check does not authenticate the
+// proof, so its binding is only trustworthy when verified with
+// VerifyFreshProof.
+func (policy FreshnessPolicy) Check(proof *FreshProof) error {
+	if policy.Binding != nil && !bytes.Equal(policy.Binding, proof.Binding) {
+		return errors.Join(ErrBindingMismatch, fmt.Errorf("proof bound to %q but expected %q", proof.Binding, policy.Binding))
+	}
+	if proof.Height < policy.MinHeight {
+		return errors.Join(ErrStaleProof, fmt.Errorf("height %d is below minimum height %d", proof.Height, policy.MinHeight))
+	}
+	if policy.MaxAge > 0 {
+		clock := policy.Clock
+		if clock == nil {
+			clock = SystemClock
+		}
+		if age := clock.Now().Sub(proof.Time); age > policy.MaxAge {
+			return errors.Join(ErrStaleProof, fmt.Errorf("age %s exceeds maximum age %s", age, policy.MaxAge))
+		}
+	}
+	trusted := policy.Root
+	if policy.Roots != nil {
+		var err error
+		if trusted, err = policy.Roots.RootAt(proof.Height); err != nil {
+			return err
+		}
+	} else if trusted == nil {
+		return errors.Join(ErrRootNotFound, errors.New("policy trusts no root"))
+	}
+	if !bytes.Equal(trusted, proof.Root) {
+		return errors.Join(ErrRootNotFound, fmt.Errorf("root %x is not trusted at height %d", proof.Root, proof.Height))
+	}
+	return nil
+}
+
+// VerifyFreshProof checks the proof satisfies the freshness policy provided,
+// including that its root is trusted by the policy, before verifying it
+// against that root, returning any policy violation as an
+// error. If the proof carries a spec fingerprint an error wrapping
+// ErrSpecMismatch is returned if it does not match the spec provided. If the
+// policy authenticates proofs, an error wrapping ErrProofNotAuthenticated is
+// returned if the proof's signature is rejected, as it is if the policy has a
+// binding but no authenticator.
+func VerifyFreshProof(proof *FreshProof, key, value []byte, policy FreshnessPolicy, spec *TrieSpec) (bool, error) {
+	if proof.Proof == nil {
+		return false, errors.Join(ErrBadProof, errors.New("missing proof"))
+	}
+	if proof.SpecFingerprint != nil {
+		if err := spec.checkFingerprint(proof.SpecFingerprint); err != nil {
+			return false, err
+		}
+	}
+	if err := policy.authenticate(proof, spec); err != nil {
+		return false, err
+	}
+	if err := policy.Check(proof); err != nil {
+		return false, err
+	}
+	return VerifyProof(proof.Proof, proof.Root, key, value, spec)
+}
+
+// authenticate verifies the proof's signature of its digest with the policy's
+// authenticator, which is required if the policy has a binding.
+func (policy FreshnessPolicy) authenticate(proof *FreshProof, spec *TrieSpec) error {
+	if policy.Authenticate == nil {
+		if policy.Binding != nil {
+			return errors.Join(ErrProofNotAuthenticated, errors.New("bindings cannot be verified without an authenticator"))
+		}
+		return nil
+	}
+	if err := policy.Authenticate(proof.Digest(spec), proof.Signature); err != nil {
+		return errors.Join(ErrProofNotAuthenticated, err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package smt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestFreshProof(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, trie.Commit())
+
+	committedAt := time.Unix(1700000000, 0)
+	proof, err := ProveFresh(trie, []byte("foo"), 10, committedAt)
+	require.NoError(t, err)
+
+	bz, err := proof.Marshal()
+	require.NoError(t, err)
+	decoded := new(FreshProof)
+	require.NoError(t, decoded.Unmarshal(bz))
+	require.Equal(t, uint64(10), decoded.Height)
+	require.True(t, committedAt.Equal(decoded.Time))
+
+	clock := NewManualClock(committedAt.Add(time.Minute))
+	root := trie.Root()
+	fresh := FreshnessPolicy{MaxAge: time.Hour, MinHeight: 10, Root: root, Clock: clock}
+	valid, err := VerifyFreshProof(decoded, []byte("foo"), []byte("bar"), fresh, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+	valid, err = VerifyFreshProof(decoded, []byte("foo"), []byte("baz"), fresh, trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+
+	clock.Advance(2 * time.Hour)
+	_, err = VerifyFreshProof(decoded, []byte("foo"), []byte("bar"), fresh, trie.Spec())
+	require.ErrorIs(t, err, ErrStaleProof)
+	tooLow := FreshnessPolicy{MinHeight: 11, Root: root}
+	_, err = VerifyFreshProof(decoded, []byte("foo"), []byte("bar"), tooLow, trie.Spec())
+	require.ErrorIs(t, err, ErrStaleProof)
+
+	// Proofs are not verified against the root they claim
+	_, err = VerifyFreshProof(decoded, []byte("foo"), []byte("bar"), FreshnessPolicy{}, trie.Spec())
+	require.ErrorIs(t, err, ErrRootNotFound)
+	forged := *decoded
+	forged.Root = []byte("forged root")
+	_, err = VerifyFreshProof(&forged, []byte("foo"), []byte("bar"), FreshnessPolicy{Root: root}, trie.Spec())
+	require.ErrorIs(t, err, ErrRootNotFound)
+
+	// The claimed height and root must be trusted by the policy's oracle
+	oracle := NewMemoryRootOracle(nil)
+	require.NoError(t, oracle.Update(10, trie.Root()))
+	valid, err = VerifyFreshProof(decoded, []byte("foo"), []byte("bar"), FreshnessPolicy{Roots: oracle}, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+	decoded.Height = 12
+	_, err = VerifyFreshProof(decoded, []byte("foo"), []byte("bar"), FreshnessPolicy{Roots: oracle}, trie.Spec())
+	require.ErrorIs(t, err, ErrRootNotFound)
+	require.NoError(t, oracle.Update(12, []byte("other root")))
+	_, err = VerifyFreshProof(decoded, []byte("foo"), []byte("bar"), FreshnessPolicy{Roots: oracle}, trie.Spec())
+	require.ErrorIs(t, err, ErrRootNotFound)
+
+	_, err = VerifyFreshProof(&FreshProof{}, []byte("foo"), []byte("bar"), fresh, trie.Spec())
+	require.ErrorIs(t, err, ErrBadProof)
+}
+
+func TestFreshProof_Binding(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, trie.Commit())
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	proof, err := ProveFresh(trie, []byte("foo"), 1, time.Unix(1700000000, 0))
+	require.NoError(t, err)
+	proof.Binding = []byte("chain-a")
+	proof.Sign(key, trie.Spec())
+	digest := proof.Digest(trie.Spec())
+
+	policy := FreshnessPolicy{Root: trie.Root(), Binding: []byte("chain-a"), Authenticate: Ed25519FreshProofAuthenticator(pub)}
+	valid, err := VerifyFreshProof(proof, []byte("foo"), []byte("bar"), policy, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// Proofs for another deployment, or without a binding, are rejected
+	other := policy
+	other.Binding = []byte("chain-b")
+	_, err = VerifyFreshProof(proof, []byte("foo"), []byte("bar"), other, trie.Spec())
+	require.ErrorIs(t, err, ErrBindingMismatch)
+	unbound := *proof
+	unbound.Binding = nil
+	_, err = VerifyFreshProof(&unbound, []byte("foo"), []byte("bar"), policy, trie.Spec())
+	require.ErrorIs(t, err, ErrProofNotAuthenticated)
+
+	// Rebinding a proof changes its digest, so its signature no longer holds
+	rebound := *proof
+	rebound.Binding = []byte("chain-b")
+	require.NotEqual(t, digest, rebound.Digest(trie.Spec()))
+	_, err = VerifyFreshProof(&rebound, []byte("foo"), []byte("bar"), other, trie.Spec())
+	require.ErrorIs(t, err, ErrProofNotAuthenticated)
+	bz, err := proof.Marshal()
+	require.NoError(t, err)
+	decoded := new(FreshProof)
+	require.NoError(t, decoded.Unmarshal(bz))
+	require.Equal(t, digest, decoded.Digest(trie.Spec()))
+
+	// Bindings are only checked against authenticated proofs
+	_, err = VerifyFreshProof(proof, []byte("foo"), []byte("bar"), FreshnessPolicy{Root: trie.Root(), Binding: []byte("chain-a")}, trie.Spec())
+	require.ErrorIs(t, err, ErrProofNotAuthenticated)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	proof.Sign(otherKey, trie.Spec())
+	_, err = VerifyFreshProof(proof, []byte("foo"), []byte("bar"), policy, trie.Spec())
+	require.ErrorIs(t, err, ErrProofNotAuthenticated)
+}
@@ -134,8 +134,8 @@ func (th *trieHasher) digestSumLeafNode(path, data []byte) (digest, value []byte
 }
 
 // digestSumInnerNode returns the encoded inner node data as well as its hash (i.e. digest)
-func (th *trieHasher) digestSumInnerNode(leftData, rightData []byte) (digest, value []byte) {
-	value = encodeSumInnerNode(leftData, rightData)
+func (th *trieHasher) digestSumInnerNode(agg AggregateFunc, leftData, rightData []byte) (digest, value []byte) {
+	value = encodeSumInnerNode(agg, leftData, rightData)
 	firstSumByteIdx, firstCountByteIdx := getFirstMetaByteIdx(value)
 
 	digest = th.digestData(value)
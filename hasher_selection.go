@@ -0,0 +1,237 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+	"sync"
+	"time"
+)
+
+const (
+	// hasherBenchmarkInputSize is the size of the inputs hashed when
+	// benchmarking hasher implementations, roughly the size of an encoded
+	// inner node
+	hasherBenchmarkInputSize = 65
+	// hasherBenchmarkRounds is the number of inputs hashed when benchmarking
+	// hasher implementations
+	hasherBenchmarkRounds = 2000
+)
+
+var (
+	// ErrHasherNotFound is returned when selecting a hasher implementation
+	// which is not registered.
+	ErrHasherNotFound = errors.New("hasher implementation not found")
+	// ErrHasherMismatch is returned when registering a hasher implementation
+	// whose digests differ from those of the algorithm's other implementations.
+	ErrHasherMismatch = errors.New("hasher implementation digests mismatch")
+
+	// hasherTestVectors are hashed to check implementations of the same
+	// algorithm agree
+	hasherTestVectors = [][]byte{
+		nil,
+		[]byte("abc"),
+		bytes.Repeat([]byte{0xa5}, hasherBenchmarkInputSize),
+		bytes.Repeat([]byte("smt"), 1000),
+	}
+
+	// defaultHasherRegistry is the registry of hasher implementations used by
+	// the package level functions
+	defaultHasherRegistry = newHasherRegistry()
+)
+
+// HasherImplementation is an implementation of a hash algorithm, such as the
+// standard library's or one using SIMD instructions, which can be selected at
+// runtime. Every implementation of an algorithm must produce the same digests,
+// so tries built with any of them are interchangeable.
+type HasherImplementation struct {
+	// Algorithm is the name of the hash algorithm implemented, e.g. "sha256"
+	Algorithm string
+	// Name is the name of the implementation, e.g. "stdlib"
+	Name string
+	// New returns a new hasher of the implementation
+	New func() hash.Hash
+}
+
+// hasherRegistry holds the registered hasher implementations of every
+// algorithm and the implementation selected by benchmark for each.
+type hasherRegistry struct {
+	mu              sync.Mutex
+	implementations map[string][]HasherImplementation
+	selected        map[string]HasherImplementation
+}
+
+// newHasherRegistry returns a registry holding the standard library's
+// implementations.
+func newHasherRegistry() *hasherRegistry {
+	registry := &hasherRegistry{
+		implementations: make(map[string][]HasherImplementation),
+		selected:        make(map[string]HasherImplementation),
+	}
+	registry.implementations["sha256"] = []HasherImplementation{
+		{Algorithm: "sha256", Name: "stdlib", New: sha256.New},
+	}
+	registry.implementations["sha512_256"] = []HasherImplementation{
+		{Algorithm: "sha512_256", Name: "stdlib", New: sha512.New512_256},
+	}
+	return registry
+}
+
+// RegisterHasherImplementation registers an implementation of a hash
+// algorithm for selection, e.g. from a SIMD or assembly hashing library. An
+// error wrapping ErrHasherMismatch is returned if its digests differ from
+// those of the implementations already registered for the algorithm.
+// The standard library's implementations of "sha256" and "sha512_256" are
+// registered by default, note they already use hardware acceleration (e.g.
+// SHA-NI or the ARMv8 SHA2 instructions) when available.
+func RegisterHasherImplementation(impl HasherImplementation) error {
+	return defaultHasherRegistry.register(impl)
+}
+
+// SelectHasher returns the implementation of the hash algorithm with the
+// given name, or the fastest implementation of the algorithm on this machine
+// if the name is empty. The fastest implementation is chosen by a
+// micro-benchmark run the first time it is requested, so all tries of a
+// process use the same implementation.
+func SelectHasher(algorithm, name string) (HasherImplementation, error) {
+	return defaultHasherRegistry.selectHasher(algorithm, name)
+}
+
+// WithHasherImplementation returns an Option hashing the trie with the
+// implementation provided, replacing the hasher the trie was created with,
+// and recording the implementation in the trie's spec. Custom path and value
+// hashers are left unchanged.
+func WithHasherImplementation(impl HasherImplementation) TrieSpecOption {
+	return func(ts *TrieSpec) {
+		ts.th = *NewTrieHasher(impl.New())
+		if _, ok := ts.ph.(*pathHasher); ok {
+			ts.ph = &pathHasher{ts.th}
+		}
+		if _, ok := ts.vh.(*valueHasher); ok {
+			ts.vh = &valueHasher{ts.th}
+		}
+		ts.hasherImpl = impl.Algorithm + "/" + impl.Name
+	}
+}
+
+// HasherImplementation returns the algorithm and name of the hasher
+// implementation the trie was configured with, separated by a slash (e.g.
+// "sha256/stdlib"), or an empty string if it was created with a hasher
+// directly.
+func (spec *TrieSpec) HasherImplementation() string {
+	return spec.hasherImpl
+}
+
+// register registers the implementation in the registry
+func (registry *hasherRegistry) register(impl HasherImplementation) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	existing := registry.implementations[impl.Algorithm]
+	for _, other := range existing {
+		if other.Name == impl.Name {
+			return fmt.Errorf("hasher implementation %s/%s already registered", impl.Algorithm, impl.Name)
+		}
+	}
+	if len(existing) > 0 {
+		reference, candidate := existing[0].New(), impl.New()
+		for _, vector := range hasherTestVectors {
+			if !bytes.Equal(hashWith(reference, vector), hashWith(candidate, vector)) {
+				return errors.Join(ErrHasherMismatch,
+					fmt.Errorf("%s/%s differs from %s/%s", impl.Algorithm, impl.Name, impl.Algorithm, existing[0].Name))
+			}
+		}
+	}
+	registry.implementations[impl.Algorithm] = append(existing, impl)
+	// Benchmark the algorithm again with the new implementation next time
+	delete(registry.selected, impl.Algorithm)
+	return nil
+}
+
+// selectHasher returns the named or fastest implementation of the algorithm
+func (registry *hasherRegistry) selectHasher(algorithm, name string) (HasherImplementation, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	implementations := registry.implementations[algorithm]
+	if name != "" {
+		for _, impl := range implementations {
+			if impl.Name == name {
+				return impl, nil
+			}
+		}
+		return HasherImplementation{}, errors.Join(ErrHasherNotFound, fmt.Errorf("%s/%s", algorithm, name))
+	}
+	if len(implementations) == 0 {
+		return HasherImplementation{}, errors.Join(ErrHasherNotFound, fmt.Errorf("no implementation of %s", algorithm))
+	}
+	if impl, ok := registry.selected[algorithm]; ok {
+		return impl, nil
+	}
+	fastest, fastestTime := implementations[0], time.Duration(-1)
+	for _, impl := range implementations {
+		if elapsed := benchmarkHasher(impl.New()); fastestTime < 0 || elapsed < fastestTime {
+			fastest, fastestTime = impl, elapsed
+		}
+	}
+	registry.selected[algorithm] = fastest
+	return fastest, nil
+}
+
+// benchmarkHasher returns the time taken by the hasher to hash a fixed number
+// of node sized inputs
+func benchmarkHasher(hasher hash.Hash) time.Duration {
+	input := bytes.Repeat([]byte{0xa5}, hasherBenchmarkInputSize)
+	digest := make([]byte, 0, hasher.Size())
+	start := time.Now()
+	for i := 0; i < hasherBenchmarkRounds; i++ {
+		hasher.Write(input)
+		digest = hasher.Sum(digest[:0])
+		hasher.Reset()
+	}
+	return time.Since(start)
+}
+
+// hashWith returns the digest of the data with the hasher provided
+func hashWith(hasher hash.Hash, data []byte) []byte {
+	hasher.Reset()
+	hasher.Write(data)
+	digest := hasher.Sum(nil)
+	hasher.Reset()
+	return digest
+}
+
+// list returns every registered implementation of every algorithm
+func (registry *hasherRegistry) list() []HasherImplementation {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	var implementations []HasherImplementation
+	for _, impls := range registry.implementations {
+		implementations = append(implementations, impls...)
+	}
+	return implementations
+}
+
+// hasherConstructor returns the constructor of a registered implementation
+// producing the same digests as the trie's hasher, so that the trie can be
+// hashed concurrently, or nil if none is registered.
+func (spec *TrieSpec) hasherConstructor() func() hash.Hash {
+	for _, impl := range defaultHasherRegistry.list() {
+		candidate := impl.New()
+		if candidate.Size() != spec.th.hasher.Size() {
+			continue
+		}
+		matches := true
+		for _, vector := range hasherTestVectors {
+			if !bytes.Equal(hashWith(spec.th.hasher, vector), hashWith(candidate, vector)) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return impl.New
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"hash"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// slowHasher is a sha256 implementation doing redundant work on every digest
+type slowHasher struct {
+	hash.Hash
+}
+
+func (h slowHasher) Sum(b []byte) []byte {
+	for i := 0; i < 20; i++ {
+		h.Hash.Sum(nil)
+	}
+	return h.Hash.Sum(b)
+}
+
+// wrongHasher is a broken sha256 implementation
+type wrongHasher struct {
+	hash.Hash
+}
+
+func (h wrongHasher) Sum(b []byte) []byte {
+	return append(h.Hash.Sum(b), 0)[1:]
+}
+
+func TestSelectHasher(t *testing.T) {
+	registry := newHasherRegistry()
+	require.NoError(t, registry.register(HasherImplementation{
+		Algorithm: "sha256",
+		Name:      "slow",
+		New:       func() hash.Hash { return slowHasher{sha256.New()} },
+	}))
+	err := registry.register(HasherImplementation{
+		Algorithm: "sha256",
+		Name:      "wrong",
+		New:       func() hash.Hash { return wrongHasher{sha256.New()} },
+	})
+	require.ErrorIs(t, err, ErrHasherMismatch)
+	require.Error(t, registry.register(HasherImplementation{Algorithm: "sha256", Name: "slow", New: sha256.New}))
+
+	// The fastest implementation is selected by default
+	impl, err := registry.selectHasher("sha256", "")
+	require.NoError(t, err)
+	require.Equal(t, "stdlib", impl.Name)
+
+	// Implementations can be selected explicitly
+	impl, err = registry.selectHasher("sha256", "slow")
+	require.NoError(t, err)
+	require.Equal(t, "slow", impl.Name)
+
+	_, err = registry.selectHasher("sha256", "missing")
+	require.ErrorIs(t, err, ErrHasherNotFound)
+	_, err = registry.selectHasher("md5", "")
+	require.ErrorIs(t, err, ErrHasherNotFound)
+}
+
+func TestWithHasherImplementation(t *testing.T) {
+	impl, err := SelectHasher("sha256", "")
+	require.NoError(t, err)
+
+	// The trie is hashed with the implementation rather than its hasher
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), wrongHasher{sha256.New()}, WithHasherImplementation(impl))
+	require.Equal(t, "sha256/stdlib", trie.Spec().HasherImplementation())
+	reference := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.Empty(t, reference.Spec().HasherImplementation())
+
+	for _, trie := range []*SMT{trie, reference} {
+		require.NoError(t, trie.Update([]byte("key"), []byte("value")))
+		require.NoError(t, trie.Update([]byte("other"), []byte("value")))
+	}
+	require.Equal(t, reference.Root(), trie.Root())
+}
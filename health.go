@@ -0,0 +1,133 @@
+package smt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// healthCheckKey is the key written and deleted by health checks, it cannot
+// collide with the digests nodes (or the value hashes preimages) are stored
+// under as long as the hasher's size differs from its length.
+var healthCheckKey = reserveKey("health checks", []byte("smt/health-check"))
+
+// ErrUnhealthy is returned by health checks failing to round-trip a value
+// through a store or to reach the trie's root.
+var ErrUnhealthy = errors.New("unhealthy")
+
+// CheckResult is the result of a single check of a health check
+type CheckResult struct {
+	// Name identifies the check, e.g. "nodes" for the node store round-trip
+	Name string
+	// Latency is the time the check took
+	Latency time.Duration
+	// Err is the reason the check failed, nil if it passed
+	Err error
+}
+
+// HealthStatus is the structured result of a health check, suitable for
+// readiness probes.
+type HealthStatus struct {
+	// Healthy is true if every check passed
+	Healthy bool
+	// Checks are the results of every check performed, in order
+	Checks []CheckResult
+}
+
+// Err returns the errors of the failed checks joined, or nil if healthy
+func (status HealthStatus) Err() error {
+	var errs []error
+	for _, check := range status.Checks {
+		errs = append(errs, check.Err)
+	}
+	return errors.Join(errs...)
+}
+
+// add runs the check provided, recording its result and latency as measured
+// by the clock unless the context is done, in which case the context's error
+// is recorded.
+func (status *HealthStatus) add(ctx context.Context, clock Clock, name string, check func() error) {
+	start := clock.Now()
+	err := ctx.Err()
+	if err == nil {
+		err = check()
+	}
+	status.Checks = append(status.Checks, CheckResult{Name: name, Latency: clock.Now().Sub(start), Err: err})
+	if err != nil {
+		status.Healthy = false
+	}
+}
+
+// HealthCheck performs a quick write/read/delete round-trip on the trie's
+// node store, and checks the root of the last commit can be read from it.
+// Checks not performed before the context is done fail with its error.
+func (smt *SMT) HealthCheck(ctx context.Context) HealthStatus {
+	status := HealthStatus{Healthy: true}
+	status.add(ctx, smt.Clock(), "nodes", func() error { return storeRoundTrip(smt.nodes, smt.Clock()) })
+	status.add(ctx, smt.Clock(), "root", smt.checkRootReachable)
+	return status
+}
+
+// WithNodeVerification returns an Option checking every node read from the
+// node store hashes to the digest it was read under, failing the read with
+// ErrCorruptNode (and counting it in the trie's Metrics) otherwise. This costs
+// one hash per node read, so without it only nodes which cannot be decoded
+// are detected, while health checks always verify the root.
+func WithNodeVerification() TrieSpecOption {
+	return func(ts *TrieSpec) { ts.verifyNodes = true }
+}
+
+// checkRootReachable checks the root of the last commit, if any, is present
+// and intact in the node store
+func (smt *SMT) checkRootReachable() error {
+	if smt.rootHash == nil || bytes.Equal(smt.rootHash, smt.placeholder()) {
+		return nil
+	}
+	data, err := smt.nodes.Get(smt.rootHash)
+	if err != nil {
+		return errors.Join(ErrUnhealthy, err)
+	}
+	if err := smt.checkNode(data, smt.rootHash, true); err != nil {
+		smt.metrics.Corruptions++
+		return errors.Join(ErrUnhealthy, err)
+	}
+	return nil
+}
+
+// storeRoundTrip writes, reads back and deletes a value from the store, the
+// current time of the clock provided
+func storeRoundTrip(store kvstore.MapStore, clock Clock) error {
+	value := []byte(clock.Now().UTC().Format(time.RFC3339Nano))
+	if err := store.Set(healthCheckKey, value); err != nil {
+		return errors.Join(ErrUnhealthy, err)
+	}
+	got, err := store.Get(healthCheckKey)
+	if err != nil {
+		return errors.Join(ErrUnhealthy, err)
+	}
+	if !bytes.Equal(got, value) {
+		return errors.Join(ErrUnhealthy, errors.New("read value does not match written value"))
+	}
+	if err := store.Delete(healthCheckKey); err != nil {
+		return errors.Join(ErrUnhealthy, err)
+	}
+	if _, err := store.Get(healthCheckKey); err == nil {
+		return errors.Join(ErrUnhealthy, errors.New("deleted value still present"))
+	}
+	return nil
+}
+
+// HealthCheck performs the health check of the trie, see SMT.HealthCheck,
+// along with a round-trip on the preimages store.
+func (smt *SMTWithStorage) HealthCheck(ctx context.Context) HealthStatus {
+	smt.commitMu.RLock()
+	defer smt.commitMu.RUnlock()
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	status := smt.trie.HealthCheck(ctx)
+	status.add(ctx, smt.trie.Clock(), "preimages", func() error { return storeRoundTrip(smt.preimages, smt.trie.Clock()) })
+	return status
+}
@@ -0,0 +1,51 @@
+package smt
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_HealthCheck(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleTrie(nodes, sha256.New())
+
+	// An empty trie is healthy
+	status := trie.HealthCheck(context.Background())
+	require.True(t, status.Healthy)
+	require.NoError(t, status.Err())
+	require.Len(t, status.Checks, 2)
+
+	require.NoError(t, trie.Update([]byte("key"), []byte("value")))
+	require.NoError(t, trie.Commit())
+	status = trie.HealthCheck(context.Background())
+	require.True(t, status.Healthy)
+	require.Equal(t, 1, nodes.Len(), "health check key left in store")
+
+	// A missing root is reported
+	require.NoError(t, nodes.ClearAll())
+	status = trie.HealthCheck(context.Background())
+	require.False(t, status.Healthy)
+	require.ErrorIs(t, status.Err(), ErrUnhealthy)
+	require.Equal(t, "root", status.Checks[1].Name)
+	require.NoError(t, status.Checks[0].Err)
+
+	// A failing store is reported
+	trie = NewSparseMerkleTrie(&crashingStore{MapStore: simplemap.NewSimpleMap()}, sha256.New())
+	status = trie.HealthCheck(context.Background())
+	require.False(t, status.Healthy)
+	require.ErrorIs(t, status.Checks[0].Err, errCrash)
+
+	// Checks fail once the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	smt := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	status = smt.HealthCheck(ctx)
+	require.False(t, status.Healthy)
+	require.Len(t, status.Checks, 3)
+	require.ErrorIs(t, status.Err(), context.Canceled)
+}
@@ -0,0 +1,201 @@
+package smt
+
+import (
+	"container/heap"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// PathCount is the approximate number of times a path was accessed
+type PathCount struct {
+	Path  []byte
+	Count uint64
+}
+
+// AccessTracker tracks the approximate access frequencies of the paths of a
+// trie in constant memory, using a count-min sketch for the frequencies and a
+// bounded set of candidates for the most frequently accessed (hot) paths,
+// kept in a min-heap so that the coldest candidate is found in constant time.
+// Counts are never underestimated, but may be overestimated by colliding
+// paths, with the error shrinking as the width and depth grow.
+// AccessTracker is safe for concurrent use.
+type AccessTracker struct {
+	mu       sync.Mutex
+	width    int
+	counts   [][]uint64
+	hot      map[string]*hotPath
+	coldest  hotPaths
+	capacity int
+}
+
+// NewAccessTracker returns a new AccessTracker whose sketch has depth rows of
+// width counters, tracking up to capacity candidate hot paths, or an error if
+// any of the sizes is not positive.
+func NewAccessTracker(width, depth, capacity int) (*AccessTracker, error) {
+	if width <= 0 || depth <= 0 || capacity <= 0 {
+		return nil, fmt.Errorf("invalid access tracker sizes width %d, depth %d and capacity %d: must be positive",
+			width, depth, capacity)
+	}
+	counts := make([][]uint64, depth)
+	for i := range counts {
+		counts[i] = make([]uint64, width)
+	}
+	return &AccessTracker{
+		width:    width,
+		counts:   counts,
+		hot:      make(map[string]*hotPath, capacity),
+		coldest:  make(hotPaths, 0, capacity),
+		capacity: capacity,
+	}, nil
+}
+
+// WithAccessTracker returns an Option that records every Get, Update, Delete
+// and Prove of the trie's paths in the AccessTracker provided.
+func WithAccessTracker(tracker *AccessTracker) TrieSpecOption {
+	return func(ts *TrieSpec) { ts.tracker = tracker }
+}
+
+// Record records an access to the path provided
+func (tracker *AccessTracker) Record(path []byte) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	estimate := ^uint64(0)
+	for row := range tracker.counts {
+		col := tracker.index(row, path)
+		tracker.counts[row][col]++
+		if tracker.counts[row][col] < estimate {
+			estimate = tracker.counts[row][col]
+		}
+	}
+
+	if candidate, ok := tracker.hot[string(path)]; ok {
+		candidate.count = estimate
+		heap.Fix(&tracker.coldest, candidate.index)
+		return
+	}
+	if len(tracker.hot) < tracker.capacity {
+		candidate := &hotPath{path: string(path), count: estimate}
+		tracker.hot[candidate.path] = candidate
+		heap.Push(&tracker.coldest, candidate)
+		return
+	}
+	// Replace the coldest candidate if the path is now hotter
+	coldest := tracker.coldest[0]
+	if estimate > coldest.count {
+		delete(tracker.hot, coldest.path)
+		coldest.path, coldest.count = string(path), estimate
+		tracker.hot[coldest.path] = coldest
+		heap.Fix(&tracker.coldest, 0)
+	}
+}
+
+// Estimate returns the approximate number of accesses to the path provided
+func (tracker *AccessTracker) Estimate(path []byte) uint64 {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	return tracker.estimate(path)
+}
+
+// HotPaths returns up to n of the most frequently accessed paths, in
+// descending order of their approximate access counts, or none if n is not
+// positive.
+func (tracker *AccessTracker) HotPaths(n int) []PathCount {
+	if n <= 0 {
+		return nil
+	}
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	hot := make([]PathCount, 0, len(tracker.hot))
+	for path := range tracker.hot {
+		hot = append(hot, PathCount{Path: []byte(path), Count: tracker.estimate([]byte(path))})
+	}
+	sort.Slice(hot, func(i, j int) bool {
+		if hot[i].Count != hot[j].Count {
+			return hot[i].Count > hot[j].Count
+		}
+		return string(hot[i].Path) < string(hot[j].Path)
+	})
+	if len(hot) > n {
+		hot = hot[:n]
+	}
+	return hot
+}
+
+// HotPaths returns up to n of the most frequently accessed paths of the trie
+// if it has an AccessTracker configured, see AccessTracker.HotPaths.
+func (spec *TrieSpec) HotPaths(n int) []PathCount {
+	if spec.tracker == nil {
+		return nil
+	}
+	return spec.tracker.HotPaths(n)
+}
+
+// recordAccess records an access to the path if the trie has an
+// AccessTracker configured
+func (spec *TrieSpec) recordAccess(path []byte) {
+	if spec.tracker != nil {
+		spec.tracker.Record(path)
+	}
+}
+
+// estimate returns the minimum count of the path across the sketch's rows,
+// the caller must hold the tracker's lock.
+func (tracker *AccessTracker) estimate(path []byte) uint64 {
+	estimate := ^uint64(0)
+	for row := range tracker.counts {
+		if count := tracker.counts[row][tracker.index(row, path)]; count < estimate {
+			estimate = count
+		}
+	}
+	return estimate
+}
+
+// index returns the column of the path in the given row of the sketch, each
+// row hashing the path with a different seed.
+func (tracker *AccessTracker) index(row int, path []byte) int {
+	h := fnv.New64a()
+	h.Write([]byte{byte(row), byte(row >> 8)})
+	h.Write(path)
+	return int(h.Sum64() % uint64(tracker.width))
+}
+
+// hotPath is a candidate hot path of an AccessTracker, along with its count
+// when it was last recorded and its index in the tracker's heap.
+type hotPath struct {
+	path  string
+	count uint64
+	index int
+}
+
+// hotPaths is a min-heap of candidate hot paths ordered by count, breaking
+// ties by path so eviction does not depend on the order of accesses.
+type hotPaths []*hotPath
+
+func (h hotPaths) Len() int { return len(h) }
+
+func (h hotPaths) Less(i, j int) bool {
+	if h[i].count != h[j].count {
+		return h[i].count < h[j].count
+	}
+	return h[i].path < h[j].path
+}
+
+func (h hotPaths) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+
+func (h *hotPaths) Push(x any) {
+	candidate := x.(*hotPath)
+	candidate.index = len(*h)
+	*h = append(*h, candidate)
+}
+
+func (h *hotPaths) Pop() any {
+	old := *h
+	candidate := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return candidate
+}
@@ -0,0 +1,81 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestAccessTracker(t *testing.T) {
+	tracker, err := NewAccessTracker(64, 4, 8)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		tracker.Record([]byte(fmt.Sprintf("cold%d", i)))
+	}
+	for i := 0; i < 50; i++ {
+		tracker.Record([]byte("hot"))
+		if i%2 == 0 {
+			tracker.Record([]byte("warm"))
+		}
+	}
+	require.GreaterOrEqual(t, tracker.Estimate([]byte("hot")), uint64(50))
+	require.GreaterOrEqual(t, tracker.Estimate([]byte("warm")), uint64(25))
+
+	hot := tracker.HotPaths(2)
+	require.Len(t, hot, 2)
+	require.Equal(t, []byte("hot"), hot[0].Path)
+	require.Equal(t, []byte("warm"), hot[1].Path)
+	require.GreaterOrEqual(t, hot[0].Count, uint64(50))
+	require.Len(t, tracker.HotPaths(100), 8)
+	require.Empty(t, tracker.HotPaths(0))
+	require.Empty(t, tracker.HotPaths(-1))
+
+	// Paths hotter than the coldest candidate replace it
+	for i := 0; i < 10; i++ {
+		tracker.Record([]byte("rising"))
+	}
+	hot = tracker.HotPaths(8)
+	require.Equal(t, []byte("rising"), hot[2].Path)
+
+	// The sizes of the tracker must be positive
+	for _, sizes := range [][3]int{{0, 4, 8}, {64, -1, 8}, {64, 4, 0}} {
+		_, err := NewAccessTracker(sizes[0], sizes[1], sizes[2])
+		require.Error(t, err)
+	}
+}
+
+func TestSMT_HotPaths(t *testing.T) {
+	tracker, err := NewAccessTracker(1024, 4, 16)
+	require.NoError(t, err)
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithAccessTracker(tracker))
+	require.Nil(t, NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New()).HotPaths(1))
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key%d", i)), []byte("value")))
+	}
+	for i := 0; i < 5; i++ {
+		_, err = trie.Get([]byte("key3"))
+		require.NoError(t, err)
+		_, err = trie.Prove([]byte("key3"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, trie.Delete([]byte("key3")))
+
+	hot := trie.HotPaths(1)
+	require.Len(t, hot, 1)
+	require.Equal(t, trie.ph.Path([]byte("key3")), hot[0].Path)
+	require.Equal(t, uint64(12), hot[0].Count)
+
+	// Sum tries record each access once
+	sumTracker, err := NewAccessTracker(1024, 4, 16)
+	require.NoError(t, err)
+	smst := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New(), WithAccessTracker(sumTracker))
+	require.NoError(t, smst.Update([]byte("key"), []byte("value"), 1))
+	_, _, err = smst.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), sumTracker.Estimate(smst.ph.Path([]byte("key"))))
+}
@@ -0,0 +1,93 @@
+package smt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Ensure the indexPathHasher is a PathHasher validating its keys
+var (
+	_ PathHasher   = (*indexPathHasher)(nil)
+	_ KeyValidator = (*indexPathHasher)(nil)
+)
+
+// ErrInvalidKey is returned when a key is rejected by the trie's PathHasher
+var ErrInvalidKey = errors.New("invalid key")
+
+// KeyValidator is an optional interface of PathHashers which only map some
+// keys to paths, the tries reject any key it returns an error for.
+type KeyValidator interface {
+	// ValidateKey returns an error if the key cannot be mapped to a path
+	ValidateKey(key []byte) error
+}
+
+// indexPathHasher maps fixed size keys directly to paths, without hashing
+type indexPathHasher struct {
+	size int
+}
+
+// NewIndexPathHasher returns a PathHasher using keys of exactly size bytes as
+// their paths, without hashing them, producing a trie of depth 8*size. This
+// allows compact tries with small proofs over small keyspaces, such as a trie
+// of validators indexed by a 32-bit integer (size 4) with at most 32 side
+// nodes per proof. Keys of any other size are rejected with ErrInvalidKey,
+// IndexKey can be used to encode integer indexes as keys.
+//
+// As keys are not hashed the trie is only balanced if the keys are evenly
+// distributed, e.g. sequential indexes, which it is the caller's
+// responsibility to ensure.
+func NewIndexPathHasher(size int) PathHasher {
+	return &indexPathHasher{size: size}
+}
+
+// IndexKey encodes the index as a big-endian key of size bytes, for use with
+// an index PathHasher, truncating the index to its low-order bytes if it does
+// not fit.
+func IndexKey(index uint64, size int) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], index)
+	key := make([]byte, size)
+	if size >= len(buf) {
+		copy(key[size-len(buf):], buf[:])
+	} else {
+		copy(key, buf[len(buf)-size:])
+	}
+	return key
+}
+
+// Path satisfies the PathHasher#Path interface, keys of the wrong size, which
+// are rejected by ValidateKey, are padded or truncated to the path size.
+func (ph *indexPathHasher) Path(key []byte) []byte {
+	path := make([]byte, ph.size)
+	if len(key) >= ph.size {
+		copy(path, key[:ph.size])
+	} else {
+		copy(path, key)
+	}
+	return path
+}
+
+// PathSize satisfies the PathHasher#PathSize interface
+func (ph *indexPathHasher) PathSize() int {
+	return ph.size
+}
+
+// ValidateKey satisfies the KeyValidator#ValidateKey interface
+func (ph *indexPathHasher) ValidateKey(key []byte) error {
+	if len(key) != ph.size {
+		return errors.Join(ErrInvalidKey, fmt.Errorf("got %d bytes but want %d", len(key), ph.size))
+	}
+	return nil
+}
+
+// path returns the path of the key provided, or an error if the PathHasher
+// rejects the key.
+func (spec *TrieSpec) path(key []byte) ([]byte, error) {
+	if validator, ok := spec.ph.(KeyValidator); ok {
+		if err := validator.ValidateKey(key); err != nil {
+			return nil, err
+		}
+	}
+	return spec.ph.Path(key), nil
+}
@@ -0,0 +1,111 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestIndexKey(t *testing.T) {
+	require.Equal(t, []byte{0, 0, 1, 2}, IndexKey(0x0102, 4))
+	require.Equal(t, []byte{0xff, 0xff}, IndexKey(0x1ffff, 2))
+	require.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 1}, IndexKey(1, 10))
+}
+
+func TestSMT_IndexPathHasher(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleTrie(nodes, sha256.New(), WithPathHasher(NewIndexPathHasher(4)))
+	const n = 1000
+	for i := uint64(0); i < n; i++ {
+		require.NoError(t, trie.Update(IndexKey(i, 4), IndexKey(i, 8)))
+	}
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+
+	trie = ImportSparseMerkleTrie(nodes, sha256.New(), root, WithPathHasher(NewIndexPathHasher(4)))
+	for _, i := range []uint64{0, 1, 500, n - 1, n, 1 << 31} {
+		key := IndexKey(i, 4)
+		value := IndexKey(i, 8)
+		if i >= n {
+			value = defaultEmptyValue
+		}
+		proof, err := trie.Prove(key)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(proof.SideNodes), 32)
+		valid, err := VerifyProof(proof, root, key, value, trie.Spec())
+		require.NoError(t, err)
+		require.True(t, valid, "index %d", i)
+	}
+
+	// Keys of the wrong size are rejected
+	for _, key := range [][]byte{{1}, IndexKey(1, 5)} {
+		_, err := trie.Get(key)
+		require.ErrorIs(t, err, ErrInvalidKey)
+		require.ErrorIs(t, trie.Update(key, []byte("value")), ErrInvalidKey)
+		require.ErrorIs(t, trie.Delete(key), ErrInvalidKey)
+		_, err = trie.Prove(key)
+		require.ErrorIs(t, err, ErrInvalidKey)
+	}
+	proof, err := trie.Prove(IndexKey(1, 4))
+	require.NoError(t, err)
+	_, err = VerifyProof(proof, root, append(IndexKey(1, 4), 0), IndexKey(1, 8), trie.Spec())
+	require.ErrorIs(t, err, ErrBadProof)
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestSMST_IndexPathHasher(t *testing.T) {
+	// Closest proofs can only be verified without a value hasher
+	trie := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New(),
+		WithPathHasher(NewIndexPathHasher(2)), WithValueHasher(nil))
+	for i := uint64(0); i < 100; i++ {
+		require.NoError(t, trie.Update(IndexKey(i, 2), []byte("validator"), i))
+	}
+	require.Equal(t, uint64(4950), trie.Sum())
+	proof, err := trie.Prove(IndexKey(42, 2))
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(proof.SideNodes), 16)
+	valid, err := VerifySumProof(proof, trie.Root(), IndexKey(42, 2), []byte("validator"), 42, 1, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	closest, err := trie.ProveClosest(IndexKey(200, 2))
+	require.NoError(t, err)
+	valid, err = VerifyClosestProof(closest, trie.Root(), trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+}
+
+func TestSMT_IndexPathHasherFullDepth(t *testing.T) {
+	// Every leaf of a dense trie is at its maximum depth
+	options := []TrieSpecOption{WithPathHasher(NewIndexPathHasher(1)), WithValueHasher(nil)}
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), options...)
+	for i := uint64(0); i < 256; i += 2 {
+		require.NoError(t, trie.Update(IndexKey(i, 1), []byte("value")))
+	}
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+	trie = ImportSparseMerkleTrie(trie.nodes, sha256.New(), root, options...)
+
+	for i := uint64(0); i < 256; i++ {
+		key := IndexKey(i, 1)
+		value := []byte("value")
+		if i%2 == 1 {
+			value = defaultEmptyValue
+		}
+		proof, err := trie.Prove(key)
+		require.NoError(t, err)
+		valid, err := VerifyProof(proof, root, key, value, trie.Spec())
+		require.NoError(t, err)
+		require.True(t, valid, "index %d", i)
+
+		closest, err := trie.ProveClosest(key)
+		require.NoError(t, err)
+		require.Equal(t, IndexKey(i&^1, 1), closest.ClosestPath, "index %d", i)
+		valid, err = VerifyClosestProof(closest, root, trie.Spec())
+		require.NoError(t, err)
+		require.True(t, valid, "index %d", i)
+	}
+}
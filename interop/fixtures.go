@@ -0,0 +1,179 @@
+package interop
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pokt-network/smt"
+)
+
+// HexBytes is a byte slice encoded as a hex string in JSON
+type HexBytes []byte
+
+// MarshalJSON encodes the bytes as a hex string
+func (bz HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(bz))
+}
+
+// UnmarshalJSON decodes the bytes from a hex string
+func (bz *HexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	if len(decoded) == 0 {
+		decoded = nil
+	}
+	*bz = decoded
+	return nil
+}
+
+// Proof is the language neutral encoding of a smt.SparseMerkleProof
+type Proof struct {
+	SideNodes             []HexBytes `json:"side_nodes"`
+	NonMembershipLeafData HexBytes   `json:"non_membership_leaf_data"`
+	SiblingData           HexBytes   `json:"sibling_data"`
+}
+
+// Fixture is a proof along with the inputs it is verified against and the
+// expected verification result. An empty value denotes a non-membership
+// proof.
+type Fixture struct {
+	Name    string   `json:"name"`
+	SumTrie bool     `json:"sum_trie"`
+	Root    HexBytes `json:"root"`
+	Key     HexBytes `json:"key"`
+	Value   HexBytes `json:"value"`
+	// Sum and Count are only used for sum trie fixtures
+	Sum   uint64 `json:"sum"`
+	Count uint64 `json:"count"`
+	Proof Proof  `json:"proof"`
+	Valid bool   `json:"valid"`
+}
+
+// Spec returns the trie spec the fixture's proof is verified with
+func (fixture *Fixture) Spec() *smt.TrieSpec {
+	spec := smt.NewTrieSpec(sha256.New(), fixture.SumTrie)
+	return &spec
+}
+
+// Verify verifies the fixture's proof with this package's verifier,
+// returning the verification result, which is expected to match Valid.
+func (fixture *Fixture) Verify() (bool, error) {
+	proof := &smt.SparseMerkleProof{
+		NonMembershipLeafData: fixture.Proof.NonMembershipLeafData,
+		SiblingData:           fixture.Proof.SiblingData,
+	}
+	for _, sideNode := range fixture.Proof.SideNodes {
+		proof.SideNodes = append(proof.SideNodes, sideNode)
+	}
+	if fixture.SumTrie {
+		return smt.VerifySumProof(proof, fixture.Root, fixture.Key, fixture.Value,
+			fixture.Sum, fixture.Count, fixture.Spec())
+	}
+	return smt.VerifyProof(proof, fixture.Root, fixture.Key, fixture.Value, fixture.Spec())
+}
+
+// encodeProof converts a proof to its language neutral encoding
+func encodeProof(proof *smt.SparseMerkleProof) Proof {
+	encoded := Proof{
+		NonMembershipLeafData: proof.NonMembershipLeafData,
+		SiblingData:           proof.SiblingData,
+	}
+	for _, sideNode := range proof.SideNodes {
+		encoded.SideNodes = append(encoded.SideNodes, sideNode)
+	}
+	return encoded
+}
+
+// GenerateFixtures builds a trie (or sum trie) of n random leaves from the
+// seed provided and returns fixtures for each leaf: a valid membership proof,
+// the same proof for a tampered value, and a valid non-membership proof of a
+// key derived from the leaf's key.
+func GenerateFixtures(seed int64, n int, sumTrie bool) ([]Fixture, error) {
+	spec := smt.NewTrieSpec(sha256.New(), sumTrie)
+	var prove func(key []byte) (*smt.SparseMerkleProof, error)
+	var root smt.MerkleRoot
+	var leaves []smt.KeyValueWeight
+	if sumTrie {
+		trie, kvs, err := smt.BuildRandomSumTree(seed, n, 1<<32, &spec)
+		if err != nil {
+			return nil, err
+		}
+		prove, root, leaves = trie.Prove, trie.Root(), kvs
+	} else {
+		trie, kvs, err := smt.BuildRandomTree(seed, n, &spec)
+		if err != nil {
+			return nil, err
+		}
+		prove, root = trie.Prove, trie.Root()
+		for _, kv := range kvs {
+			leaves = append(leaves, smt.KeyValueWeight{KeyValue: kv})
+		}
+	}
+
+	fixtures := make([]Fixture, 0, 3*len(leaves))
+	for i, leaf := range leaves {
+		proof, err := prove(leaf.Key)
+		if err != nil {
+			return nil, err
+		}
+		member := Fixture{
+			Name:    fmt.Sprintf("membership-%d", i),
+			SumTrie: sumTrie,
+			Root:    HexBytes(root),
+			Key:     leaf.Key,
+			Value:   leaf.Value,
+			Proof:   encodeProof(proof),
+			Valid:   true,
+		}
+		if sumTrie {
+			member.Sum, member.Count = leaf.Weight, 1
+		}
+		tampered := member
+		tampered.Name = fmt.Sprintf("tampered-value-%d", i)
+		tampered.Value = append(bytes.Clone(leaf.Value), 0)
+		tampered.Valid = false
+
+		absentKey := sha256.Sum256(append(bytes.Clone(leaf.Key), "absent"...))
+		proof, err = prove(absentKey[:])
+		if err != nil {
+			return nil, err
+		}
+		absent := Fixture{
+			Name:    fmt.Sprintf("non-membership-%d", i),
+			SumTrie: sumTrie,
+			Root:    HexBytes(root),
+			Key:     absentKey[:],
+			Proof:   encodeProof(proof),
+			Valid:   true,
+		}
+		fixtures = append(fixtures, member, tampered, absent)
+	}
+	return fixtures, nil
+}
+
+// ReadFixtures decodes a JSON array of fixtures from the reader provided
+func ReadFixtures(r io.Reader) ([]Fixture, error) {
+	var fixtures []Fixture
+	if err := json.NewDecoder(r).Decode(&fixtures); err != nil {
+		return nil, errors.Join(errors.New("invalid fixtures"), err)
+	}
+	return fixtures, nil
+}
+
+// WriteFixtures encodes the fixtures as a JSON array to the writer provided
+func WriteFixtures(w io.Writer, fixtures []Fixture) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(fixtures)
+}
@@ -0,0 +1,14 @@
+// Package interop provides a harness checking the compatibility of proofs
+// between this implementation and verifiers written in other languages.
+//
+// Proofs are exchanged as language neutral JSON fixtures, each holding a proof
+// along with the root, key, value (and sum) it is verified against and the
+// expected verification result. Compatibility is checked in both directions:
+// fixtures produced by external provers are checked against this package's
+// verifier with CheckFixtures, and fixtures generated from this package's
+// tries are checked against external verifiers with CheckVerifier, allowing
+// protocol teams to run the harness in their own CI.
+//
+// All fixtures use the default trie spec with SHA-256, as it is the spec
+// reference implementations are expected to support.
+package interop
@@ -0,0 +1,106 @@
+package interop
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// Mismatch is a fixture whose verification result differs from the expected
+// result.
+type Mismatch struct {
+	Name     string
+	Expected bool
+	Got      bool
+	// Err is the error returned by the verifier, if any
+	Err error
+}
+
+// Error returns a human readable description of the mismatch
+func (mismatch Mismatch) Error() string {
+	if mismatch.Err != nil {
+		return fmt.Sprintf("fixture %s: expected %t, got error: %v", mismatch.Name, mismatch.Expected, mismatch.Err)
+	}
+	return fmt.Sprintf("fixture %s: expected %t, got %t", mismatch.Name, mismatch.Expected, mismatch.Got)
+}
+
+// CheckFixtures verifies every fixture provided (e.g. produced by an external
+// prover) with this package's verifier, returning the fixtures whose result
+// does not match the expected one. Errors are only expected from invalid
+// proofs, they are reported as mismatches for valid ones.
+func CheckFixtures(fixtures []Fixture) []Mismatch {
+	var mismatches []Mismatch
+	for i := range fixtures {
+		valid, err := fixtures[i].Verify()
+		if valid != fixtures[i].Valid || (err != nil && fixtures[i].Valid) {
+			mismatches = append(mismatches, Mismatch{
+				Name:     fixtures[i].Name,
+				Expected: fixtures[i].Valid,
+				Got:      valid,
+				Err:      err,
+			})
+		}
+	}
+	return mismatches
+}
+
+// Verifier is an external verifier, returning the verification result of
+// every fixture provided in order.
+type Verifier interface {
+	Verify(ctx context.Context, fixtures []Fixture) ([]bool, error)
+}
+
+// CommandVerifier is a Verifier shelling out to a reference verifier. The
+// command is given the fixtures as a JSON array on its standard input and
+// must write a JSON array of the verification results (booleans) to its
+// standard output, ignoring the fixtures' expected results.
+type CommandVerifier struct {
+	Path string
+	Args []string
+	// Env is the environment of the command, inherited if nil
+	Env []string
+}
+
+// Verify satisfies the Verifier interface
+func (verifier *CommandVerifier) Verify(ctx context.Context, fixtures []Fixture) ([]bool, error) {
+	input := bytes.NewBuffer(nil)
+	if err := WriteFixtures(input, fixtures); err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, verifier.Path, verifier.Args...)
+	cmd.Env = verifier.Env
+	cmd.Stdin = input
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Join(err, fmt.Errorf("stderr: %s", stderr.String()))
+	}
+	var results []bool
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		return nil, errors.Join(errors.New("invalid verifier output"), err)
+	}
+	return results, nil
+}
+
+// CheckVerifier verifies the fixtures provided (e.g. generated with
+// GenerateFixtures) with the external verifier, returning the fixtures whose
+// result does not match the expected one.
+func CheckVerifier(ctx context.Context, verifier Verifier, fixtures []Fixture) ([]Mismatch, error) {
+	results, err := verifier.Verify(ctx, fixtures)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != len(fixtures) {
+		return nil, fmt.Errorf("verifier returned %d results for %d fixtures", len(results), len(fixtures))
+	}
+	var mismatches []Mismatch
+	for i, valid := range results {
+		if valid != fixtures[i].Valid {
+			mismatches = append(mismatches, Mismatch{Name: fixtures[i].Name, Expected: fixtures[i].Valid, Got: valid})
+		}
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,88 @@
+package interop
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// helperEnv is set when the test binary is run as an external verifier
+const helperEnv = "INTEROP_HELPER_VERIFIER"
+
+// TestHelperVerifier is not a real test, it acts as an external verifier when
+// the test binary is run by a CommandVerifier. If the helper environment
+// variable is set to "broken" it accepts every proof.
+func TestHelperVerifier(t *testing.T) {
+	mode := os.Getenv(helperEnv)
+	if mode == "" {
+		t.Skip("only run as an external verifier")
+	}
+	fixtures, err := ReadFixtures(os.Stdin)
+	require.NoError(t, err)
+	results := make([]bool, len(fixtures))
+	for i := range fixtures {
+		results[i] = true
+		if mode != "broken" {
+			results[i], _ = fixtures[i].Verify()
+		}
+	}
+	require.NoError(t, json.NewEncoder(os.Stdout).Encode(results))
+	os.Exit(0)
+}
+
+func helperVerifier(mode string) *CommandVerifier {
+	return &CommandVerifier{
+		Path: os.Args[0],
+		Args: []string{"-test.run=^TestHelperVerifier$"},
+		Env:  append(os.Environ(), helperEnv+"="+mode),
+	}
+}
+
+func TestFixtures_RoundTrip(t *testing.T) {
+	for _, sumTrie := range []bool{false, true} {
+		fixtures, err := GenerateFixtures(1, 10, sumTrie)
+		require.NoError(t, err)
+		require.Len(t, fixtures, 30)
+		require.Empty(t, CheckFixtures(fixtures))
+
+		// Fixtures survive the JSON encoding
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, WriteFixtures(buf, fixtures))
+		decoded, err := ReadFixtures(buf)
+		require.NoError(t, err)
+		require.Empty(t, CheckFixtures(decoded))
+
+		// Generation is deterministic
+		again, err := GenerateFixtures(1, 10, sumTrie)
+		require.NoError(t, err)
+		require.Equal(t, fixtures, again)
+
+		// Fixtures with the wrong expectation are reported
+		decoded[0].Valid = false
+		mismatches := CheckFixtures(decoded)
+		require.Len(t, mismatches, 1)
+		require.Equal(t, decoded[0].Name, mismatches[0].Name)
+	}
+}
+
+func TestCheckVerifier(t *testing.T) {
+	fixtures, err := GenerateFixtures(2, 5, true)
+	require.NoError(t, err)
+
+	mismatches, err := CheckVerifier(context.Background(), helperVerifier("reference"), fixtures)
+	require.NoError(t, err)
+	require.Empty(t, mismatches)
+
+	// A verifier accepting every proof is caught by the tampered fixtures
+	mismatches, err = CheckVerifier(context.Background(), helperVerifier("broken"), fixtures)
+	require.NoError(t, err)
+	require.Len(t, mismatches, 5)
+	for _, mismatch := range mismatches {
+		require.False(t, mismatch.Expected)
+		require.Contains(t, mismatch.Error(), "tampered-value")
+	}
+}
@@ -0,0 +1,223 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidCursor is returned when seeking to a malformed cursor.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrCursorRootMismatch is returned when seeking to a cursor created at a
+	// different root than the trie's current root.
+	ErrCursorRootMismatch = errors.New("cursor root does not match trie root")
+)
+
+// Iterator iterates over the leaves of a trie in ascending path order.
+//
+// The iterator holds no references into the trie between calls to Next, each
+// call seeks the next leaf from the root, so its position is fully described
+// by the root it iterates and the path of its current leaf. This position is
+// exposed as a serialisable cursor, allowing iteration to be resumed with
+// SeekCursor by another iterator, even in another process, as long as the
+// trie is at the same root. Modifying the trie while iterating over it results
+// in an undefined (but safe) iteration order.
+type Iterator struct {
+	trie      *SMT
+	root      MerkleRoot
+	path      []byte
+	valueHash []byte
+	err       error
+	done      bool
+}
+
+// Iterator returns a new Iterator positioned before the first leaf of the
+// trie.
+func (smt *SMT) Iterator() *Iterator {
+	return &Iterator{trie: smt, root: smt.Root()}
+}
+
+// Next advances the iterator to the next leaf, returning false when there are
+// no more leaves or an error occurred.
+func (it *Iterator) Next() bool {
+	if it.done || it.err != nil {
+		return false
+	}
+	leaf, err := it.trie.nextLeaf(it.trie.root, 0, it.path)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	if leaf == nil {
+		it.done = true
+		return false
+	}
+	it.path, it.valueHash = leaf.path, leaf.valueHash
+	return true
+}
+
+// Path returns a copy of the path of the current leaf
+func (it *Iterator) Path() []byte {
+	return bytes.Clone(it.path)
+}
+
+// ValueHash returns a copy of the value hash of the current leaf, for sum
+// tries this includes the leaf's sum and count.
+func (it *Iterator) ValueHash() []byte {
+	return bytes.Clone(it.valueHash)
+}
+
+// Err returns the error encountered while iterating, if any
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Cursor returns a serialised cursor of the iterator's position, formed of
+// the root it iterates followed by the path of its current leaf (if any).
+func (it *Iterator) Cursor() []byte {
+	cursor := make([]byte, 0, len(it.root)+len(it.path))
+	cursor = append(cursor, it.root...)
+	return append(cursor, it.path...)
+}
+
+// SeekCursor positions the iterator at the cursor provided, so that the next
+// call to Next returns the leaf following the one the cursor was created at.
+// ErrCursorRootMismatch is returned if the trie is not at the cursor's root.
+func (it *Iterator) SeekCursor(cursor []byte) error {
+	hashSize, pathSize := it.trie.hashSize(), it.trie.ph.PathSize()
+	if len(cursor) != hashSize && len(cursor) != hashSize+pathSize {
+		return errors.Join(ErrInvalidCursor, fmt.Errorf("got %d bytes", len(cursor)))
+	}
+	root := it.trie.Root()
+	if !bytes.Equal(cursor[:hashSize], root) {
+		return ErrCursorRootMismatch
+	}
+	it.root = root
+	it.path, it.valueHash = nil, nil
+	if len(cursor) > hashSize {
+		it.path = bytes.Clone(cursor[hashSize:])
+	}
+	it.err, it.done = nil, false
+	return nil
+}
+
+// nextLeaf returns the leaf with the smallest path greater than the path
+// provided in the subtrie rooted at the node at the given depth, or the first
+// leaf if the path is nil. Persisted nodes are read from the node store
+// without being cached, so iterating never grows the trie held in memory.
+func (smt *SMT) nextLeaf(node trieNode, depth int, after []byte) (*leafNode, error) {
+	node, err := smt.resolveLazy(node)
+	if err != nil {
+		return nil, err
+	}
+	switch n := node.(type) {
+	case *leafNode:
+		if after == nil || bytes.Compare(n.path, after) > 0 {
+			return n, nil
+		}
+		return nil, nil
+	case *extensionNode:
+		if after != nil {
+			for i := n.pathStart(); i < n.pathEnd(); i++ {
+				extBit, afterBit := getPathBit(n.path, i), getPathBit(after, i)
+				if extBit < afterBit {
+					// Every leaf under the extension precedes the path
+					return nil, nil
+				}
+				if extBit > afterBit {
+					// Every leaf under the extension follows the path
+					return smt.nextLeaf(n.child, n.pathEnd(), nil)
+				}
+			}
+		}
+		return smt.nextLeaf(n.child, n.pathEnd(), after)
+	case *innerNode:
+		if after != nil && getPathBit(after, depth) != leftChildBit {
+			return smt.nextLeaf(n.rightChild, depth+1, after)
+		}
+		leaf, err := smt.nextLeaf(n.leftChild, depth+1, after)
+		if leaf != nil || err != nil {
+			return leaf, err
+		}
+		return smt.nextLeaf(n.rightChild, depth+1, nil)
+	}
+	return nil, nil
+}
+
+// IteratePrefix calls fn with the path and value hash of every leaf whose
+// path starts with the first prefixBits bits of the prefix provided, in
+// ascending path order, until fn returns false. Only the subtrie under the
+// prefix is visited, so the cost is that of reaching the subtrie plus the
+// size of the subtrie, not that of the whole trie. As keys are hashed into
+// paths, prefixes are only meaningful for keys with a non-hashing path hasher
+// (e.g. WithPathHasher(NewIndexPathHasher(...))) or to visit a range of paths.
+// Persisted nodes are read from the node store without being cached.
+func (smt *SMT) IteratePrefix(prefix []byte, prefixBits int, fn func(path, valueHash []byte) bool) error {
+	if smt.closed {
+		return ErrClosed
+	}
+	path, err := smt.prefixPath(prefix, prefixBits)
+	if err != nil {
+		return err
+	}
+	root, err := smt.subtreeRoot(path, prefixBits)
+	if err != nil {
+		return err
+	}
+	_, err = smt.iterateLeaves(root, fn)
+	return err
+}
+
+// iterateLeaves calls fn with every leaf of the subtrie rooted at the node
+// provided in ascending path order, returning false once fn returns false.
+func (smt *SMT) iterateLeaves(node trieNode, fn func(path, valueHash []byte) bool) (bool, error) {
+	node, err := smt.resolveLazy(node)
+	if err != nil {
+		return false, err
+	}
+	switch n := node.(type) {
+	case *leafNode:
+		return fn(bytes.Clone(n.path), bytes.Clone(n.valueHash)), nil
+	case *extensionNode:
+		return smt.iterateLeaves(n.child, fn)
+	case *innerNode:
+		if ok, err := smt.iterateLeaves(n.leftChild, fn); !ok || err != nil {
+			return ok, err
+		}
+		return smt.iterateLeaves(n.rightChild, fn)
+	}
+	return true, nil
+}
+
+// ListKeys returns up to limit leaf paths of the trie in ascending order,
+// starting after the page token provided, or from the first leaf if it is nil.
+// As keys are hashed into paths, the paths of the leaves are returned and not
+// their keys. The token of the next page is returned if there are more leaves
+// to list, it is an iterator cursor and so is only valid while the trie
+// remains at the same root.
+func (smt *SMT) ListKeys(startAfter []byte, limit int) (paths [][]byte, nextPageToken []byte, err error) {
+	if limit <= 0 {
+		return nil, nil, fmt.Errorf("invalid limit %d: must be positive", limit)
+	}
+	it := smt.Iterator()
+	if startAfter != nil {
+		if err := it.SeekCursor(startAfter); err != nil {
+			return nil, nil, err
+		}
+	}
+	for len(paths) < limit && it.Next() {
+		paths = append(paths, it.Path())
+	}
+	if err := it.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(paths) < limit {
+		return paths, nil, nil
+	}
+	cursor := it.Cursor()
+	if !it.Next() {
+		return paths, nil, it.Err()
+	}
+	return paths, cursor, nil
+}
@@ -0,0 +1,191 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_Iterator(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleTrie(nodes, sha256.New())
+
+	it := trie.Iterator()
+	require.False(t, it.Next())
+	require.NoError(t, it.Err())
+
+	var paths [][]byte
+	valueHashes := make(map[string][]byte)
+	for i := 0; i < 200; i++ {
+		key := []byte(fmt.Sprintf("key%d", i))
+		require.NoError(t, trie.Update(key, key))
+		paths = append(paths, trie.ph.Path(key))
+		valueHashes[string(trie.ph.Path(key))] = trie.valueHash(key)
+	}
+	sort.Slice(paths, func(i, j int) bool { return bytes.Compare(paths[i], paths[j]) < 0 })
+	require.NoError(t, trie.Commit())
+
+	var got [][]byte
+	for it = trie.Iterator(); it.Next(); {
+		got = append(got, it.Path())
+		require.Equal(t, valueHashes[string(it.Path())], it.ValueHash())
+	}
+	require.NoError(t, it.Err())
+	require.Equal(t, paths, got)
+	require.False(t, it.Next())
+
+	// Iterating an imported trie neither caches its nodes nor exposes them
+	imported := ImportSparseMerkleTrie(nodes, sha256.New(), trie.Root())
+	it = imported.Iterator()
+	require.True(t, it.Next())
+	it.Path()[0] ^= 0xff
+	it.ValueHash()[0] ^= 0xff
+	require.Equal(t, paths[0], it.Path())
+	require.Equal(t, valueHashes[string(paths[0])], it.ValueHash())
+	require.IsType(t, &lazyNode{}, imported.root)
+}
+
+func TestSMT_Iterator_Cursor(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleTrie(nodes, sha256.New())
+	for i := 0; i < 100; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key%d", i)), []byte{byte(i)}))
+	}
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+
+	// Read the first page and save the cursor
+	var got [][]byte
+	it := trie.Iterator()
+	for len(got) < 40 && it.Next() {
+		got = append(got, it.Path())
+	}
+	cursor := it.Cursor()
+
+	// Resume in a new "process" importing the trie at the same root
+	imported := ImportSparseMerkleTrie(nodes, sha256.New(), root)
+	resumed := imported.Iterator()
+	require.NoError(t, resumed.SeekCursor(cursor))
+	for resumed.Next() {
+		got = append(got, resumed.Path())
+	}
+	require.NoError(t, resumed.Err())
+	require.Len(t, got, 100)
+	require.True(t, sort.SliceIsSorted(got, func(i, j int) bool { return bytes.Compare(got[i], got[j]) < 0 }))
+
+	// A cursor with only the root restarts the iteration
+	fresh := imported.Iterator()
+	require.NoError(t, fresh.SeekCursor(root))
+	require.True(t, fresh.Next())
+	require.Equal(t, got[0], fresh.Path())
+
+	require.ErrorIs(t, fresh.SeekCursor(cursor[:10]), ErrInvalidCursor)
+	require.NoError(t, imported.Update([]byte("new"), []byte("value")))
+	require.ErrorIs(t, imported.Iterator().SeekCursor(cursor), ErrCursorRootMismatch)
+}
+
+func TestSMST_Iterator(t *testing.T) {
+	trie := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := 0; i < 20; i++ {
+		require.NoError(t, trie.Update([]byte{byte(i)}, []byte{byte(i)}, uint64(i)))
+	}
+	var sum, count uint64
+	for it := trie.Iterator(); it.Next(); {
+		s, c := parseSumAndCount(it.ValueHash())
+		sum += s
+		count += c
+	}
+	require.Equal(t, trie.Sum(), sum)
+	require.Equal(t, trie.Count(), count)
+}
+
+func TestSMT_ListKeys(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	paths, next, err := trie.ListKeys(nil, 10)
+	require.NoError(t, err)
+	require.Empty(t, paths)
+	require.Nil(t, next)
+
+	for i := 0; i < 25; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key%d", i)), []byte{byte(i)}))
+	}
+
+	var all [][]byte
+	var pages int
+	for token := []byte(nil); ; pages++ {
+		paths, token, err = trie.ListKeys(token, 10)
+		require.NoError(t, err)
+		all = append(all, paths...)
+		if token == nil {
+			break
+		}
+	}
+	require.Equal(t, 2, pages)
+	require.Len(t, all, 25)
+	require.True(t, sort.SliceIsSorted(all, func(i, j int) bool { return bytes.Compare(all[i], all[j]) < 0 }))
+
+	// An exactly filled last page has no next page token
+	paths, next, err = trie.ListKeys(nil, 25)
+	require.NoError(t, err)
+	require.Len(t, paths, 25)
+	require.Nil(t, next)
+
+	_, _, err = trie.ListKeys(nil, 0)
+	require.Error(t, err)
+	_, next, err = trie.ListKeys(nil, 5)
+	require.NoError(t, err)
+	require.NoError(t, trie.Delete([]byte("key0")))
+	_, _, err = trie.ListKeys(next, 5)
+	require.ErrorIs(t, err, ErrCursorRootMismatch)
+}
+
+func TestSMT_IteratePrefix(t *testing.T) {
+	// Namespaced keys are visited in order with a non-hashing path hasher
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithPathHasher(NewIndexPathHasher(4)))
+	for _, namespace := range []byte{0x01, 0x02, 0x03} {
+		for i := byte(0); i < 10; i++ {
+			require.NoError(t, trie.Update([]byte{namespace, 0, 0, i}, []byte{namespace, i}))
+		}
+	}
+	require.NoError(t, trie.Commit())
+
+	var paths [][]byte
+	require.NoError(t, trie.IteratePrefix([]byte{0x02}, 8, func(path, valueHash []byte) bool {
+		paths = append(paths, path)
+		require.Equal(t, trie.valueHash([]byte{0x02, path[3]}), valueHash)
+		return true
+	}))
+	require.Len(t, paths, 10)
+	for i, path := range paths {
+		require.Equal(t, []byte{0x02, 0, 0, byte(i)}, path)
+	}
+
+	// Iteration stops when fn returns false
+	paths = nil
+	require.NoError(t, trie.IteratePrefix(nil, 0, func(path, _ []byte) bool {
+		paths = append(paths, path)
+		return len(paths) < 15
+	}))
+	require.Len(t, paths, 15)
+	require.Equal(t, []byte{0x02, 0, 0, 4}, paths[14])
+
+	// Prefixes of a single leaf or of no leaf
+	var count int
+	count1 := func([]byte, []byte) bool { count++; return true }
+	require.NoError(t, trie.IteratePrefix([]byte{0x03, 0, 0, 7}, 32, count1))
+	require.Equal(t, 1, count)
+	require.NoError(t, trie.IteratePrefix([]byte{0x04}, 8, count1))
+	require.NoError(t, trie.IteratePrefix([]byte{0x03, 0, 1}, 24, count1))
+	require.Equal(t, 1, count)
+	// The bits of the prefix beyond prefixBits are ignored
+	require.NoError(t, trie.IteratePrefix([]byte{0x03, 0x03}, 14, count1))
+	require.Equal(t, 11, count)
+
+	require.Error(t, trie.IteratePrefix([]byte{0x01}, 9, count1))
+}
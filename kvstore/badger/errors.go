@@ -35,4 +35,7 @@ var (
 	// ErrBadgerGettingStoreLength is returned when the badger store fails to
 	// get the length of the database
 	ErrBadgerGettingStoreLength = errors.New("unable to get database length")
+	// ErrBadgerCompactingStore is returned when the badger store fails to
+	// compact the database
+	ErrBadgerCompactingStore = errors.New("unable to compact database")
 )
@@ -1,13 +1,17 @@
 package badger
 
 import (
+	"context"
 	"io"
 
 	"github.com/pokt-network/smt/kvstore"
 )
 
 // Ensure the BadgerKVStore can be used as an SMT node store
-var _ kvstore.MapStore = (BadgerKVStore)(nil)
+var (
+	_ kvstore.MapStore  = (BadgerKVStore)(nil)
+	_ kvstore.Compactor = (BadgerKVStore)(nil)
+)
 
 // BadgerKVStore is an interface that defines a key-value store
 // that can be used standalone or as the node store for an SMT.
@@ -48,4 +52,7 @@ type BadgerKVStore interface {
 
 	// ClearAll deletes all key-value pairs in the store
 	ClearAll() error
+	// CompactStore flattens the LSM tree and garbage collects the value log,
+	// returning the space reclaimed by deletes to the filesystem
+	CompactStore(ctx context.Context) error
 }
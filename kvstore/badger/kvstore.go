@@ -1,14 +1,20 @@
 package badger
 
 import (
+	"context"
 	"errors"
 	"io"
 
 	badgerv4 "github.com/dgraph-io/badger/v4"
+
+	"github.com/pokt-network/smt/kvstore"
 )
 
 const (
 	maxPendingWrites = 16 // used in backup restoration
+
+	compactionWorkers    = 2   // used in flattening the LSM tree
+	valueLogDiscardRatio = 0.5 // used in value log garbage collection
 )
 
 var _ BadgerKVStore = &badgerKVStore{}
@@ -59,6 +65,9 @@ func (store *badgerKVStore) Get(key []byte) ([]byte, error) {
 		}
 		return nil
 	}); err != nil {
+		if errors.Is(err, badgerv4.ErrKeyNotFound) {
+			err = errors.Join(kvstore.ErrKeyNotFound, err)
+		}
 		return nil, errors.Join(ErrBadgerUnableToGetValue, err)
 	}
 	return val, nil
@@ -150,6 +159,32 @@ func (store *badgerKVStore) Restore(r io.Reader) error {
 	return nil
 }
 
+// CompactStore flattens the LSM tree into a single level and then garbage
+// collects the value log until no more files can be rewritten, so the space
+// reclaimed by deletes (e.g. after pruning a trie) returns to the filesystem.
+// The context is checked between value log files. In-memory stores have no
+// value log to garbage collect.
+func (store *badgerKVStore) CompactStore(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := store.db.Flatten(compactionWorkers); err != nil {
+		return errors.Join(ErrBadgerCompactingStore, err)
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := store.db.RunValueLogGC(valueLogDiscardRatio)
+		if errors.Is(err, badgerv4.ErrNoRewrite) || errors.Is(err, badgerv4.ErrGCInMemoryMode) {
+			return nil
+		}
+		if err != nil {
+			return errors.Join(ErrBadgerCompactingStore, err)
+		}
+	}
+}
+
 // Stop closes the database connection, disabling any access to the store
 func (store *badgerKVStore) Stop() error {
 	if err := store.db.Close(); err != nil {
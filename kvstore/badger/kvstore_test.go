@@ -2,6 +2,8 @@ package badger_test
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -400,3 +402,22 @@ func setupStore(t *testing.T, store badger.BadgerKVStore) {
 	err = store.Set([]byte("baz"), []byte("bin"))
 	require.NoError(t, err)
 }
+
+func TestBadger_KVStore_CompactStore(t *testing.T) {
+	store, err := badger.NewKVStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Stop()
+
+	value := bytes.Repeat([]byte("v"), 1<<10)
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, store.Set([]byte(fmt.Sprintf("key-%d", i)), value))
+	}
+	for i := 0; i < 900; i++ {
+		require.NoError(t, store.Delete([]byte(fmt.Sprintf("key-%d", i))))
+	}
+	require.NoError(t, store.CompactStore(context.Background()))
+	require.Equal(t, 100, store.Len())
+	got, err := store.Get([]byte("key-999"))
+	require.NoError(t, err)
+	require.Equal(t, value, got)
+}
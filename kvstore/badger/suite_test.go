@@ -0,0 +1,20 @@
+package badger_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/badger"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
+)
+
+func TestBadger_KVStore_Suite(t *testing.T) {
+	kvstoretest.Suite(t, func(t *testing.T) kvstore.MapStore {
+		store, err := badger.NewKVStore("")
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, store.Stop()) })
+		return store
+	})
+}
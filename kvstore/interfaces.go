@@ -1,12 +1,27 @@
 package kvstore
 
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrCompactionUnsupported is returned when compacting a store which does
+	// not support compaction.
+	ErrCompactionUnsupported = errors.New("store does not support compaction")
+	// ErrKeyNotFound is wrapped by the errors stores return when getting a key
+	// which is not present, to tell it apart from failing to read the store.
+	ErrKeyNotFound = errors.New("key not found")
+)
+
 // MapStore defines an interface that represents a key-value store that backs
 // the SM(S)T. It is the minimum viable subset of functionality a key-value
 // store requires in order to back an SM(S)T.
 type MapStore interface {
 	// --- Accessors ---
 
-	// Get returns the value for a given key
+	// Get returns the value for a given key, or an error wrapping
+	// ErrKeyNotFound if it is not present
 	Get(key []byte) ([]byte, error)
 	// Set sets/updates the value for a given key
 	Set(key, value []byte) error
@@ -20,3 +35,21 @@ type MapStore interface {
 	// ClearAll deletes all key-value pairs in the store
 	ClearAll() error
 }
+
+// Compactor is implemented by stores able to compact their underlying storage
+// on demand, returning the space reclaimed by deletes (e.g. after pruning a
+// trie) to the filesystem.
+type Compactor interface {
+	// CompactStore compacts the store, stopping early if the context is done
+	CompactStore(ctx context.Context) error
+}
+
+// CompactStore compacts the store provided if it implements Compactor,
+// returning ErrCompactionUnsupported otherwise.
+func CompactStore(ctx context.Context, store MapStore) error {
+	compactor, ok := store.(Compactor)
+	if !ok {
+		return ErrCompactionUnsupported
+	}
+	return compactor.CompactStore(ctx)
+}
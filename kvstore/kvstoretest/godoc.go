@@ -0,0 +1,10 @@
+// Package kvstoretest provides a conformance test suite for implementations
+// of the kvstore.MapStore interface, so that third-party backends can certify
+// they can be used as the node (or value) store of a trie.
+//
+// The suite exercises the semantics the tries rely on: reads of missing keys,
+// overwrites, deletes, binary and prefix keys, concurrent use and a full trie
+// round-trip through the store. As the MapStore interface exposes neither
+// iteration nor batched writes these are not covered, backends offering them
+// should test them separately.
+package kvstoretest
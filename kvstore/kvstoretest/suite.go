@@ -0,0 +1,193 @@
+package kvstoretest
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Factory returns a new, empty store for a single test of the suite
+type Factory func(t *testing.T) kvstore.MapStore
+
+// Suite runs the conformance suite against the stores returned by the
+// factory provided, each test using a new store.
+func Suite(t *testing.T, factory Factory) {
+	t.Helper()
+	tests := []struct {
+		name string
+		test func(t *testing.T, store kvstore.MapStore)
+	}{
+		{"GetMissing", testGetMissing},
+		{"SetGet", testSetGet},
+		{"Overwrite", testOverwrite},
+		{"Delete", testDelete},
+		{"BinaryKeys", testBinaryKeys},
+		{"Len", testLen},
+		{"ClearAll", testClearAll},
+		{"Concurrent", testConcurrent},
+		{"TrieRoundTrip", testTrieRoundTrip},
+		{"Compact", testCompact},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, factory(t))
+		})
+	}
+}
+
+func testGetMissing(t *testing.T, store kvstore.MapStore) {
+	_, err := store.Get([]byte("missing"))
+	require.ErrorIs(t, err, kvstore.ErrKeyNotFound, "reading a missing key must return an error wrapping kvstore.ErrKeyNotFound")
+}
+
+func testSetGet(t *testing.T, store kvstore.MapStore) {
+	require.NoError(t, store.Set([]byte("key"), []byte("value")))
+	value, err := store.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), value)
+}
+
+func testOverwrite(t *testing.T, store kvstore.MapStore) {
+	require.NoError(t, store.Set([]byte("key"), []byte("value")))
+	require.NoError(t, store.Set([]byte("key"), []byte("value2")))
+	value, err := store.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value2"), value)
+	require.Equal(t, 1, store.Len())
+}
+
+func testDelete(t *testing.T, store kvstore.MapStore) {
+	require.NoError(t, store.Set([]byte("key"), []byte("value")))
+	require.NoError(t, store.Set([]byte("key2"), []byte("value2")))
+	require.NoError(t, store.Delete([]byte("key")))
+	_, err := store.Get([]byte("key"))
+	require.Error(t, err, "reading a deleted key must return an error")
+	value, err := store.Get([]byte("key2"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value2"), value)
+}
+
+func testBinaryKeys(t *testing.T, store kvstore.MapStore) {
+	// Keys are digests, so may hold any byte and be prefixes of one another
+	keys := [][]byte{{0}, {0, 0}, {0, 0, 0}, {0xff}, {0xff, 0}, {0, 0xff}}
+	for i, key := range keys {
+		require.NoError(t, store.Set(key, []byte{byte(i)}))
+	}
+	for i, key := range keys {
+		value, err := store.Get(key)
+		require.NoError(t, err)
+		require.Equal(t, []byte{byte(i)}, value, "key %x", key)
+	}
+	require.Equal(t, len(keys), store.Len())
+}
+
+func testLen(t *testing.T, store kvstore.MapStore) {
+	require.Zero(t, store.Len())
+	for i := 0; i < 10; i++ {
+		require.NoError(t, store.Set([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+	}
+	require.Equal(t, 10, store.Len())
+	require.NoError(t, store.Delete([]byte("key-0")))
+	require.Equal(t, 9, store.Len())
+}
+
+func testClearAll(t *testing.T, store kvstore.MapStore) {
+	for i := 0; i < 10; i++ {
+		require.NoError(t, store.Set([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+	}
+	require.NoError(t, store.ClearAll())
+	require.Zero(t, store.Len())
+	_, err := store.Get([]byte("key-0"))
+	require.Error(t, err)
+}
+
+func testConcurrent(t *testing.T, store kvstore.MapStore) {
+	const workers, writes = 8, 50
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				key := []byte(fmt.Sprintf("key-%d-%d", w, i))
+				if err := store.Set(key, key); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := store.Get(key); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, workers*writes, store.Len())
+}
+
+func testCompact(t *testing.T, store kvstore.MapStore) {
+	if _, ok := store.(kvstore.Compactor); !ok {
+		require.ErrorIs(t, kvstore.CompactStore(context.Background(), store), kvstore.ErrCompactionUnsupported)
+		return
+	}
+	for i := 0; i < 100; i++ {
+		require.NoError(t, store.Set([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+	}
+	for i := 0; i < 50; i++ {
+		require.NoError(t, store.Delete([]byte(fmt.Sprintf("key-%d", i))))
+	}
+	require.NoError(t, kvstore.CompactStore(context.Background(), store))
+	// Compaction must not lose or resurrect any entry
+	require.Equal(t, 50, store.Len())
+	_, err := store.Get([]byte("key-0"))
+	require.Error(t, err)
+	value, err := store.Get([]byte("key-99"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), value)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, kvstore.CompactStore(ctx, store), context.Canceled)
+}
+
+func testTrieRoundTrip(t *testing.T, store kvstore.MapStore) {
+	trie := smt.NewSparseMerkleTrie(store, sha256.New())
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		require.NoError(t, trie.Update(key, key))
+	}
+	require.NoError(t, trie.Commit())
+	for i := 0; i < 100; i += 2 {
+		require.NoError(t, trie.Delete([]byte(fmt.Sprintf("key-%d", i))))
+	}
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+
+	imported := smt.ImportSparseMerkleTrie(store, sha256.New(), root)
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		valueHash, err := imported.Get(key)
+		require.NoError(t, err)
+		if i%2 == 0 {
+			require.Nil(t, valueHash, "deleted key %s", key)
+			continue
+		}
+		proof, err := imported.Prove(key)
+		require.NoError(t, err)
+		valid, err := smt.VerifyProof(proof, root, key, key, imported.Spec())
+		require.NoError(t, err)
+		require.True(t, valid, "key %s", key)
+	}
+}
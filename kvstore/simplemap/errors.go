@@ -2,11 +2,15 @@ package simplemap
 
 import (
 	"errors"
+	"fmt"
+
+	"github.com/pokt-network/smt/kvstore"
 )
 
 var (
-	// ErrKVStoreKeyNotFound is returned when a key is not present in the trie.
-	ErrKVStoreKeyNotFound = errors.New("key already empty")
+	// ErrKVStoreKeyNotFound is returned when a key is not present in the trie,
+	// it wraps kvstore.ErrKeyNotFound.
+	ErrKVStoreKeyNotFound = fmt.Errorf("key already empty: %w", kvstore.ErrKeyNotFound)
 	// ErrKVStoreEmptyKey is returned when the given key is empty.
 	ErrKVStoreEmptyKey = errors.New("key is empty")
 )
@@ -1,15 +1,22 @@
 package simplemap
 
 import (
+	"context"
+	"sync"
+
 	"github.com/pokt-network/smt/kvstore"
 )
 
 // Ensure that the SimpleMap can be used as an SMT node store
-var _ kvstore.MapStore = (*simpleMap)(nil)
+var (
+	_ kvstore.MapStore  = (*simpleMap)(nil)
+	_ kvstore.Compactor = (*simpleMap)(nil)
+)
 
-// simpleMap is a simple in-memory map.
+// simpleMap is a simple in-memory map, safe for concurrent use.
 type simpleMap struct {
-	m map[string][]byte
+	mu sync.RWMutex
+	m  map[string][]byte
 }
 
 // NewSimpleMap creates a new SimpleMap instance.
@@ -33,6 +40,8 @@ func (sm *simpleMap) Get(key []byte) ([]byte, error) {
 		return nil, ErrKVStoreEmptyKey
 	}
 
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
 	if value, ok := sm.m[string(key)]; ok {
 		return value, nil
 	}
@@ -45,6 +54,8 @@ func (sm *simpleMap) Set(key []byte, value []byte) error {
 	if len(key) == 0 {
 		return ErrKVStoreEmptyKey
 	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
 	sm.m[string(key)] = value
 	return nil
 }
@@ -54,6 +65,8 @@ func (sm *simpleMap) Delete(key []byte) error {
 	if len(key) == 0 {
 		return ErrKVStoreEmptyKey
 	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
 	_, ok := sm.m[string(key)]
 	if ok {
 		delete(sm.m, string(key))
@@ -64,12 +77,32 @@ func (sm *simpleMap) Delete(key []byte) error {
 
 // Len returns the number of key-value pairs in the store.
 func (sm *simpleMap) Len() int {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
 	return len(sm.m)
 }
 
 // ClearAll clears all key-value pairs
 // NB: This should only be used for testing purposes.
 func (sm *simpleMap) ClearAll() error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
 	sm.m = make(map[string][]byte)
 	return nil
 }
+
+// CompactStore copies the entries into a new map, as Go maps never shrink,
+// releasing the memory held by deleted entries.
+func (sm *simpleMap) CompactStore(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	m := make(map[string][]byte, len(sm.m))
+	for k, v := range sm.m {
+		m[k] = v
+	}
+	sm.m = m
+	return nil
+}
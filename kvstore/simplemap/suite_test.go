@@ -0,0 +1,15 @@
+package simplemap_test
+
+import (
+	"testing"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSimpleMap_Suite(t *testing.T) {
+	kvstoretest.Suite(t, func(t *testing.T) kvstore.MapStore {
+		return simplemap.NewSimpleMap()
+	})
+}
@@ -0,0 +1,108 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ConflictFunc resolves a leaf present in both tries being merged with
+// different value hashes, returning the value hash to keep or nil to delete
+// the leaf. For sum tries the value hashes include the leaf's weight and
+// count, as stored in the trie.
+type ConflictFunc func(path, ours, theirs []byte) ([]byte, error)
+
+// Merge folds the leaves of another trie into the trie, which must share the
+// same spec. Leaves only present in the other trie are inserted, while leaves
+// present in both tries with different value hashes are resolved by the
+// ConflictFunc provided, or replaced by the other trie's if it is nil. Every
+// conflict is resolved before the trie is modified, so if the ConflictFunc
+// returns an error the trie is left unchanged. Likewise, the merged leaves are
+// checked against the trie's depth limit (see WithDepthLimit) before it is
+// modified. The other trie is only read.
+//
+// As tries only hold the paths of their keys, no events are published for
+// the merged leaves.
+func (smt *SMT) Merge(other *SMT, resolve ConflictFunc) error {
+	if smt.closed || other.closed {
+		return ErrClosed
+	}
+	if !bytes.Equal(smt.Spec().Fingerprint(), other.Spec().Fingerprint()) {
+		return errors.Join(ErrSpecMismatch, errors.New("cannot merge tries with different specs"))
+	}
+	if smt == other {
+		return nil
+	}
+
+	type change struct{ path, valueHash []byte }
+	var changes []change
+	var err error
+	_, iterErr := other.iterateLeaves(other.root, func(path, theirs []byte) bool {
+		var ours *leafNode
+		if ours, err = smt.findLeaf(path); err != nil {
+			return false
+		}
+		switch {
+		case ours == nil:
+			changes = append(changes, change{path, theirs})
+		case bytes.Equal(ours.valueHash, theirs):
+		case resolve == nil:
+			changes = append(changes, change{path, theirs})
+		default:
+			var valueHash []byte
+			if valueHash, err = resolve(path, ours.valueHash, theirs); err != nil {
+				return false
+			}
+			if !bytes.Equal(valueHash, ours.valueHash) {
+				changes = append(changes, change{path, valueHash})
+			}
+		}
+		return true
+	})
+	if err = errors.Join(iterErr, err); err != nil {
+		return err
+	}
+
+	// The inserted leaves are checked against the depth limit together, as
+	// for batch updates, with the alarm called with their paths in place of
+	// their keys
+	var paths [][]byte
+	var leaves []*leafNode
+	for _, c := range changes {
+		if c.valueHash != nil {
+			paths = append(paths, c.path)
+			leaves = append(leaves, &leafNode{path: c.path, valueHash: c.valueHash})
+		}
+	}
+	if err = smt.checkBatchDepths(paths, leaves); err != nil {
+		return err
+	}
+
+	// The leaves are walked in path order so each node along their shared
+	// paths is only resolved once, as for batch updates
+	var orphans orphanNodes
+	for _, c := range changes {
+		var newRoot trieNode
+		if err = smt.resolvePath(c.path, c.valueHash == nil); err != nil {
+			return err
+		}
+		if c.valueHash == nil {
+			newRoot, err = smt.delete(smt.root, 0, c.path, &orphans)
+		} else {
+			newRoot, err = smt.update(smt.root, 0, c.path, c.valueHash, &orphans)
+		}
+		if err != nil {
+			return err
+		}
+		smt.root = newRoot
+		smt.updates++
+		if c.valueHash == nil {
+			smt.metrics.Deletes++
+		} else {
+			smt.metrics.Updates++
+		}
+	}
+	if len(orphans) > 0 {
+		smt.orphans = append(smt.orphans, orphans)
+	}
+	return nil
+}
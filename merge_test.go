@@ -0,0 +1,116 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_Merge(t *testing.T) {
+	shardA := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	shardB := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	expected := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := 0; i < 50; i++ {
+		key := []byte(fmt.Sprintf("a-%d", i))
+		require.NoError(t, shardA.Update(key, []byte("a")))
+		require.NoError(t, expected.Update(key, []byte("a")))
+		key = []byte(fmt.Sprintf("b-%d", i))
+		require.NoError(t, shardB.Update(key, []byte("b")))
+		require.NoError(t, expected.Update(key, []byte("b")))
+	}
+	// Shared keys, with identical and conflicting values
+	require.NoError(t, shardA.Update([]byte("same"), []byte("value")))
+	require.NoError(t, shardB.Update([]byte("same"), []byte("value")))
+	require.NoError(t, expected.Update([]byte("same"), []byte("value")))
+	require.NoError(t, shardA.Update([]byte("conflict"), []byte("ours")))
+	require.NoError(t, shardB.Update([]byte("conflict"), []byte("theirs")))
+	require.NoError(t, shardA.Commit())
+	require.NoError(t, shardB.Commit())
+	rootB := shardB.Root()
+
+	// A failing resolver leaves the trie unchanged
+	errConflict := errors.New("conflict")
+	rootA := shardA.Root()
+	err := shardA.Merge(shardB, func(path, ours, theirs []byte) ([]byte, error) {
+		return nil, errConflict
+	})
+	require.ErrorIs(t, err, errConflict)
+	require.Equal(t, rootA, shardA.Root())
+
+	conflicts := 0
+	require.NoError(t, shardA.Merge(shardB, func(path, ours, theirs []byte) ([]byte, error) {
+		conflicts++
+		require.Equal(t, shardA.ph.Path([]byte("conflict")), path)
+		require.Equal(t, shardA.valueHash([]byte("ours")), ours)
+		require.Equal(t, shardA.valueHash([]byte("theirs")), theirs)
+		return ours, nil
+	}))
+	require.Equal(t, 1, conflicts)
+	require.NoError(t, expected.Update([]byte("conflict"), []byte("ours")))
+	require.Equal(t, expected.Root(), shardA.Root())
+	require.Equal(t, rootB, shardB.Root())
+
+	// Merged tries commit and reimport like any other
+	require.NoError(t, shardA.Commit())
+	imported := ImportSparseMerkleTrie(shardA.nodes, sha256.New(), shardA.Root())
+	got, err := imported.Get([]byte("b-7"))
+	require.NoError(t, err)
+	require.Equal(t, shardA.valueHash([]byte("b")), got)
+
+	// Resolving to nil deletes the leaf, and a nil resolver takes theirs
+	require.NoError(t, shardA.Merge(shardB, func(path, ours, theirs []byte) ([]byte, error) {
+		return nil, nil
+	}))
+	has, err := shardA.Has([]byte("conflict"))
+	require.NoError(t, err)
+	require.False(t, has)
+	require.NoError(t, shardA.Update([]byte("conflict"), []byte("ours")))
+	require.NoError(t, shardA.Merge(shardB, nil))
+	got, err = shardA.Get([]byte("conflict"))
+	require.NoError(t, err)
+	require.Equal(t, shardA.valueHash([]byte("theirs")), got)
+
+	// Tries with different specs cannot be merged
+	other := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha512.New())
+	require.ErrorIs(t, shardA.Merge(other, nil), ErrSpecMismatch)
+}
+
+func TestSMST_Merge(t *testing.T) {
+	shardA := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	shardB := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := 0; i < 20; i++ {
+		require.NoError(t, shardA.Update([]byte(fmt.Sprintf("a-%d", i)), []byte("a"), 1))
+		require.NoError(t, shardB.Update([]byte(fmt.Sprintf("b-%d", i)), []byte("b"), 2))
+	}
+	require.NoError(t, shardA.SMT.Merge(shardB.SMT, nil))
+	require.Equal(t, uint64(60), shardA.Sum())
+	require.Equal(t, uint64(40), shardA.Count())
+}
+
+func TestSMT_MergeDepthLimit(t *testing.T) {
+	nilPathHasher := WithPathHasher(newNilPathHasher(sha256.Size))
+	ours := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), nilPathHasher, WithDepthLimit(4, nil))
+	theirs := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), nilPathHasher)
+
+	// Leaves sharing 8 bits of their paths are inserted at depth 9, whether
+	// the other is in the trie or merged along with them
+	first, second := make([]byte, sha256.Size), make([]byte, sha256.Size)
+	second[1] = 0x80
+	require.NoError(t, theirs.Update(first, []byte("a")))
+	require.NoError(t, theirs.Update(second, []byte("b")))
+	root := ours.Root()
+	require.ErrorIs(t, ours.Merge(theirs, nil), ErrDepthLimitExceeded)
+	require.Equal(t, root, ours.Root())
+
+	require.NoError(t, ours.Update(first, []byte("a")))
+	root = ours.Root()
+	require.NoError(t, theirs.Delete(first))
+	require.ErrorIs(t, ours.Merge(theirs, nil), ErrDepthLimitExceeded)
+	require.Equal(t, root, ours.Root())
+}
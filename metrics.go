@@ -0,0 +1,116 @@
+package smt
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+)
+
+// metricsPrefix is the prefix of the keys the metrics of tries configured
+// with WithPersistentMetrics are stored under in their node stores, followed
+// by their metrics namespace if any. Keys with the prefix cannot collide with
+// the digests nodes are stored under as long as the hasher's size differs
+// from their length.
+var metricsPrefix = reservePrefix("metrics", []byte("smt/metrics"))
+
+// Metrics are the cumulative operational counters of a trie
+type Metrics struct {
+	// Updates is the number of leaves updated
+	Updates uint64
+	// Deletes is the number of leaves deleted
+	Deletes uint64
+	// Commits is the number of commits
+	Commits uint64
+	// Reclaimed is the number of orphaned nodes deleted by commits
+	Reclaimed uint64
+	// Anomalies is the number of commits flagged as anomalous, see
+	// WithCommitAnomalyThreshold
+	Anomalies uint64
+	// Corruptions is the number of nodes read from the node store which could
+	// not be decoded or, for tries configured WithNodeVerification, did not
+	// match their digest, including the root by health checks, which always
+	// verify it. Nodes missing from the node store are not counted.
+	Corruptions uint64
+}
+
+// WithPersistentMetrics returns an Option persisting the trie's Metrics to its
+// node store when it is closed, and reloading them when the trie is created,
+// so that its operational history survives restarts. Metrics of tries which
+// are not closed (e.g. after a crash) are lost since the last Close. Tries
+// sharing a node store must persist their metrics under different namespaces,
+// see WithMetricsNamespace; the shards of a ShardedSMT do so automatically.
+func WithPersistentMetrics() TrieSpecOption {
+	return func(ts *TrieSpec) { ts.persistMetrics = true }
+}
+
+// WithMetricsNamespace returns an Option persisting the trie's metrics under
+// the namespace provided, so that the metrics of tries sharing a node store do
+// not overwrite each other. It has no effect without WithPersistentMetrics.
+func WithMetricsNamespace(namespace string) TrieSpecOption {
+	return func(ts *TrieSpec) { ts.metricsNamespace = namespace }
+}
+
+// withShardMetricsNamespace returns an Option persisting the metrics of the
+// shard of a ShardedSMT with the given index under its own namespace, within
+// that of the sharded trie.
+func withShardMetricsNamespace(shard int) TrieSpecOption {
+	return func(ts *TrieSpec) {
+		ts.metricsNamespace = fmt.Sprintf("%s/shard/%d", ts.metricsNamespace, shard)
+	}
+}
+
+// Metrics returns the cumulative counters of the trie, including those
+// reloaded from its node store if it persists its metrics. If the persisted
+// metrics could not be reloaded when the trie was created the error is
+// returned, along with the counters since the trie was created.
+func (smt *SMT) Metrics() (Metrics, error) {
+	return smt.metrics, smt.metricsErr
+}
+
+// metricsKey returns the key the trie's metrics are persisted under
+func (spec *TrieSpec) metricsKey() []byte {
+	if spec.metricsNamespace == "" {
+		return metricsPrefix
+	}
+	return append(append(bytes.Clone(metricsPrefix), '/'), spec.metricsNamespace...)
+}
+
+// loadMetrics reloads the metrics persisted in the node store, if the trie
+// persists its metrics. New stores hold no metrics, so the metrics start from
+// zero if none are found.
+func (smt *SMT) loadMetrics() error {
+	if !smt.persistMetrics {
+		return nil
+	}
+	bz, err := smt.nodes.Get(smt.metricsKey())
+	if isKeyNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("loading metrics: %w", err)
+	}
+	var metrics Metrics
+	if err := gob.NewDecoder(bytes.NewBuffer(bz)).Decode(&metrics); err != nil {
+		return fmt.Errorf("decoding metrics: %w", err)
+	}
+	smt.metrics = metrics
+	return nil
+}
+
+// saveMetrics persists the metrics to the node store, if the trie persists
+// its metrics. Metrics which could not be reloaded are not overwritten, so
+// that a transient read failure does not reset them.
+func (smt *SMT) saveMetrics() error {
+	if !smt.persistMetrics {
+		return nil
+	}
+	if smt.metricsErr != nil {
+		return errors.Join(errors.New("not overwriting metrics which failed to load"), smt.metricsErr)
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := gob.NewEncoder(buf).Encode(smt.metrics); err != nil {
+		return err
+	}
+	return smt.nodes.Set(smt.metricsKey(), buf.Bytes())
+}
@@ -0,0 +1,129 @@
+package smt
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_Metrics(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleTrie(nodes, sha256.New(), WithPersistentMetrics(), WithBorrowedStores())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, trie.UpdateBatch([][]byte{[]byte("baz"), []byte("bin")}, [][]byte{[]byte("qux"), []byte("bob")}))
+	require.NoError(t, trie.Commit())
+	require.NoError(t, trie.Delete([]byte("foo")))
+	require.NoError(t, trie.Commit())
+
+	metrics, err := trie.Metrics()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), metrics.Updates)
+	require.Equal(t, uint64(1), metrics.Deletes)
+	require.Equal(t, uint64(2), metrics.Commits)
+	require.Positive(t, metrics.Reclaimed)
+	require.Zero(t, metrics.Corruptions)
+
+	// Metrics survive restarts
+	root := trie.Root()
+	require.NoError(t, trie.Close())
+	trie = ImportSparseMerkleTrie(nodes, sha256.New(), root,
+		WithPersistentMetrics(), WithBorrowedStores(), WithNodeVerification())
+	reloaded, err := trie.Metrics()
+	require.NoError(t, err)
+	require.Equal(t, metrics, reloaded)
+
+	// Missing nodes are not corruptions, while nodes not matching their
+	// digest are when verified
+	rootNode, err := nodes.Get(root)
+	require.NoError(t, err)
+	require.NoError(t, nodes.Delete(root))
+	_, err = trie.Get([]byte("baz"))
+	require.ErrorIs(t, err, kvstore.ErrKeyNotFound)
+	require.False(t, trie.HealthCheck(context.Background()).Healthy)
+	reloaded, err = trie.Metrics()
+	require.NoError(t, err)
+	require.Zero(t, reloaded.Corruptions)
+	rootNode[len(rootNode)-1] ^= 0xff
+	require.NoError(t, nodes.Set(root, rootNode))
+	_, err = trie.Get([]byte("baz"))
+	require.ErrorIs(t, err, ErrCorruptNode)
+	require.False(t, trie.HealthCheck(context.Background()).Healthy)
+	reloaded, err = trie.Metrics()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), reloaded.Corruptions)
+
+	// Tries without persistent metrics start from zero, and without node
+	// verification only count nodes which cannot be decoded
+	trie = ImportSparseMerkleTrie(nodes, sha256.New(), root)
+	reloaded, err = trie.Metrics()
+	require.NoError(t, err)
+	require.Equal(t, Metrics{}, reloaded)
+	_, err = trie.Get([]byte("baz"))
+	require.NotErrorIs(t, err, ErrCorruptNode)
+	require.NoError(t, nodes.Set(root, []byte("garbage")))
+	trie = ImportSparseMerkleTrie(nodes, sha256.New(), root)
+	_, err = trie.Get([]byte("baz"))
+	require.ErrorIs(t, err, ErrCorruptNode)
+	reloaded, err = trie.Metrics()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), reloaded.Corruptions)
+}
+
+func TestSMT_MetricsNamespaces(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	first := NewSparseMerkleTrie(nodes, sha256.New(),
+		WithPersistentMetrics(), WithMetricsNamespace("first"), WithBorrowedStores())
+	second := NewSparseMerkleTrie(nodes, sha256.New(),
+		WithPersistentMetrics(), WithMetricsNamespace("second"), WithBorrowedStores())
+	require.NoError(t, first.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, first.Commit())
+	require.NoError(t, first.Close())
+	require.NoError(t, second.Close())
+
+	// The metrics of tries sharing a store do not overwrite each other
+	first = NewSparseMerkleTrie(nodes, sha256.New(), WithPersistentMetrics(), WithMetricsNamespace("first"))
+	metrics, err := first.Metrics()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), metrics.Updates)
+
+	// Nor do those of the shards of a sharded trie
+	sharded, err := NewShardedSMT(nodes, 2, sha256.New, WithPersistentMetrics(), WithBorrowedStores())
+	require.NoError(t, err)
+	var key []byte
+	for i := 0; key == nil; i++ {
+		candidate := []byte(fmt.Sprintf("key%d", i))
+		index, err := sharded.ShardOf(candidate)
+		require.NoError(t, err)
+		if index == 0 {
+			key = candidate
+		}
+	}
+	require.NoError(t, sharded.Update(key, []byte("value")))
+	require.NoError(t, sharded.Commit())
+	for _, s := range sharded.shards {
+		require.NoError(t, s.trie.Close())
+	}
+	for i := range sharded.shards {
+		shard := NewSparseMerkleTrie(nodes, sha256.New(), shardOptions([]TrieSpecOption{WithPersistentMetrics()}, i)...)
+		metrics, err := shard.Metrics()
+		require.NoError(t, err)
+		require.Equal(t, uint64(1-i), metrics.Updates)
+	}
+}
+
+func TestSMT_MetricsLoadError(t *testing.T) {
+	// Failing to read the persisted metrics is reported, and they are not
+	// overwritten when the trie is closed
+	nodes := &unreadableStore{simplemap.NewSimpleMap()}
+	trie := NewSparseMerkleTrie(nodes, sha256.New(), WithPersistentMetrics())
+	_, err := trie.Metrics()
+	require.ErrorIs(t, err, errCrash)
+	require.ErrorIs(t, trie.Close(), errCrash)
+	require.Zero(t, nodes.Len())
+}
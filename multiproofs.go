@@ -0,0 +1,350 @@
+package smt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+func init() {
+	gob.Register(SparseMerkleMultiProof{})
+}
+
+// SparseMerkleMultiProof is a Merkle proof for several keys of a trie at once.
+//
+// It holds the side nodes of the subtrie spanned by the paths of the keys in
+// canonical depth-first (left before right) order, without their positions:
+// the verifier recomputes the position of every side node from the paths of
+// the keys and the depths at which they terminate, so every side node shared
+// between the keys is only included once and no per-node index is needed.
+type SparseMerkleMultiProof struct {
+	// SideNodes are the side nodes of the subtrie spanned by the proven
+	// paths, in depth-first order.
+	SideNodes [][]byte
+
+	// TerminalDepths are the depths of the nodes (leaves or empty subtries)
+	// the proven paths terminate at, in depth-first order.
+	TerminalDepths []int
+
+	// NonMembershipLeafData holds for every terminal node the data of the
+	// unrelated leaf found there, nil if the terminal node is empty or the
+	// leaf of one of the proven keys.
+	NonMembershipLeafData [][]byte
+}
+
+// Marshal serialises the SparseMerkleMultiProof to bytes
+func (proof *SparseMerkleMultiProof) Marshal() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(proof); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal deserialises the SparseMerkleMultiProof from bytes
+func (proof *SparseMerkleMultiProof) Unmarshal(bz []byte) error {
+	buf := bytes.NewBuffer(bz)
+	dec := gob.NewDecoder(buf)
+	return dec.Decode(proof)
+}
+
+// validateBasic performs basic sanity checks on the proof so that a malicious
+// proof cannot cause the verifier to fatally exit.
+func (proof *SparseMerkleMultiProof) validateBasic(numKeys int, spec *TrieSpec) error {
+	maxDepth := spec.ph.PathSize() * 8
+	if len(proof.SideNodes) > numKeys*maxDepth {
+		return fmt.Errorf("too many side nodes: got %d but max is %d", len(proof.SideNodes), numKeys*maxDepth)
+	}
+	for _, sideNode := range proof.SideNodes {
+		if len(sideNode) != spec.hashSize() {
+			return fmt.Errorf("invalid side node size: got %d but want %d", len(sideNode), spec.hashSize())
+		}
+	}
+	if len(proof.TerminalDepths) != len(proof.NonMembershipLeafData) {
+		return fmt.Errorf("got %d terminal depths for %d terminal leaves",
+			len(proof.TerminalDepths), len(proof.NonMembershipLeafData))
+	}
+	if len(proof.TerminalDepths) > numKeys {
+		return fmt.Errorf("too many terminal nodes: got %d but max is %d", len(proof.TerminalDepths), numKeys)
+	}
+	for i, depth := range proof.TerminalDepths {
+		if depth < 0 || depth > maxDepth {
+			return fmt.Errorf("invalid terminal depth: got %d, outside of [0, %d]", depth, maxDepth)
+		}
+		leafData := proof.NonMembershipLeafData[i]
+		if leafData != nil && (len(leafData) < len(leafNodePrefix)+spec.ph.PathSize() || !isLeafNode(leafData)) {
+			return fmt.Errorf("invalid non-membership leaf data: %x", leafData)
+		}
+	}
+	return nil
+}
+
+// multiProofEntry is a key being proven by a multiproof
+type multiProofEntry struct {
+	path []byte
+	// valueHash is the value hash of the key's leaf, nil if the key is absent
+	valueHash []byte
+	// proof is the key's individual proof, only used when proving
+	proof *SparseMerkleProof
+}
+
+// sortMultiProofEntries sorts the entries by path, returning an error if any
+// two entries share a path
+func sortMultiProofEntries(entries []multiProofEntry) error {
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].path, entries[j].path) < 0
+	})
+	for i := 1; i < len(entries); i++ {
+		if bytes.Equal(entries[i-1].path, entries[i].path) {
+			return fmt.Errorf("duplicate key for path %x", entries[i].path)
+		}
+	}
+	return nil
+}
+
+// splitMultiProofEntries splits entries sorted by path into those going left
+// and right at the given depth
+func splitMultiProofEntries(entries []multiProofEntry, depth int) (left, right []multiProofEntry) {
+	split := sort.Search(len(entries), func(i int) bool {
+		return getPathBit(entries[i].path, depth) != leftChildBit
+	})
+	return entries[:split], entries[split:]
+}
+
+// ProveMulti generates a SparseMerkleMultiProof of the membership or
+// non-membership of every key provided.
+func (smt *SMT) ProveMulti(keys [][]byte) (*SparseMerkleMultiProof, error) {
+	entries := make([]multiProofEntry, 0, len(keys))
+	for _, key := range keys {
+		path, err := smt.path(key)
+		if err != nil {
+			return nil, err
+		}
+		valueHash, err := smt.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		proof, err := smt.Prove(key)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(valueHash, defaultEmptyValue) {
+			valueHash = nil
+		}
+		entries = append(entries, multiProofEntry{path: path, valueHash: valueHash, proof: proof})
+	}
+	if err := sortMultiProofEntries(entries); err != nil {
+		return nil, err
+	}
+	proof := &SparseMerkleMultiProof{}
+	if len(entries) > 0 {
+		buildMultiProof(proof, entries, 0)
+	}
+	return proof, nil
+}
+
+// buildMultiProof appends the side nodes and terminal nodes of the subtrie
+// spanned by the entries at the given depth to the proof, in depth-first order.
+func buildMultiProof(proof *SparseMerkleMultiProof, entries []multiProofEntry, depth int) {
+	// Every entry terminates at the same node if any does at this depth
+	sideNodes := entries[0].proof.SideNodes
+	if len(sideNodes) == depth {
+		var leafData []byte
+		member := false
+		for _, entry := range entries {
+			member = member || entry.valueHash != nil
+		}
+		if !member {
+			leafData = entries[0].proof.NonMembershipLeafData
+		}
+		proof.TerminalDepths = append(proof.TerminalDepths, depth)
+		proof.NonMembershipLeafData = append(proof.NonMembershipLeafData, leafData)
+		return
+	}
+	sideNode := sideNodes[len(sideNodes)-1-depth]
+	left, right := splitMultiProofEntries(entries, depth)
+	switch {
+	case len(left) > 0 && len(right) > 0:
+		buildMultiProof(proof, left, depth+1)
+		buildMultiProof(proof, right, depth+1)
+	case len(left) > 0:
+		buildMultiProof(proof, left, depth+1)
+		proof.SideNodes = append(proof.SideNodes, sideNode)
+	default:
+		proof.SideNodes = append(proof.SideNodes, sideNode)
+		buildMultiProof(proof, right, depth+1)
+	}
+}
+
+// VerifyMultiProof verifies a SparseMerkleMultiProof of the keys provided,
+// where the value at the same index as each key is its value, or nil if the
+// key is proven absent.
+func VerifyMultiProof(proof *SparseMerkleMultiProof, root []byte, keys, values [][]byte, spec *TrieSpec) (bool, error) {
+	if len(keys) != len(values) {
+		return false, errors.Join(ErrBadProof, fmt.Errorf("got %d keys and %d values", len(keys), len(values)))
+	}
+	valueHashes := make([][]byte, len(keys))
+	for i, value := range values {
+		if !bytes.Equal(value, defaultEmptyValue) {
+			valueHashes[i] = spec.valueHash(value)
+		}
+	}
+	return verifyMultiProof(proof, root, keys, valueHashes, spec)
+}
+
+// VerifySumMultiProof verifies a SparseMerkleMultiProof of the keys of a sum
+// trie provided, where the value and sum at the same index as each key are
+// its value and sum, or nil and zero if the key is proven absent.
+func VerifySumMultiProof(
+	proof *SparseMerkleMultiProof,
+	root []byte,
+	keys, values [][]byte,
+	sums []uint64,
+	spec *TrieSpec,
+) (bool, error) {
+	if len(keys) != len(values) || len(keys) != len(sums) {
+		return false, errors.Join(ErrBadProof,
+			fmt.Errorf("got %d keys, %d values and %d sums", len(keys), len(values), len(sums)))
+	}
+	valueHashes := make([][]byte, len(keys))
+	for i, value := range values {
+		if bytes.Equal(value, defaultEmptyValue) && sums[i] == 0 {
+			continue
+		}
+		var sumBz [sumSizeBytes]byte
+		binary.BigEndian.PutUint64(sumBz[:], sums[i])
+		var countBz [countSizeBytes]byte
+		binary.BigEndian.PutUint64(countBz[:], 1)
+		valueHash := spec.valueHash(value)
+		valueHash = append(valueHash, sumBz[:]...)
+		valueHashes[i] = append(valueHash, countBz[:]...)
+	}
+	return verifyMultiProof(proof, root, keys, valueHashes, spec)
+}
+
+// verifyMultiProof verifies the proof for the keys provided, with the value
+// hash at the same index as each key, or nil if the key is proven absent.
+func verifyMultiProof(proof *SparseMerkleMultiProof, root []byte, keys, valueHashes [][]byte, spec *TrieSpec) (bool, error) {
+	if err := proof.validateBasic(len(keys), spec); err != nil {
+		return false, errors.Join(ErrBadProof, err)
+	}
+	entries := make([]multiProofEntry, 0, len(keys))
+	for i, key := range keys {
+		path, err := spec.path(key)
+		if err != nil {
+			return false, errors.Join(ErrBadProof, err)
+		}
+		entries = append(entries, multiProofEntry{path: path, valueHash: valueHashes[i]})
+	}
+	if err := sortMultiProofEntries(entries); err != nil {
+		return false, errors.Join(ErrBadProof, err)
+	}
+	if len(entries) == 0 {
+		return len(proof.SideNodes) == 0 && len(proof.TerminalDepths) == 0, nil
+	}
+	verifier := &multiProofVerifier{proof: proof, spec: spec}
+	digest, err := verifier.verify(entries, 0)
+	if err != nil {
+		if errors.Is(err, errMultiProofMismatch) {
+			return false, nil
+		}
+		return false, errors.Join(ErrBadProof, err)
+	}
+	if verifier.sideNodes != len(proof.SideNodes) || verifier.terminals != len(proof.TerminalDepths) {
+		return false, errors.Join(ErrBadProof, errors.New("unused side or terminal nodes"))
+	}
+	return bytes.Equal(digest, root), nil
+}
+
+// errMultiProofMismatch is returned while verifying a well formed multiproof
+// which does not prove the keys and values provided
+var errMultiProofMismatch = errors.New("multiproof does not match keys")
+
+// multiProofVerifier consumes the side and terminal nodes of a multiproof in
+// depth-first order
+type multiProofVerifier struct {
+	proof     *SparseMerkleMultiProof
+	spec      *TrieSpec
+	sideNodes int
+	terminals int
+}
+
+// nextSideNode returns the next side node of the proof
+func (v *multiProofVerifier) nextSideNode() ([]byte, error) {
+	if v.sideNodes >= len(v.proof.SideNodes) {
+		return nil, errors.New("not enough side nodes")
+	}
+	v.sideNodes++
+	return v.proof.SideNodes[v.sideNodes-1], nil
+}
+
+// verify recomputes the digest of the subtrie spanned by the entries at the
+// given depth
+func (v *multiProofVerifier) verify(entries []multiProofEntry, depth int) ([]byte, error) {
+	if v.terminals < len(v.proof.TerminalDepths) && v.proof.TerminalDepths[v.terminals] == depth {
+		leafData := v.proof.NonMembershipLeafData[v.terminals]
+		v.terminals++
+		return v.verifyTerminal(entries, leafData)
+	}
+	if depth >= v.spec.ph.PathSize()*8 {
+		return nil, errors.New("paths do not terminate")
+	}
+	left, right := splitMultiProofEntries(entries, depth)
+	var leftDigest, rightDigest []byte
+	var err error
+	if len(left) > 0 {
+		if leftDigest, err = v.verify(left, depth+1); err != nil {
+			return nil, err
+		}
+	} else if leftDigest, err = v.nextSideNode(); err != nil {
+		return nil, err
+	}
+	if len(right) > 0 {
+		if rightDigest, err = v.verify(right, depth+1); err != nil {
+			return nil, err
+		}
+	} else if rightDigest, err = v.nextSideNode(); err != nil {
+		return nil, err
+	}
+	digest, _ := v.spec.digestInnerNode(leftDigest, rightDigest)
+	return digest, nil
+}
+
+// verifyTerminal returns the digest of the terminal node the entries'
+// paths all terminate at
+func (v *multiProofVerifier) verifyTerminal(entries []multiProofEntry, leafData []byte) ([]byte, error) {
+	var member *multiProofEntry
+	for i := range entries {
+		if entries[i].valueHash == nil {
+			continue
+		}
+		if member != nil {
+			// Two leaves cannot terminate at the same node
+			return nil, errMultiProofMismatch
+		}
+		member = &entries[i]
+	}
+	if member != nil {
+		if leafData != nil {
+			return nil, errMultiProofMismatch
+		}
+		digest, _ := v.spec.digestLeaf(member.path, member.valueHash)
+		return digest, nil
+	}
+	if leafData == nil {
+		return v.spec.placeholder(), nil
+	}
+	path, valueHash := v.spec.parseLeafNode(leafData)
+	for _, entry := range entries {
+		if bytes.Equal(path, entry.path) {
+			// This is not an unrelated leaf
+			return nil, errMultiProofMismatch
+		}
+	}
+	digest, _ := v.spec.digestLeaf(path, valueHash)
+	return digest, nil
+}
@@ -0,0 +1,153 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestMultiProof(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := 0; i < 50; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		require.NoError(t, trie.Update(key, []byte(fmt.Sprintf("value-%d", i))))
+	}
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+
+	// Prove a mix of present and absent keys
+	var keys, values [][]byte
+	var proofs []*SparseMerkleProof
+	for i := 0; i < 60; i += 3 {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		var value []byte
+		if i < 50 {
+			value = []byte(fmt.Sprintf("value-%d", i))
+		}
+		proof, err := trie.Prove(key)
+		require.NoError(t, err)
+		keys, values, proofs = append(keys, key), append(values, value), append(proofs, proof)
+	}
+
+	proof, err := trie.ProveMulti(keys)
+	require.NoError(t, err)
+	aggregated, err := AggregateProofs(proofs)
+	require.NoError(t, err)
+	require.Less(t, len(proof.SideNodes), len(aggregated.SideNodes))
+
+	bz, err := proof.Marshal()
+	require.NoError(t, err)
+	aggregatedBz, err := aggregated.Marshal()
+	require.NoError(t, err)
+	require.Less(t, len(bz), len(aggregatedBz))
+	decoded := new(SparseMerkleMultiProof)
+	require.NoError(t, decoded.Unmarshal(bz))
+
+	valid, err := VerifyMultiProof(decoded, root, keys, values, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// The keys can be verified in any order
+	reversedKeys := make([][]byte, len(keys))
+	reversedValues := make([][]byte, len(values))
+	for i := range keys {
+		reversedKeys[len(keys)-1-i], reversedValues[len(keys)-1-i] = keys[i], values[i]
+	}
+	valid, err = VerifyMultiProof(decoded, root, reversedKeys, reversedValues, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// A wrong value invalidates the proof
+	values[0] = []byte("wrong")
+	valid, err = VerifyMultiProof(decoded, root, keys, values, trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+	values[0] = []byte("value-0")
+
+	// Claiming an absent key is present invalidates the proof
+	values[len(values)-1] = []byte("value-57")
+	valid, err = VerifyMultiProof(decoded, root, keys, values, trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+	values[len(values)-1] = nil
+
+	// Claiming a present key is absent invalidates the proof
+	values[0] = nil
+	valid, err = VerifyMultiProof(decoded, root, keys, values, trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+	values[0] = []byte("value-0")
+
+	// The proof does not verify for a subset of the keys
+	valid, _ = VerifyMultiProof(decoded, root, keys[1:], values[1:], trie.Spec())
+	require.False(t, valid)
+
+	// Malformed proofs are rejected
+	malformed := *decoded
+	malformed.SideNodes = append([][]byte{[]byte("short")}, decoded.SideNodes[1:]...)
+	_, err = VerifyMultiProof(&malformed, root, keys, values, trie.Spec())
+	require.ErrorIs(t, err, ErrBadProof)
+
+	// Duplicate keys are rejected
+	_, err = trie.ProveMulti([][]byte{keys[0], keys[0]})
+	require.Error(t, err)
+}
+
+func TestMultiProof_EdgeCases(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+
+	// Absent keys in an empty trie
+	keys := [][]byte{[]byte("a"), []byte("b")}
+	proof, err := trie.ProveMulti(keys)
+	require.NoError(t, err)
+	require.Empty(t, proof.SideNodes)
+	valid, err := VerifyMultiProof(proof, trie.Root(), keys, [][]byte{nil, nil}, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// A single leaf proving both its own and another key's absence
+	require.NoError(t, trie.Update([]byte("a"), []byte("value")))
+	proof, err = trie.ProveMulti(keys)
+	require.NoError(t, err)
+	valid, err = VerifyMultiProof(proof, trie.Root(), keys, [][]byte{[]byte("value"), nil}, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+	valid, err = VerifyMultiProof(proof, trie.Root(), keys, [][]byte{[]byte("value"), []byte("value")}, trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+
+	// No keys
+	proof, err = trie.ProveMulti(nil)
+	require.NoError(t, err)
+	valid, err = VerifyMultiProof(proof, trie.Root(), nil, nil, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+}
+
+func TestSumMultiProof(t *testing.T) {
+	trie := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := 0; i < 20; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		require.NoError(t, trie.Update(key, []byte(fmt.Sprintf("value-%d", i)), uint64(i)))
+	}
+	require.NoError(t, trie.Commit())
+
+	keys := [][]byte{[]byte("key-3"), []byte("key-7"), []byte("key-42")}
+	values := [][]byte{[]byte("value-3"), []byte("value-7"), nil}
+	sums := []uint64{3, 7, 0}
+	proof, err := trie.ProveMulti(keys)
+	require.NoError(t, err)
+
+	valid, err := VerifySumMultiProof(proof, trie.Root(), keys, values, sums, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	sums[1] = 8
+	valid, err = VerifySumMultiProof(proof, trie.Root(), keys, values, sums, trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+}
@@ -0,0 +1,151 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrQuotaExceeded is returned (wrapped in a QuotaError) when an update
+	// would take a namespace over its quota.
+	ErrQuotaExceeded = errors.New("namespace quota exceeded")
+	// ErrNamespaceNotFound is returned when referring to a namespace which is
+	// not registered.
+	ErrNamespaceNotFound = errors.New("namespace not found")
+)
+
+// NamespaceQuota limits the number of keys and bytes stored in a namespace of
+// an SMTWithStorage. The bytes of a key are the length of the key plus the
+// length of its value. Zero valued limits are not enforced.
+type NamespaceQuota struct {
+	MaxKeys  uint64
+	MaxBytes uint64
+}
+
+// NamespaceUsage is the number of keys and bytes stored in a namespace
+type NamespaceUsage struct {
+	Keys  uint64
+	Bytes uint64
+}
+
+// QuotaError is returned when an update is rejected as it would take its
+// namespace over its quota. It matches ErrQuotaExceeded with errors.Is.
+type QuotaError struct {
+	Namespace string
+	Key       []byte
+	// Resource is the limit which would be exceeded, either "keys" or "bytes"
+	Resource string
+	// Limit is the quota of the resource and Requested the usage the update
+	// would have resulted in
+	Limit     uint64
+	Requested uint64
+}
+
+// Error satisfies the error interface
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: namespace %q, key %x: %d %s requested but limit is %d",
+		ErrQuotaExceeded, e.Namespace, e.Key, e.Requested, e.Resource, e.Limit)
+}
+
+// Is allows the QuotaError to match ErrQuotaExceeded
+func (e *QuotaError) Is(target error) bool { return target == ErrQuotaExceeded }
+
+// NamespaceUsage returns the current usage of the namespace with the given
+// name, or ErrNamespaceNotFound if it is not registered.
+//
+// Usage is tracked in memory from the moment the namespace is registered, a
+// trie imported with existing keys in the namespace must restore its usage
+// with SetNamespaceUsage for the quota to account for them.
+func (smt *SMTWithStorage) NamespaceUsage(name string) (NamespaceUsage, error) {
+	smt.commitMu.RLock()
+	defer smt.commitMu.RUnlock()
+	ns, err := smt.namespaceByName(name)
+	if err != nil {
+		return NamespaceUsage{}, err
+	}
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	return ns.usage, nil
+}
+
+// SetNamespaceUsage overrides the usage of the namespace with the given name,
+// returning ErrNamespaceNotFound if it is not registered.
+func (smt *SMTWithStorage) SetNamespaceUsage(name string, usage NamespaceUsage) error {
+	smt.commitMu.RLock()
+	defer smt.commitMu.RUnlock()
+	ns, err := smt.namespaceByName(name)
+	if err != nil {
+		return err
+	}
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	ns.usage = usage
+	return nil
+}
+
+// namespaceByName returns the registered namespace with the given name, the
+// caller must hold the commit lock.
+func (smt *SMTWithStorage) namespaceByName(name string) (*Namespace, error) {
+	for _, ns := range smt.namespaces {
+		if ns.Name == name {
+			return ns, nil
+		}
+	}
+	return nil, errors.Join(ErrNamespaceNotFound, fmt.Errorf("%q", name))
+}
+
+// namespaceDelta returns the namespace of the key along with the usage it has
+// before and after the key's value is replaced with the one provided (nil
+// for a deletion), or nil if the key belongs to no namespace. The caller must
+// hold the key's lock.
+func (smt *SMTWithStorage) namespaceDelta(key, value []byte) (ns *Namespace, before, after NamespaceUsage, err error) {
+	if ns = smt.namespace(key); ns == nil {
+		return nil, before, after, nil
+	}
+	previous, err := smt.getValue(key)
+	if err != nil {
+		return nil, before, after, err
+	}
+	if !bytes.Equal(previous, defaultEmptyValue) {
+		before = NamespaceUsage{Keys: 1, Bytes: uint64(len(key) + len(previous))}
+	}
+	if value != nil {
+		after = NamespaceUsage{Keys: 1, Bytes: uint64(len(key) + len(value))}
+	}
+	return ns, before, after, nil
+}
+
+// checkQuota returns a QuotaError if replacing the before usage of the key
+// with the after usage takes the namespace from the current usage provided
+// over its quota, otherwise it returns the namespace's resulting usage.
+func (ns *Namespace) checkQuota(key []byte, current, before, after NamespaceUsage) (NamespaceUsage, error) {
+	usage := NamespaceUsage{
+		Keys:  replaceUsage(current.Keys, before.Keys, after.Keys),
+		Bytes: replaceUsage(current.Bytes, before.Bytes, after.Bytes),
+	}
+	// Updates never increasing the usage are always accepted, so that tenants
+	// over their quota (e.g. after it was lowered) can still shrink
+	if ns.Quota.MaxKeys > 0 && after.Keys > before.Keys && usage.Keys > ns.Quota.MaxKeys {
+		return usage, &QuotaError{
+			Namespace: ns.Name, Key: key, Resource: "keys",
+			Limit: ns.Quota.MaxKeys, Requested: usage.Keys,
+		}
+	}
+	if ns.Quota.MaxBytes > 0 && after.Bytes > before.Bytes && usage.Bytes > ns.Quota.MaxBytes {
+		return usage, &QuotaError{
+			Namespace: ns.Name, Key: key, Resource: "bytes",
+			Limit: ns.Quota.MaxBytes, Requested: usage.Bytes,
+		}
+	}
+	return usage, nil
+}
+
+// replaceUsage returns the usage after replacing before with after, without
+// underflowing when the tracked usage was not restored for an imported trie
+func replaceUsage(usage, before, after uint64) uint64 {
+	if before > usage {
+		return after
+	}
+	return usage - before + after
+}
@@ -0,0 +1,87 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMTWithStorage_NamespaceQuotas(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.RegisterNamespace(Namespace{
+		Name:   "tenant-a",
+		Prefix: []byte("a/"),
+		Quota:  NamespaceQuota{MaxKeys: 2},
+	}))
+	require.NoError(t, trie.RegisterNamespace(Namespace{
+		Name:   "tenant-b",
+		Prefix: []byte("b/"),
+		Quota:  NamespaceQuota{MaxBytes: 16},
+	}))
+
+	// Key-count quotas reject new keys but not overwrites
+	require.NoError(t, trie.Update([]byte("a/1"), []byte("one")))
+	require.NoError(t, trie.Update([]byte("a/2"), []byte("two")))
+	root := trie.Root()
+	err := trie.Update([]byte("a/3"), []byte("three"))
+	require.ErrorIs(t, err, ErrQuotaExceeded)
+	var quotaErr *QuotaError
+	require.ErrorAs(t, err, &quotaErr)
+	require.Equal(t, "tenant-a", quotaErr.Namespace)
+	require.Equal(t, "keys", quotaErr.Resource)
+	require.Equal(t, uint64(2), quotaErr.Limit)
+	require.Equal(t, uint64(3), quotaErr.Requested)
+	require.Equal(t, root, trie.Root(), "rejected updates must not modify the trie")
+	require.NoError(t, trie.Update([]byte("a/2"), []byte("second")))
+
+	usage, err := trie.NamespaceUsage("tenant-a")
+	require.NoError(t, err)
+	require.Equal(t, NamespaceUsage{Keys: 2, Bytes: 3 + 3 + 3 + 6}, usage)
+
+	// Deleting keys releases quota
+	require.NoError(t, trie.Delete([]byte("a/1")))
+	require.NoError(t, trie.Update([]byte("a/3"), []byte("three")))
+
+	// Byte quotas account for the key and value, including across commits
+	require.NoError(t, trie.Update([]byte("b/1"), []byte("12345678")))
+	require.NoError(t, trie.Commit())
+	err = trie.Update([]byte("b/2"), []byte("123456"))
+	require.ErrorAs(t, err, &quotaErr)
+	require.Equal(t, "bytes", quotaErr.Resource)
+	require.Equal(t, uint64(20), quotaErr.Requested)
+	// Shrinking a value is accepted and frees bytes for other keys
+	require.NoError(t, trie.Update([]byte("b/1"), []byte("1234")))
+	require.NoError(t, trie.Update([]byte("b/2"), []byte("123")))
+	usage, err = trie.NamespaceUsage("tenant-b")
+	require.NoError(t, err)
+	require.Equal(t, NamespaceUsage{Keys: 2, Bytes: 7 + 6}, usage)
+
+	// Keys outside any namespace are not limited
+	for i := 0; i < 5; i++ {
+		require.NoError(t, trie.Update([]byte{'c', byte(i)}, []byte("unlimited")))
+	}
+
+	_, err = trie.NamespaceUsage("tenant-c")
+	require.ErrorIs(t, err, ErrNamespaceNotFound)
+}
+
+func TestSMTWithStorage_NamespaceQuotasRestoreUsage(t *testing.T) {
+	nodes, preimages := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	trie := NewSMTWithStorage(nodes, preimages, sha256.New())
+	require.NoError(t, trie.Update([]byte("a/1"), []byte("one")))
+	require.NoError(t, trie.Commit())
+
+	imported, err := ImportSMTWithStorage(nodes, preimages, sha256.New(), trie.Root())
+	require.NoError(t, err)
+	require.NoError(t, imported.RegisterNamespace(Namespace{
+		Name:   "tenant-a",
+		Prefix: []byte("a/"),
+		Quota:  NamespaceQuota{MaxKeys: 1},
+	}))
+	require.NoError(t, imported.SetNamespaceUsage("tenant-a", NamespaceUsage{Keys: 1, Bytes: 6}))
+	require.ErrorIs(t, imported.Update([]byte("a/2"), []byte("two")), ErrQuotaExceeded)
+	require.NoError(t, imported.Update([]byte("a/1"), []byte("uno")))
+}
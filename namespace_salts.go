@@ -0,0 +1,32 @@
+package smt
+
+import "encoding/binary"
+
+// SaltValue returns the value inserted into the trie for a value stored in a
+// namespace with the given salt: the value prefixed by the length of the salt
+// and the salt itself, or the value unaltered if the salt is empty.
+//
+// As the salt is hashed along with the value, identical values stored in
+// namespaces with different salts produce different leaf digests, so a proof
+// for a value in one namespace cannot be replayed as a proof for another.
+// Proofs of values in salted namespaces are verified against the salted value:
+//
+//	VerifyProof(proof, root, key, SaltValue(salt, value), spec)
+func SaltValue(salt, value []byte) []byte {
+	if len(salt) == 0 {
+		return value
+	}
+	salted := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(salt)+len(value)), uint64(len(salt)))
+	salted = append(salted, salt...)
+	return append(salted, value...)
+}
+
+// leafValue returns the value inserted into the trie for the value of the key
+// provided, salted with the salt of the key's namespace (if any). The caller
+// must hold the commit lock.
+func (smt *SMTWithStorage) leafValue(key, value []byte) []byte {
+	if ns := smt.namespace(key); ns != nil {
+		return SaltValue(ns.Salt, value)
+	}
+	return value
+}
@@ -0,0 +1,61 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMTWithStorage_NamespaceSalts(t *testing.T) {
+	nodes, preimages := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	trie := NewSMTWithStorage(nodes, preimages, sha256.New())
+	require.NoError(t, trie.RegisterNamespace(Namespace{Name: "a", Prefix: []byte("a/"), Salt: []byte("app-a")}))
+	require.NoError(t, trie.RegisterNamespace(Namespace{Name: "b", Prefix: []byte("b/"), Salt: []byte("app-b")}))
+
+	value := []byte("same value")
+	require.NoError(t, trie.Update([]byte("a/key"), value))
+	require.NoError(t, trie.UpdateBatch([][]byte{[]byte("b/key"), []byte("plain")}, [][]byte{value, value}))
+	require.NoError(t, trie.Commit())
+
+	// Identical values hash differently in differently salted namespaces
+	hashA, err := trie.Get([]byte("a/key"))
+	require.NoError(t, err)
+	hashB, err := trie.Get([]byte("b/key"))
+	require.NoError(t, err)
+	hashPlain, err := trie.Get([]byte("plain"))
+	require.NoError(t, err)
+	require.NotEqual(t, hashA, hashB)
+	require.NotEqual(t, hashA, hashPlain)
+	require.Equal(t, trie.trie.valueHash(value), hashPlain)
+
+	// The unsalted values are returned, including after a reimport
+	imported, err := ImportSMTWithStorage(nodes, preimages, sha256.New(), trie.Root())
+	require.NoError(t, err)
+	for _, key := range []string{"a/key", "b/key", "plain"} {
+		got, err := imported.GetValue([]byte(key))
+		require.NoError(t, err)
+		require.Equal(t, value, got)
+	}
+
+	// Proofs verify against the salted value only
+	proof, err := trie.Prove([]byte("a/key"))
+	require.NoError(t, err)
+	valid, err := VerifyProof(proof, trie.Root(), []byte("a/key"), SaltValue([]byte("app-a"), value), trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+	valid, err = VerifyProof(proof, trie.Root(), []byte("a/key"), value, trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+	valid, err = VerifyProof(proof, trie.Root(), []byte("a/key"), SaltValue([]byte("app-b"), value), trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+}
+
+func TestSaltValue(t *testing.T) {
+	require.Equal(t, []byte("value"), SaltValue(nil, []byte("value")))
+	// The salt is length prefixed so salts cannot be shifted into values
+	require.NotEqual(t, SaltValue([]byte("ab"), []byte("c")), SaltValue([]byte("a"), []byte("bc")))
+}
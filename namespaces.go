@@ -0,0 +1,124 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidValue is returned (wrapped in a ValidationError) when a value
+	// is rejected by the validator of its namespace.
+	ErrInvalidValue = errors.New("invalid value")
+	// ErrNamespaceConflict is returned when registering a namespace whose
+	// prefix overlaps with an already registered namespace.
+	ErrNamespaceConflict = errors.New("namespace prefix conflicts with a registered namespace")
+)
+
+// ValueValidator checks the serialised value about to be stored at a key,
+// returning a non-nil error to reject it.
+type ValueValidator func(key, value []byte) error
+
+// Namespace describes the schema of all keys sharing a common prefix in an
+// SMTWithStorage.
+type Namespace struct {
+	// Name is a human readable name for the namespace used in errors
+	Name string
+	// Prefix is the key prefix identifying the keys in the namespace
+	Prefix []byte
+	// Codec is the ValueCodec used for typed values in the namespace, if nil
+	// the trie's codec is used
+	Codec ValueCodec
+	// Validate is called with every value updated in the namespace, if nil
+	// all values are accepted
+	Validate ValueValidator
+	// Quota limits the keys and bytes stored in the namespace, see
+	// NamespaceQuota
+	Quota NamespaceQuota
+	// Salt is a domain separation tag mixed into the hashes of the values in
+	// the namespace, see SaltValue
+	Salt []byte
+
+	// usage is the namespace's current usage, guarded by the trie lock
+	usage NamespaceUsage
+}
+
+// ValidationError is returned when a value is rejected by the validator of
+// its namespace. It matches ErrInvalidValue with errors.Is.
+type ValidationError struct {
+	Namespace string
+	Key       []byte
+	Err       error
+}
+
+// Error satisfies the error interface
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: namespace %q, key %x: %v", ErrInvalidValue, e.Namespace, e.Key, e.Err)
+}
+
+// Unwrap returns the error returned by the validator
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// Is allows the ValidationError to match ErrInvalidValue
+func (e *ValidationError) Is(target error) bool { return target == ErrInvalidValue }
+
+// ValidateAs returns a ValueValidator that decodes values with the codec
+// provided into a T, rejecting values that fail to decode, before passing
+// them to the check function (which may be nil).
+func ValidateAs[T any](codec ValueCodec, check func(key []byte, v T) error) ValueValidator {
+	return func(key, value []byte) error {
+		var v T
+		if err := codec.Unmarshal(value, &v); err != nil {
+			return fmt.Errorf("malformed value: %w", err)
+		}
+		if check == nil {
+			return nil
+		}
+		return check(key, v)
+	}
+}
+
+// RegisterNamespace registers the namespace provided so that every update to
+// a key with its prefix is validated, and typed values use its codec.
+// ErrNamespaceConflict is returned if the prefix overlaps with that of an
+// already registered namespace.
+func (smt *SMTWithStorage) RegisterNamespace(ns Namespace) error {
+	smt.commitMu.Lock()
+	defer smt.commitMu.Unlock()
+	for _, registered := range smt.namespaces {
+		if bytes.HasPrefix(ns.Prefix, registered.Prefix) || bytes.HasPrefix(registered.Prefix, ns.Prefix) {
+			return errors.Join(
+				ErrNamespaceConflict,
+				fmt.Errorf("%q (%x) overlaps %q (%x)", ns.Name, ns.Prefix, registered.Name, registered.Prefix),
+			)
+		}
+	}
+	ns.Prefix = bytes.Clone(ns.Prefix)
+	ns.Salt = bytes.Clone(ns.Salt)
+	smt.namespaces = append(smt.namespaces, &ns)
+	return nil
+}
+
+// namespace returns the namespace the key belongs to or nil, the caller must
+// hold the commit lock.
+func (smt *SMTWithStorage) namespace(key []byte) *Namespace {
+	for _, ns := range smt.namespaces {
+		if bytes.HasPrefix(key, ns.Prefix) {
+			return ns
+		}
+	}
+	return nil
+}
+
+// validate checks the value against the validator of the key's namespace,
+// the caller must hold the commit lock.
+func (smt *SMTWithStorage) validate(key, value []byte) error {
+	ns := smt.namespace(key)
+	if ns == nil || ns.Validate == nil {
+		return nil
+	}
+	if err := ns.Validate(key, value); err != nil {
+		return &ValidationError{Namespace: ns.Name, Key: key, Err: err}
+	}
+	return nil
+}
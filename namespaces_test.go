@@ -0,0 +1,74 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMTWithStorage_Namespaces(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+
+	errNoOwner := errors.New("owner is required")
+	require.NoError(t, trie.RegisterNamespace(Namespace{
+		Name:   "accounts",
+		Prefix: []byte("acc/"),
+		Codec:  JSONCodec{},
+		Validate: ValidateAs(JSONCodec{}, func(_ []byte, account testAccount) error {
+			if account.Owner == "" {
+				return errNoOwner
+			}
+			return nil
+		}),
+	}))
+	require.NoError(t, trie.RegisterNamespace(Namespace{
+		Name:   "flags",
+		Prefix: []byte("flag/"),
+		Validate: func(_, value []byte) error {
+			if len(value) != 1 {
+				return errors.New("flags are a single byte")
+			}
+			return nil
+		},
+	}))
+
+	// Overlapping prefixes are rejected in both directions
+	err := trie.RegisterNamespace(Namespace{Name: "nested", Prefix: []byte("acc/admin/")})
+	require.ErrorIs(t, err, ErrNamespaceConflict)
+	err = trie.RegisterNamespace(Namespace{Name: "wide", Prefix: []byte("ac")})
+	require.ErrorIs(t, err, ErrNamespaceConflict)
+
+	// Valid typed values use the namespace's codec
+	account := testAccount{Owner: "alice", Balance: 1}
+	require.NoError(t, trie.UpdateTyped([]byte("acc/alice"), account))
+	raw, err := trie.GetValue([]byte("acc/alice"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Owner":"alice","Balance":1,"Tags":null}`, string(raw))
+	var got testAccount
+	require.NoError(t, trie.GetTyped([]byte("acc/alice"), &got))
+	require.Equal(t, account, got)
+
+	// Values failing validation are rejected with structured errors
+	root := trie.Root()
+	err = trie.UpdateTyped([]byte("acc/bob"), testAccount{Balance: 1})
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.ErrorIs(t, err, errNoOwner)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Equal(t, "accounts", validationErr.Namespace)
+	require.Equal(t, []byte("acc/bob"), validationErr.Key)
+
+	err = trie.Update([]byte("acc/carol"), []byte("not json"))
+	require.ErrorIs(t, err, ErrInvalidValue)
+	err = trie.Update([]byte("flag/x"), []byte("too long"))
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.Equal(t, root, trie.Root(), "rejected updates must not modify the trie")
+
+	// Keys outside any namespace are unrestricted
+	require.NoError(t, trie.Update([]byte("flag/x"), []byte{1}))
+	require.NoError(t, trie.Update([]byte("other"), []byte("anything")))
+}
@@ -78,14 +78,15 @@ func encodeExtensionNode(pathBounds [2]byte, path, childData []byte) (data []byt
 	return
 }
 
-// encodeSumInnerNode encodes an inner node for an smst given the data for both children
-func encodeSumInnerNode(leftData, rightData []byte) (data []byte) {
+// encodeSumInnerNode encodes an inner node for an smst given the data for both
+// children, combining their sums with the AggregateFunc provided
+func encodeSumInnerNode(agg AggregateFunc, leftData, rightData []byte) (data []byte) {
 	leftSum, leftCount := parseSumAndCount(leftData)
 	rightSum, rightCount := parseSumAndCount(rightData)
 
 	// Compute the SumBz of the current node
 	var SumBz [sumSizeBytes]byte
-	binary.BigEndian.PutUint64(SumBz[:], leftSum+rightSum)
+	binary.BigEndian.PutUint64(SumBz[:], agg.aggregate(leftSum, leftCount, rightSum, rightCount))
 
 	// Compute the count of the current node
 	var countBz [countSizeBytes]byte
@@ -0,0 +1,223 @@
+package smt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// proofArchiveIndexKey is the key the index of a ProofArchive is stored under
+// in its store, it cannot collide with the root and path keys of the proofs.
+var proofArchiveIndexKey = reserveKey("proof archive", []byte("smt/proof-archive/index"))
+
+// archivedProof is an entry of the index of a ProofArchive
+type archivedProof struct {
+	Key    []byte
+	Size   int
+	Expiry int64 // unix nanoseconds, zero if the proof does not expire
+}
+
+// ProofArchive stores generated proofs keyed by the root they were generated
+// against and the path they prove, so repeated requests for the same proof
+// (e.g. from a public proof endpoint) are served without touching the trie.
+//
+// Proofs expire after the archive's TTL and the oldest proofs are evicted
+// once the total size of the archived proofs exceeds its maximum size. The
+// proofs and the index of the archive are persisted in its store, which
+// should not be used for anything else, so the archive survives restarts.
+// ProofArchive is safe for concurrent use.
+type ProofArchive struct {
+	mu       sync.Mutex
+	store    kvstore.MapStore
+	ttl      time.Duration
+	maxBytes int
+	now      func() time.Time
+	// index holds the archived proofs in insertion order
+	index []archivedProof
+	size  int
+}
+
+// NewProofArchive returns a ProofArchive persisting proofs in the store
+// provided, loading the proofs already archived there. Proofs expire after
+// ttl, or never if it is zero, and the oldest proofs are evicted once the
+// archived proofs exceed maxBytes, or never if it is zero. An error wrapping
+// ErrReservedKeyspace is returned if the store is a PrefixedStore whose prefix
+// overlaps a reserved key.
+func NewProofArchive(store kvstore.MapStore, ttl time.Duration, maxBytes int) (*ProofArchive, error) {
+	if err := checkStoreKeyspaces(store); err != nil {
+		return nil, err
+	}
+	archive := &ProofArchive{store: store, ttl: ttl, maxBytes: maxBytes, now: time.Now}
+	// Stores do not share a not found error, an empty store has no index yet
+	if store.Len() > 0 {
+		indexBz, err := store.Get(proofArchiveIndexKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := gob.NewDecoder(bytes.NewReader(indexBz)).Decode(&archive.index); err != nil {
+			return nil, err
+		}
+	}
+	for _, entry := range archive.index {
+		archive.size += entry.Size
+	}
+	return archive, nil
+}
+
+// SetClock sets the clock proofs expire by, which is the SystemClock by
+// default.
+func (archive *ProofArchive) SetClock(clock Clock) {
+	archive.mu.Lock()
+	defer archive.mu.Unlock()
+	archive.now = clock.Now
+}
+
+// Len returns the number of archived proofs, including expired proofs not
+// evicted yet.
+func (archive *ProofArchive) Len() int {
+	archive.mu.Lock()
+	defer archive.mu.Unlock()
+	return len(archive.index)
+}
+
+// Size returns the total size in bytes of the archived proofs
+func (archive *ProofArchive) Size() int {
+	archive.mu.Lock()
+	defer archive.mu.Unlock()
+	return archive.size
+}
+
+// Put archives the proof for the path provided generated against the root,
+// replacing any proof already archived for them. Proofs larger than the
+// maximum size of the archive are not archived.
+func (archive *ProofArchive) Put(root, path []byte, proof *SparseMerkleProof) error {
+	proofBz, err := proof.Marshal()
+	if err != nil {
+		return err
+	}
+	archive.mu.Lock()
+	defer archive.mu.Unlock()
+	key := archiveKey(root, path)
+	entry := archivedProof{Key: key, Size: len(proofBz)}
+	if archive.maxBytes > 0 && entry.Size > archive.maxBytes {
+		return nil
+	}
+	if archive.ttl > 0 {
+		entry.Expiry = archive.now().Add(archive.ttl).UnixNano()
+	}
+	archive.remove(key)
+	archive.evict(entry.Size)
+
+	value := make([]byte, 8, 8+len(proofBz))
+	binary.BigEndian.PutUint64(value, uint64(entry.Expiry))
+	if err := archive.store.Set(key, append(value, proofBz...)); err != nil {
+		return err
+	}
+	archive.index = append(archive.index, entry)
+	archive.size += entry.Size
+	return archive.saveIndex()
+}
+
+// Get returns the proof archived for the path provided against the root,
+// returning ErrKeyNotFound if there is none or it has expired. As stores do
+// not share a not found error, failing to read the proof from the store is
+// also reported as ErrKeyNotFound.
+func (archive *ProofArchive) Get(root, path []byte) (*SparseMerkleProof, error) {
+	archive.mu.Lock()
+	defer archive.mu.Unlock()
+	value, err := archive.store.Get(archiveKey(root, path))
+	if err != nil || len(value) < 8 {
+		return nil, ErrKeyNotFound
+	}
+	if expiry := int64(binary.BigEndian.Uint64(value[:8])); expiry != 0 && archive.now().UnixNano() >= expiry {
+		return nil, ErrKeyNotFound
+	}
+	proof := new(SparseMerkleProof)
+	if err := proof.Unmarshal(value[8:]); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// Prove returns the proof for the key provided against the current root of
+// the trie, serving it from the archive if possible and otherwise generating
+// and archiving it.
+func (archive *ProofArchive) Prove(trie SparseMerkleTrie, key []byte) (*SparseMerkleProof, error) {
+	path, err := trie.Spec().path(key)
+	if err != nil {
+		return nil, err
+	}
+	root := trie.Root()
+	proof, err := archive.Get(root, path)
+	if !errors.Is(err, ErrKeyNotFound) {
+		return proof, err
+	}
+	if proof, err = trie.Prove(key); err != nil {
+		return nil, err
+	}
+	return proof, archive.Put(root, path, proof)
+}
+
+// Prune evicts every expired proof from the archive
+func (archive *ProofArchive) Prune() error {
+	archive.mu.Lock()
+	defer archive.mu.Unlock()
+	archive.evict(0)
+	return archive.saveIndex()
+}
+
+// evict removes the expired proofs and then the oldest proofs until there is
+// room for a proof of the given size, the caller must hold the lock.
+// Failing to delete an evicted proof from the store only leaks it, so errors
+// are ignored.
+func (archive *ProofArchive) evict(size int) {
+	now := archive.now().UnixNano()
+	kept := archive.index[:0]
+	for _, entry := range archive.index {
+		if entry.Expiry != 0 && now >= entry.Expiry {
+			_ = archive.store.Delete(entry.Key)
+			archive.size -= entry.Size
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	archive.index = kept
+	for archive.maxBytes > 0 && len(archive.index) > 0 && archive.size+size > archive.maxBytes {
+		oldest := archive.index[0]
+		_ = archive.store.Delete(oldest.Key)
+		archive.size -= oldest.Size
+		archive.index = archive.index[1:]
+	}
+}
+
+// remove removes the proof with the key provided from the index, if present,
+// the caller must hold the lock.
+func (archive *ProofArchive) remove(key []byte) {
+	for i, entry := range archive.index {
+		if bytes.Equal(entry.Key, key) {
+			archive.size -= entry.Size
+			archive.index = append(archive.index[:i], archive.index[i+1:]...)
+			return
+		}
+	}
+}
+
+// saveIndex persists the index of the archive, the caller must hold the lock.
+func (archive *ProofArchive) saveIndex() error {
+	buf := bytes.NewBuffer(nil)
+	if err := gob.NewEncoder(buf).Encode(archive.index); err != nil {
+		return err
+	}
+	return archive.store.Set(proofArchiveIndexKey, buf.Bytes())
+}
+
+// archiveKey returns the key a proof is archived under
+func archiveKey(root, path []byte) []byte {
+	key := make([]byte, 0, len(root)+len(path))
+	return append(append(key, root...), path...)
+}
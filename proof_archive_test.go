@@ -0,0 +1,113 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestProofArchive(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := 0; i < 20; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		require.NoError(t, trie.Update(key, []byte(fmt.Sprintf("value-%d", i))))
+	}
+	require.NoError(t, trie.Commit())
+
+	store := simplemap.NewSimpleMap()
+	archive, err := NewProofArchive(store, time.Minute, 0)
+	require.NoError(t, err)
+	now := time.Unix(0, 0)
+	archive.now = func() time.Time { return now }
+
+	key := []byte("key-0")
+	path := trie.Spec().ph.Path(key)
+	_, err = archive.Get(trie.Root(), path)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	proof, err := archive.Prove(trie, key)
+	require.NoError(t, err)
+	require.Equal(t, 1, archive.Len())
+	archived, err := archive.Get(trie.Root(), path)
+	require.NoError(t, err)
+	require.Equal(t, proof, archived)
+
+	// Repeat requests are served from the archive without proving again
+	counting := &countingProver{SparseMerkleTrie: trie}
+	archived, err = archive.Prove(counting, key)
+	require.NoError(t, err)
+	require.Equal(t, proof, archived)
+	require.Zero(t, counting.proves)
+
+	// The archive persists across restarts
+	reopened, err := NewProofArchive(store, time.Minute, 0)
+	require.NoError(t, err)
+	reopened.now = archive.now
+	require.Equal(t, 1, reopened.Len())
+	require.Equal(t, archive.Size(), reopened.Size())
+	archived, err = reopened.Get(trie.Root(), path)
+	require.NoError(t, err)
+	require.Equal(t, proof, archived)
+
+	// Proofs expire after the TTL
+	now = now.Add(time.Minute)
+	_, err = archive.Get(trie.Root(), path)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	require.NoError(t, archive.Prune())
+	require.Equal(t, 0, archive.Len())
+	require.Equal(t, 0, archive.Size())
+	require.Equal(t, 1, store.Len()) // only the index remains
+}
+
+func TestProofArchive_Eviction(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := 0; i < 20; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		require.NoError(t, trie.Update(key, []byte(fmt.Sprintf("value-%d", i))))
+	}
+	require.NoError(t, trie.Commit())
+
+	proof, err := trie.Prove([]byte("key-0"))
+	require.NoError(t, err)
+	proofBz, err := proof.Marshal()
+	require.NoError(t, err)
+
+	// Room for roughly three proofs
+	maxBytes := 3*len(proofBz) + len(proofBz)/2
+	archive, err := NewProofArchive(simplemap.NewSimpleMap(), 0, maxBytes)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		_, err := archive.Prove(trie, []byte(fmt.Sprintf("key-%d", i)))
+		require.NoError(t, err)
+		require.LessOrEqual(t, archive.Size(), maxBytes)
+	}
+	require.Less(t, archive.Len(), 10)
+
+	// The oldest proofs are evicted first
+	_, err = archive.Get(trie.Root(), trie.Spec().ph.Path([]byte("key-0")))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = archive.Get(trie.Root(), trie.Spec().ph.Path([]byte("key-9")))
+	require.NoError(t, err)
+
+	// Proofs larger than the archive are not archived
+	small, err := NewProofArchive(simplemap.NewSimpleMap(), 0, 1)
+	require.NoError(t, err)
+	require.NoError(t, small.Put(trie.Root(), trie.Spec().ph.Path([]byte("key-0")), proof))
+	require.Equal(t, 0, small.Len())
+}
+
+// countingProver counts the proofs generated by the trie it wraps
+type countingProver struct {
+	SparseMerkleTrie
+	proves int
+}
+
+func (trie *countingProver) Prove(key []byte) (*SparseMerkleProof, error) {
+	trie.proves++
+	return trie.SparseMerkleTrie.Prove(key)
+}
@@ -0,0 +1,121 @@
+package smt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// proofCompressionVersion is the version byte prefixing compressed proofs
+	proofCompressionVersion = 1
+	// minProofMatch is the minimum length of a repeated run of bytes encoded
+	// as a back-reference, shorter runs are cheaper to encode literally
+	minProofMatch = 6
+	// maxDecompressedProofBytes bounds the size of decompressed proofs so a
+	// malicious input cannot exhaust memory
+	maxDecompressedProofBytes = 16 << 20
+)
+
+// CompressProofBytes compresses a serialised proof (of any type) of a trie
+// with the spec provided. The serialised proof is LZ77 encoded with the spec's
+// placeholder primed into the window, so that placeholder side nodes and runs
+// of them, as well as digests repeated within the proof (e.g. side nodes shared
+// between the keys of an aggregated proof), are encoded as short
+// back-references to their previous occurrence.
+//
+// The compressed bytes are decompressed with DecompressProofBytes, given the
+// same spec.
+func CompressProofBytes(bz []byte, spec *TrieSpec) []byte {
+	dict := spec.placeholder()
+	buf := make([]byte, 0, len(dict)+len(bz))
+	buf = append(append(buf, dict...), bz...)
+
+	out := []byte{proofCompressionVersion}
+	// positions maps every window of minProofMatch bytes to the position of
+	// its latest occurrence
+	positions := make(map[string]int, len(buf))
+	index := func(from, to int) {
+		for j := from; j < to && j+minProofMatch <= len(buf); j++ {
+			positions[string(buf[j:j+minProofMatch])] = j
+		}
+	}
+	index(0, len(dict))
+
+	literalStart := len(dict)
+	flushLiteral := func(end int) {
+		if end > literalStart {
+			out = binary.AppendUvarint(out, uint64(end-literalStart)<<1)
+			out = append(out, buf[literalStart:end]...)
+		}
+	}
+	for i := len(dict); i < len(buf); {
+		if i+minProofMatch > len(buf) {
+			break
+		}
+		start, ok := positions[string(buf[i:i+minProofMatch])]
+		if !ok {
+			index(i, i+1)
+			i++
+			continue
+		}
+		// Matches may overlap the bytes being encoded, encoding runs
+		length := minProofMatch
+		for i+length < len(buf) && buf[start+length] == buf[i+length] {
+			length++
+		}
+		flushLiteral(i)
+		out = binary.AppendUvarint(out, uint64(length)<<1|1)
+		out = binary.AppendUvarint(out, uint64(i-start))
+		index(i, i+length)
+		i += length
+		literalStart = i
+	}
+	flushLiteral(len(buf))
+	return out
+}
+
+// DecompressProofBytes decompresses a proof compressed with
+// CompressProofBytes for a trie with the spec provided, returning an error
+// wrapping ErrBadProof if the compressed bytes are malformed.
+func DecompressProofBytes(bz []byte, spec *TrieSpec) ([]byte, error) {
+	if len(bz) == 0 || bz[0] != proofCompressionVersion {
+		return nil, errors.Join(ErrBadProof, errors.New("unknown proof compression version"))
+	}
+	dict := spec.placeholder()
+	buf := append([]byte{}, dict...)
+	for i := 1; i < len(bz); {
+		op, n := binary.Uvarint(bz[i:])
+		if n <= 0 {
+			return nil, errors.Join(ErrBadProof, errors.New("truncated compressed proof"))
+		}
+		i += n
+		length := op >> 1
+		if length > maxDecompressedProofBytes-uint64(len(buf)-len(dict)) {
+			return nil, errors.Join(ErrBadProof,
+				fmt.Errorf("decompressed proof exceeds %d bytes", maxDecompressedProofBytes))
+		}
+		if op&1 == 0 {
+			if length > uint64(len(bz)-i) {
+				return nil, errors.Join(ErrBadProof, errors.New("truncated compressed proof"))
+			}
+			buf = append(buf, bz[i:i+int(length)]...)
+			i += int(length)
+			continue
+		}
+		distance, n := binary.Uvarint(bz[i:])
+		if n <= 0 {
+			return nil, errors.Join(ErrBadProof, errors.New("truncated compressed proof"))
+		}
+		i += n
+		if distance == 0 || distance > uint64(len(buf)) {
+			return nil, errors.Join(ErrBadProof, fmt.Errorf("invalid back-reference distance %d", distance))
+		}
+		// Copy byte by byte as the reference may overlap the bytes copied
+		start := len(buf) - int(distance)
+		for j := 0; j < int(length); j++ {
+			buf = append(buf, buf[start+j])
+		}
+	}
+	return buf[len(dict):], nil
+}
@@ -0,0 +1,103 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestCompressProofBytes(t *testing.T) {
+	// Index paths leave the trie sparse along the shared leading zero bits of
+	// its keys, filling proofs with placeholder side nodes
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(),
+		WithPathHasher(NewIndexPathHasher(8)))
+	var keys [][]byte
+	for i := 0; i < 10; i++ {
+		key := IndexKey(uint64(i*1000), 8)
+		keys = append(keys, key)
+		require.NoError(t, trie.Update(key, []byte(fmt.Sprintf("value-%d", i))))
+	}
+	require.NoError(t, trie.Commit())
+
+	var proofs []*SparseMerkleProof
+	for _, key := range keys {
+		proof, err := trie.Prove(key)
+		require.NoError(t, err)
+		proofs = append(proofs, proof)
+	}
+	aggregated, err := AggregateProofs(proofs)
+	require.NoError(t, err)
+	multi, err := trie.ProveMulti(keys)
+	require.NoError(t, err)
+
+	serialised := map[string]func() ([]byte, error){
+		"proof":      proofs[0].Marshal,
+		"aggregated": aggregated.Marshal,
+		"multi":      multi.Marshal,
+	}
+	for name, marshal := range serialised {
+		t.Run(name, func(t *testing.T) {
+			bz, err := marshal()
+			require.NoError(t, err)
+			compressed := CompressProofBytes(bz, trie.Spec())
+			// Proofs of sparse tries compress by over 30%
+			require.Less(t, float64(len(compressed)), 0.7*float64(len(bz)))
+
+			decompressed, err := DecompressProofBytes(compressed, trie.Spec())
+			require.NoError(t, err)
+			require.Equal(t, bz, decompressed)
+		})
+	}
+
+	// The decompressed proof still verifies
+	bz, err := proofs[0].Marshal()
+	require.NoError(t, err)
+	decompressed, err := DecompressProofBytes(CompressProofBytes(bz, trie.Spec()), trie.Spec())
+	require.NoError(t, err)
+	proof := new(SparseMerkleProof)
+	require.NoError(t, proof.Unmarshal(decompressed))
+	valid, err := VerifyProof(proof, trie.Root(), keys[0], []byte("value-0"), trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+}
+
+func TestCompressProofBytes_RoundTrip(t *testing.T) {
+	spec := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New()).Spec()
+	inputs := [][]byte{
+		nil,
+		[]byte("short"),
+		make([]byte, 1000),
+		[]byte("abcabcabcabcabcabcabcabcabcabcabcx"),
+		append(spec.placeholder(), spec.placeholder()...),
+	}
+	for _, input := range inputs {
+		decompressed, err := DecompressProofBytes(CompressProofBytes(input, spec), spec)
+		require.NoError(t, err)
+		require.Equal(t, string(input), string(decompressed))
+	}
+}
+
+func TestDecompressProofBytes_Malformed(t *testing.T) {
+	spec := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New()).Spec()
+	malformed := [][]byte{
+		nil,
+		{0xff},
+		{proofCompressionVersion, 0x80},
+		// Literal longer than the input
+		{proofCompressionVersion, 10 << 1, 'a'},
+		// Back-reference before the start of the window
+		{proofCompressionVersion, 6<<1 | 1, 100},
+		// Back-reference with no distance
+		{proofCompressionVersion, 6<<1 | 1, 0},
+		// Back-reference expanding beyond the size limit
+		{proofCompressionVersion, 0xff, 0xff, 0xff, 0xff, 0x0f, 1},
+	}
+	for _, bz := range malformed {
+		_, err := DecompressProofBytes(bz, spec)
+		require.ErrorIs(t, err, ErrBadProof)
+	}
+}
@@ -0,0 +1,130 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrProofNotPatchable is returned when a proof cannot be patched as the
+// changes applied to the trie affect the leaf being proven, or the position
+// of its path, in which case a new proof must be generated.
+var ErrProofNotPatchable = errors.New("proof not patchable")
+
+// SubtreeChange is the digest of the subtrie holding the leaves whose paths
+// share the first Depth bits of Path, after the trie was changed.
+type SubtreeChange struct {
+	Path   []byte
+	Depth  int
+	Digest []byte
+	// Terminal is true if the subtrie holds at most a single leaf, in which
+	// case it is the deepest change along Path.
+	Terminal bool
+}
+
+// ProofChangeset is the set of subtries changed by a set of updates to a
+// trie, which can be applied to the proofs generated before the updates with
+// PatchProof.
+type ProofChangeset []SubtreeChange
+
+// ProofChangeset returns the changeset of the updates (or deletions) of the
+// keys provided since proofs were last generated, from the current state of
+// the trie. It holds the digest of every subtrie along the path of each key,
+// down to the subtrie holding at most a single leaf.
+func (smt *SMT) ProofChangeset(keys [][]byte) (ProofChangeset, error) {
+	var changes ProofChangeset
+	for _, key := range keys {
+		path := smt.ph.Path(key)
+		node := smt.root
+		var err error
+		depth := 0
+		for ; depth < smt.depth(); depth++ {
+			node, err = smt.resolveLazy(node)
+			if err != nil {
+				return nil, err
+			}
+			if node == nil {
+				break
+			}
+			if leaf, ok := node.(*leafNode); ok {
+				// The subtrie holds a single leaf, it is the leaf itself if the
+				// leaf's path is within the subtrie and empty otherwise.
+				digest := smt.placeholder()
+				if countCommonPrefixBits(leaf.path, path, 0) >= depth {
+					digest = smt.digest(leaf)
+				}
+				changes = append(changes, SubtreeChange{Path: path, Depth: depth, Digest: digest, Terminal: true})
+				break
+			}
+			// Extension nodes are expanded to find the subtrie at every depth
+			if extNode, ok := node.(*extensionNode); ok {
+				node = extNode.expand()
+			}
+			changes = append(changes, SubtreeChange{Path: path, Depth: depth, Digest: smt.digest(node)})
+			inner := node.(*innerNode)
+			if getPathBit(path, depth) == leftChildBit {
+				node = inner.leftChild
+			} else {
+				node = inner.rightChild
+			}
+		}
+		if node == nil {
+			changes = append(changes, SubtreeChange{Path: path, Depth: depth, Digest: smt.placeholder(), Terminal: true})
+		}
+	}
+	return changes, nil
+}
+
+// PatchProof returns a copy of the proof for the given key with its side
+// nodes updated to reflect the changeset provided, so that it verifies
+// against the root of the trie the changeset was generated from.
+//
+// ErrProofNotPatchable is returned if the changeset affects the leaf being
+// proven or the position of its path, e.g. when a leaf was inserted next to
+// it or removed all of its neighbours. The SiblingData of the patched proof
+// is dropped if its closest side node changed, as the changeset does not hold
+// the sibling's data.
+func PatchProof(
+	proof *SparseMerkleProof,
+	key []byte,
+	changeset ProofChangeset,
+	spec *TrieSpec,
+) (*SparseMerkleProof, error) {
+	if err := proof.validateBasic(spec); err != nil {
+		return nil, errors.Join(ErrBadProof, err)
+	}
+	path := spec.ph.Path(key)
+	numSideNodes := len(proof.SideNodes)
+	patched := &SparseMerkleProof{
+		SideNodes:             make([][]byte, numSideNodes),
+		NonMembershipLeafData: proof.NonMembershipLeafData,
+		SiblingData:           proof.SiblingData,
+	}
+	copy(patched.SideNodes, proof.SideNodes)
+
+	for _, change := range changeset {
+		if len(change.Path) != len(path) || change.Depth < 0 || change.Depth > len(path)*8 {
+			return nil, fmt.Errorf("invalid subtree change at depth %d", change.Depth)
+		}
+		common := countCommonPrefixBits(path, change.Path, 0)
+		switch {
+		case common >= change.Depth && change.Depth < numSideNodes && !change.Terminal:
+			// An ancestor of the leaf, recomputed when verifying
+		case common >= change.Depth || common >= numSideNodes:
+			// The subtrie at the position of the leaf changed, or the leaf
+			// was moved up the trie
+			return nil, errors.Join(ErrProofNotPatchable,
+				fmt.Errorf("subtrie at depth %d along the proven path changed", change.Depth))
+		case common == change.Depth-1:
+			// The sibling subtrie at the depth of the common prefix
+			idx := numSideNodes - 1 - common
+			patched.SideNodes[idx] = change.Digest
+			if idx == 0 && !bytes.Equal(change.Digest, proof.SideNodes[0]) {
+				patched.SiblingData = nil
+			}
+		}
+		// Changes deeper within a sibling subtrie are reflected by the change
+		// of the sibling subtrie itself
+	}
+	return patched, nil
+}
@@ -0,0 +1,108 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestPatchProof(t *testing.T) {
+	for _, sumTrie := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sumTrie=%t", sumTrie), func(t *testing.T) {
+			nodes := simplemap.NewSimpleMap()
+			var trie *SMT
+			var update func(key, value []byte) error
+			if sumTrie {
+				smst := NewSparseMerkleSumTrie(nodes, sha256.New())
+				trie = smst.SMT
+				update = func(key, value []byte) error { return smst.Update(key, value, uint64(len(value))) }
+			} else {
+				trie = NewSparseMerkleTrie(nodes, sha256.New())
+				update = trie.Update
+			}
+			for i := 0; i < 100; i++ {
+				key := []byte(fmt.Sprintf("key-%d", i))
+				require.NoError(t, update(key, key))
+			}
+			require.NoError(t, trie.Commit())
+
+			// Prove present and absent keys against the original root
+			var proven [][]byte
+			proofs := make(map[string]*SparseMerkleProof)
+			for i := 0; i < 150; i += 2 {
+				key := []byte(fmt.Sprintf("key-%d", i))
+				proof, err := trie.Prove(key)
+				require.NoError(t, err)
+				proven, proofs[string(key)] = append(proven, key), proof
+			}
+
+			// Change unrelated keys: update, insert and delete
+			var changed [][]byte
+			for i := 1; i < 150; i += 10 {
+				key := []byte(fmt.Sprintf("key-%d", i))
+				if i%20 == 1 && i < 100 {
+					require.NoError(t, trie.Delete(key))
+				} else {
+					require.NoError(t, update(key, append(key, '!')))
+				}
+				changed = append(changed, key)
+			}
+			require.NoError(t, trie.Commit())
+			changeset, err := trie.ProofChangeset(changed)
+			require.NoError(t, err)
+
+			patchedCount := 0
+			for _, key := range proven {
+				fresh, err := trie.Prove(key)
+				require.NoError(t, err)
+				patched, err := PatchProof(proofs[string(key)], key, changeset, trie.Spec())
+				if errors.Is(err, ErrProofNotPatchable) {
+					continue
+				}
+				require.NoError(t, err)
+				patchedCount++
+				require.Equal(t, fresh.SideNodes, patched.SideNodes, "key %s", key)
+				require.Equal(t, fresh.NonMembershipLeafData, patched.NonMembershipLeafData)
+			}
+			require.Greater(t, patchedCount, len(proven)/2)
+
+			// Changing the proven key itself cannot be patched
+			key := proven[0]
+			require.NoError(t, update(key, []byte("new")))
+			changeset, err = trie.ProofChangeset([][]byte{key})
+			require.NoError(t, err)
+			_, err = PatchProof(proofs[string(key)], key, changeset, trie.Spec())
+			require.ErrorIs(t, err, ErrProofNotPatchable)
+		})
+	}
+}
+
+func TestPatchProof_Verify(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := 0; i < 20; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		require.NoError(t, trie.Update(key, []byte("value")))
+	}
+	key := []byte("key-0")
+	proof, err := trie.Prove(key)
+	require.NoError(t, err)
+
+	other := []byte("key-7")
+	require.NoError(t, trie.Update(other, []byte("updated")))
+	changeset, err := trie.ProofChangeset([][]byte{other})
+	require.NoError(t, err)
+
+	valid, err := VerifyProof(proof, trie.Root(), key, []byte("value"), trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+	patched, err := PatchProof(proof, key, changeset, trie.Spec())
+	require.NoError(t, err)
+	valid, err = VerifyProof(patched, trie.Root(), key, []byte("value"), trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+}
@@ -320,10 +320,79 @@ func (proof *SparseCompactMerkleClosestProof) Unmarshal(bz []byte) error {
 
 // VerifyProof verifies a Merkle proof.
 func VerifyProof(proof *SparseMerkleProof, root, key, value []byte, spec *TrieSpec) (bool, error) {
+	if IsEmptyRoot(root, spec) {
+		return verifyEmptyRootProof(proof, key, value, spec)
+	}
 	result, _, err := verifyProofWithUpdates(proof, root, key, value, spec)
 	return result, err
 }
 
+// verifyEmptyRootProof verifies a Merkle proof against the empty root without
+// hashing: no key is in an empty trie, so the only valid proof is that of
+// the absence of the key, with no side nodes and no unrelated leaf.
+func verifyEmptyRootProof(proof *SparseMerkleProof, key, value []byte, spec *TrieSpec) (bool, error) {
+	if _, err := spec.path(key); err != nil {
+		return false, errors.Join(ErrBadProof, err)
+	}
+	if err := proof.validateBasic(spec); err != nil {
+		return false, errors.Join(ErrBadProof, err)
+	}
+	return bytes.Equal(value, defaultEmptyValue) &&
+		len(proof.SideNodes) == 0 &&
+		proof.NonMembershipLeafData == nil, nil
+}
+
+// VerifyEmptyRootProof verifies that the proof is a proof of the absence of
+// the key from an empty trie, as returned by Prove on an empty trie, e.g. to
+// check that a key did not exist in the genesis state of a protocol.
+func VerifyEmptyRootProof(proof *SparseMerkleProof, key []byte, spec *TrieSpec) (bool, error) {
+	return verifyEmptyRootProof(proof, key, defaultEmptyValue, spec)
+}
+
+// ProofMismatchReason describes why a proof does not match a root
+type ProofMismatchReason int
+
+const (
+	// ProofMatches indicates the proof matches the root
+	ProofMatches ProofMismatchReason = iota
+	// ProofRootMismatch indicates the proof is well formed but recomputes to a
+	// different root, the proof is likely outdated and should be refetched.
+	// As proofs do not commit to their root, this is indistinguishable from a
+	// proof of a different value for the key.
+	ProofRootMismatch
+	// ProofMalformed indicates the proof is invalid irrespective of the root
+	// and should be rejected.
+	ProofMalformed
+)
+
+// String returns a human readable description of the reason
+func (reason ProofMismatchReason) String() string {
+	switch reason {
+	case ProofMatches:
+		return "proof matches root"
+	case ProofRootMismatch:
+		return "proof is valid for a different root"
+	case ProofMalformed:
+		return "proof is malformed"
+	}
+	return fmt.Sprintf("unknown proof mismatch reason %d", int(reason))
+}
+
+// ProofMatchesRoot verifies a Merkle proof like VerifyProof, but returns the
+// reason it does not match the root, distinguishing malformed proofs from
+// well formed proofs for a different root, allowing callers to decide
+// between refetching and rejecting the proof.
+func ProofMatchesRoot(proof *SparseMerkleProof, root, key, value []byte, spec *TrieSpec) (bool, ProofMismatchReason) {
+	valid, _, err := verifyProofWithUpdates(proof, root, key, value, spec)
+	if err != nil {
+		return false, ProofMalformed
+	}
+	if !valid {
+		return false, ProofRootMismatch
+	}
+	return true, ProofMatches
+}
+
 // VerifySumProof verifies a Merkle proof for a sum trie.
 func VerifySumProof(proof *SparseMerkleProof, root, key, value []byte, sum, count uint64, spec *TrieSpec) (bool, error) {
 	var sumBz [sumSizeBytes]byte
@@ -344,6 +413,7 @@ func VerifySumProof(proof *SparseMerkleProof, root, key, value []byte, sum, coun
 		ph:      spec.ph,
 		vh:      spec.vh,
 		sumTrie: spec.sumTrie,
+		agg:     spec.agg,
 	}
 
 	nvh := WithValueHasher(nil)
@@ -366,6 +436,7 @@ func VerifyClosestProof(proof *SparseMerkleClosestProof, root []byte, spec *Trie
 		ph:      newNilPathHasher(spec.ph.PathSize()),
 		vh:      spec.vh,
 		sumTrie: spec.sumTrie,
+		agg:     spec.agg,
 	}
 
 	// Verify the closest proof for a basic SMT
@@ -398,7 +469,10 @@ func verifyProofWithUpdates(
 	spec *TrieSpec,
 ) (bool, [][][]byte, error) {
 	// Retrieve the trie path for the key being proven
-	path := spec.ph.Path(key)
+	path, err := spec.path(key)
+	if err != nil {
+		return false, nil, errors.Join(ErrBadProof, err)
+	}
 
 	if err := proof.validateBasic(spec); err != nil {
 		return false, nil, errors.Join(ErrBadProof, err)
@@ -209,3 +209,27 @@ func checkClosestCompactEquivalence(t *testing.T, proof *SparseMerkleClosestProo
 	require.NoErrorf(t, err, "failed to decompact proof: %v", err)
 	require.Equal(t, proof, decompactedProof)
 }
+
+func TestProofMatchesRoot(t *testing.T) {
+	trie := setupTrie(t)
+	oldRoot := trie.Root()
+	proof, err := trie.Prove([]byte("key"))
+	require.NoError(t, err)
+
+	matches, reason := ProofMatchesRoot(proof, oldRoot, []byte("key"), []byte("value"), trie.Spec())
+	require.True(t, matches)
+	require.Equal(t, ProofMatches, reason)
+
+	// The proof is outdated once the trie is updated
+	require.NoError(t, trie.Update([]byte("key4"), []byte("value4")))
+	matches, reason = ProofMatchesRoot(proof, trie.Root(), []byte("key"), []byte("value"), trie.Spec())
+	require.False(t, matches)
+	require.Equal(t, ProofRootMismatch, reason)
+
+	// Malformed proofs are rejected irrespective of the root
+	proof.SideNodes = append(proof.SideNodes, make([][]byte, trie.Spec().ph.PathSize()*8)...)
+	matches, reason = ProofMatchesRoot(proof, oldRoot, []byte("key"), []byte("value"), trie.Spec())
+	require.False(t, matches)
+	require.Equal(t, ProofMalformed, reason)
+	require.Equal(t, "proof is malformed", reason.String())
+}
@@ -0,0 +1,100 @@
+package smt
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// The number of bytes in the keys and values of randomly built tries
+const randomTreeEntrySize = 32
+
+// KeyValue is a key-value pair inserted into a randomly built trie
+type KeyValue struct {
+	Key   []byte
+	Value []byte
+}
+
+// KeyValueWeight is a key-value pair and its weight inserted into a randomly
+// built sum trie
+type KeyValueWeight struct {
+	KeyValue
+	Weight uint64
+}
+
+// BuildRandomTree builds and commits an in-memory trie with the spec provided
+// holding n random key-value pairs, returning the trie and the pairs in
+// insertion order. The same seed always builds the same trie, making it
+// suitable for reproducible benchmarks and interoperability tests.
+func BuildRandomTree(seed int64, n int, spec *TrieSpec) (*SMT, []KeyValue, error) {
+	return BuildRandomTreeFromSource(rand.NewSource(seed), n, spec)
+}
+
+// BuildRandomTreeFromSource is BuildRandomTree drawing its randomness from
+// the source provided, allowing embedders (e.g. simulation frameworks) to
+// control all randomness.
+func BuildRandomTreeFromSource(src rand.Source, n int, spec *TrieSpec) (*SMT, []KeyValue, error) {
+	if spec.sumTrie {
+		return nil, nil, errors.New("cannot build a trie with a sum trie spec")
+	}
+	r := rand.New(src)
+	trie := &SMT{TrieSpec: *spec, nodes: simplemap.NewSimpleMap()}
+	entries := make([]KeyValue, n)
+	for i := range entries {
+		entries[i] = randomKeyValue(r)
+		if err := trie.Update(entries[i].Key, entries[i].Value); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := trie.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return trie, entries, nil
+}
+
+// BuildRandomSumTree builds and commits an in-memory sum trie with the spec
+// provided holding n random key-value pairs with random weights below
+// maxWeight, returning the trie and the entries in insertion order. The same
+// seed always builds the same trie.
+func BuildRandomSumTree(seed int64, n int, maxWeight uint64, spec *TrieSpec) (*SMST, []KeyValueWeight, error) {
+	return BuildRandomSumTreeFromSource(rand.NewSource(seed), n, maxWeight, spec)
+}
+
+// BuildRandomSumTreeFromSource is BuildRandomSumTree drawing its randomness
+// from the source provided.
+func BuildRandomSumTreeFromSource(
+	src rand.Source,
+	n int,
+	maxWeight uint64,
+	spec *TrieSpec,
+) (*SMST, []KeyValueWeight, error) {
+	if !spec.sumTrie {
+		return nil, nil, errors.New("cannot build a sum trie with a non-sum trie spec")
+	}
+	if maxWeight == 0 {
+		return nil, nil, errors.New("max weight must be positive")
+	}
+	r := rand.New(src)
+	trie := &SMST{TrieSpec: *spec, SMT: &SMT{TrieSpec: *spec, nodes: simplemap.NewSimpleMap()}}
+	WithValueHasher(nil)(&trie.SMT.TrieSpec)
+	entries := make([]KeyValueWeight, n)
+	for i := range entries {
+		entries[i] = KeyValueWeight{KeyValue: randomKeyValue(r), Weight: uint64(r.Int63()) % maxWeight}
+		if err := trie.Update(entries[i].Key, entries[i].Value, entries[i].Weight); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := trie.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return trie, entries, nil
+}
+
+// randomKeyValue returns a random key-value pair read from the source provided
+func randomKeyValue(r *rand.Rand) KeyValue {
+	kv := KeyValue{Key: make([]byte, randomTreeEntrySize), Value: make([]byte, randomTreeEntrySize)}
+	r.Read(kv.Key)   // nolint: errcheck
+	r.Read(kv.Value) // nolint: errcheck
+	return kv
+}
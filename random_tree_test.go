@@ -0,0 +1,83 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRandomTree(t *testing.T) {
+	spec := NewTrieSpec(sha256.New(), false)
+	trie, entries, err := BuildRandomTree(42, 100, &spec)
+	require.NoError(t, err)
+	require.Len(t, entries, 100)
+
+	same, sameEntries, err := BuildRandomTree(42, 100, &spec)
+	require.NoError(t, err)
+	require.Equal(t, entries, sameEntries)
+	require.Equal(t, trie.Root(), same.Root())
+
+	other, _, err := BuildRandomTree(43, 100, &spec)
+	require.NoError(t, err)
+	require.NotEqual(t, trie.Root(), other.Root())
+
+	_, _, err = BuildRandomSumTree(42, 100, 10, &spec)
+	require.Error(t, err)
+
+	for _, entry := range entries[:10] {
+		proof, err := trie.Prove(entry.Key)
+		require.NoError(t, err)
+		valid, err := VerifyProof(proof, trie.Root(), entry.Key, entry.Value, &spec)
+		require.NoError(t, err)
+		require.True(t, valid)
+	}
+}
+
+func TestBuildRandomSumTree(t *testing.T) {
+	spec := NewTrieSpec(sha256.New(), true)
+	trie, entries, err := BuildRandomSumTree(7, 50, 1000, &spec)
+	require.NoError(t, err)
+	require.Len(t, entries, 50)
+
+	var sum uint64
+	for _, entry := range entries {
+		require.Less(t, entry.Weight, uint64(1000))
+		sum += entry.Weight
+	}
+	require.Equal(t, sum, trie.Sum())
+	require.Equal(t, uint64(50), trie.Count())
+
+	same, _, err := BuildRandomSumTree(7, 50, 1000, &spec)
+	require.NoError(t, err)
+	require.Equal(t, trie.Root(), same.Root())
+
+	_, _, err = BuildRandomTree(7, 50, &spec)
+	require.Error(t, err)
+	_, _, err = BuildRandomSumTree(7, 50, 0, &spec)
+	require.Error(t, err)
+
+	entry := entries[0]
+	proof, err := trie.Prove(entry.Key)
+	require.NoError(t, err)
+	valid, err := VerifySumProof(proof, trie.Root(), entry.Key, entry.Value, entry.Weight, 1, &spec)
+	require.NoError(t, err)
+	require.True(t, valid)
+}
+
+func TestBuildRandomTreeFromSource(t *testing.T) {
+	spec := NewTrieSpec(sha256.New(), false)
+	seeded, _, err := BuildRandomTree(1, 20, &spec)
+	require.NoError(t, err)
+	sourced, _, err := BuildRandomTreeFromSource(rand.NewSource(1), 20, &spec)
+	require.NoError(t, err)
+	require.Equal(t, seeded.Root(), sourced.Root())
+
+	sumSpec := NewTrieSpec(sha256.New(), true)
+	seededSum, _, err := BuildRandomSumTree(1, 20, 100, &sumSpec)
+	require.NoError(t, err)
+	sourcedSum, _, err := BuildRandomSumTreeFromSource(rand.NewSource(1), 20, 100, &sumSpec)
+	require.NoError(t, err)
+	require.Equal(t, seededSum.Root(), sourcedSum.Root())
+}
@@ -0,0 +1,112 @@
+package smt
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// RangeLeaf is a leaf of a trie returned by Range
+type RangeLeaf struct {
+	Path []byte
+	// ValueHash is the value hash of the leaf, for sum tries this includes
+	// the leaf's sum and count
+	ValueHash []byte
+}
+
+// Range returns every leaf of the trie whose path falls in the interval from
+// startPath (inclusive) to endPath (exclusive), in ascending path order. Both
+// paths must be the size of a path, and the interval is empty if endPath is
+// not greater than startPath. As keys are hashed into paths, ranges split the
+// trie into contiguous chunks, e.g. for state sync. Only the subtries
+// overlapping the interval are visited, lazy nodes are resolved and cached
+// along the way.
+func (smt *SMT) Range(startPath, endPath []byte) ([]RangeLeaf, error) {
+	if smt.closed {
+		return nil, ErrClosed
+	}
+	pathSize := smt.ph.PathSize()
+	if len(startPath) != pathSize || len(endPath) != pathSize {
+		return nil, fmt.Errorf("invalid range paths of %d and %d bytes: must be %d bytes", len(startPath), len(endPath), pathSize)
+	}
+	var leaves []RangeLeaf
+	if bytes.Compare(startPath, endPath) >= 0 {
+		return leaves, nil
+	}
+	r := &pathRange{start: startPath, end: endPath}
+	if err := smt.rangeLeaves(&smt.root, 0, false, false, r, &leaves); err != nil {
+		return nil, err
+	}
+	return leaves, nil
+}
+
+// pathRange is the interval of paths of a call to Range
+type pathRange struct {
+	start, end []byte
+}
+
+// bit checks whether the subtrie below the path bit provided at the given
+// depth can hold leaves in the range, given whether the bits above it already
+// place it after the start and before the end of the range, and returns the
+// updated positions.
+func (r *pathRange) bit(bit, depth int, afterStart, beforeEnd bool) (overlaps, after, before bool) {
+	if !afterStart {
+		startBit := getPathBit(r.start, depth)
+		if bit < startBit {
+			return false, false, false
+		}
+		afterStart = bit > startBit
+	}
+	if !beforeEnd {
+		endBit := getPathBit(r.end, depth)
+		if bit > endBit {
+			return false, false, false
+		}
+		beforeEnd = bit < endBit
+	}
+	return true, afterStart, beforeEnd
+}
+
+// rangeLeaves appends the leaves in the range of the subtrie rooted at the
+// node at the given depth to the leaves provided
+func (smt *SMT) rangeLeaves(node *trieNode, depth int, afterStart, beforeEnd bool, r *pathRange, leaves *[]RangeLeaf) error {
+	var err error
+	*node, err = smt.resolveLazy(*node)
+	if err != nil {
+		return err
+	}
+	switch n := (*node).(type) {
+	case *leafNode:
+		if bytes.Compare(n.path, r.start) >= 0 && bytes.Compare(n.path, r.end) < 0 {
+			*leaves = append(*leaves, RangeLeaf{Path: bytes.Clone(n.path), ValueHash: bytes.Clone(n.valueHash)})
+		}
+	case *extensionNode:
+		for i := n.pathStart(); i < n.pathEnd(); i++ {
+			var overlaps bool
+			if overlaps, afterStart, beforeEnd = r.bit(getPathBit(n.path, i), i, afterStart, beforeEnd); !overlaps {
+				return nil
+			}
+		}
+		return smt.rangeLeaves(&n.child, n.pathEnd(), afterStart, beforeEnd, r, leaves)
+	case *innerNode:
+		for bit, child := range []*trieNode{&n.leftChild, &n.rightChild} {
+			overlaps, after, before := r.bit(bit, depth, afterStart, beforeEnd)
+			if !overlaps {
+				continue
+			}
+			if err := smt.rangeLeaves(child, depth+1, after, before, r, leaves); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Range returns every leaf of the trie whose path falls in the interval from
+// startPath (inclusive) to endPath (exclusive), see SMT.Range.
+func (smt *SMTWithStorage) Range(startPath, endPath []byte) ([]RangeLeaf, error) {
+	smt.commitMu.RLock()
+	defer smt.commitMu.RUnlock()
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	return smt.trie.Range(startPath, endPath)
+}
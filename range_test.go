@@ -0,0 +1,81 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_Range(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	var paths [][]byte
+	valueHashes := make(map[string][]byte)
+	for i := 0; i < 200; i++ {
+		key := []byte(fmt.Sprintf("key%d", i))
+		require.NoError(t, trie.Update(key, key))
+		paths = append(paths, trie.ph.Path(key))
+		valueHashes[string(trie.ph.Path(key))] = trie.valueHash(key)
+	}
+	sort.Slice(paths, func(i, j int) bool { return bytes.Compare(paths[i], paths[j]) < 0 })
+	require.NoError(t, trie.Commit())
+	trie = ImportSparseMerkleTrie(trie.nodes, sha256.New(), trie.Root())
+
+	first, last := make([]byte, 32), bytes.Repeat([]byte{0xff}, 32)
+	leaves, err := trie.Range(first, last)
+	require.NoError(t, err)
+	require.Len(t, leaves, len(paths))
+
+	// Ranges bounded by leaves include their start but not their end
+	leaves, err = trie.Range(paths[10], paths[20])
+	require.NoError(t, err)
+	require.Len(t, leaves, 10)
+	for i, leaf := range leaves {
+		require.Equal(t, paths[10+i], leaf.Path)
+		require.Equal(t, valueHashes[string(leaf.Path)], leaf.ValueHash)
+	}
+
+	// The leaves returned do not alias the trie's nodes
+	leaves[0].Path[0] ^= 0xff
+	leaves[0].ValueHash[0] ^= 0xff
+	leaves, err = trie.Range(paths[10], paths[11])
+	require.NoError(t, err)
+	require.Equal(t, paths[10], leaves[0].Path)
+	require.Equal(t, valueHashes[string(paths[10])], leaves[0].ValueHash)
+
+	// Random ranges hold exactly the leaves within them
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		start, end := make([]byte, 32), make([]byte, 32)
+		rng.Read(start)
+		rng.Read(end)
+		if bytes.Compare(start, end) > 0 {
+			start, end = end, start
+		}
+		var expected [][]byte
+		for _, path := range paths {
+			if bytes.Compare(path, start) >= 0 && bytes.Compare(path, end) < 0 {
+				expected = append(expected, path)
+			}
+		}
+		leaves, err := trie.Range(start, end)
+		require.NoError(t, err)
+		var got [][]byte
+		for _, leaf := range leaves {
+			got = append(got, leaf.Path)
+		}
+		require.Equal(t, expected, got)
+	}
+
+	leaves, err = trie.Range(paths[20], paths[10])
+	require.NoError(t, err)
+	require.Empty(t, leaves)
+	_, err = trie.Range(first[:4], last)
+	require.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package smt
+
+import (
+	"bytes"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Ensure the tracingStore can be used as an SMT node store
+var _ kvstore.MapStore = (*tracingStore)(nil)
+
+// NodeRead is a single read of a node from the node store
+type NodeRead struct {
+	// Depth is the depth (in bits) in the trie of the node read
+	Depth int
+	// Digest is the key the node was read from the node store with
+	Digest []byte
+	// Bytes is the size of the encoded node read
+	Bytes int
+}
+
+// ReadTrace is the trace of every node store read made by a single query.
+// Nodes already cached in memory (e.g. resolved by an earlier query or not yet
+// committed) are not read from the node store and so do not appear in it.
+type ReadTrace struct {
+	Reads []NodeRead
+}
+
+// TotalBytes returns the number of bytes read from the node store
+func (trace *ReadTrace) TotalBytes() (total int) {
+	for _, read := range trace.Reads {
+		total += read.Bytes
+	}
+	return total
+}
+
+// TraceGet is a debug variant of Get that also returns the trace of every
+// node store read made to retrieve the value hash, for tuning caches and
+// diagnosing pathological key distributions.
+func (smt *SMT) TraceGet(key []byte) ([]byte, *ReadTrace, error) {
+	var valueHash []byte
+	trace, err := smt.trace(func() (err error) {
+		valueHash, err = smt.Get(key)
+		return err
+	})
+	return valueHash, trace, err
+}
+
+// TraceProve is a debug variant of Prove that also returns the trace of every
+// node store read made to generate the proof.
+func (smt *SMT) TraceProve(key []byte) (*SparseMerkleProof, *ReadTrace, error) {
+	var proof *SparseMerkleProof
+	trace, err := smt.trace(func() (err error) {
+		proof, err = smt.Prove(key)
+		return err
+	})
+	return proof, trace, err
+}
+
+// trace runs the query provided recording the node store reads it makes. As
+// a query follows a single path from the root, the depth of every node read
+// is derived from the type of the node read before it.
+func (smt *SMT) trace(query func() error) (*ReadTrace, error) {
+	store := &tracingStore{MapStore: smt.nodes}
+	smt.nodes = store
+	defer func() { smt.nodes = store.MapStore }()
+	err := query()
+
+	trace := &ReadTrace{Reads: store.reads}
+	depth := 0
+	for i, data := range store.data {
+		trace.Reads[i].Depth = depth
+		switch {
+		case isInnerNode(data):
+			depth++
+		case isExtNode(data):
+			depth = int(data[prefixLen+1])
+		}
+	}
+	return trace, err
+}
+
+// tracingStore is a node store recording every successful read made through it
+type tracingStore struct {
+	kvstore.MapStore
+	reads []NodeRead
+	data  [][]byte
+}
+
+// Get satisfies the MapStore#Get interface
+func (store *tracingStore) Get(key []byte) ([]byte, error) {
+	data, err := store.MapStore.Get(key)
+	if err != nil {
+		return data, err
+	}
+	store.reads = append(store.reads, NodeRead{Digest: bytes.Clone(key), Bytes: len(data)})
+	store.data = append(store.data, data)
+	return data, nil
+}
+
+// TraceGet is a debug variant of Get that also returns the trace of every
+// node store read made, see SMT.TraceGet. As tracing intercepts the node
+// store it blocks all other operations until it returns.
+func (smt *SMTWithStorage) TraceGet(key []byte) ([]byte, *ReadTrace, error) {
+	smt.commitMu.Lock()
+	defer smt.commitMu.Unlock()
+	return smt.trie.TraceGet(key)
+}
+
+// TraceProve is a debug variant of Prove that also returns the trace of every
+// node store read made, see SMT.TraceProve. As tracing intercepts the node
+// store it blocks all other operations until it returns.
+func (smt *SMTWithStorage) TraceProve(key []byte) (*SparseMerkleProof, *ReadTrace, error) {
+	smt.commitMu.Lock()
+	defer smt.commitMu.Unlock()
+	return smt.trie.TraceProve(key)
+}
@@ -0,0 +1,64 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_ReadTrace(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleTrie(nodes, sha256.New())
+	for i := 0; i < 64; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key%d", i)), []byte{byte(i)}))
+	}
+
+	// Uncommitted nodes are in memory and never read from the store
+	_, trace, err := trie.TraceGet([]byte("key1"))
+	require.NoError(t, err)
+	require.Empty(t, trace.Reads)
+
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+	trie = ImportSparseMerkleTrie(nodes, sha256.New(), root)
+
+	valueHash, trace, err := trie.TraceGet([]byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, trie.valueHash([]byte{1}), valueHash)
+	require.NotEmpty(t, trace.Reads)
+	require.Equal(t, 0, trace.Reads[0].Depth)
+	require.Equal(t, []byte(root), trace.Reads[0].Digest)
+	var total int
+	for i, read := range trace.Reads {
+		data, err := nodes.Get(read.Digest)
+		require.NoError(t, err)
+		require.Equal(t, len(data), read.Bytes)
+		total += read.Bytes
+		if i > 0 {
+			require.Greater(t, read.Depth, trace.Reads[i-1].Depth)
+		}
+	}
+	require.Equal(t, total, trace.TotalBytes())
+	last := trace.Reads[len(trace.Reads)-1]
+	data, err := nodes.Get(last.Digest)
+	require.NoError(t, err)
+	require.True(t, isLeafNode(data))
+
+	// The resolved nodes are now cached so reading again is free
+	_, trace, err = trie.TraceGet([]byte("key1"))
+	require.NoError(t, err)
+	require.Empty(t, trace.Reads)
+
+	// Proving another key only reads the nodes not already resolved
+	proof, trace, err := trie.TraceProve([]byte("key2"))
+	require.NoError(t, err)
+	require.NotEmpty(t, trace.Reads)
+	valid, err := VerifyProof(proof, root, []byte("key2"), []byte{2}, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+	require.Equal(t, nodes, trie.nodes)
+}
@@ -0,0 +1,134 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// ErrReservedKeyspace is returned when a key prefix supplied by the user
+// overlaps with the keys the library reserves for its own persisted state.
+var ErrReservedKeyspace = errors.New("prefix overlaps reserved keyspace")
+
+// ReservedKey is a key, or key prefix, the library persists its internal
+// state under in the stores it is given. Reserved keys are never renamed, so
+// that stores written by previous versions remain readable.
+type ReservedKey struct {
+	// Key is the reserved key, or key prefix if Prefix is true
+	Key []byte
+	// Prefix is true if every key starting with Key is reserved
+	Prefix bool
+	// Owner describes the subsystem persisting state under the key
+	Owner string
+}
+
+// overlaps returns true if a key starting with the prefix provided may
+// collide with the reserved key
+func (reserved ReservedKey) overlaps(prefix []byte) bool {
+	if reserved.Prefix && bytes.HasPrefix(prefix, reserved.Key) {
+		return true
+	}
+	return bytes.HasPrefix(reserved.Key, prefix)
+}
+
+// reservedKeys is the registry of reserved keys, added to by the subsystems
+// declaring them
+var reservedKeys []ReservedKey
+
+// reserveKey registers the key as reserved by its owner and returns it
+func reserveKey(owner string, key []byte) []byte {
+	return reserve(ReservedKey{Key: key, Owner: owner})
+}
+
+// reservePrefix registers every key with the prefix as reserved by its owner
+// and returns it
+func reservePrefix(owner string, prefix []byte) []byte {
+	return reserve(ReservedKey{Key: prefix, Prefix: true, Owner: owner})
+}
+
+// reserve registers the reserved key, panicking if it collides with a key
+// reserved by another subsystem, so that conflicting subsystems are caught as
+// soon as the package is loaded rather than silently clobbering each other.
+func reserve(key ReservedKey) []byte {
+	if err := checkReserved(reservedKeys, key); err != nil {
+		panic(err)
+	}
+	reservedKeys = append(reservedKeys, key)
+	return key.Key
+}
+
+// checkReserved returns an error if the key collides with any of the
+// reserved keys provided
+func checkReserved(reserved []ReservedKey, key ReservedKey) error {
+	for _, r := range reserved {
+		if collides(r, key) {
+			return errors.Join(
+				ErrReservedKeyspace,
+				fmt.Errorf("%q reserved by %s collides with %q reserved by %s", key.Key, key.Owner, r.Key, r.Owner),
+			)
+		}
+	}
+	return nil
+}
+
+// collides returns true if the two reserved keys share any key
+func collides(a, b ReservedKey) bool {
+	if a.Prefix && bytes.HasPrefix(b.Key, a.Key) {
+		return true
+	}
+	if b.Prefix && bytes.HasPrefix(a.Key, b.Key) {
+		return true
+	}
+	return bytes.Equal(a.Key, b.Key)
+}
+
+// ReservedKeys returns every key and key prefix reserved by the library
+func ReservedKeys() []ReservedKey {
+	keys := make([]ReservedKey, len(reservedKeys))
+	copy(keys, reservedKeys)
+	return keys
+}
+
+// CheckKeyspace returns an error wrapping ErrReservedKeyspace if keys with the
+// given prefix may collide with a reserved key. Applications sharing a store
+// with a trie (or any other subsystem of the library) should check the prefix
+// they store their own keys under.
+func CheckKeyspace(prefix []byte) error {
+	for _, reserved := range reservedKeys {
+		if reserved.overlaps(prefix) {
+			return errors.Join(
+				ErrReservedKeyspace,
+				fmt.Errorf("%q overlaps %q reserved by %s", prefix, reserved.Key, reserved.Owner),
+			)
+		}
+	}
+	return nil
+}
+
+// PrefixedStore is a MapStore keeping its keys under a prefix of an
+// underlying store shared with other data, e.g. with another trie. The
+// constructors returning an error (e.g. ImportSMTWithStorage and
+// NewShardedSMTWithStores) check the prefixes of the stores they are given
+// with CheckKeyspace, so a trie's keys cannot clobber the reserved keys of
+// another subsystem sharing the underlying store. Callers of the other
+// constructors should check the prefix themselves.
+type PrefixedStore interface {
+	kvstore.MapStore
+	// Prefix returns the prefix the store's keys are kept under
+	Prefix() []byte
+}
+
+// checkStoreKeyspaces checks the prefix of every PrefixedStore provided
+// with CheckKeyspace
+func checkStoreKeyspaces(stores ...kvstore.MapStore) error {
+	for _, store := range stores {
+		if prefixed, ok := store.(PrefixedStore); ok {
+			if err := CheckKeyspace(prefixed.Prefix()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestReservedKeys(t *testing.T) {
+	owners := make(map[string]bool)
+	for _, reserved := range ReservedKeys() {
+		owners[reserved.Owner] = true
+	}
+	for _, owner := range []string{"commit journal", "health checks", "metrics", "key preimages", "snapshots"} {
+		require.True(t, owners[owner], owner)
+	}
+
+	// Prefixes covering, or covered by, reserved keys are rejected
+	require.ErrorIs(t, CheckKeyspace([]byte("smt/")), ErrReservedKeyspace)
+	require.ErrorIs(t, CheckKeyspace([]byte("smt/metrics")), ErrReservedKeyspace)
+	require.ErrorIs(t, CheckKeyspace([]byte("smt/key/app")), ErrReservedKeyspace)
+	require.ErrorIs(t, CheckKeyspace(nil), ErrReservedKeyspace)
+	require.ErrorIs(t, CheckKeyspace([]byte("smt/metrics/app")), ErrReservedKeyspace)
+	// Keys under a reserved key (but not prefix) can never equal it
+	require.NoError(t, CheckKeyspace([]byte("smt/commit-journal/app")))
+	require.NoError(t, CheckKeyspace([]byte("app/")))
+}
+
+func TestReservedKeys_Collisions(t *testing.T) {
+	reserved := []ReservedKey{
+		{Key: []byte("smt/a"), Owner: "a"},
+		{Key: []byte("smt/b/"), Prefix: true, Owner: "b"},
+	}
+	require.ErrorIs(t, checkReserved(reserved, ReservedKey{Key: []byte("smt/a")}), ErrReservedKeyspace)
+	require.ErrorIs(t, checkReserved(reserved, ReservedKey{Key: []byte("smt/b/c")}), ErrReservedKeyspace)
+	require.ErrorIs(t, checkReserved(reserved, ReservedKey{Key: []byte("smt/"), Prefix: true}), ErrReservedKeyspace)
+	require.ErrorIs(t, checkReserved(reserved, ReservedKey{Key: []byte("smt/b/c/"), Prefix: true}), ErrReservedKeyspace)
+	require.NoError(t, checkReserved(reserved, ReservedKey{Key: []byte("smt/ab")}))
+	require.NoError(t, checkReserved(reserved, ReservedKey{Key: []byte("smt/c/"), Prefix: true}))
+
+	// The library's own reserved keys never collide
+	for i, key := range reservedKeys {
+		require.NoError(t, checkReserved(reservedKeys[:i], key))
+	}
+}
+
+// prefixedStore is a PrefixedStore over a simple map
+type prefixedStore struct {
+	kvstore.MapStore
+	prefix []byte
+}
+
+func (store *prefixedStore) Prefix() []byte {
+	return store.prefix
+}
+
+func TestReservedKeys_PrefixedStores(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSMTWithStorage(nodes, simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, trie.Commit())
+
+	// Stores under prefixes overlapping reserved keys are rejected
+	reserved := &prefixedStore{MapStore: simplemap.NewSimpleMap(), prefix: []byte("smt/")}
+	_, err := ImportSMTWithStorage(nodes, reserved, sha256.New(), trie.Root())
+	require.ErrorIs(t, err, ErrReservedKeyspace)
+	_, err = ImportSMTWithSnapshots(reserved, simplemap.NewSimpleMap(), sha256.New())
+	require.ErrorIs(t, err, ErrReservedKeyspace)
+	_, err = NewShardedSMT(reserved, 2, sha256.New)
+	require.ErrorIs(t, err, ErrReservedKeyspace)
+	_, err = NewProofArchive(reserved, 0, 0)
+	require.ErrorIs(t, err, ErrReservedKeyspace)
+	_, err = ImportDecayingSparseMerkleSumTrie(reserved, simplemap.NewSimpleMap(), sha256.New(), LinearDecay(1), nil)
+	require.ErrorIs(t, err, ErrReservedKeyspace)
+
+	// While those under other prefixes are accepted
+	preimages := &prefixedStore{MapStore: simplemap.NewSimpleMap(), prefix: []byte("app/")}
+	_, err = ImportSMTWithStorage(nodes, preimages, sha256.New(), trie.Root())
+	require.NoError(t, err)
+	_, err = NewShardedSMT(preimages, 2, sha256.New)
+	require.NoError(t, err)
+}
@@ -0,0 +1,94 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// SetRoot repoints the trie at the committed root provided, discarding any
+// uncommitted changes, so that it continues operating from that root. The
+// root's nodes must still be in the node store: as commits delete the nodes
+// they orphan, only the latest committed root and the roots retained by an
+// SMTWithSnapshots can be restored. If the root's node cannot be read an
+// error wrapping ErrKeyNotFound is returned and the trie is left unchanged.
+// The nodes of the root the trie is moved away from are not deleted.
+func (smt *SMT) SetRoot(root MerkleRoot) error {
+	if smt.closed {
+		return ErrClosed
+	}
+	if !bytes.Equal(root, smt.placeholder()) {
+		if _, err := smt.nodes.Get(root); err != nil {
+			return errors.Join(ErrKeyNotFound, fmt.Errorf("root %x not in node store", []byte(root)), err)
+		}
+	}
+	smt.root = &lazyNode{bytes.Clone(root)}
+	smt.rootHash = bytes.Clone(root)
+	smt.orphans = nil
+	smt.updates = 0
+	return nil
+}
+
+// Discard throws away every update and delete made since the trie was last
+// committed (or imported), reverting it to its committed root. As updates
+// only modify the trie in memory until it is committed, nothing needs to be
+// removed from the node store.
+func (smt *SMT) Discard() error {
+	if smt.closed {
+		return ErrClosed
+	}
+	smt.root = nil
+	if smt.rootHash != nil && !bytes.Equal(smt.rootHash, smt.placeholder()) {
+		smt.root = &lazyNode{smt.rootHash}
+	}
+	smt.orphans = nil
+	smt.updates = 0
+	return nil
+}
+
+// Discard throws away every change made since the trie was last committed,
+// including the pending values, see SMT.Discard. As for SetRoot, the usage of
+// namespaces is not reverted.
+func (smt *SMTWithStorage) Discard() error {
+	smt.commitMu.Lock()
+	defer smt.commitMu.Unlock()
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	if err := smt.trie.Discard(); err != nil {
+		return err
+	}
+	smt.clearPending()
+	return nil
+}
+
+// SetRoot repoints the trie at the committed root provided, see SMT.SetRoot.
+// Pending values are discarded along with the other uncommitted changes,
+// while the values of the root the trie is moved away from remain in the
+// preimages store. The usage of namespaces is not rolled back, and should be
+// restored with SetNamespaceUsage if quotas are enforced.
+func (smt *SMTWithStorage) SetRoot(root MerkleRoot) error {
+	smt.commitMu.Lock()
+	defer smt.commitMu.Unlock()
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	if err := smt.trie.SetRoot(root); err != nil {
+		return err
+	}
+	smt.clearPending()
+	return nil
+}
+
+// Rollback repoints the trie at the retained snapshot root provided,
+// discarding any uncommitted changes, see SMT.SetRoot. ErrSnapshotNotFound is
+// returned if the root is not retained. Later snapshots are retained until
+// pruned, and the next commit is retained as a new snapshot at the next
+// height.
+func (trie *SMTWithSnapshots) Rollback(root MerkleRoot) error {
+	if trie.closed {
+		return ErrClosed
+	}
+	if !trie.retained(root) {
+		return ErrSnapshotNotFound
+	}
+	return trie.SetRoot(root)
+}
@@ -0,0 +1,118 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_SetRoot(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v1")))
+	require.NoError(t, trie.Commit())
+	committed := trie.Root()
+
+	// Uncommitted changes are discarded
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v2")))
+	require.NoError(t, trie.Update([]byte("bar"), []byte("v2")))
+	require.NoError(t, trie.SetRoot(committed))
+	require.Equal(t, committed, trie.Root())
+	got, err := trie.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, trie.valueHash([]byte("v1")), got)
+
+	// Roots whose nodes were pruned cannot be restored
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v3")))
+	require.NoError(t, trie.Commit())
+	latest := trie.Root()
+	require.ErrorIs(t, trie.SetRoot(committed), ErrKeyNotFound)
+	require.Equal(t, latest, trie.Root())
+
+	// The empty root can always be restored
+	require.NoError(t, trie.SetRoot(trie.placeholder()))
+	require.Equal(t, MerkleRoot(trie.placeholder()), trie.Root())
+}
+
+func TestSMTWithStorage_SetRoot(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v1")))
+	require.NoError(t, trie.Commit())
+	committed := trie.Root()
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v2")))
+	require.NoError(t, trie.SetRoot(committed))
+	require.Empty(t, trie.pending)
+	value, err := trie.GetValue([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("v1"), value)
+}
+
+func TestSMTWithSnapshots_Rollback(t *testing.T) {
+	trie := NewSMTWithSnapshots(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v1")))
+	_, root1, err := trie.SaveVersion()
+	require.NoError(t, err)
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v2")))
+	_, _, err = trie.SaveVersion()
+	require.NoError(t, err)
+
+	require.ErrorIs(t, trie.Rollback([]byte("unknown")), ErrSnapshotNotFound)
+	require.NoError(t, trie.Rollback(root1))
+	got, err := trie.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, trie.valueHash([]byte("v1")), got)
+
+	// The trie continues from the restored root at the next version
+	require.NoError(t, trie.Update([]byte("bar"), []byte("v3")))
+	version, _, err := trie.SaveVersion()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), version)
+	got, err = trie.GetVersion(3, []byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, trie.valueHash([]byte("v1")), got)
+}
+
+func TestSMT_Discard(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleTrie(nodes, sha256.New())
+	// Discarding a new trie empties it
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v1")))
+	require.NoError(t, trie.Discard())
+	require.Equal(t, MerkleRoot(trie.placeholder()), trie.Root())
+
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v1")))
+	require.NoError(t, trie.Commit())
+	committed := trie.Root()
+	storeLen := nodes.Len()
+
+	// Nothing reaches the node store until the trie is committed
+	for i := 0; i < 10; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte("v2")))
+	}
+	require.NoError(t, trie.Delete([]byte("foo")))
+	require.Equal(t, storeLen, nodes.Len())
+	require.NoError(t, trie.Discard())
+	require.Equal(t, committed, trie.Root())
+	require.Equal(t, 0, trie.updates)
+
+	// Committing after discarding keeps the committed nodes
+	require.NoError(t, trie.Commit())
+	got, err := ImportSparseMerkleTrie(nodes, sha256.New(), committed).Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, trie.valueHash([]byte("v1")), got)
+}
+
+func TestSMTWithStorage_Discard(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v1")))
+	require.NoError(t, trie.Commit())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v2")))
+	require.NoError(t, trie.Discard())
+	require.Empty(t, trie.pending)
+	value, err := trie.GetValue([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("v1"), value)
+}
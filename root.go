@@ -1,6 +1,9 @@
 package smt
 
-import "encoding/binary"
+import (
+	"bytes"
+	"encoding/binary"
+)
 
 const (
 	// These are intentionally exposed to allow for for testing and custom
@@ -9,6 +12,18 @@ const (
 	SmstRootSizeBytes = SmtRootSizeBytes + sumSizeBytes + countSizeBytes
 )
 
+// EmptyRoot returns the root of an empty trie with the spec provided, the
+// root of the genesis state of a trie.
+func EmptyRoot(spec *TrieSpec) MerkleRoot {
+	return bytes.Clone(spec.placeholder())
+}
+
+// IsEmptyRoot returns true if the root is the root of an empty trie with the
+// spec provided.
+func IsEmptyRoot(root []byte, spec *TrieSpec) bool {
+	return bytes.Equal(root, spec.placeholder())
+}
+
 // Sum returns the uint64 sum of the merkle root, it checks the length of the
 // merkle root and if it is no the same as the size of the SMST's expected
 // root hash it will panic.
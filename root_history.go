@@ -0,0 +1,93 @@
+package smt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoRootHistory is returned when looking up the root history of a trie
+	// not configured with WithRootHistory.
+	ErrNoRootHistory = errors.New("root history is not enabled")
+
+	// rootHistoryPrefix prefixes the keys the root history of a trie
+	// configured with WithRootHistory is stored under in its node store: each
+	// root under its big-endian sequence number, and the latest sequence number
+	// under rootHistoryHeadKey.
+	rootHistoryPrefix  = reservePrefix("root history", []byte("smt/root-history/"))
+	rootHistoryHeadKey = append(append([]byte(nil), rootHistoryPrefix...), "head"...)
+)
+
+// WithRootHistory returns an Option appending the root of the trie to a log in
+// its node store every time it is committed, so that the roots of previous
+// commits can be looked up by their sequence number with RootAt, e.g. for
+// light clients verifying proofs against roots a few blocks old. The first
+// commit has sequence number 1. The log is append-only: roots are recorded
+// even if unchanged since the previous commit, and are never pruned.
+func WithRootHistory() TrieSpecOption {
+	return func(ts *TrieSpec) { ts.rootHistory = true }
+}
+
+// RootAt returns the root of the trie recorded by the commit with the given
+// sequence number, an error wrapping ErrKeyNotFound if no commit with the
+// sequence number was recorded, or the error reading the node store failed
+// with.
+func (smt *SMT) RootAt(seq uint64) (MerkleRoot, error) {
+	if !smt.rootHistory {
+		return nil, ErrNoRootHistory
+	}
+	root, err := smt.nodes.Get(rootHistoryKey(seq))
+	if isKeyNotFound(err) || (err == nil && root == nil) {
+		return nil, errors.Join(ErrKeyNotFound, fmt.Errorf("no root recorded at sequence %d", seq))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading root at sequence %d: %w", seq, err)
+	}
+	return root, nil
+}
+
+// LatestRootSeq returns the sequence number of the latest commit recorded in
+// the trie's root history, or 0 if none was recorded. Failing to read the
+// node store, or a malformed head, is reported rather than treated as an
+// empty history, so that commits never overwrite the recorded roots.
+func (smt *SMT) LatestRootSeq() (uint64, error) {
+	if !smt.rootHistory {
+		return 0, ErrNoRootHistory
+	}
+	bz, err := smt.nodes.Get(rootHistoryHeadKey)
+	if isKeyNotFound(err) || (err == nil && bz == nil) {
+		// New stores hold no history
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading root history head: %w", err)
+	}
+	if len(bz) != 8 {
+		return 0, fmt.Errorf("malformed root history head of %d bytes", len(bz))
+	}
+	return binary.BigEndian.Uint64(bz), nil
+}
+
+// recordRoot appends the root being committed to the root history, if the
+// trie records its root history.
+func (smt *SMT) recordRoot(root []byte) error {
+	if !smt.rootHistory {
+		return nil
+	}
+	seq, err := smt.LatestRootSeq()
+	if err != nil {
+		return err
+	}
+	seq++
+	if err := smt.nodes.Set(rootHistoryKey(seq), root); err != nil {
+		return err
+	}
+	return smt.nodes.Set(rootHistoryHeadKey, binary.BigEndian.AppendUint64(nil, seq))
+}
+
+// rootHistoryKey returns the key the root with the given sequence number is
+// stored under
+func rootHistoryKey(seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte(nil), rootHistoryPrefix...), seq)
+}
@@ -0,0 +1,110 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_RootHistory(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleTrie(nodes, sha256.New(), WithRootHistory())
+	seq, err := trie.LatestRootSeq()
+	require.NoError(t, err)
+	require.Zero(t, seq)
+	_, err = trie.RootAt(1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	var roots []MerkleRoot
+	for i := 0; i < 5; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key%d", i)), []byte("value")))
+		require.NoError(t, trie.Commit())
+		roots = append(roots, trie.Root())
+	}
+	// Unchanged roots are recorded too
+	require.NoError(t, trie.Commit())
+	roots = append(roots, trie.Root())
+
+	seq, err = trie.LatestRootSeq()
+	require.NoError(t, err)
+	require.Equal(t, uint64(len(roots)), seq)
+	for i, root := range roots {
+		got, err := trie.RootAt(uint64(i + 1))
+		require.NoError(t, err)
+		require.Equal(t, root, got)
+	}
+	_, err = trie.RootAt(0)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = trie.RootAt(seq + 1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	// The history survives reopening the trie
+	reopened := ImportSparseMerkleTrie(nodes, sha256.New(), trie.Root(), WithRootHistory())
+	require.NoError(t, reopened.Update([]byte("key"), []byte("value")))
+	require.NoError(t, reopened.Commit())
+	got, err := reopened.RootAt(seq + 1)
+	require.NoError(t, err)
+	require.Equal(t, reopened.Root(), got)
+	got, err = reopened.RootAt(1)
+	require.NoError(t, err)
+	require.Equal(t, roots[0], got)
+
+	// Tries without a history cannot look it up
+	plain := NewSparseMerkleTrie(nodes, sha256.New())
+	_, err = plain.RootAt(1)
+	require.ErrorIs(t, err, ErrNoRootHistory)
+	_, err = plain.LatestRootSeq()
+	require.ErrorIs(t, err, ErrNoRootHistory)
+}
+
+func TestSMTWithStorage_RootHistory(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New(), WithRootHistory())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+	require.NoError(t, trie.Update([]byte("foo"), []byte("baz")))
+	require.NoError(t, trie.Commit())
+
+	got, err := trie.RootAt(1)
+	require.NoError(t, err)
+	require.Equal(t, root, got)
+	got, err = trie.RootAt(2)
+	require.NoError(t, err)
+	require.Equal(t, trie.Root(), got)
+}
+
+func TestSMT_RootHistoryReadErrors(t *testing.T) {
+	store := &failingReadStore{MapStore: simplemap.NewSimpleMap()}
+	trie := NewSparseMerkleTrie(store, sha256.New(), WithRootHistory())
+	var roots []MerkleRoot
+	for i := 0; i < 3; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key%d", i)), []byte("value")))
+		require.NoError(t, trie.Commit())
+		roots = append(roots, trie.Root())
+	}
+
+	// Failing to read the history is not mistaken for an empty history
+	require.NoError(t, trie.Update([]byte("key"), []byte("value")))
+	store.failing = true
+	_, err := trie.LatestRootSeq()
+	require.Error(t, err)
+	_, err = trie.RootAt(1)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrKeyNotFound)
+	require.Error(t, trie.Commit())
+
+	// So the recorded roots are never overwritten
+	store.failing = false
+	seq, err := trie.LatestRootSeq()
+	require.NoError(t, err)
+	require.Equal(t, uint64(len(roots)), seq)
+	for i, root := range roots {
+		got, err := trie.RootAt(uint64(i + 1))
+		require.NoError(t, err)
+		require.Equal(t, root, got)
+	}
+}
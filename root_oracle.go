@@ -0,0 +1,258 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// rootSubscriptionBufferSize is the number of root updates buffered for each
+// subscriber before further updates are dropped for that subscriber.
+const rootSubscriptionBufferSize = 16
+
+// checkpointDomainTag is prepended to the signed checkpoint payload to
+// domain separate checkpoint signatures from any other signed message.
+var checkpointDomainTag = []byte("smt/checkpoint")
+
+// Ensure the oracles satisfy the RootOracle interface
+var (
+	_ RootOracle = (*MemoryRootOracle)(nil)
+	_ RootOracle = (*CheckpointRootOracle)(nil)
+)
+
+var (
+	// ErrRootNotFound is returned when a RootOracle has no trusted root for
+	// the height requested.
+	ErrRootNotFound = errors.New("trusted root not found")
+	// ErrRootRejected is returned when a new root is rejected by the update
+	// policy of a RootOracle.
+	ErrRootRejected = errors.New("trusted root rejected")
+)
+
+// RootUpdate is a trusted root along with the height it was committed at.
+type RootUpdate struct {
+	Height uint64
+	Root   MerkleRoot
+}
+
+// RootOracle supplies the roots a verifier trusts, such as the roots of a
+// chain's finalised blocks.
+type RootOracle interface {
+	// LatestRoot returns the most recent trusted root, returning
+	// ErrRootNotFound if no root is trusted yet.
+	LatestRoot() (RootUpdate, error)
+	// RootAt returns the trusted root at the given height, returning
+	// ErrRootNotFound if it is unknown.
+	RootAt(height uint64) (MerkleRoot, error)
+	// Subscribe returns a channel that receives every root trusted from now
+	// on, and a function that cancels the subscription and closes the channel.
+	Subscribe() (<-chan RootUpdate, func())
+}
+
+// RootUpdatePolicy decides whether a new root may replace the latest trusted
+// root, prev is nil when no root is trusted yet.
+type RootUpdatePolicy func(prev *RootUpdate, next RootUpdate) error
+
+// MonotonicRootPolicy only accepts roots at a strictly greater height than
+// the latest trusted root.
+func MonotonicRootPolicy(prev *RootUpdate, next RootUpdate) error {
+	if prev != nil && next.Height <= prev.Height {
+		return fmt.Errorf("height %d is not above latest height %d", next.Height, prev.Height)
+	}
+	return nil
+}
+
+// OracleRoot adapts a RootOracle into a TrustedRootFunc returning its latest
+// root, for use with the VerifyingClient.
+func OracleRoot(oracle RootOracle) TrustedRootFunc {
+	return func() (MerkleRoot, error) {
+		latest, err := oracle.LatestRoot()
+		if err != nil {
+			return nil, err
+		}
+		return latest.Root, nil
+	}
+}
+
+// MemoryRootOracle is an in-memory RootOracle whose roots are supplied by the
+// caller through Update, subject to its update policy.
+type MemoryRootOracle struct {
+	mu          sync.RWMutex
+	policy      RootUpdatePolicy
+	latest      *RootUpdate
+	roots       map[uint64]MerkleRoot
+	subscribers map[int]chan RootUpdate
+	nextSubID   int
+}
+
+// NewMemoryRootOracle returns a new MemoryRootOracle applying the policy
+// provided to every update, if the policy is nil MonotonicRootPolicy is used.
+func NewMemoryRootOracle(policy RootUpdatePolicy) *MemoryRootOracle {
+	if policy == nil {
+		policy = MonotonicRootPolicy
+	}
+	return &MemoryRootOracle{
+		policy:      policy,
+		roots:       make(map[uint64]MerkleRoot),
+		subscribers: make(map[int]chan RootUpdate),
+	}
+}
+
+// Update trusts the root provided at the given height if the update policy
+// accepts it, notifying all subscribers. An error wrapping ErrRootRejected is
+// returned if the policy rejects the update.
+func (o *MemoryRootOracle) Update(height uint64, root MerkleRoot) error {
+	update := RootUpdate{Height: height, Root: bytes.Clone(root)}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.policy(o.latest, update); err != nil {
+		return errors.Join(ErrRootRejected, err)
+	}
+	o.roots[height] = update.Root
+	if o.latest == nil || height >= o.latest.Height {
+		o.latest = &update
+	}
+	for _, sub := range o.subscribers {
+		// Never block the updater on a slow subscriber
+		select {
+		case sub <- update:
+		default:
+		}
+	}
+	return nil
+}
+
+// LatestRoot satisfies the RootOracle#LatestRoot interface
+func (o *MemoryRootOracle) LatestRoot() (RootUpdate, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if o.latest == nil {
+		return RootUpdate{}, ErrRootNotFound
+	}
+	return *o.latest, nil
+}
+
+// RootAt satisfies the RootOracle#RootAt interface
+func (o *MemoryRootOracle) RootAt(height uint64) (MerkleRoot, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	root, ok := o.roots[height]
+	if !ok {
+		return nil, ErrRootNotFound
+	}
+	return root, nil
+}
+
+// Subscribe satisfies the RootOracle#Subscribe interface. Updates are
+// delivered on a buffered channel and dropped for subscribers that fall
+// behind rather than blocking the oracle.
+func (o *MemoryRootOracle) Subscribe() (<-chan RootUpdate, func()) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	id := o.nextSubID
+	o.nextSubID++
+	ch := make(chan RootUpdate, rootSubscriptionBufferSize)
+	o.subscribers[id] = ch
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			o.mu.Lock()
+			defer o.mu.Unlock()
+			delete(o.subscribers, id)
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// SignedCheckpoint is a root at a given height signed by a checkpoint
+// authority.
+type SignedCheckpoint struct {
+	Height    uint64
+	Root      MerkleRoot
+	Signature []byte
+}
+
+// checkpointPayload returns the bytes signed for a checkpoint
+func checkpointPayload(height uint64, root MerkleRoot) []byte {
+	var heightBz [8]byte
+	binary.BigEndian.PutUint64(heightBz[:], height)
+	payload := make([]byte, 0, len(checkpointDomainTag)+len(heightBz)+len(root))
+	payload = append(payload, checkpointDomainTag...)
+	payload = append(payload, heightBz[:]...)
+	payload = append(payload, root...)
+	return payload
+}
+
+// SignCheckpoint returns a SignedCheckpoint for the root at the given height
+// signed with the ed25519 private key provided.
+func SignCheckpoint(key ed25519.PrivateKey, height uint64, root MerkleRoot) *SignedCheckpoint {
+	return &SignedCheckpoint{
+		Height:    height,
+		Root:      bytes.Clone(root),
+		Signature: ed25519.Sign(key, checkpointPayload(height, root)),
+	}
+}
+
+// CheckpointVerifier verifies the signature of a SignedCheckpoint.
+type CheckpointVerifier func(*SignedCheckpoint) error
+
+// Ed25519CheckpointVerifier returns a CheckpointVerifier accepting checkpoints
+// signed by any of the ed25519 public keys provided.
+func Ed25519CheckpointVerifier(keys ...ed25519.PublicKey) CheckpointVerifier {
+	return func(cp *SignedCheckpoint) error {
+		payload := checkpointPayload(cp.Height, cp.Root)
+		for _, key := range keys {
+			if ed25519.Verify(key, payload, cp.Signature) {
+				return nil
+			}
+		}
+		return errors.New("checkpoint signature does not match any trusted key")
+	}
+}
+
+// CheckpointRootOracle is a RootOracle that only trusts roots delivered as
+// checkpoints carrying a valid signature.
+type CheckpointRootOracle struct {
+	roots  *MemoryRootOracle
+	verify CheckpointVerifier
+}
+
+// NewCheckpointRootOracle returns a new CheckpointRootOracle verifying every
+// checkpoint with the verifier provided before applying the update policy.
+func NewCheckpointRootOracle(verify CheckpointVerifier, policy RootUpdatePolicy) *CheckpointRootOracle {
+	return &CheckpointRootOracle{
+		roots:  NewMemoryRootOracle(policy),
+		verify: verify,
+	}
+}
+
+// AddCheckpoint trusts the root of the checkpoint provided if its signature
+// is valid and the update policy accepts it. An error wrapping
+// ErrRootRejected is returned otherwise.
+func (o *CheckpointRootOracle) AddCheckpoint(cp *SignedCheckpoint) error {
+	if err := o.verify(cp); err != nil {
+		return errors.Join(ErrRootRejected, err)
+	}
+	return o.roots.Update(cp.Height, cp.Root)
+}
+
+// LatestRoot satisfies the RootOracle#LatestRoot interface
+func (o *CheckpointRootOracle) LatestRoot() (RootUpdate, error) {
+	return o.roots.LatestRoot()
+}
+
+// RootAt satisfies the RootOracle#RootAt interface
+func (o *CheckpointRootOracle) RootAt(height uint64) (MerkleRoot, error) {
+	return o.roots.RootAt(height)
+}
+
+// Subscribe satisfies the RootOracle#Subscribe interface
+func (o *CheckpointRootOracle) Subscribe() (<-chan RootUpdate, func()) {
+	return o.roots.Subscribe()
+}
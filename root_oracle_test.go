@@ -0,0 +1,98 @@
+package smt
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestMemoryRootOracle_Update(t *testing.T) {
+	oracle := NewMemoryRootOracle(nil)
+
+	_, err := oracle.LatestRoot()
+	require.ErrorIs(t, err, ErrRootNotFound)
+
+	updates, cancel := oracle.Subscribe()
+
+	require.NoError(t, oracle.Update(1, MerkleRoot("root1")))
+	require.NoError(t, oracle.Update(2, MerkleRoot("root2")))
+
+	// The default policy rejects roots that do not advance the height
+	require.ErrorIs(t, oracle.Update(2, MerkleRoot("other")), ErrRootRejected)
+	require.ErrorIs(t, oracle.Update(1, MerkleRoot("other")), ErrRootRejected)
+
+	latest, err := oracle.LatestRoot()
+	require.NoError(t, err)
+	require.Equal(t, RootUpdate{Height: 2, Root: MerkleRoot("root2")}, latest)
+
+	root, err := oracle.RootAt(1)
+	require.NoError(t, err)
+	require.Equal(t, MerkleRoot("root1"), root)
+	_, err = oracle.RootAt(3)
+	require.ErrorIs(t, err, ErrRootNotFound)
+
+	require.Equal(t, RootUpdate{Height: 1, Root: MerkleRoot("root1")}, <-updates)
+	require.Equal(t, RootUpdate{Height: 2, Root: MerkleRoot("root2")}, <-updates)
+
+	cancel()
+	cancel() // cancelling twice is a no-op
+	_, ok := <-updates
+	require.False(t, ok)
+	require.NoError(t, oracle.Update(3, MerkleRoot("root3")))
+}
+
+func TestCheckpointRootOracle_AddCheckpoint(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	_, otherPriv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	oracle := NewCheckpointRootOracle(Ed25519CheckpointVerifier(pub), nil)
+
+	require.NoError(t, oracle.AddCheckpoint(SignCheckpoint(priv, 10, MerkleRoot("root10"))))
+
+	// Checkpoints signed by an unknown key are rejected
+	err = oracle.AddCheckpoint(SignCheckpoint(otherPriv, 11, MerkleRoot("root11")))
+	require.ErrorIs(t, err, ErrRootRejected)
+
+	// Tampering with a signed checkpoint invalidates it
+	cp := SignCheckpoint(priv, 12, MerkleRoot("root12"))
+	cp.Height = 13
+	require.ErrorIs(t, oracle.AddCheckpoint(cp), ErrRootRejected)
+
+	latest, err := oracle.LatestRoot()
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), latest.Height)
+}
+
+func TestRootOracle_VerifyingClient(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, trie.Commit())
+
+	oracle := NewMemoryRootOracle(nil)
+	require.NoError(t, oracle.Update(1, trie.Root()))
+
+	source := ProofSourceFunc(func(key []byte, _ MerkleRoot) ([]byte, *SparseMerkleProof, error) {
+		value, err := trie.GetValue(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		proof, err := trie.Prove(key)
+		return value, proof, err
+	})
+	client := NewVerifyingClient(source, OracleRoot(oracle), trie.Spec())
+
+	value, err := client.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), value)
+
+	// Once the oracle trusts a new root the stale server state is rejected
+	require.NoError(t, oracle.Update(2, MerkleRoot(make([]byte, SmtRootSizeBytes))))
+	_, err = client.Get([]byte("foo"))
+	require.ErrorIs(t, err, ErrBadProof)
+}
@@ -0,0 +1,179 @@
+package smt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// rootPublicationBufferSize is the number of commits buffered while a root is
+// being published before further commits are dropped.
+const rootPublicationBufferSize = 16
+
+// ErrRootNotPublished is returned when requesting the publication receipt of
+// a root which has not been published.
+var ErrRootNotPublished = errors.New("root not published")
+
+// RootPublisher publishes roots to an external system, such as a chain or a
+// timestamping service, returning the system's receipt of the publication
+// (e.g. a transaction hash).
+type RootPublisher interface {
+	Publish(ctx context.Context, root MerkleRoot) (receipt []byte, err error)
+}
+
+// RetryPolicy is the policy for retrying failed publications, waiting
+// InitialBackoff after the first failure and doubling the wait after every
+// subsequent failure, up to MaxBackoff. Zero valued fields default to a single
+// attempt and no maximum backoff.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// backoff returns the wait after the given number of failed attempts
+func (policy RetryPolicy) backoff(failures int) time.Duration {
+	backoff := policy.InitialBackoff
+	for i := 1; i < failures; i++ {
+		if policy.MaxBackoff > 0 && backoff >= policy.MaxBackoff {
+			break
+		}
+		backoff *= 2
+	}
+	if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+		return policy.MaxBackoff
+	}
+	return backoff
+}
+
+// PublicationReceipt records the publication of a root by a RootPublisher.
+type PublicationReceipt struct {
+	Root MerkleRoot
+	// Receipt is the receipt returned by the RootPublisher
+	Receipt []byte
+	// Attempts is the number of attempts the publication took
+	Attempts    int
+	PublishedAt time.Time
+}
+
+// RootPublication publishes the roots of a trie with a RootPublisher,
+// retrying failed publications according to its RetryPolicy, and records the
+// receipt of every published root. RootPublication is safe for concurrent use.
+type RootPublication struct {
+	publisher RootPublisher
+	policy    RetryPolicy
+	clock     Clock
+	mu        sync.Mutex
+	receipts  []PublicationReceipt
+	published map[string]int // index of the receipt of every published root
+}
+
+// NewRootPublication returns a new RootPublication publishing roots with the
+// publisher provided.
+func NewRootPublication(publisher RootPublisher, policy RetryPolicy) *RootPublication {
+	return &RootPublication{
+		publisher: publisher,
+		policy:    policy,
+		clock:     SystemClock,
+		published: make(map[string]int),
+	}
+}
+
+// Publish publishes the root, retrying failed attempts, and returns its
+// receipt. Roots already published are not published again, their existing
+// receipt is returned instead. The error of the last attempt is returned if
+// every attempt failed, or the context's error if it is done while waiting to
+// retry.
+func (p *RootPublication) Publish(ctx context.Context, root MerkleRoot) (PublicationReceipt, error) {
+	if receipt, err := p.Receipt(root); err == nil {
+		return receipt, nil
+	}
+	maxAttempts := p.policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			timer := p.clock.NewTimer(p.policy.backoff(attempt - 1))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return PublicationReceipt{}, ctx.Err()
+			case <-timer.C():
+			}
+		}
+		var receipt []byte
+		receipt, err = p.publisher.Publish(ctx, root)
+		if err == nil {
+			return p.record(PublicationReceipt{
+				Root:        bytes.Clone(root),
+				Receipt:     receipt,
+				Attempts:    attempt,
+				PublishedAt: p.clock.Now(),
+			}), nil
+		}
+	}
+	return PublicationReceipt{}, fmt.Errorf("publishing root %x failed after %d attempts: %w", root, maxAttempts, err)
+}
+
+// SetClock sets the clock timing retries and publications, which is the
+// SystemClock by default. It must be set before any root is published.
+func (p *RootPublication) SetClock(clock Clock) {
+	p.clock = clock
+}
+
+// Receipt returns the receipt of the root's publication, returning
+// ErrRootNotPublished if it has not been published.
+func (p *RootPublication) Receipt(root MerkleRoot) (PublicationReceipt, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	i, ok := p.published[string(root)]
+	if !ok {
+		return PublicationReceipt{}, ErrRootNotPublished
+	}
+	return p.receipts[i], nil
+}
+
+// Receipts returns the receipts of every published root, in the order they
+// were published.
+func (p *RootPublication) Receipts() []PublicationReceipt {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PublicationReceipt(nil), p.receipts...)
+}
+
+// Run publishes the root of every commit published to the EventBus provided,
+// which the trie must be configured with using WithEventBus, until the
+// context is cancelled or a root fails to be published. Commits made while a
+// root is being published are buffered, and dropped once the buffer is full.
+func (p *RootPublication) Run(ctx context.Context, bus *EventBus) error {
+	sub := bus.Subscribe(rootPublicationBufferSize, EventCommit)
+	defer sub.Cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-sub.Events():
+			if _, err := p.Publish(ctx, event.(CommitEvent).Root); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// record records the receipt of a published root, returning the receipt
+// already recorded if the root was published concurrently.
+func (p *RootPublication) record(receipt PublicationReceipt) PublicationReceipt {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if i, ok := p.published[string(receipt.Root)]; ok {
+		return p.receipts[i]
+	}
+	p.published[string(receipt.Root)] = len(p.receipts)
+	p.receipts = append(p.receipts, receipt)
+	return receipt
+}
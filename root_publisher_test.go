@@ -0,0 +1,111 @@
+package smt
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// flakyPublisher fails the first failures publications of every root
+type flakyPublisher struct {
+	mu       sync.Mutex
+	failures int
+	attempts map[string]int
+	roots    chan MerkleRoot
+}
+
+func (p *flakyPublisher) Publish(_ context.Context, root MerkleRoot) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.attempts[string(root)]++
+	if p.attempts[string(root)] <= p.failures {
+		return nil, errors.New("chain unavailable")
+	}
+	if p.roots != nil {
+		p.roots <- root
+	}
+	return append([]byte("tx-"), root[:4]...), nil
+}
+
+func TestRootPublication_Retry(t *testing.T) {
+	publisher := &flakyPublisher{failures: 2, attempts: make(map[string]int)}
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	publication := NewRootPublication(publisher, policy)
+	root := MerkleRoot("root")
+
+	_, err := publication.Receipt(root)
+	require.ErrorIs(t, err, ErrRootNotPublished)
+
+	receipt, err := publication.Publish(context.Background(), root)
+	require.NoError(t, err)
+	require.Equal(t, 3, receipt.Attempts)
+	require.Equal(t, []byte("tx-root"), receipt.Receipt)
+
+	// Published roots are not published again
+	again, err := publication.Publish(context.Background(), root)
+	require.NoError(t, err)
+	require.Equal(t, receipt, again)
+	require.Equal(t, 3, publisher.attempts["root"])
+
+	// Publications fail once the attempts are exhausted
+	publisher.failures = 5
+	_, err = publication.Publish(context.Background(), MerkleRoot("other"))
+	require.ErrorContains(t, err, "chain unavailable")
+	require.Equal(t, 3, publisher.attempts["other"])
+	require.Len(t, publication.Receipts(), 1)
+
+	// Waiting to retry is cancelled with the context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewRootPublication(publisher, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour}).
+		Publish(ctx, MerkleRoot("cancelled"))
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	require.Equal(t, time.Second, policy.backoff(1))
+	require.Equal(t, 2*time.Second, policy.backoff(2))
+	require.Equal(t, 4*time.Second, policy.backoff(3))
+	require.Equal(t, 5*time.Second, policy.backoff(4))
+	require.Equal(t, 5*time.Second, policy.backoff(100))
+}
+
+func TestRootPublication_Run(t *testing.T) {
+	bus := NewEventBus()
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithEventBus(bus))
+	publisher := &flakyPublisher{
+		failures: 1,
+		attempts: make(map[string]int),
+		roots:    make(chan MerkleRoot, 1),
+	}
+	publication := NewRootPublication(publisher, RetryPolicy{MaxAttempts: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- publication.Run(ctx, bus) }()
+	// Wait for Run to subscribe to the bus
+	require.Eventually(t, func() bool {
+		bus.mu.RLock()
+		defer bus.mu.RUnlock()
+		return len(bus.subscribers) == 1
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, trie.Update([]byte("key"), []byte("value")))
+	require.NoError(t, trie.Commit())
+	require.Equal(t, trie.Root(), <-publisher.roots)
+	require.Eventually(t, func() bool {
+		_, err := publication.Receipt(trie.Root())
+		return err == nil
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
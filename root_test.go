@@ -74,3 +74,40 @@ func TestMerkleRoot_TrieTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestEmptyRoot(t *testing.T) {
+	trie := smt.NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	sumTrie := smt.NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.Equal(t, trie.Root(), smt.EmptyRoot(trie.Spec()))
+	require.Equal(t, sumTrie.Root(), smt.EmptyRoot(sumTrie.Spec()))
+	require.Len(t, smt.EmptyRoot(sumTrie.Spec()), smt.SmstRootSizeBytes)
+	require.True(t, smt.IsEmptyRoot(trie.Root(), trie.Spec()))
+	require.False(t, smt.IsEmptyRoot(trie.Root(), sumTrie.Spec()))
+
+	// Every key is proven absent from the empty root
+	proof, err := trie.Prove([]byte("key"))
+	require.NoError(t, err)
+	valid, err := smt.VerifyEmptyRootProof(proof, []byte("key"), trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+	valid, err = smt.VerifyProof(proof, smt.EmptyRoot(trie.Spec()), []byte("key"), nil, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+	valid, err = smt.VerifyProof(proof, smt.EmptyRoot(trie.Spec()), []byte("key"), []byte("value"), trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+	sumProof, err := sumTrie.Prove([]byte("key"))
+	require.NoError(t, err)
+	valid, err = smt.VerifySumProof(sumProof, sumTrie.Root(), []byte("key"), nil, 0, 0, sumTrie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// Proofs from a non-empty trie are not proofs against the empty root
+	require.NoError(t, trie.Update([]byte("other"), []byte("value")))
+	require.False(t, smt.IsEmptyRoot(trie.Root(), trie.Spec()))
+	proof, err = trie.Prove([]byte("key"))
+	require.NoError(t, err)
+	valid, err = smt.VerifyEmptyRootProof(proof, []byte("key"), trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+}
@@ -0,0 +1,247 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// fieldSaltSize is the size of the random salts of committed fields, hiding
+// the values of undisclosed fields from verifiers guessing them
+const fieldSaltSize = 16
+
+var (
+	// fieldLeafPrefix, fieldNodePrefix and fieldCountPrefix separate the
+	// leaves of field commitments from their inner nodes and from the
+	// commitment itself, binding the root of the fields' tree to their number
+	fieldLeafPrefix  = []byte{0}
+	fieldNodePrefix  = []byte{1}
+	fieldCountPrefix = []byte{2}
+)
+
+// ErrFieldNotFound is returned when disclosing a field a structured value does
+// not have.
+var ErrFieldNotFound = errors.New("field not found")
+
+// StructuredValue is a value made of named fields, each committed to by a leaf
+// of a small Merkle tree, so that its fields can be disclosed individually.
+// The commitment to the fields' tree, returned by Commitment, is the value
+// inserted into the trie. The StructuredValue must be kept by its holder to disclose
+// fields, as its salts cannot be recovered from the trie.
+type StructuredValue struct {
+	// Fields are sorted by name
+	Fields []CommittedField
+}
+
+// CommittedField is a field of a StructuredValue along with the random salt
+// it is committed with.
+type CommittedField struct {
+	Name  string
+	Value []byte
+	Salt  []byte
+}
+
+// DisclosedField is a field disclosed by a DisclosureProof along with the
+// path proving it is a leaf of the fields' tree.
+type DisclosedField struct {
+	CommittedField
+	// Index is the position of the field in its structured value
+	Index int
+	// Path holds the digests of the siblings of the field's leaf, from the
+	// leaf up to the root of the fields' tree
+	Path [][]byte
+}
+
+// DisclosureProof reveals selected fields of a structured value along with
+// the proof of its commitment in a trie, see VerifyDisclosure.
+type DisclosureProof struct {
+	// FieldCount is the number of fields of the structured value
+	FieldCount int
+	Fields     []DisclosedField
+	// Proof is the proof of the structured value's commitment at its key
+	Proof *SparseMerkleProof
+}
+
+// NewStructuredValue returns a StructuredValue holding the fields provided,
+// each committed with a random salt. At least one field must be provided.
+func NewStructuredValue(fields map[string][]byte) (*StructuredValue, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("structured value has no fields")
+	}
+	value := &StructuredValue{Fields: make([]CommittedField, 0, len(fields))}
+	for name, data := range fields {
+		salt := make([]byte, fieldSaltSize)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return nil, err
+		}
+		value.Fields = append(value.Fields, CommittedField{Name: name, Value: bytes.Clone(data), Salt: salt})
+	}
+	sort.Slice(value.Fields, func(i, j int) bool {
+		return value.Fields[i].Name < value.Fields[j].Name
+	})
+	return value, nil
+}
+
+// Commitment returns the commitment to the tree of the value's fields and
+// their number, hashed with the trie hasher of the spec provided, to be
+// inserted into a trie with that spec as the value of the structured value's
+// key.
+func (v *StructuredValue) Commitment(spec *TrieSpec) []byte {
+	leaves := make([][]byte, len(v.Fields))
+	for i := range v.Fields {
+		leaves[i] = v.Fields[i].digest(spec)
+	}
+	return fieldsCommitment(spec, len(leaves), fieldsRoot(spec, leaves))
+}
+
+// Disclose returns a DisclosureProof revealing the fields with the names
+// provided, and only these, along with the proof of the value's commitment
+// provided, e.g. from Prove. An error wrapping ErrFieldNotFound is returned if
+// the value has no field with one of the names.
+func (v *StructuredValue) Disclose(proof *SparseMerkleProof, spec *TrieSpec, names ...string) (*DisclosureProof, error) {
+	leaves := make([][]byte, len(v.Fields))
+	for i := range v.Fields {
+		leaves[i] = v.Fields[i].digest(spec)
+	}
+	disclosure := &DisclosureProof{FieldCount: len(v.Fields), Proof: proof}
+	for _, name := range names {
+		index := sort.Search(len(v.Fields), func(i int) bool { return v.Fields[i].Name >= name })
+		if index == len(v.Fields) || v.Fields[index].Name != name {
+			return nil, errors.Join(ErrFieldNotFound, fmt.Errorf("%q", name))
+		}
+		field := v.Fields[index]
+		disclosure.Fields = append(disclosure.Fields, DisclosedField{
+			CommittedField: CommittedField{Name: field.Name, Value: bytes.Clone(field.Value), Salt: bytes.Clone(field.Salt)},
+			Index:          index,
+			Path:           fieldPath(spec, leaves, index),
+		})
+	}
+	return disclosure, nil
+}
+
+// VerifyDisclosure verifies that every field disclosed by the proof is a field
+// of the structured value whose commitment is proven at the key against the
+// root provided, and returns the disclosed fields' values by name. An error
+// wrapping ErrBadProof is returned otherwise. The values of the fields which
+// were not disclosed are not learnt.
+func VerifyDisclosure(proof *DisclosureProof, root, key []byte, spec *TrieSpec) (map[string][]byte, error) {
+	if proof.FieldCount <= 0 || len(proof.Fields) == 0 {
+		return nil, errors.Join(ErrBadProof, errors.New("no fields disclosed"))
+	}
+	var commitment []byte
+	fields := make(map[string][]byte, len(proof.Fields))
+	for _, field := range proof.Fields {
+		if field.Index < 0 || field.Index >= proof.FieldCount {
+			return nil, errors.Join(ErrBadProof, fmt.Errorf("field %q at index %d of %d fields", field.Name, field.Index, proof.FieldCount))
+		}
+		if _, ok := fields[field.Name]; ok {
+			return nil, errors.Join(ErrBadProof, fmt.Errorf("field %q disclosed twice", field.Name))
+		}
+		fieldRoot, ok := fieldRootFromPath(spec, field.digest(spec), field.Index, proof.FieldCount, field.Path)
+		if !ok {
+			return nil, errors.Join(ErrBadProof, fmt.Errorf("invalid path for field %q", field.Name))
+		}
+		if commitment == nil {
+			commitment = fieldRoot
+		} else if !bytes.Equal(commitment, fieldRoot) {
+			return nil, errors.Join(ErrBadProof, fmt.Errorf("field %q is not a field of the disclosed value", field.Name))
+		}
+		fields[field.Name] = field.Value
+	}
+	commitment = fieldsCommitment(spec, proof.FieldCount, commitment)
+	valid, err := VerifyProof(proof.Proof, root, key, commitment, spec)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errors.Join(ErrBadProof, fmt.Errorf("proof for key %x does not verify against root %x", key, root))
+	}
+	return fields, nil
+}
+
+// digest returns the digest of the field's leaf in the tree of fields
+func (field *CommittedField) digest(spec *TrieSpec) []byte {
+	buf := bytes.NewBuffer(nil)
+	buf.Write(fieldLeafPrefix)
+	for _, data := range [][]byte{field.Salt, []byte(field.Name), field.Value} {
+		var bz [8]byte
+		binary.BigEndian.PutUint64(bz[:], uint64(len(data)))
+		buf.Write(bz[:])
+		buf.Write(data)
+	}
+	return spec.th.digestData(buf.Bytes())
+}
+
+// fieldsCommitment returns the commitment to the root of a tree of count
+// fields, as the shape of the tree, and so the paths of its leaves, depends
+// on their number
+func fieldsCommitment(spec *TrieSpec, count int, root []byte) []byte {
+	data := make([]byte, 0, len(fieldCountPrefix)+8+len(root))
+	data = append(data, fieldCountPrefix...)
+	data = binary.BigEndian.AppendUint64(data, uint64(count))
+	return spec.th.digestData(append(data, root...))
+}
+
+// fieldsRoot returns the root of the tree of the leaves provided, split at the
+// largest power of two smaller than the number of leaves as in RFC 6962
+func fieldsRoot(spec *TrieSpec, leaves [][]byte) []byte {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	split := fieldsSplit(len(leaves))
+	return fieldNode(spec, fieldsRoot(spec, leaves[:split]), fieldsRoot(spec, leaves[split:]))
+}
+
+// fieldPath returns the digests of the siblings of the leaf at the index
+// provided, from the leaf up to the root of the tree of the leaves
+func fieldPath(spec *TrieSpec, leaves [][]byte, index int) [][]byte {
+	if len(leaves) == 1 {
+		return nil
+	}
+	split := fieldsSplit(len(leaves))
+	if index < split {
+		return append(fieldPath(spec, leaves[:split], index), fieldsRoot(spec, leaves[split:]))
+	}
+	return append(fieldPath(spec, leaves[split:], index-split), fieldsRoot(spec, leaves[:split]))
+}
+
+// fieldRootFromPath returns the root of a tree of count leaves holding the
+// leaf provided at the index provided, computed from the leaf's path, or false
+// if the path does not have the length of the tree
+func fieldRootFromPath(spec *TrieSpec, leaf []byte, index, count int, path [][]byte) ([]byte, bool) {
+	if count == 1 {
+		return leaf, len(path) == 0
+	}
+	if len(path) == 0 {
+		return nil, false
+	}
+	split, sibling := fieldsSplit(count), path[len(path)-1]
+	if index < split {
+		left, ok := fieldRootFromPath(spec, leaf, index, split, path[:len(path)-1])
+		return fieldNode(spec, left, sibling), ok
+	}
+	right, ok := fieldRootFromPath(spec, leaf, index-split, count-split, path[:len(path)-1])
+	return fieldNode(spec, sibling, right), ok
+}
+
+// fieldNode returns the digest of the inner node of the tree of fields with
+// the children provided
+func fieldNode(spec *TrieSpec, left, right []byte) []byte {
+	data := make([]byte, 0, len(fieldNodePrefix)+len(left)+len(right))
+	data = append(data, fieldNodePrefix...)
+	data = append(data, left...)
+	return spec.th.digestData(append(data, right...))
+}
+
+// fieldsSplit returns the largest power of two smaller than the count
+func fieldsSplit(count int) int {
+	split := 1
+	for split*2 < count {
+		split *= 2
+	}
+	return split
+}
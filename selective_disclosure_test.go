@@ -0,0 +1,89 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestStructuredValue_Disclose(t *testing.T) {
+	smt := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, smt.Update([]byte("other"), []byte("value")))
+
+	// Structured values of every size disclose each of their fields
+	for count := 1; count <= 9; count++ {
+		fields := make(map[string][]byte, count)
+		for i := 0; i < count; i++ {
+			fields[fmt.Sprintf("field-%d", i)] = []byte(fmt.Sprintf("value-%d", i))
+		}
+		value, err := NewStructuredValue(fields)
+		require.NoError(t, err)
+		key := []byte(fmt.Sprintf("credential-%d", count))
+		require.NoError(t, smt.Update(key, value.Commitment(smt.Spec())))
+		proof, err := smt.Prove(key)
+		require.NoError(t, err)
+
+		for i := 0; i < count; i++ {
+			name := fmt.Sprintf("field-%d", i)
+			disclosure, err := value.Disclose(proof, smt.Spec(), name)
+			require.NoError(t, err)
+			disclosed, err := VerifyDisclosure(disclosure, smt.Root(), key, smt.Spec())
+			require.NoError(t, err)
+			require.Equal(t, map[string][]byte{name: fields[name]}, disclosed)
+		}
+	}
+}
+
+func TestVerifyDisclosure(t *testing.T) {
+	smt := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	value, err := NewStructuredValue(map[string][]byte{
+		"name":    []byte("alice"),
+		"age":     []byte("42"),
+		"country": []byte("ch"),
+	})
+	require.NoError(t, err)
+	key := []byte("credential")
+	require.NoError(t, smt.Update(key, value.Commitment(smt.Spec())))
+	proof, err := smt.Prove(key)
+	require.NoError(t, err)
+
+	_, err = value.Disclose(proof, smt.Spec(), "missing")
+	require.ErrorIs(t, err, ErrFieldNotFound)
+
+	disclosure, err := value.Disclose(proof, smt.Spec(), "age", "country")
+	require.NoError(t, err)
+	fields, err := VerifyDisclosure(disclosure, smt.Root(), key, smt.Spec())
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"age": []byte("42"), "country": []byte("ch")}, fields)
+
+	// A disclosed value cannot be altered
+	disclosure.Fields[0].Value = []byte("18")
+	_, err = VerifyDisclosure(disclosure, smt.Root(), key, smt.Spec())
+	require.ErrorIs(t, err, ErrBadProof)
+
+	// Nor moved to another key
+	disclosure, err = value.Disclose(proof, smt.Spec(), "age")
+	require.NoError(t, err)
+	_, err = VerifyDisclosure(disclosure, smt.Root(), []byte("other"), smt.Spec())
+	require.ErrorIs(t, err, ErrBadProof)
+
+	// Nor claimed from a tree of another size
+	disclosure.FieldCount = 4
+	_, err = VerifyDisclosure(disclosure, smt.Root(), key, smt.Spec())
+	require.ErrorIs(t, err, ErrBadProof)
+
+	// Fields of different values cannot be combined
+	other, err := NewStructuredValue(map[string][]byte{"name": []byte("alice"), "age": []byte("18"), "country": []byte("ch")})
+	require.NoError(t, err)
+	forged, err := other.Disclose(proof, smt.Spec(), "age")
+	require.NoError(t, err)
+	disclosure, err = value.Disclose(proof, smt.Spec(), "name")
+	require.NoError(t, err)
+	disclosure.Fields = append(disclosure.Fields, forged.Fields...)
+	_, err = VerifyDisclosure(disclosure, smt.Root(), key, smt.Spec())
+	require.ErrorIs(t, err, ErrBadProof)
+}
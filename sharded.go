@@ -0,0 +1,391 @@
+package smt
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash"
+	"sync"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// maxShardBits is the maximum number of path bits shards are selected by, so
+// a ShardedSMT has at most 256 shards
+const maxShardBits = 8
+
+func init() {
+	gob.Register(ShardedProof{})
+}
+
+// ShardedSMT partitions the key space of a trie across a power of two number
+// of shards by the leading bits of the keys' paths, each shard being an SMT
+// of its own. Updates, proofs and commits of different shards run in
+// parallel, and the shard roots are combined into a single root by a binary
+// Merkle tree of inner nodes, so every key is provable against the combined
+// root with a ShardedProof.
+//
+// A ShardedSMT is safe for concurrent use, operations on the same shard are
+// serialised. Sum tries cannot be sharded.
+type ShardedSMT struct {
+	// spec hashes the keys and combined root, only used while holding mu
+	spec      TrieSpec
+	mu        sync.Mutex
+	newHasher func() hash.Hash
+	options   []TrieSpecOption
+
+	shards []*shard
+	// bits is the number of leading path bits selecting the shard of a key
+	bits int
+}
+
+// shard is a shard of a ShardedSMT
+type shard struct {
+	mu   sync.Mutex
+	trie *SMT
+}
+
+// ShardedProof is a proof of a key of a ShardedSMT against its combined root
+type ShardedProof struct {
+	// Proof is the proof of the key against the root of its shard
+	Proof *SparseMerkleProof
+	// ShardSideNodes are the side nodes of the root of the key's shard in the
+	// tree combining the shard roots, the deepest first
+	ShardSideNodes [][]byte
+}
+
+// NewShardedSMT returns a new, empty ShardedSMT with the number of shards
+// provided, which must be a power of two no greater than 256, storing every
+// shard in the node store provided. As shards are hashed in parallel, each
+// shard is given its own hasher returned by newHasher, custom hashers provided
+// in the options are shared by every shard and must be safe for concurrent
+// use.
+func NewShardedSMT(
+	nodes kvstore.MapStore,
+	shards int,
+	newHasher func() hash.Hash,
+	options ...TrieSpecOption,
+) (*ShardedSMT, error) {
+	return NewShardedSMTWithStores(singleShardStore(nodes), shards, newHasher, options...)
+}
+
+// ImportShardedSMT returns a ShardedSMT with a shard per root provided,
+// stored in the node store provided.
+func ImportShardedSMT(
+	nodes kvstore.MapStore,
+	roots []MerkleRoot,
+	newHasher func() hash.Hash,
+	options ...TrieSpecOption,
+) (*ShardedSMT, error) {
+	return ImportShardedSMTWithStores(singleShardStore(nodes), roots, newHasher, options...)
+}
+
+// ShardStores returns the node store of every shard of a ShardedSMT, so that
+// shards can be spread across several stores (e.g. on different disks or
+// machines), see StoreRing.
+type ShardStores func(shard int) kvstore.MapStore
+
+// singleShardStore returns the ShardStores storing every shard in one store
+func singleShardStore(nodes kvstore.MapStore) ShardStores {
+	return func(int) kvstore.MapStore { return nodes }
+}
+
+// NewShardedSMTWithStores returns a new, empty ShardedSMT with every shard
+// stored in the node store returned for it by stores, see NewShardedSMT.
+// An error wrapping ErrReservedKeyspace is returned if a shard's store is a
+// PrefixedStore whose prefix overlaps a reserved key.
+func NewShardedSMTWithStores(
+	stores ShardStores,
+	shards int,
+	newHasher func() hash.Hash,
+	options ...TrieSpecOption,
+) (*ShardedSMT, error) {
+	return newShardedSMT(shards, stores, newHasher, func(nodes kvstore.MapStore, i int) *SMT {
+		return NewSparseMerkleTrie(nodes, newHasher(), shardOptions(options, i)...)
+	}, options)
+}
+
+// ImportShardedSMTWithStores returns a ShardedSMT with a shard per root
+// provided, each stored in the node store returned for it by stores.
+func ImportShardedSMTWithStores(
+	stores ShardStores,
+	roots []MerkleRoot,
+	newHasher func() hash.Hash,
+	options ...TrieSpecOption,
+) (*ShardedSMT, error) {
+	return newShardedSMT(len(roots), stores, newHasher, func(nodes kvstore.MapStore, i int) *SMT {
+		return ImportSparseMerkleTrie(nodes, newHasher(), roots[i], shardOptions(options, i)...)
+	}, options)
+}
+
+// shardOptions returns the options of the shard with the given index, those
+// of the sharded trie followed by the shard's own
+func shardOptions(options []TrieSpecOption, shard int) []TrieSpecOption {
+	return append(append([]TrieSpecOption(nil), options...), withShardMetricsNamespace(shard))
+}
+
+// newShardedSMT returns a ShardedSMT with the shards returned by newShard for
+// the node store of each shard
+func newShardedSMT(
+	shards int,
+	stores ShardStores,
+	newHasher func() hash.Hash,
+	newShard func(nodes kvstore.MapStore, i int) *SMT,
+	options []TrieSpecOption,
+) (*ShardedSMT, error) {
+	bits := 0
+	for 1<<bits < shards {
+		bits++
+	}
+	if shards < 1 || 1<<bits != shards || bits > maxShardBits {
+		return nil, fmt.Errorf("invalid number of shards %d: must be a power of two up to %d", shards, 1<<maxShardBits)
+	}
+	trie := &ShardedSMT{
+		spec:      NewTrieSpec(newHasher(), false, options...),
+		newHasher: newHasher,
+		options:   options,
+		shards:    make([]*shard, shards),
+		bits:      bits,
+	}
+	if trie.spec.ph.PathSize()*8 < bits {
+		return nil, fmt.Errorf("paths of %d bytes cannot select %d shards", trie.spec.ph.PathSize(), shards)
+	}
+	for i := range trie.shards {
+		nodes := stores(i)
+		if nodes == nil {
+			return nil, fmt.Errorf("no node store for shard %d", i)
+		}
+		if err := checkStoreKeyspaces(nodes); err != nil {
+			return nil, err
+		}
+		trie.shards[i] = &shard{trie: newShard(nodes, i)}
+	}
+	return trie, nil
+}
+
+// Spec returns a new TrieSpec of the shards, which proofs are verified with,
+// with its own hasher so it can be used concurrently with the trie
+func (trie *ShardedSMT) Spec() *TrieSpec {
+	spec := NewTrieSpec(trie.newHasher(), false, trie.options...)
+	return &spec
+}
+
+// Shards returns the number of shards of the trie
+func (trie *ShardedSMT) Shards() int {
+	return len(trie.shards)
+}
+
+// ShardOf returns the index of the shard the key belongs to
+func (trie *ShardedSMT) ShardOf(key []byte) (int, error) {
+	trie.mu.Lock()
+	path, err := trie.spec.path(key)
+	trie.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	return trie.shardOfPath(path), nil
+}
+
+// shardOfPath returns the index of the shard of the path
+func (trie *ShardedSMT) shardOfPath(path []byte) int {
+	index := 0
+	for depth := 0; depth < trie.bits; depth++ {
+		index = index<<1 | getPathBit(path, depth)
+	}
+	return index
+}
+
+// withShard calls fn with the trie of the key's shard while holding its lock
+func (trie *ShardedSMT) withShard(key []byte, fn func(*SMT) error) error {
+	index, err := trie.ShardOf(key)
+	if err != nil {
+		return err
+	}
+	s := trie.shards[index]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.trie)
+}
+
+// Update sets the value for the given key in its shard
+func (trie *ShardedSMT) Update(key, value []byte) error {
+	return trie.withShard(key, func(smt *SMT) error { return smt.Update(key, value) })
+}
+
+// Delete deletes the value for the given key from its shard
+func (trie *ShardedSMT) Delete(key []byte) error {
+	return trie.withShard(key, func(smt *SMT) error { return smt.Delete(key) })
+}
+
+// Get returns the value hash stored at the given key
+func (trie *ShardedSMT) Get(key []byte) (valueHash []byte, err error) {
+	err = trie.withShard(key, func(smt *SMT) error {
+		valueHash, err = smt.Get(key)
+		return err
+	})
+	return valueHash, err
+}
+
+// UpdateBatch inserts every value for the key at the same index, updating
+// every shard in parallel, see SMT.UpdateBatch. As each shard is updated
+// independently, if any shard fails the others may still be updated.
+func (trie *ShardedSMT) UpdateBatch(keys, values [][]byte) error {
+	if len(keys) != len(values) {
+		return errors.Join(ErrBatchMismatch, fmt.Errorf("%d keys and %d values", len(keys), len(values)))
+	}
+	shardKeys := make([][][]byte, len(trie.shards))
+	shardValues := make([][][]byte, len(trie.shards))
+	for i, key := range keys {
+		index, err := trie.ShardOf(key)
+		if err != nil {
+			return err
+		}
+		shardKeys[index] = append(shardKeys[index], key)
+		shardValues[index] = append(shardValues[index], values[i])
+	}
+	return trie.parallel(func(i int, smt *SMT) error {
+		if len(shardKeys[i]) == 0 {
+			return nil
+		}
+		return smt.UpdateBatch(shardKeys[i], shardValues[i])
+	})
+}
+
+// Commit commits every shard in parallel
+func (trie *ShardedSMT) Commit() error {
+	return trie.parallel(func(_ int, smt *SMT) error { return smt.Commit() })
+}
+
+// ShardRoots returns the root of every shard, hashed in parallel
+func (trie *ShardedSMT) ShardRoots() []MerkleRoot {
+	roots := make([]MerkleRoot, len(trie.shards))
+	_ = trie.parallel(func(i int, smt *SMT) error {
+		roots[i] = smt.Root()
+		return nil
+	})
+	return roots
+}
+
+// Root returns the combined root of the shards
+func (trie *ShardedSMT) Root() MerkleRoot {
+	root, _ := trie.combine(trie.ShardRoots(), -1)
+	return root
+}
+
+// Prove generates a ShardedProof for the given key against the combined root
+func (trie *ShardedSMT) Prove(key []byte) (*ShardedProof, error) {
+	index, err := trie.ShardOf(key)
+	if err != nil {
+		return nil, err
+	}
+	roots := trie.ShardRoots()
+	proof := &ShardedProof{}
+	s := trie.shards[index]
+	s.mu.Lock()
+	proof.Proof, err = s.trie.Prove(key)
+	// The shard may have been updated since its root was read
+	roots[index] = s.trie.Root()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	_, proof.ShardSideNodes = trie.combine(roots, index)
+	return proof, nil
+}
+
+// combine returns the root of the tree combining the shard roots provided,
+// along with the side nodes of the shard with the given index, if any
+func (trie *ShardedSMT) combine(roots []MerkleRoot, index int) (MerkleRoot, [][]byte) {
+	trie.mu.Lock()
+	defer trie.mu.Unlock()
+	var sideNodes [][]byte
+	level := make([][]byte, len(roots))
+	for i, root := range roots {
+		level[i] = root
+	}
+	for len(level) > 1 {
+		if index >= 0 {
+			sideNodes = append(sideNodes, level[index^1])
+			index /= 2
+		}
+		next := make([][]byte, len(level)/2)
+		for i := range next {
+			next[i], _ = trie.spec.digestInnerNode(level[2*i], level[2*i+1])
+		}
+		level = next
+	}
+	return level[0], sideNodes
+}
+
+// parallel calls fn with every shard in parallel while holding its lock,
+// returning the errors of every shard joined.
+func (trie *ShardedSMT) parallel(fn func(i int, smt *SMT) error) error {
+	errs := make([]error, len(trie.shards))
+	var wg sync.WaitGroup
+	for i, s := range trie.shards {
+		wg.Add(1)
+		go func(i int, s *shard) {
+			defer wg.Done()
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			errs[i] = fn(i, s.trie)
+		}(i, s)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Marshal serialises the ShardedProof to bytes
+func (proof *ShardedProof) Marshal() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(proof); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal deserialises the ShardedProof from bytes
+func (proof *ShardedProof) Unmarshal(bz []byte) error {
+	buf := bytes.NewBuffer(bz)
+	dec := gob.NewDecoder(buf)
+	return dec.Decode(proof)
+}
+
+// VerifyShardedProof verifies a ShardedProof of the key and value provided,
+// or of the key's absence if the value is nil, against the combined root of a
+// ShardedSMT with the spec provided.
+func VerifyShardedProof(proof *ShardedProof, root, key, value []byte, spec *TrieSpec) (bool, error) {
+	if proof == nil || proof.Proof == nil {
+		return false, errors.Join(ErrBadProof, errors.New("missing shard proof"))
+	}
+	if len(proof.ShardSideNodes) > maxShardBits {
+		return false, errors.Join(ErrBadProof, fmt.Errorf("too many shard side nodes: %d", len(proof.ShardSideNodes)))
+	}
+	for _, sideNode := range proof.ShardSideNodes {
+		if len(sideNode) != spec.hashSize() {
+			return false, errors.Join(ErrBadProof, errors.New("invalid shard side node size"))
+		}
+	}
+	_, updates, err := verifyProofWithUpdates(proof.Proof, nil, key, value, spec)
+	if err != nil {
+		return false, err
+	}
+	path, err := spec.path(key)
+	if err != nil {
+		return false, errors.Join(ErrBadProof, err)
+	}
+	// The shard's root is recomputed from the proof, then combined with the
+	// other shards' roots up to the combined root
+	current := updates[len(updates)-1][0]
+	bits := len(proof.ShardSideNodes)
+	for i, sideNode := range proof.ShardSideNodes {
+		if getPathBit(path, bits-1-i) == leftChildBit {
+			current, _ = spec.digestInnerNode(current, sideNode)
+		} else {
+			current, _ = spec.digestInnerNode(sideNode, current)
+		}
+	}
+	return bytes.Equal(current, root), nil
+}
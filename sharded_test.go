@@ -0,0 +1,103 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestShardedSMT(t *testing.T) {
+	_, err := NewShardedSMT(simplemap.NewSimpleMap(), 3, sha256.New)
+	require.Error(t, err)
+	_, err = NewShardedSMT(simplemap.NewSimpleMap(), 512, sha256.New)
+	require.Error(t, err)
+
+	nodes := simplemap.NewSimpleMap()
+	trie, err := NewShardedSMT(nodes, 4, sha256.New)
+	require.NoError(t, err)
+	require.Equal(t, 4, trie.Shards())
+
+	// Concurrent updates of different keys
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				key := []byte(fmt.Sprintf("key-%d-%d", w, i))
+				require.NoError(t, trie.Update(key, []byte("value")))
+			}
+		}(w)
+	}
+	wg.Wait()
+	var keys, values [][]byte
+	for i := 0; i < 50; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("batch-%d", i)))
+		values = append(values, []byte(fmt.Sprintf("value-%d", i)))
+	}
+	require.NoError(t, trie.UpdateBatch(keys, values))
+	require.NoError(t, trie.Delete([]byte("key-0-0")))
+	require.NoError(t, trie.Commit())
+
+	// Every shard only holds the keys with its path prefix
+	for i, root := range trie.ShardRoots() {
+		shard := ImportSparseMerkleTrie(nodes, sha256.New(), root)
+		it := shard.Iterator()
+		for it.Next() {
+			require.Equal(t, i, trie.shardOfPath(it.Path()))
+		}
+		require.NoError(t, it.Err())
+	}
+
+	got, err := trie.Get([]byte("batch-7"))
+	require.NoError(t, err)
+	require.Equal(t, trie.Spec().valueHash([]byte("value-7")), got)
+
+	root := trie.Root()
+	for _, tc := range []struct {
+		key, value []byte
+	}{
+		{[]byte("batch-7"), []byte("value-7")},
+		{[]byte("key-3-24"), []byte("value")},
+		{[]byte("key-0-0"), nil},
+		{[]byte("absent"), nil},
+	} {
+		proof, err := trie.Prove(tc.key)
+		require.NoError(t, err)
+		require.Len(t, proof.ShardSideNodes, 2)
+		bz, err := proof.Marshal()
+		require.NoError(t, err)
+		decoded := &ShardedProof{}
+		require.NoError(t, decoded.Unmarshal(bz))
+		valid, err := VerifyShardedProof(decoded, root, tc.key, tc.value, trie.Spec())
+		require.NoError(t, err)
+		require.True(t, valid, string(tc.key))
+		valid, err = VerifyShardedProof(decoded, root, tc.key, []byte("wrong"), trie.Spec())
+		require.NoError(t, err)
+		require.False(t, valid)
+	}
+
+	// The trie is reimported from its shard roots
+	imported, err := ImportShardedSMT(nodes, trie.ShardRoots(), sha256.New)
+	require.NoError(t, err)
+	require.Equal(t, root, imported.Root())
+}
+
+func TestShardedSMT_SingleShard(t *testing.T) {
+	trie, err := NewShardedSMT(simplemap.NewSimpleMap(), 1, sha256.New)
+	require.NoError(t, err)
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	// A single shard's root is the combined root
+	require.Equal(t, trie.ShardRoots()[0], trie.Root())
+	proof, err := trie.Prove([]byte("foo"))
+	require.NoError(t, err)
+	require.Empty(t, proof.ShardSideNodes)
+	valid, err := VerifyShardedProof(proof, trie.Root(), []byte("foo"), []byte("bar"), trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+}
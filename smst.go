@@ -57,6 +57,7 @@ func NewSparseMerkleSumTrie(
 	}
 	nilValueHasher := WithValueHasher(nil)
 	nilValueHasher(&smt.TrieSpec)
+	smt.metricsErr = smt.loadMetrics()
 
 	return &SMST{
 		TrieSpec: trieSpec,
@@ -174,7 +175,8 @@ func (smst *SMST) Root() MerkleRoot {
 	return smst.SMT.Root() // [digest]+[binary sum]
 }
 
-// Sum returns the sum of the entire trie stored in the root.
+// Sum returns the sum of the entire trie stored in the root, or the aggregate
+// of all the leaves' weights if the trie uses a different AggregateFunc.
 // If the tree is not a sum tree, it will panic.
 func (smst *SMST) Sum() uint64 {
 	rootDigest := []byte(smst.Root())
@@ -182,6 +184,9 @@ func (smst *SMST) Sum() uint64 {
 	if !smst.Spec().sumTrie {
 		panic("SMST: not a merkle sum trie")
 	}
+	if smst.closed {
+		return 0
+	}
 
 	firstSumByteIdx, firstCountByteIdx := getFirstMetaByteIdx(rootDigest)
 
@@ -197,6 +202,9 @@ func (smst *SMST) Count() uint64 {
 	if !smst.Spec().sumTrie {
 		panic("SMST: not a merkle sum trie")
 	}
+	if smst.closed {
+		return 0
+	}
 
 	_, firstCountByteIdx := getFirstMetaByteIdx(rootDigest)
 
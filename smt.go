@@ -2,6 +2,8 @@ package smt
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"hash"
 
 	"github.com/pokt-network/smt/kvstore"
@@ -21,6 +23,16 @@ type SMT struct {
 	root trieNode
 	// Lists of per-operation orphan sets
 	orphans []orphanNodes
+	// Whether the trie has been closed
+	closed bool
+	// Number of updates and deletes since the last commit
+	updates int
+	// Statistics of the last commit
+	lastCommit CommitStats
+	// Cumulative operational counters
+	metrics Metrics
+	// metricsErr is the error reloading the persisted metrics, if any
+	metricsErr error
 }
 
 // Hashes of persisted nodes deleted from trie
@@ -40,6 +52,7 @@ func NewSparseMerkleTrie(
 	for _, option := range options {
 		option(&smt.TrieSpec)
 	}
+	smt.metricsErr = smt.loadMetrics()
 	return &smt
 }
 
@@ -57,42 +70,75 @@ func ImportSparseMerkleTrie(
 	return smt
 }
 
-// Root returns the root hash of the trie
+// Root returns the root hash of the trie, or nil once it is closed
 func (smt *SMT) Root() MerkleRoot {
+	if smt.closed {
+		return nil
+	}
 	return smt.digest(smt.root)
 }
 
 // Get returns the hash (i.e. digest) of the leaf value stored at the given key
 func (smt *SMT) Get(key []byte) ([]byte, error) {
-	path := smt.ph.Path(key)
-	// The leaf node whose value will be returned
-	var leaf *leafNode
-	var err error
+	if smt.closed {
+		return nil, ErrClosed
+	}
+	path, err := smt.path(key)
+	if err != nil {
+		return nil, err
+	}
+	smt.recordAccess(path)
+	leaf, err := smt.findLeaf(path)
+	if err != nil {
+		return nil, err
+	}
+	if leaf == nil {
+		return defaultEmptyValue, nil
+	}
+	return leaf.valueHash, nil
+}
+
+// Has returns true if a leaf for the given key is present in the trie, only
+// reading the trie's nodes. Unlike comparing the result of Get against the
+// default value, this also recognises leaves holding an empty value hash.
+func (smt *SMT) Has(key []byte) (bool, error) {
+	if smt.closed {
+		return false, ErrClosed
+	}
+	path, err := smt.path(key)
+	if err != nil {
+		return false, err
+	}
+	smt.recordAccess(path)
+	leaf, err := smt.findLeaf(path)
+	return leaf != nil, err
+}
 
+// findLeaf returns the leaf with the given path, or nil if it is not in the
+// trie, resolving and caching lazy nodes along the way.
+func (smt *SMT) findLeaf(path []byte) (leaf *leafNode, err error) {
 	// Loop throughout the entire trie to find the corresponding leaf for the
-	// given key.
+	// given path.
 	for currNode, depth := &smt.root, 0; ; depth++ {
-		*currNode, err = smt.resolveLazy(*currNode)
-		if err != nil {
+		if err = smt.resolveCached(currNode); err != nil {
 			return nil, err
 		}
 		if *currNode == nil {
-			break
+			return nil, nil
 		}
 		if n, ok := (*currNode).(*leafNode); ok {
 			if bytes.Equal(path, n.path) {
-				leaf = n
+				return n, nil
 			}
-			break
+			return nil, nil
 		}
 		if extNode, ok := (*currNode).(*extensionNode); ok {
 			if _, fullMatch := extNode.boundsMatch(path, depth); !fullMatch {
-				break
+				return nil, nil
 			}
 			depth += extNode.length()
 			currNode = &extNode.child
-			*currNode, err = smt.resolveLazy(*currNode)
-			if err != nil {
+			if err = smt.resolveCached(currNode); err != nil {
 				return nil, err
 			}
 		}
@@ -103,25 +149,37 @@ func (smt *SMT) Get(key []byte) ([]byte, error) {
 			currNode = &inner.rightChild
 		}
 	}
-	if leaf == nil {
-		return defaultEmptyValue, nil
-	}
-	return leaf.valueHash, nil
 }
 
 // Update inserts the `value` for the given `key` into the SMT
 func (smt *SMT) Update(key, value []byte) error {
+	if smt.closed {
+		return ErrClosed
+	}
 	// Convert the key into a path by computing its digest
-	path := smt.ph.Path(key)
+	path, err := smt.path(key)
+	if err != nil {
+		return err
+	}
+	smt.recordAccess(path)
+	if err := smt.checkDepth(key, path); err != nil {
+		return err
+	}
 
 	// Convert the value into a hash by computing its digest
-	valueHash := smt.valueHash(value)
+	return smt.updateLeaf(key, path, smt.valueHash(value))
+}
 
+// updateLeaf inserts the leaf with the value hash provided at the key's path
+func (smt *SMT) updateLeaf(key, path, valueHash []byte) error {
 	// Update the trie with the new key-value pair
 	var orphans orphanNodes
 
 	// Compute the new root by inserting (path, valueHash) starting from the
 	// root of the tree in order to find the correct position of the new leaf.
+	if err := smt.resolvePath(path, false); err != nil {
+		return err
+	}
 	newRoot, err := smt.update(smt.root, 0, path, valueHash, &orphans)
 	if err != nil {
 		return err
@@ -130,6 +188,9 @@ func (smt *SMT) Update(key, value []byte) error {
 	if len(orphans) > 0 {
 		smt.orphans = append(smt.orphans, orphans)
 	}
+	smt.updates++
+	smt.metrics.Updates++
+	smt.emit(UpdateEvent{Key: bytes.Clone(key), ValueHash: bytes.Clone(valueHash)})
 	return nil
 }
 
@@ -230,7 +291,17 @@ func (smt *SMT) update(
 
 // Delete removes the node at the path corresponding to the given key
 func (smt *SMT) Delete(key []byte) error {
-	path := smt.ph.Path(key)
+	if smt.closed {
+		return ErrClosed
+	}
+	path, err := smt.path(key)
+	if err != nil {
+		return err
+	}
+	smt.recordAccess(path)
+	if err := smt.resolvePath(path, true); err != nil {
+		return err
+	}
 	var orphans orphanNodes
 	trie, err := smt.delete(smt.root, 0, path, &orphans)
 	if err != nil {
@@ -240,9 +311,34 @@ func (smt *SMT) Delete(key []byte) error {
 	if len(orphans) > 0 {
 		smt.orphans = append(smt.orphans, orphans)
 	}
+	smt.updates++
+	smt.metrics.Deletes++
+	smt.emit(DeleteEvent{Key: bytes.Clone(key)})
 	return nil
 }
 
+// DeleteWithPrevious removes the leaf for the given key, returning the value
+// hash it held and whether it existed. Unlike Delete, deleting a key which is
+// not present is not an error.
+func (smt *SMT) DeleteWithPrevious(key []byte) (previous []byte, existed bool, err error) {
+	if smt.closed {
+		return nil, false, ErrClosed
+	}
+	path, err := smt.path(key)
+	if err != nil {
+		return nil, false, err
+	}
+	leaf, err := smt.findLeaf(path)
+	if err != nil || leaf == nil {
+		return nil, false, err
+	}
+	previous = leaf.valueHash
+	if err := smt.Delete(key); err != nil {
+		return nil, false, err
+	}
+	return previous, true, nil
+}
+
 func (smt *SMT) delete(node trieNode, depth int, path []byte, orphans *orphanNodes,
 ) (trieNode, error) {
 	node, err := smt.resolveLazy(node)
@@ -295,8 +391,7 @@ func (smt *SMT) delete(node trieNode, depth int, path []byte, orphans *orphanNod
 	if err != nil {
 		return node, err
 	}
-	*sib, err = smt.resolveLazy(*sib)
-	if err != nil {
+	if err = smt.resolveCached(sib); err != nil {
 		return node, err
 	}
 	// Handle replacement of this node, depending on the new child states.
@@ -322,7 +417,42 @@ func (smt *SMT) delete(node trieNode, depth int, path []byte, orphans *orphanNod
 
 // Prove generates a SparseMerkleProof for the given key
 func (smt *SMT) Prove(key []byte) (proof *SparseMerkleProof, err error) {
-	path := smt.ph.Path(key)
+	if smt.closed {
+		return nil, ErrClosed
+	}
+	path, err := smt.path(key)
+	if err != nil {
+		return nil, err
+	}
+	smt.recordAccess(path)
+	proof, _, err = smt.prove(path)
+	return proof, err
+}
+
+// GetWithProof returns the value hash stored at the given key along with a
+// SparseMerkleProof for it, from a single traversal of the trie.
+func (smt *SMT) GetWithProof(key []byte) (valueHash []byte, proof *SparseMerkleProof, err error) {
+	if smt.closed {
+		return nil, nil, ErrClosed
+	}
+	path, err := smt.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	smt.recordAccess(path)
+	proof, leaf, err := smt.prove(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if leaf == nil {
+		return defaultEmptyValue, proof, nil
+	}
+	return leaf.valueHash, proof, nil
+}
+
+// prove generates a SparseMerkleProof for the given path, returning the leaf
+// at the path if it is present.
+func (smt *SMT) prove(path []byte) (proof *SparseMerkleProof, found *leafNode, err error) {
 	var siblings []trieNode
 	var sib trieNode
 
@@ -330,7 +460,7 @@ func (smt *SMT) Prove(key []byte) (proof *SparseMerkleProof, err error) {
 	for depth := 0; depth < smt.depth(); depth++ {
 		node, err = smt.resolveLazy(node)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if node == nil {
 			break
@@ -348,7 +478,7 @@ func (smt *SMT) Prove(key []byte) (proof *SparseMerkleProof, err error) {
 				node = extNode.child
 				node, err = smt.resolveLazy(node)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 			} else {
 				node = extNode.expand()
@@ -362,6 +492,11 @@ func (smt *SMT) Prove(key []byte) (proof *SparseMerkleProof, err error) {
 		}
 		siblings = append(siblings, sib)
 	}
+	// Leaves at the maximum depth are not resolved by the loop above
+	node, err = smt.resolveLazy(node)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// Deal with non-membership proofs. If there is no leaf on this path,
 	// we do not need to add anything else to the proof.
@@ -372,6 +507,8 @@ func (smt *SMT) Prove(key []byte) (proof *SparseMerkleProof, err error) {
 			// This is a non-membership proof that involves showing a different leaf.
 			// Add the leaf data to the proof.
 			leafData = encodeLeafNode(leaf.path, leaf.valueHash)
+		} else {
+			found = leaf
 		}
 	}
 	// Hash siblings from bottom up.
@@ -390,11 +527,11 @@ func (smt *SMT) Prove(key []byte) (proof *SparseMerkleProof, err error) {
 	if sib != nil {
 		sib, err = smt.resolveLazy(sib)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		proof.SiblingData = smt.encode(sib)
 	}
-	return proof, nil
+	return proof, found, nil
 }
 
 // ProveClosest generates a SparseMerkleProof of inclusion for the first
@@ -412,6 +549,9 @@ func (smt *SMT) ProveClosest(path []byte) (
 	proof *SparseMerkleClosestProof, // proof of the key-value pair found
 	err error, // the error value encountered
 ) {
+	if smt.closed {
+		return nil, ErrClosed
+	}
 	// Ensure the path provided is the correct length for the path hasher.
 	if len(path) != smt.Spec().ph.PathSize() {
 		return nil, ErrInvalidClosestPath
@@ -433,8 +573,9 @@ func (smt *SMT) ProveClosest(path []byte) (
 
 	node := smt.root
 	depth := 0
-	// continuously traverse the trie until we hit a leaf node
-	for depth < smt.depth() {
+	// continuously traverse the trie until we hit a leaf node, which may be at
+	// the maximum depth of the trie
+	for depth <= smt.depth() {
 		// save current node information as "parent" info
 		if node != nil {
 			parent = node
@@ -553,6 +694,54 @@ func (smt *SMT) resolveLazy(node trieNode) (trieNode, error) {
 	return smt.resolveNode(stub.digest)
 }
 
+// resolveCached resolves the node in place, caching it in the trie, and
+// leaves it unchanged if reading the node store fails.
+func (smt *SMT) resolveCached(node *trieNode) error {
+	resolved, err := smt.resolveLazy(*node)
+	if err != nil {
+		return err
+	}
+	*node = resolved
+	return nil
+}
+
+// resolvePath resolves every node along the path, and their siblings if
+// siblings is true as deletes may collapse them, caching them in the trie.
+// This ensures updates and deletes at the path read every node they need
+// before modifying the trie, so that it is left unchanged if reading the node
+// store fails.
+func (smt *SMT) resolvePath(path []byte, siblings bool) error {
+	node := &smt.root
+	depth := 0
+	for {
+		if err := smt.resolveCached(node); err != nil {
+			return err
+		}
+		switch n := (*node).(type) {
+		case *extensionNode:
+			if _, fullMatch := n.boundsMatch(path, depth); !fullMatch {
+				return nil
+			}
+			depth += n.length()
+			node = &n.child
+		case *innerNode:
+			child, sib := &n.leftChild, &n.rightChild
+			if getPathBit(path, depth) != leftChildBit {
+				child, sib = sib, child
+			}
+			if siblings {
+				if err := smt.resolveCached(sib); err != nil {
+					return err
+				}
+			}
+			depth++
+			node = child
+		default:
+			return nil
+		}
+	}
+}
+
 // resolveNode returns a trieNode (inner, leaf, or extension) based on what they
 // keyHash points to.
 func (smt *SMT) resolveNode(digest []byte) (trieNode, error) {
@@ -566,10 +755,27 @@ func (smt *SMT) resolveNode(digest []byte) (trieNode, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := smt.checkNode(data, digest, smt.verifyNodes); err != nil {
+		smt.metrics.Corruptions++
+		return nil, err
+	}
 
 	return smt.parseTrieNode(data, digest)
 }
 
+// checkNode returns an error wrapping ErrCorruptNode if the data read from
+// the node store for the digest provided is not an encoded node, or if verify
+// is true and it does not hash to the digest.
+func (smt *SMT) checkNode(data, digest []byte, verify bool) error {
+	if len(data) <= prefixLen || !(isLeafNode(data) || isExtNode(data) || isInnerNode(data)) {
+		return errors.Join(ErrCorruptNode, fmt.Errorf("node %x cannot be decoded", digest))
+	}
+	if verify && !bytes.Equal(smt.hashPreimage(data), digest) {
+		return errors.Join(ErrCorruptNode, fmt.Errorf("node %x does not match its digest", digest))
+	}
+	return nil
+}
+
 // parseTrieNode returns a trieNode (inner, leaf, or extension) based on the
 // first byte of the data.
 func (smt *SMT) parseTrieNode(data, digest []byte) (trieNode, error) {
@@ -616,6 +822,10 @@ func (smt *SMT) resolveSumNode(digest []byte) (trieNode, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := smt.checkNode(data, digest, smt.verifyNodes); err != nil {
+		smt.metrics.Corruptions++
+		return nil, err
+	}
 
 	return smt.parseSumTrieNode(data, digest)
 }
@@ -655,24 +865,97 @@ func (smt *SMT) parseSumTrieNode(data, digest []byte) (trieNode, error) {
 
 // Commit persists all dirty nodes in the trie, deletes all orphaned
 // nodes from the database and then computes and saves the root hash
-func (smt *SMT) Commit() (err error) {
-	// All orphans are persisted and have cached digests, so we don't need to check for null
+func (smt *SMT) Commit() error {
+	if smt.closed {
+		return ErrClosed
+	}
+	pending, err := smt.writeCommit()
+	if err != nil {
+		return err
+	}
+	smt.finishCommit(pending)
+	return nil
+}
+
+// pendingCommit is a commit whose writes were made to the node store but
+// which the trie does not consider committed yet
+type pendingCommit struct {
+	root    []byte
+	pruned  [][]byte
+	written []trieNode
+}
+
+// writeCommit deletes the orphaned nodes and writes the dirty nodes and the
+// root history of a commit to the node store, marking the nodes written as
+// persisted. The commit must then either be finished with finishCommit, or
+// reverted with revertCommit if its writes were not durably applied. Failed
+// writes are reverted before returning.
+func (smt *SMT) writeCommit() (*pendingCommit, error) {
+	pending := &pendingCommit{root: smt.Root()}
 	for _, orphans := range smt.orphans {
+		// All orphans are persisted and have cached digests, so we don't need to check for null
 		for _, hash := range orphans {
-			if err = smt.nodes.Delete(hash); err != nil {
-				return
+			if err := smt.nodes.Delete(hash); err != nil {
+				return nil, err
 			}
 		}
+		pending.pruned = append(pending.pruned, orphans...)
+	}
+	err := smt.commit(smt.root, func(node trieNode) {
+		pending.written = append(pending.written, node)
+	})
+	if err != nil {
+		smt.revertCommit(pending)
+		return nil, err
+	}
+	if err := smt.recordRoot(pending.root); err != nil {
+		smt.revertCommit(pending)
+		return nil, err
+	}
+	return pending, nil
+}
+
+// revertCommit marks the nodes written by the pending commit as dirty again,
+// so that they are written by the next commit along with its orphans.
+func (smt *SMT) revertCommit(pending *pendingCommit) {
+	for _, node := range pending.written {
+		switch n := node.(type) {
+		case *leafNode:
+			n.persisted = false
+		case *innerNode:
+			n.persisted = false
+		case *extensionNode:
+			n.persisted = false
+		}
 	}
+}
+
+// finishCommit makes the pending commit the trie's last commit, updating its
+// statistics and publishing its events.
+func (smt *SMT) finishCommit(pending *pendingCommit) {
 	smt.orphans = nil
-	if err = smt.commit(smt.root); err != nil {
-		return
+	if len(pending.pruned) > 0 {
+		smt.emit(PruneEvent{Digests: pending.pruned})
+	}
+	smt.rootHash = pending.root
+	smt.lastCommit = CommitStats{
+		Root:     smt.rootHash,
+		Updates:  smt.updates,
+		Written:  len(pending.written),
+		Orphaned: len(pending.pruned),
+	}
+	smt.updates = 0
+	smt.metrics.Commits++
+	smt.metrics.Reclaimed += uint64(len(pending.pruned))
+	smt.emit(CommitEvent{Root: smt.rootHash, Stats: smt.lastCommit})
+	if smt.checkCommit(smt.lastCommit) {
+		smt.metrics.Anomalies++
 	}
-	smt.rootHash = smt.Root()
-	return
 }
 
-func (smt *SMT) commit(node trieNode) error {
+// commit writes the dirty nodes of the subtrie rooted at node to the node
+// store, marking them persisted and calling written with each of them.
+func (smt *SMT) commit(node trieNode, written func(trieNode)) error {
 	if node != nil && node.Persisted() {
 		return nil
 	}
@@ -680,22 +963,23 @@ func (smt *SMT) commit(node trieNode) error {
 	case *leafNode:
 		n.persisted = true
 	case *innerNode:
-		n.persisted = true
-		if err := smt.commit(n.leftChild); err != nil {
+		if err := smt.commit(n.leftChild, written); err != nil {
 			return err
 		}
-		if err := smt.commit(n.rightChild); err != nil {
+		if err := smt.commit(n.rightChild, written); err != nil {
 			return err
 		}
-	case *extensionNode:
 		n.persisted = true
-		if err := smt.commit(n.child); err != nil {
+	case *extensionNode:
+		if err := smt.commit(n.child, written); err != nil {
 			return err
 		}
+		n.persisted = true
 	default:
 		return nil
 	}
 	preimage := smt.encode(node)
+	written(node)
 	return smt.nodes.Set(smt.digest(node), preimage)
 }
 
@@ -403,3 +403,28 @@ func TestSMT_ProveClosest_Proof(t *testing.T) {
 		checkClosestCompactEquivalence(t, proof512, smt512.Spec())
 	}
 }
+
+func TestSMT_GetWithProof(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, trie.Update([]byte("baz"), []byte("qux")))
+	require.NoError(t, trie.Commit())
+
+	valueHash, proof, err := trie.GetWithProof([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, trie.valueHash([]byte("bar")), valueHash)
+	expected, err := trie.Prove([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, expected, proof)
+	valid, err := VerifyProof(proof, trie.Root(), []byte("foo"), []byte("bar"), trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// Missing keys return the default value with a non-membership proof
+	valueHash, proof, err = trie.GetWithProof([]byte("missing"))
+	require.NoError(t, err)
+	require.Equal(t, defaultEmptyValue, valueHash)
+	valid, err = VerifyProof(proof, trie.Root(), []byte("missing"), nil, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+}
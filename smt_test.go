@@ -3,7 +3,8 @@ package smt
 import (
 	"crypto/rand"
 	"crypto/sha256"
-	"hash"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -12,22 +13,11 @@ import (
 	"github.com/pokt-network/smt/kvstore/simplemap"
 )
 
-func NewSMTWithStorage(
-	nodes, preimages kvstore.MapStore,
-	hasher hash.Hash,
-	options ...TrieSpecOption,
-) *SMTWithStorage {
-	return &SMTWithStorage{
-		SMT:       NewSparseMerkleTrie(nodes, hasher, options...),
-		preimages: preimages,
-	}
-}
-
 func TestSMT_TrieUpdateBasic(t *testing.T) {
 	smn := simplemap.NewSimpleMap()
 	smv := simplemap.NewSimpleMap()
 	lazy := NewSparseMerkleTrie(smn, sha256.New())
-	smt := &SMTWithStorage{SMT: lazy, preimages: smv}
+	smt := &SMTWithStorage{trie: lazy, preimages: smv}
 	var value []byte
 	var has bool
 
@@ -81,12 +71,12 @@ func TestSMT_TrieUpdateBasic(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte("testValue2"), value)
 
-	require.NoError(t, lazy.Commit())
+	require.NoError(t, smt.Commit())
 
 	// Test that a trie can be imported from a KVStore
 	lazy = ImportSparseMerkleTrie(smn, sha256.New(), smt.Root())
 	require.NoError(t, err)
-	smt = &SMTWithStorage{SMT: lazy, preimages: smv}
+	smt = &SMTWithStorage{trie: lazy, preimages: smv}
 
 	value, err = smt.GetValue([]byte("testKey"))
 	require.NoError(t, err)
@@ -106,7 +96,7 @@ func TestSMT_TrieDeleteBasic(t *testing.T) {
 	smn := simplemap.NewSimpleMap()
 	smv := simplemap.NewSimpleMap()
 	lazy := NewSparseMerkleTrie(smn, sha256.New())
-	smt := &SMTWithStorage{SMT: lazy, preimages: smv}
+	smt := &SMTWithStorage{trie: lazy, preimages: smv}
 	rootEmpty := smt.Root()
 
 	// Testing inserting, deleting a key, and inserting it again.
@@ -323,7 +313,7 @@ func TestSMT_OrphanRemoval(t *testing.T) {
 		smv = simplemap.NewSimpleMap()
 		require.NoError(t, err)
 		impl = NewSparseMerkleTrie(smn, sha256.New())
-		smt = &SMTWithStorage{SMT: impl, preimages: smv}
+		smt = &SMTWithStorage{trie: impl, preimages: smv}
 
 		err = smt.Update([]byte("testKey"), []byte("testValue"))
 		require.NoError(t, err)
@@ -397,3 +387,67 @@ func TestSMT_OrphanRemoval(t *testing.T) {
 		}
 	})
 }
+
+func TestSMT_Has(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithValueHasher(nil))
+
+	has, err := trie.Has([]byte("foo"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// Leaves holding an empty value are indistinguishable from missing keys
+	// with Get, but not with Has
+	require.NoError(t, trie.Update([]byte("foo"), nil))
+	valueHash, err := trie.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, defaultEmptyValue, valueHash)
+	has, err = trie.Has([]byte("foo"))
+	require.NoError(t, err)
+	require.True(t, has)
+
+	require.NoError(t, trie.Delete([]byte("foo")))
+	has, err = trie.Has([]byte("foo"))
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
+// failingReadStore is a MapStore failing every read while failing is true
+type failingReadStore struct {
+	kvstore.MapStore
+	failing bool
+}
+
+func (store *failingReadStore) Get(key []byte) ([]byte, error) {
+	if store.failing {
+		return nil, errors.New("read failed")
+	}
+	return store.MapStore.Get(key)
+}
+
+func TestSMT_ReadErrorsLeaveTrieUnchanged(t *testing.T) {
+	store := &failingReadStore{MapStore: simplemap.NewSimpleMap()}
+	trie := NewSparseMerkleTrie(store, sha256.New())
+	for i := 0; i < 100; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key%d", i)), []byte("value")))
+	}
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+	trie = ImportSparseMerkleTrie(store, sha256.New(), root)
+
+	// Operations failing to read a node leave the trie as it was
+	store.failing = true
+	_, err := trie.Get([]byte("key1"))
+	require.Error(t, err)
+	require.Error(t, trie.Update([]byte("key2"), []byte("value2")))
+	require.Error(t, trie.Delete([]byte("key3")))
+	require.Equal(t, root, trie.Root())
+
+	store.failing = false
+	for i := 0; i < 100; i++ {
+		valueHash, err := trie.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, trie.valueHash([]byte("value")), valueHash)
+	}
+	require.NoError(t, trie.Delete([]byte("key3")))
+	require.NotEqual(t, root, trie.Root())
+}
@@ -0,0 +1,500 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+	"hash/fnv"
+	"io"
+	"sync"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// keyLockStripes is the number of mutexes the per-key locks of an
+// SMTWithStorage are striped across.
+const keyLockStripes = 64
+
+// SMTWithStorage wraps an SMT with a mapping of value hashes to values
+// (preimages), so that it can be used as a general purpose authenticated
+// key-value store.
+// Note: this doesn't delete from preimages (inputs to hashing functions),
+// since there could be duplicate stored values.
+//
+// SMTWithStorage is safe for concurrent use, provided both of its stores are.
+// Operations on the same key are serialised end-to-end by striped per-key
+// locks, mutations of the in-memory trie are serialised by a single trie lock
+// and Commit waits for all in-flight operations before persisting the trie.
+// This lets value store I/O for unrelated keys proceed concurrently. The SMT
+// is wrapped rather than embedded, so only its methods which are safe to call
+// concurrently and keep the values consistent with the trie are offered:
+// operations building or replacing the whole trie, such as Merge, BulkLoad
+// and ImportSnapshot, and its unlocked Iterator are not.
+//
+// Values are buffered in memory until the trie is committed, when they are
+// written along with the trie's nodes atomically, see Commit.
+type SMTWithStorage struct {
+	trie      *SMT
+	preimages kvstore.MapStore
+	// pending are the values (by value hash) and keys (by path) not yet
+	// written to preimages, guarded by trieMu, and pendingOrder the order they
+	// were added in.
+	pending      map[string][]byte
+	pendingOrder []string
+	// pendingBytes is the total size of the pending keys and values
+	pendingBytes uint64
+	// staging are the limits of the uncommitted changes, guarded by trieMu,
+	// and stagingFreed is closed once the changes are next committed or
+	// discarded, waking the operations waiting for them to be
+	staging      StagingLimits
+	stagingFreed chan struct{}
+	// codec is the ValueCodec used by UpdateTyped and GetTyped
+	codec ValueCodec
+	// namespaces are the registered per-prefix schemas
+	namespaces []*Namespace
+
+	// commitMu is held for reading by every operation and exclusively by
+	// Commit, so commits never interleave with in-flight operations.
+	commitMu sync.RWMutex
+	// trieMu serialises all access to the in-memory trie, as resolving lazy
+	// nodes and hashing mutate shared state.
+	trieMu sync.Mutex
+	// keyLocks are the striped per-key locks
+	keyLocks [keyLockStripes]sync.Mutex
+}
+
+// NewSMTWithStorage returns a new pointer to an SMTWithStorage struct using
+// the node store provided for the trie and the preimages store for values.
+func NewSMTWithStorage(
+	nodes, preimages kvstore.MapStore,
+	hasher hash.Hash,
+	options ...TrieSpecOption,
+) *SMTWithStorage {
+	return &SMTWithStorage{
+		trie:      NewSparseMerkleTrie(nodes, hasher, options...),
+		preimages: preimages,
+	}
+}
+
+// Update updates a key with a new value in the trie and adds the value to
+// the preimages to be written to the preimages KVStore on the next Commit.
+// Preimages are the values prior to them being hashed - they are used to
+// confirm the values are in the trie. If the key belongs to a registered
+// namespace the value is validated first, returning a ValidationError if it
+// is rejected, or a QuotaError if it would exceed the namespace's quota.
+func (smt *SMTWithStorage) Update(key, value []byte) error {
+	if err := smt.admit(); err != nil {
+		return err
+	}
+	defer smt.lockKey(key)()
+	return smt.update(key, value)
+}
+
+// update updates the key with the value, the caller must hold the key's lock.
+func (smt *SMTWithStorage) update(key, value []byte) error {
+	if err := smt.validate(key, value); err != nil {
+		return err
+	}
+	ns, before, after, err := smt.namespaceDelta(key, value)
+	if err != nil {
+		return err
+	}
+
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	if err := smt.checkStaging(1, uint64(len(key)+len(value))); err != nil {
+		return err
+	}
+	var usage NamespaceUsage
+	if ns != nil {
+		if usage, err = ns.checkQuota(key, ns.usage, before, after); err != nil {
+			return err
+		}
+	}
+	leafValue := smt.leafValue(key, value)
+	if err := smt.trie.Update(key, leafValue); err != nil {
+		return err
+	}
+	if ns != nil {
+		ns.usage = usage
+	}
+	smt.addPending(key, leafValue, value)
+	return nil
+}
+
+// Delete deletes a key from the trie, releasing its usage of its namespace's
+// quota.
+func (smt *SMTWithStorage) Delete(key []byte) error {
+	if err := smt.admit(); err != nil {
+		return err
+	}
+	defer smt.lockKey(key)()
+	ns, before, _, err := smt.namespaceDelta(key, nil)
+	if err != nil {
+		return err
+	}
+
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	if err := smt.checkStaging(1, 0); err != nil {
+		return err
+	}
+	if err := smt.trie.Delete(key); err != nil {
+		return err
+	}
+	if ns != nil {
+		ns.usage, _ = ns.checkQuota(key, ns.usage, before, NamespaceUsage{})
+	}
+	return nil
+}
+
+// DeleteWithPrevious deletes a key from the trie, returning the value it held
+// and whether it existed, see SMT.DeleteWithPrevious.
+func (smt *SMTWithStorage) DeleteWithPrevious(key []byte) (previous []byte, existed bool, err error) {
+	if err := smt.admit(); err != nil {
+		return nil, false, err
+	}
+	defer smt.lockKey(key)()
+	previous, err = smt.getValue(key)
+	if err != nil {
+		return nil, false, err
+	}
+	ns, before, _, err := smt.namespaceDelta(key, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	if err := smt.checkStaging(1, 0); err != nil {
+		return nil, false, err
+	}
+	if _, existed, err = smt.trie.DeleteWithPrevious(key); err != nil || !existed {
+		return nil, false, err
+	}
+	if ns != nil {
+		ns.usage, _ = ns.checkQuota(key, ns.usage, before, NamespaceUsage{})
+	}
+	return previous, true, nil
+}
+
+// Get returns the value hash stored for the given key in the trie.
+func (smt *SMTWithStorage) Get(key []byte) ([]byte, error) {
+	defer smt.lockKey(key)()
+	return smt.getValueHash(key)
+}
+
+// GetValue gets the value of a key from the trie.
+func (smt *SMTWithStorage) GetValue(key []byte) ([]byte, error) {
+	defer smt.lockKey(key)()
+	return smt.getValue(key)
+}
+
+// Has returns true if the key is present in the trie, see SMT.Has. The value
+// store is never read.
+func (smt *SMTWithStorage) Has(key []byte) (bool, error) {
+	defer smt.lockKey(key)()
+
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	return smt.trie.Has(key)
+}
+
+// Root returns the root hash of the trie
+func (smt *SMTWithStorage) Root() MerkleRoot {
+	defer smt.lockTrie()()
+	return smt.trie.Root()
+}
+
+// Spec returns the TrieSpec of the trie
+func (smt *SMTWithStorage) Spec() *TrieSpec {
+	return smt.trie.Spec()
+}
+
+// Prove generates a SparseMerkleProof for the given key
+func (smt *SMTWithStorage) Prove(key []byte) (*SparseMerkleProof, error) {
+	defer smt.lockKey(key)()
+
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	return smt.trie.Prove(key)
+}
+
+// GetWithProof returns the value of a key along with a SparseMerkleProof for
+// it, see SMT.GetWithProof. As the key is locked throughout, the proof is
+// always for the value returned.
+func (smt *SMTWithStorage) GetWithProof(key []byte) ([]byte, *SparseMerkleProof, error) {
+	defer smt.lockKey(key)()
+
+	smt.trieMu.Lock()
+	valueHash, proof, err := smt.trie.GetWithProof(key)
+	smt.trieMu.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	value, err := smt.lookupValue(valueHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	return value, proof, nil
+}
+
+// ProveClosest generates a SparseMerkleClosestProof for the path provided
+func (smt *SMTWithStorage) ProveClosest(path []byte) (*SparseMerkleClosestProof, error) {
+	defer smt.lockTrie()()
+	return smt.trie.ProveClosest(path)
+}
+
+// ProveAbsent generates a single proof that none of the keys provided are
+// present in the trie, see SMT.ProveAbsent.
+func (smt *SMTWithStorage) ProveAbsent(keys [][]byte) (*SparseMerkleAggregatedProof, error) {
+	defer smt.lockTrie()()
+	return smt.trie.ProveAbsent(keys)
+}
+
+// ImportSMTWithStorage returns a new pointer to an SMTWithStorage struct with
+// the root hash provided, using the node store provided for the trie and the
+// preimages store for values. If a commit of the stores was interrupted it is
+// completed first, and the trie is imported at its root instead. An error
+// wrapping ErrReservedKeyspace is returned if either store is a
+// PrefixedStore whose prefix overlaps a reserved key.
+func ImportSMTWithStorage(
+	nodes, preimages kvstore.MapStore,
+	hasher hash.Hash,
+	root []byte,
+	options ...TrieSpecOption,
+) (*SMTWithStorage, error) {
+	if err := checkStoreKeyspaces(nodes, preimages); err != nil {
+		return nil, err
+	}
+	recovered, err := RecoverSMTWithStorage(nodes, preimages)
+	if err != nil {
+		return nil, err
+	}
+	if recovered != nil {
+		root = recovered
+	}
+	return &SMTWithStorage{
+		trie:      ImportSparseMerkleTrie(nodes, hasher, root, options...),
+		preimages: preimages,
+	}, nil
+}
+
+// ListKeys returns up to limit leaf paths of the trie in ascending order,
+// starting after the page token provided, see SMT.ListKeys.
+func (smt *SMTWithStorage) ListKeys(startAfter []byte, limit int) ([][]byte, []byte, error) {
+	defer smt.lockTrie()()
+	return smt.trie.ListKeys(startAfter, limit)
+}
+
+// ProveMulti generates a SparseMerkleMultiProof of the membership or
+// non-membership of every key provided, see SMT.ProveMulti.
+func (smt *SMTWithStorage) ProveMulti(keys [][]byte) (*SparseMerkleMultiProof, error) {
+	defer smt.lockTrie()()
+	return smt.trie.ProveMulti(keys)
+}
+
+// ProofChangeset returns the changeset of the updates of the keys provided
+// since proofs were last generated, see SMT.ProofChangeset.
+func (smt *SMTWithStorage) ProofChangeset(keys [][]byte) (ProofChangeset, error) {
+	defer smt.lockTrie()()
+	return smt.trie.ProofChangeset(keys)
+}
+
+// Diff returns the leaves changed between the two committed roots provided,
+// see SMT.Diff.
+func (smt *SMTWithStorage) Diff(from, to MerkleRoot) ([]LeafChange, error) {
+	defer smt.lockTrie()()
+	return smt.trie.Diff(from, to)
+}
+
+// IteratePrefix calls fn with the path and value hash of every leaf under the
+// prefix, see SMT.IteratePrefix. The trie is locked throughout, so fn must
+// not operate on it.
+func (smt *SMTWithStorage) IteratePrefix(prefix []byte, prefixBits int, fn func(path, valueHash []byte) bool) error {
+	defer smt.lockTrie()()
+	return smt.trie.IteratePrefix(prefix, prefixBits, fn)
+}
+
+// Walk visits every node of the trie, see SMT.Walk. The trie is locked
+// throughout, so fn must not operate on it.
+func (smt *SMTWithStorage) Walk(fn func(depth int, nodeType NodeType, hash, data []byte) error) error {
+	defer smt.lockTrie()()
+	return smt.trie.Walk(fn)
+}
+
+// Stats returns the node counts, leaf depths and size of the trie, see
+// SMT.Stats.
+func (smt *SMTWithStorage) Stats() (*TrieStats, error) {
+	defer smt.lockTrie()()
+	return smt.trie.Stats()
+}
+
+// AnalyzeDistribution reports the distribution of the leaves' paths, see
+// SMT.AnalyzeDistribution.
+func (smt *SMTWithStorage) AnalyzeDistribution(prefixBits, collisionBits int) (*DistributionReport, error) {
+	defer smt.lockTrie()()
+	return smt.trie.AnalyzeDistribution(prefixBits, collisionBits)
+}
+
+// WriteDOT renders the trie in the Graphviz DOT language, see SMT.WriteDOT.
+func (smt *SMTWithStorage) WriteDOT(w io.Writer, maxDepth int) error {
+	defer smt.lockTrie()()
+	return smt.trie.WriteDOT(w, maxDepth)
+}
+
+// ExportSnapshot writes the leaves of the trie's committed root to w, see
+// SMT.ExportSnapshot. Only the trie is exported, not its values.
+func (smt *SMTWithStorage) ExportSnapshot(w io.Writer) error {
+	defer smt.lockTrie()()
+	return smt.trie.ExportSnapshot(w)
+}
+
+// RootAt returns the root recorded by the commit with the given sequence
+// number, see SMT.RootAt.
+func (smt *SMTWithStorage) RootAt(seq uint64) (MerkleRoot, error) {
+	defer smt.lockTrie()()
+	return smt.trie.RootAt(seq)
+}
+
+// LatestRootSeq returns the sequence number of the latest commit recorded in
+// the root history, see SMT.LatestRootSeq.
+func (smt *SMTWithStorage) LatestRootSeq() (uint64, error) {
+	defer smt.lockTrie()()
+	return smt.trie.LatestRootSeq()
+}
+
+// LastCommitStats returns the statistics of the last commit of the trie
+func (smt *SMTWithStorage) LastCommitStats() CommitStats {
+	defer smt.lockTrie()()
+	return smt.trie.LastCommitStats()
+}
+
+// Metrics returns the cumulative counters of the trie, see SMT.Metrics
+func (smt *SMTWithStorage) Metrics() (Metrics, error) {
+	defer smt.lockTrie()()
+	return smt.trie.Metrics()
+}
+
+// Commit persists all dirty nodes in the trie along with the pending values,
+// waiting for all in-flight operations to complete and blocking new ones
+// until it returns.
+//
+// The nodes and values are written atomically, through a journal written to
+// the preimages store before either store is modified: if the commit is
+// interrupted the stores must be recovered with RecoverSMTWithStorage (or by
+// ImportSMTWithStorage) before use, so that no values are left without their
+// leaves or leaves without their values.
+func (smt *SMTWithStorage) Commit() error {
+	smt.commitMu.Lock()
+	defer smt.commitMu.Unlock()
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	return smt.commitJournaled()
+}
+
+// getValueHash returns the value hash for the key, the caller must hold the
+// key's lock.
+func (smt *SMTWithStorage) getValueHash(key []byte) ([]byte, error) {
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	return smt.trie.Get(key)
+}
+
+// getValue returns the value for the key, the caller must hold the key's lock.
+func (smt *SMTWithStorage) getValue(key []byte) ([]byte, error) {
+	valueHash, err := smt.getValueHash(key)
+	if err != nil {
+		return nil, err
+	}
+	return smt.lookupValue(valueHash)
+}
+
+// lookupValue returns the value with the given value hash, from the pending
+// values or the preimages store.
+func (smt *SMTWithStorage) lookupValue(valueHash []byte) ([]byte, error) {
+	if valueHash == nil {
+		return nil, nil
+	}
+	value, err := smt.lookupPreimage(valueHash)
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			// If key isn't found, return default value
+			value = defaultEmptyValue
+		} else {
+			// Otherwise percolate up any other error
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// lookupPreimage returns the preimage stored under the given key, from the
+// pending preimages or the preimages store.
+func (smt *SMTWithStorage) lookupPreimage(storeKey []byte) ([]byte, error) {
+	smt.trieMu.Lock()
+	preimage, ok := smt.pending[string(storeKey)]
+	smt.trieMu.Unlock()
+	if ok {
+		return preimage, nil
+	}
+	return smt.preimages.Get(storeKey)
+}
+
+// addPending buffers the value to be written to the preimages store on the
+// next Commit, under the value hash of the leaf value it was inserted as,
+// along with the key it was inserted at. The caller must hold the trie lock.
+func (smt *SMTWithStorage) addPending(key, leafValue, value []byte) {
+	smt.addPreimage(string(smt.trie.valueHash(leafValue)), value)
+	smt.addPreimage(string(keyPreimageKey(smt.trie.ph.Path(key))), key)
+}
+
+// addPreimage buffers the preimage to be written under the given key of the
+// preimages store on the next Commit, the caller must hold the trie lock.
+func (smt *SMTWithStorage) addPreimage(storeKey string, preimage []byte) {
+	if _, ok := smt.pending[storeKey]; ok {
+		return
+	}
+	if smt.pending == nil {
+		smt.pending = make(map[string][]byte)
+	}
+	smt.pending[storeKey] = bytes.Clone(preimage)
+	smt.pendingOrder = append(smt.pendingOrder, storeKey)
+	smt.pendingBytes += uint64(len(preimage))
+}
+
+// clearPending drops the pending preimages once they were committed or
+// discarded, releasing the operations waiting for the staging limits, the
+// caller must hold the trie lock.
+func (smt *SMTWithStorage) clearPending() {
+	smt.pending, smt.pendingOrder, smt.pendingBytes = nil, nil, 0
+	if smt.stagingFreed != nil {
+		close(smt.stagingFreed)
+		smt.stagingFreed = nil
+	}
+}
+
+// lockTrie acquires the locks required to read the whole trie and returns
+// the function releasing them.
+func (smt *SMTWithStorage) lockTrie() func() {
+	smt.commitMu.RLock()
+	smt.trieMu.Lock()
+	return func() {
+		smt.trieMu.Unlock()
+		smt.commitMu.RUnlock()
+	}
+}
+
+// lockKey acquires the locks required to operate on the key provided and
+// returns the function releasing them.
+func (smt *SMTWithStorage) lockKey(key []byte) func() {
+	smt.commitMu.RLock()
+	stripe := fnv.New32a()
+	stripe.Write(key)
+	mu := &smt.keyLocks[stripe.Sum32()%keyLockStripes]
+	mu.Lock()
+	return func() {
+		mu.Unlock()
+		smt.commitMu.RUnlock()
+	}
+}
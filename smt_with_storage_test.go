@@ -0,0 +1,97 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMTWithStorage_Concurrent(t *testing.T) {
+	const (
+		workers       = 8
+		keysPerWorker = 50
+	)
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keysPerWorker; i++ {
+				key := []byte(fmt.Sprintf("key-%d-%d", w, i))
+				value := []byte(fmt.Sprintf("value-%d-%d", w, i))
+				require.NoError(t, trie.Update(key, value))
+
+				got, err := trie.GetValue(key)
+				require.NoError(t, err)
+				require.Equal(t, value, got)
+
+				_, err = trie.Prove(key)
+				require.NoError(t, err)
+
+				// Periodically commit while other workers keep writing
+				if i%10 == 0 {
+					require.NoError(t, trie.Commit())
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	require.NoError(t, trie.Commit())
+
+	// The result must be identical to applying the same updates sequentially
+	expected := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	for w := 0; w < workers; w++ {
+		for i := 0; i < keysPerWorker; i++ {
+			key := []byte(fmt.Sprintf("key-%d-%d", w, i))
+			value := []byte(fmt.Sprintf("value-%d-%d", w, i))
+			require.NoError(t, expected.Update(key, value))
+		}
+	}
+	require.Equal(t, expected.Root(), trie.Root())
+
+	for w := 0; w < workers; w++ {
+		key := []byte(fmt.Sprintf("key-%d-0", w))
+		has, err := trie.Has(key)
+		require.NoError(t, err)
+		require.True(t, has)
+	}
+}
+
+func TestSMTWithStorage_HasSkipsValueStore(t *testing.T) {
+	preimages := simplemap.NewSimpleMap()
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), preimages, sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, trie.Commit())
+
+	// Losing the values does not affect the existence of their keys
+	require.NoError(t, preimages.ClearAll())
+	has, err := trie.Has([]byte("foo"))
+	require.NoError(t, err)
+	require.True(t, has)
+	has, err = trie.Has([]byte("baz"))
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
+func TestSMTWithStorage_GetWithProof(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+
+	// Pending and committed values are both returned
+	for i := 0; i < 2; i++ {
+		value, proof, err := trie.GetWithProof([]byte("foo"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("bar"), value)
+		valid, err := VerifyProof(proof, trie.Root(), []byte("foo"), value, trie.Spec())
+		require.NoError(t, err)
+		require.True(t, valid)
+		require.NoError(t, trie.Commit())
+	}
+}
@@ -0,0 +1,145 @@
+package smt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// snapshotExportVersion is the version of the format tries are exported
+	// in, it must be bumped whenever the format changes.
+	snapshotExportVersion = 1
+	// maxSnapshotFieldSize bounds the length of every field of an exported
+	// trie, so that a corrupted length cannot exhaust memory on import
+	maxSnapshotFieldSize = 1 << 26
+)
+
+// ErrBadSnapshot is returned when an exported trie cannot be imported as it
+// is malformed or does not rebuild the root it was exported at.
+var ErrBadSnapshot = errors.New("bad snapshot")
+
+// ExportSnapshot streams the leaves of the trie to the writer provided, so
+// that the trie can be rebuilt elsewhere with ImportSnapshot without replaying
+// its history. The export starts with the fingerprint of the trie's spec and
+// its root, followed by the path and value hash of every leaf in ascending
+// path order, each length prefixed (as uvarints), and ends with a zero
+// length. Leaves are read one at a time so the trie is never fully loaded.
+// Only the trie is exported, not the values stored by an SMTWithStorage.
+func (smt *SMT) ExportSnapshot(w io.Writer) error {
+	if smt.closed {
+		return ErrClosed
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteByte(snapshotExportVersion)
+	writeSnapshotField(bw, smt.Spec().Fingerprint())
+	writeSnapshotField(bw, smt.Root())
+	it := smt.Iterator()
+	for it.Next() {
+		writeSnapshotField(bw, it.Path())
+		writeSnapshotField(bw, it.ValueHash())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	writeSnapshotField(bw, nil)
+	return bw.Flush()
+}
+
+// ImportSnapshot rebuilds the trie from the reader provided, as written by
+// ExportSnapshot from a trie with the same spec, and commits it once its root
+// is verified to match the exported root. The trie must be empty, and is left
+// empty if the import fails. An error wrapping ErrSpecMismatch is returned if
+// the trie was exported with a different spec, and one wrapping
+// ErrBadSnapshot if the export is malformed or its root does not match.
+func (smt *SMT) ImportSnapshot(r io.Reader) (err error) {
+	if smt.closed {
+		return ErrClosed
+	}
+	if !bytes.Equal(smt.Root(), smt.placeholder()) {
+		return errors.New("cannot import a snapshot into a non-empty trie")
+	}
+	defer func() {
+		if err != nil {
+			smt.root = nil
+			smt.orphans = nil
+		}
+	}()
+
+	br := bufio.NewReader(r)
+	version, err := br.ReadByte()
+	if err != nil {
+		return errors.Join(ErrBadSnapshot, err)
+	}
+	if version != snapshotExportVersion {
+		return errors.Join(ErrBadSnapshot, fmt.Errorf("unknown snapshot version %d", version))
+	}
+	fingerprint, err := readSnapshotField(br)
+	if err != nil {
+		return err
+	}
+	if err := smt.Spec().checkFingerprint(fingerprint); err != nil {
+		return err
+	}
+	root, err := readSnapshotField(br)
+	if err != nil {
+		return err
+	}
+
+	var orphans orphanNodes
+	var previous []byte
+	for {
+		path, err := readSnapshotField(br)
+		if err != nil {
+			return err
+		}
+		if len(path) == 0 {
+			break
+		}
+		if len(path) != smt.ph.PathSize() {
+			return errors.Join(ErrBadSnapshot, fmt.Errorf("invalid path length %d", len(path)))
+		}
+		if previous != nil && bytes.Compare(previous, path) >= 0 {
+			return errors.Join(ErrBadSnapshot, errors.New("leaves out of order"))
+		}
+		valueHash, err := readSnapshotField(br)
+		if err != nil {
+			return err
+		}
+		if smt.root, err = smt.update(smt.root, 0, path, valueHash, &orphans); err != nil {
+			return err
+		}
+		previous = path
+	}
+	if !bytes.Equal(smt.Root(), root) {
+		return errors.Join(ErrBadSnapshot, fmt.Errorf("rebuilt root %x does not match %x", smt.Root(), root))
+	}
+	return smt.Commit()
+}
+
+// writeSnapshotField writes the field to the writer prefixed by its length,
+// errors are returned when the writer is flushed
+func writeSnapshotField(w *bufio.Writer, field []byte) {
+	var lenBz [binary.MaxVarintLen64]byte
+	w.Write(lenBz[:binary.PutUvarint(lenBz[:], uint64(len(field)))])
+	w.Write(field)
+}
+
+// readSnapshotField reads a length prefixed field from the reader
+func readSnapshotField(r *bufio.Reader) ([]byte, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errors.Join(ErrBadSnapshot, err)
+	}
+	if length > maxSnapshotFieldSize {
+		return nil, errors.Join(ErrBadSnapshot, fmt.Errorf("field of %d bytes too large", length))
+	}
+	field := make([]byte, length)
+	if _, err := io.ReadFull(r, field); err != nil {
+		return nil, errors.Join(ErrBadSnapshot, err)
+	}
+	return field, nil
+}
@@ -0,0 +1,64 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_ExportImportSnapshot(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := 0; i < 100; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i))))
+	}
+	require.NoError(t, trie.Commit())
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, trie.ExportSnapshot(buf))
+	export := buf.Bytes()
+
+	nodes := simplemap.NewSimpleMap()
+	imported := NewSparseMerkleTrie(nodes, sha256.New())
+	require.NoError(t, imported.ImportSnapshot(bytes.NewReader(export)))
+	require.Equal(t, trie.Root(), imported.Root())
+	// The imported trie is committed
+	reopened := ImportSparseMerkleTrie(nodes, sha256.New(), imported.Root())
+	got, err := reopened.Get([]byte("key-42"))
+	require.NoError(t, err)
+	require.Equal(t, trie.valueHash([]byte("value-42")), got)
+
+	// Only empty tries can be imported into
+	require.Error(t, imported.ImportSnapshot(bytes.NewReader(export)))
+
+	// Exports of other specs are rejected
+	other := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha512.New())
+	require.ErrorIs(t, other.ImportSnapshot(bytes.NewReader(export)), ErrSpecMismatch)
+
+	// Corrupted exports are rejected and leave the trie empty
+	corrupted := bytes.Clone(export)
+	corrupted[len(corrupted)-2] ^= 1
+	empty := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.ErrorIs(t, empty.ImportSnapshot(bytes.NewReader(corrupted)), ErrBadSnapshot)
+	require.Equal(t, empty.placeholder(), []byte(empty.Root()))
+	require.ErrorIs(t, empty.ImportSnapshot(bytes.NewReader(export[:len(export)/2])), ErrBadSnapshot)
+	require.Equal(t, empty.placeholder(), []byte(empty.Root()))
+}
+
+func TestSMST_ExportImportSnapshot(t *testing.T) {
+	trie := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := 0; i < 20; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte("value"), uint64(i)))
+	}
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, trie.ExportSnapshot(buf))
+
+	imported := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, imported.ImportSnapshot(buf))
+	require.Equal(t, trie.Root(), imported.Root())
+	require.Equal(t, trie.Sum(), imported.Sum())
+}
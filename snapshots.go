@@ -0,0 +1,388 @@
+package smt
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash"
+	"sort"
+	"time"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Ensure the readOnlyStore can be used as an SMT node store
+var _ kvstore.MapStore = readOnlyStore{}
+
+var (
+	// ErrSnapshotNotFound is returned when accessing a snapshot which is not
+	// retained, or a label which is not set.
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+	// ErrReadOnly is returned when modifying the store of a read-only
+	// snapshot.
+	ErrReadOnly = errors.New("snapshot is read-only")
+
+	// snapshotStateKey is the key the state of an SMTWithSnapshots is stored
+	// under in its metadata store
+	snapshotStateKey = reserveKey("snapshots", []byte("smt/snapshots"))
+)
+
+// Snapshot is a committed root retained by an SMTWithSnapshots.
+type Snapshot struct {
+	Root MerkleRoot
+	// Height is the commit index the root was committed at, starting at one
+	Height uint64
+	Time   time.Time
+	// Labels are the labels of the root, which is retained while it has any
+	Labels []string
+}
+
+// snapshotState is the persisted state of an SMTWithSnapshots
+type snapshotState struct {
+	Snapshots []Snapshot
+	// Labels maps every label to the root it labels
+	Labels map[string][]byte
+	// Orphans are the digests of the nodes orphaned by commits, which are
+	// only deleted once unreachable from every retained snapshot
+	Orphans [][]byte
+}
+
+// SMTWithSnapshots is an SMT retaining the roots it commits as snapshots,
+// readable until they are pruned. Rather than deleting the nodes orphaned by
+// a commit, it defers their deletion until they are unreachable from every
+// retained snapshot. Specific roots can be labelled (e.g. "epoch-42" or
+// "upgrade-v3") so they are retained regardless of their age.
+//
+// The snapshots and labels are persisted in a metadata store, which should
+// not be used for anything else. SMTWithSnapshots is not safe for concurrent
+// use.
+type SMTWithSnapshots struct {
+	*SMT
+	meta  kvstore.MapStore
+	state snapshotState
+	now   func() time.Time
+}
+
+// NewSMTWithSnapshots returns a new, empty SMTWithSnapshots using the node
+// store provided for the trie and the metadata store for its snapshots.
+func NewSMTWithSnapshots(
+	nodes, meta kvstore.MapStore,
+	hasher hash.Hash,
+	options ...TrieSpecOption,
+) *SMTWithSnapshots {
+	trie := NewSparseMerkleTrie(nodes, hasher, options...)
+	return &SMTWithSnapshots{
+		SMT:   trie,
+		meta:  meta,
+		state: snapshotState{Labels: make(map[string][]byte)},
+		now:   trie.Clock().Now,
+	}
+}
+
+// ImportSMTWithSnapshots returns the SMTWithSnapshots persisted in the stores
+// provided, at the root of its latest snapshot. An error wrapping
+// ErrReservedKeyspace is returned if either store is a PrefixedStore whose
+// prefix overlaps a reserved key.
+func ImportSMTWithSnapshots(
+	nodes, meta kvstore.MapStore,
+	hasher hash.Hash,
+	options ...TrieSpecOption,
+) (*SMTWithSnapshots, error) {
+	if err := checkStoreKeyspaces(nodes, meta); err != nil {
+		return nil, err
+	}
+	stateBz, err := meta.Get(snapshotStateKey)
+	if err != nil {
+		return nil, err
+	}
+	trie := NewSMTWithSnapshots(nodes, meta, hasher, options...)
+	if err := gob.NewDecoder(bytes.NewReader(stateBz)).Decode(&trie.state); err != nil {
+		return nil, err
+	}
+	if trie.state.Labels == nil {
+		trie.state.Labels = make(map[string][]byte)
+	}
+	if n := len(trie.state.Snapshots); n > 0 {
+		root := trie.state.Snapshots[n-1].Root
+		trie.root = &lazyNode{root}
+		trie.rootHash = root
+	}
+	return trie, nil
+}
+
+// Commit persists the trie and retains its root as a new snapshot, unless it
+// is the root of the latest snapshot. The nodes orphaned since the last commit
+// are kept until they are pruned.
+func (trie *SMTWithSnapshots) Commit() error {
+	if trie.closed {
+		return ErrClosed
+	}
+	// Take the orphans so the trie's commit does not delete them
+	for _, orphans := range trie.orphans {
+		trie.state.Orphans = append(trie.state.Orphans, orphans...)
+	}
+	trie.orphans = nil
+	if err := trie.SMT.Commit(); err != nil {
+		return err
+	}
+	root := trie.Root()
+	var height uint64
+	if n := len(trie.state.Snapshots); n > 0 {
+		latest := trie.state.Snapshots[n-1]
+		if bytes.Equal(latest.Root, root) {
+			return trie.saveState()
+		}
+		height = latest.Height
+	}
+	trie.state.Snapshots = append(trie.state.Snapshots, Snapshot{
+		Root:   root,
+		Height: height + 1,
+		Time:   trie.now(),
+	})
+	return trie.saveState()
+}
+
+// Snapshots returns the retained snapshots in the order they were committed,
+// along with their labels.
+func (trie *SMTWithSnapshots) Snapshots() []Snapshot {
+	labels := make(map[string][]string)
+	for label, root := range trie.state.Labels {
+		labels[string(root)] = append(labels[string(root)], label)
+	}
+	snapshots := make([]Snapshot, len(trie.state.Snapshots))
+	for i, snapshot := range trie.state.Snapshots {
+		snapshot.Labels = labels[string(snapshot.Root)]
+		sort.Strings(snapshot.Labels)
+		snapshots[i] = snapshot
+	}
+	return snapshots
+}
+
+// Snapshot returns a read-only view of the trie at the retained root
+// provided, returning ErrSnapshotNotFound if it is not retained. Modifying
+// the view fails with ErrReadOnly once its nodes are written.
+func (trie *SMTWithSnapshots) Snapshot(root MerkleRoot) (*SMT, error) {
+	if !trie.retained(root) {
+		return nil, ErrSnapshotNotFound
+	}
+	spec := trie.TrieSpec
+	spec.borrowStores = true
+	spec.events = nil
+	return &SMT{
+		TrieSpec: spec,
+		nodes:    readOnlyStore{trie.nodes},
+		root:     &lazyNode{root},
+		rootHash: root,
+	}, nil
+}
+
+// Label labels the retained root provided, so it is retained regardless of
+// its age, moving the label if it already labels another root. The root
+// must be retained, or ErrSnapshotNotFound is returned.
+func (trie *SMTWithSnapshots) Label(label string, root MerkleRoot) error {
+	if !trie.retained(root) {
+		return ErrSnapshotNotFound
+	}
+	trie.state.Labels[label] = bytes.Clone(root)
+	return trie.saveState()
+}
+
+// Unlabel removes the label, returning ErrSnapshotNotFound if it is not set.
+// The root it labelled is pruned on the next Prune if it is older than the
+// maximum age and has no other label.
+func (trie *SMTWithSnapshots) Unlabel(label string) error {
+	if _, ok := trie.state.Labels[label]; !ok {
+		return ErrSnapshotNotFound
+	}
+	delete(trie.state.Labels, label)
+	return trie.saveState()
+}
+
+// LabeledRoot returns the root with the label provided, returning
+// ErrSnapshotNotFound if the label is not set.
+func (trie *SMTWithSnapshots) LabeledRoot(label string) (MerkleRoot, error) {
+	root, ok := trie.state.Labels[label]
+	if !ok {
+		return nil, ErrSnapshotNotFound
+	}
+	return root, nil
+}
+
+// PrunePolicy decides which snapshots of an SMTWithSnapshots are dropped when
+// pruning. Labelled snapshots and the latest snapshot are always retained.
+type PrunePolicy struct {
+	// MaxAge is the age past which snapshots are dropped
+	MaxAge time.Duration
+	// KeepLatest is the number of most recent snapshots retained regardless
+	// of their age
+	KeepLatest int
+}
+
+// PruneReport reports the effects of pruning a trie with a PrunePolicy.
+type PruneReport struct {
+	// Dropped are the snapshots dropped, whose roots are no longer provable
+	Dropped []Snapshot
+	// Retained is the number of snapshots retained
+	Retained int
+	// Nodes is the number of nodes deleted from the node store
+	Nodes int
+	// Bytes is the total size of the nodes deleted from the node store
+	Bytes int
+}
+
+// Prune drops the snapshots the policy provided does not retain and deletes
+// the orphaned nodes no longer reachable from any retained snapshot,
+// returning a report of what was pruned. As reachability is determined by
+// walking every retained snapshot, pruning takes time proportional to the
+// number of nodes of the retained snapshots.
+func (trie *SMTWithSnapshots) Prune(policy PrunePolicy) (*PruneReport, error) {
+	plan, err := trie.planPrune(policy)
+	if err != nil {
+		return nil, err
+	}
+	for _, digest := range plan.deleted {
+		if err := trie.nodes.Delete(digest); err != nil {
+			return nil, err
+		}
+	}
+	trie.state.Snapshots = plan.kept
+	trie.state.Orphans = plan.orphans
+	if len(plan.deleted) > 0 {
+		trie.emit(PruneEvent{Digests: plan.deleted})
+	}
+	return plan.report, trie.saveState()
+}
+
+// PrunePlan returns a report of what pruning with the policy provided would
+// drop and delete, without modifying the trie or its stores, so retention
+// policies can be validated safely.
+func (trie *SMTWithSnapshots) PrunePlan(policy PrunePolicy) (*PruneReport, error) {
+	plan, err := trie.planPrune(policy)
+	if err != nil {
+		return nil, err
+	}
+	return plan.report, nil
+}
+
+// prunePlan is the outcome of pruning a trie with a policy
+type prunePlan struct {
+	report *PruneReport
+	// kept are the snapshots retained
+	kept []Snapshot
+	// deleted are the digests of the nodes to delete
+	deleted [][]byte
+	// orphans are the orphans still reachable from the retained snapshots
+	orphans [][]byte
+}
+
+// planPrune determines the snapshots the policy retains and the orphaned
+// nodes unreachable from them
+func (trie *SMTWithSnapshots) planPrune(policy PrunePolicy) (*prunePlan, error) {
+	if trie.closed {
+		return nil, ErrClosed
+	}
+	labelled := make(map[string]bool, len(trie.state.Labels))
+	for _, root := range trie.state.Labels {
+		labelled[string(root)] = true
+	}
+	plan := &prunePlan{report: &PruneReport{}}
+	cutoff := trie.now().Add(-policy.MaxAge)
+	keepLatest := policy.KeepLatest
+	if keepLatest < 1 {
+		keepLatest = 1
+	}
+	latest := len(trie.state.Snapshots) - keepLatest
+	for i, snapshot := range trie.state.Snapshots {
+		if i >= latest || labelled[string(snapshot.Root)] || !snapshot.Time.Before(cutoff) {
+			plan.kept = append(plan.kept, snapshot)
+			continue
+		}
+		plan.report.Dropped = append(plan.report.Dropped, snapshot)
+	}
+	plan.report.Retained = len(plan.kept)
+
+	reachable := make(map[string]bool)
+	for _, snapshot := range plan.kept {
+		if err := trie.markReachable(snapshot.Root, reachable); err != nil {
+			return nil, err
+		}
+	}
+	deleted := make(map[string]bool)
+	for _, digest := range trie.state.Orphans {
+		if reachable[string(digest)] {
+			plan.orphans = append(plan.orphans, digest)
+			continue
+		}
+		// The same node may have been orphaned more than once
+		if deleted[string(digest)] {
+			continue
+		}
+		data, err := trie.nodes.Get(digest)
+		if err != nil {
+			return nil, fmt.Errorf("reading orphan %x: %w", digest, err)
+		}
+		deleted[string(digest)] = true
+		plan.deleted = append(plan.deleted, digest)
+		plan.report.Bytes += len(data)
+	}
+	plan.report.Nodes = len(plan.deleted)
+	return plan, nil
+}
+
+// retained returns true if the root is the root of a retained snapshot
+func (trie *SMTWithSnapshots) retained(root MerkleRoot) bool {
+	for _, snapshot := range trie.state.Snapshots {
+		if bytes.Equal(snapshot.Root, root) {
+			return true
+		}
+	}
+	return false
+}
+
+// markReachable marks the digests of every node reachable from the digest
+// provided, skipping the subtries already marked.
+func (trie *SMTWithSnapshots) markReachable(digest []byte, reachable map[string]bool) error {
+	if reachable[string(digest)] || bytes.Equal(digest, trie.placeholder()) {
+		return nil
+	}
+	reachable[string(digest)] = true
+	node, err := trie.resolveLazy(&lazyNode{digest})
+	if err != nil {
+		return fmt.Errorf("resolving node %x: %w", digest, err)
+	}
+	switch n := node.(type) {
+	case *innerNode:
+		if err := trie.markReachable(n.leftChild.CachedDigest(), reachable); err != nil {
+			return err
+		}
+		return trie.markReachable(n.rightChild.CachedDigest(), reachable)
+	case *extensionNode:
+		return trie.markReachable(n.child.CachedDigest(), reachable)
+	}
+	return nil
+}
+
+// saveState persists the snapshots, labels and orphans of the trie
+func (trie *SMTWithSnapshots) saveState() error {
+	buf := bytes.NewBuffer(nil)
+	if err := gob.NewEncoder(buf).Encode(&trie.state); err != nil {
+		return err
+	}
+	return trie.meta.Set(snapshotStateKey, buf.Bytes())
+}
+
+// readOnlyStore is the node store of a read-only snapshot, failing every
+// modification
+type readOnlyStore struct {
+	kvstore.MapStore
+}
+
+// Set satisfies the MapStore#Set interface
+func (readOnlyStore) Set([]byte, []byte) error { return ErrReadOnly }
+
+// Delete satisfies the MapStore#Delete interface
+func (readOnlyStore) Delete([]byte) error { return ErrReadOnly }
+
+// ClearAll satisfies the MapStore#ClearAll interface
+func (readOnlyStore) ClearAll() error { return ErrReadOnly }
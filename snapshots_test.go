@@ -0,0 +1,151 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMTWithSnapshots(t *testing.T) {
+	nodes, meta := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	trie := NewSMTWithSnapshots(nodes, meta, sha256.New())
+	now := time.Unix(0, 0)
+	trie.now = func() time.Time { return now }
+
+	// Commit a root per epoch, overwriting the same keys
+	var roots []MerkleRoot
+	for epoch := 0; epoch < 5; epoch++ {
+		for i := 0; i < 10; i++ {
+			key := []byte(fmt.Sprintf("key-%d", i))
+			require.NoError(t, trie.Update(key, []byte(fmt.Sprintf("value-%d-%d", epoch, i))))
+		}
+		require.NoError(t, trie.Commit())
+		roots = append(roots, trie.Root())
+		now = now.Add(time.Hour)
+	}
+	require.Len(t, trie.Snapshots(), 5)
+	require.Equal(t, uint64(5), trie.Snapshots()[4].Height)
+
+	// Committing the same root again does not add a snapshot
+	require.NoError(t, trie.Commit())
+	require.Len(t, trie.Snapshots(), 5)
+
+	// Old snapshots are readable
+	snapshot, err := trie.Snapshot(roots[1])
+	require.NoError(t, err)
+	valueHash, err := snapshot.Get([]byte("key-3"))
+	require.NoError(t, err)
+	require.Equal(t, trie.valueHash([]byte("value-1-3")), valueHash)
+	require.NoError(t, snapshot.Update([]byte("key-3"), []byte("modified")))
+	require.ErrorIs(t, snapshot.Commit(), ErrReadOnly)
+
+	require.NoError(t, trie.Label("epoch-1", roots[1]))
+	require.NoError(t, trie.Label("upgrade", roots[1]))
+	require.ErrorIs(t, trie.Label("missing", []byte("root")), ErrSnapshotNotFound)
+	labelled, err := trie.LabeledRoot("epoch-1")
+	require.NoError(t, err)
+	require.Equal(t, roots[1], labelled)
+	require.Equal(t, []string{"epoch-1", "upgrade"}, trie.Snapshots()[1].Labels)
+
+	// Pruning drops old unlabelled snapshots, retaining labelled ones
+	sizeBefore := nodes.Len()
+	policy := PrunePolicy{MaxAge: 150 * time.Minute}
+	report, err := trie.Prune(policy)
+	require.NoError(t, err)
+	require.Len(t, report.Dropped, 2)
+	require.Equal(t, 3, report.Retained)
+	require.Equal(t, sizeBefore-nodes.Len(), report.Nodes)
+	require.Positive(t, report.Bytes)
+	snapshots := trie.Snapshots()
+	require.Len(t, snapshots, 3)
+	require.Equal(t, roots[1], snapshots[0].Root)
+	require.Equal(t, roots[3], snapshots[1].Root)
+	require.Equal(t, roots[4], snapshots[2].Root)
+	require.Less(t, nodes.Len(), sizeBefore)
+	_, err = trie.Snapshot(roots[0])
+	require.ErrorIs(t, err, ErrSnapshotNotFound)
+
+	// Every retained snapshot is still fully readable
+	for epoch, root := range map[int]MerkleRoot{1: roots[1], 3: roots[3], 4: roots[4]} {
+		snapshot, err := trie.Snapshot(root)
+		require.NoError(t, err)
+		for i := 0; i < 10; i++ {
+			valueHash, err := snapshot.Get([]byte(fmt.Sprintf("key-%d", i)))
+			require.NoError(t, err)
+			require.Equal(t, trie.valueHash([]byte(fmt.Sprintf("value-%d-%d", epoch, i))), valueHash)
+		}
+	}
+
+	// Snapshots and labels persist across restarts
+	imported, err := ImportSMTWithSnapshots(nodes, meta, sha256.New())
+	require.NoError(t, err)
+	require.Equal(t, roots[4], imported.Root())
+	require.Equal(t, trie.Snapshots(), imported.Snapshots())
+	imported.now = trie.now
+
+	// Unlabelled snapshots are pruned
+	require.NoError(t, imported.Unlabel("epoch-1"))
+	require.ErrorIs(t, imported.Unlabel("epoch-1"), ErrSnapshotNotFound)
+	_, err = imported.Prune(PrunePolicy{})
+	require.NoError(t, err)
+	require.Len(t, imported.Snapshots(), 2)
+	require.NoError(t, imported.Unlabel("upgrade"))
+	_, err = imported.Prune(PrunePolicy{})
+	require.NoError(t, err)
+	require.Len(t, imported.Snapshots(), 1)
+	_, err = imported.LabeledRoot("upgrade")
+	require.ErrorIs(t, err, ErrSnapshotNotFound)
+
+	// Only the latest snapshot's nodes remain
+	latest := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := 0; i < 10; i++ {
+		require.NoError(t, latest.Update([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-4-%d", i))))
+	}
+	require.NoError(t, latest.Commit())
+	require.Equal(t, latest.nodes.Len(), nodes.Len())
+}
+
+func TestSMTWithSnapshots_PrunePlan(t *testing.T) {
+	nodes, meta := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	trie := NewSMTWithSnapshots(nodes, meta, sha256.New())
+	now := time.Unix(0, 0)
+	trie.now = func() time.Time { return now }
+	var roots []MerkleRoot
+	for epoch := 0; epoch < 4; epoch++ {
+		for i := 0; i < 10; i++ {
+			key := []byte(fmt.Sprintf("key-%d", i))
+			require.NoError(t, trie.Update(key, []byte(fmt.Sprintf("value-%d-%d", epoch, i))))
+		}
+		require.NoError(t, trie.Commit())
+		roots = append(roots, trie.Root())
+		now = now.Add(time.Hour)
+	}
+	require.NoError(t, trie.Label("epoch-0", roots[0]))
+
+	// Planning does not modify the trie or its stores
+	policy := PrunePolicy{KeepLatest: 2}
+	sizeBefore, metaBefore := nodes.Len(), meta.Len()
+	snapshotsBefore := trie.Snapshots()
+	plan, err := trie.PrunePlan(policy)
+	require.NoError(t, err)
+	require.Equal(t, sizeBefore, nodes.Len())
+	require.Equal(t, metaBefore, meta.Len())
+	require.Equal(t, snapshotsBefore, trie.Snapshots())
+
+	// Only the unlabelled snapshot older than the two latest is dropped
+	require.Len(t, plan.Dropped, 1)
+	require.Equal(t, roots[1], plan.Dropped[0].Root)
+	require.Equal(t, 3, plan.Retained)
+	require.Positive(t, plan.Nodes)
+
+	// Pruning with the same policy does exactly what was planned
+	report, err := trie.Prune(policy)
+	require.NoError(t, err)
+	require.Equal(t, plan, report)
+	require.Equal(t, sizeBefore-plan.Nodes, nodes.Len())
+}
@@ -0,0 +1,92 @@
+package smt
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+func init() {
+	gob.Register(StagedProof{})
+}
+
+// StagedProof is a proof generated against the staged root of a trie, which
+// includes the updates made since its last commit. It is marked provisional
+// if the staged root differs from the committed root, as it then proves state
+// which may never be committed.
+type StagedProof struct {
+	Proof *SparseMerkleProof
+	// Root is the staged root the proof was generated against
+	Root MerkleRoot
+	// Provisional is true if the root was not committed when the proof was
+	// generated
+	Provisional bool
+}
+
+// Marshal serialises the StagedProof to bytes
+func (proof *StagedProof) Marshal() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(proof); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal deserialises the StagedProof from bytes
+func (proof *StagedProof) Unmarshal(bz []byte) error {
+	buf := bytes.NewBuffer(bz)
+	dec := gob.NewDecoder(buf)
+	return dec.Decode(proof)
+}
+
+// Verify verifies the proof against its staged root, callers must check the
+// proof is not provisional before relying on it outside of the application
+// logic that staged the updates.
+func (proof *StagedProof) Verify(key, value []byte, spec *TrieSpec) (bool, error) {
+	return VerifyProof(proof.Proof, proof.Root, key, value, spec)
+}
+
+// CommittedRoot returns the root of the trie as of its last commit, or as
+// imported, which differs from Root while updates are staged.
+func (smt *SMT) CommittedRoot() MerkleRoot {
+	if smt.rootHash == nil {
+		return smt.placeholder()
+	}
+	return smt.rootHash
+}
+
+// ProveStaged generates a proof for the key against the staged root of the
+// trie, observing the updates made since the last commit, marked provisional
+// if there are any.
+func (smt *SMT) ProveStaged(key []byte) (*StagedProof, error) {
+	proof, err := smt.Prove(key)
+	if err != nil {
+		return nil, err
+	}
+	root := smt.Root()
+	return &StagedProof{
+		Proof:       proof,
+		Root:        root,
+		Provisional: !bytes.Equal(root, smt.CommittedRoot()),
+	}, nil
+}
+
+// CommittedRoot returns the root of the trie as of its last commit, see
+// SMT.CommittedRoot.
+func (smt *SMTWithStorage) CommittedRoot() MerkleRoot {
+	smt.commitMu.RLock()
+	defer smt.commitMu.RUnlock()
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	return smt.trie.CommittedRoot()
+}
+
+// ProveStaged generates a proof for the key against the staged root of the
+// trie, see SMT.ProveStaged. Staged values are readable with GetValue.
+func (smt *SMTWithStorage) ProveStaged(key []byte) (*StagedProof, error) {
+	defer smt.lockKey(key)()
+
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	return smt.trie.ProveStaged(key)
+}
@@ -0,0 +1,54 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestProveStaged(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("key"), []byte("value")))
+	require.NoError(t, trie.Commit())
+	committed := trie.Root()
+	require.Equal(t, committed, trie.CommittedRoot())
+
+	proof, err := trie.ProveStaged([]byte("key"))
+	require.NoError(t, err)
+	require.False(t, proof.Provisional)
+	require.Equal(t, committed, proof.Root)
+
+	// Staged updates are observed by reads and proofs before being committed
+	require.NoError(t, trie.Update([]byte("key"), []byte("staged")))
+	value, err := trie.GetValue([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("staged"), value)
+	require.Equal(t, committed, trie.CommittedRoot())
+
+	proof, err = trie.ProveStaged([]byte("key"))
+	require.NoError(t, err)
+	require.True(t, proof.Provisional)
+	require.Equal(t, trie.Root(), proof.Root)
+	require.NotEqual(t, committed, proof.Root)
+
+	bz, err := proof.Marshal()
+	require.NoError(t, err)
+	decoded := new(StagedProof)
+	require.NoError(t, decoded.Unmarshal(bz))
+	valid, err := decoded.Verify([]byte("key"), []byte("staged"), trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// The proof is no longer provisional once the staged root is committed
+	require.NoError(t, trie.Commit())
+	proof, err = trie.ProveStaged([]byte("key"))
+	require.NoError(t, err)
+	require.False(t, proof.Provisional)
+
+	// An empty trie's committed root is the placeholder
+	empty := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.Equal(t, empty.Root(), empty.CommittedRoot())
+}
@@ -0,0 +1,160 @@
+package smt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrStagingFull is returned (wrapped in a StagingError) when an operation
+// would take the uncommitted changes of a trie over its staging limits.
+var ErrStagingFull = errors.New("staging limit exceeded")
+
+// StagingMode is how an SMTWithStorage applies backpressure once its
+// uncommitted changes reach its staging limits.
+type StagingMode int
+
+const (
+	// StagingReject rejects operations which would exceed the limits with a
+	// StagingError, leaving the caller to commit
+	StagingReject StagingMode = iota
+	// StagingCommit commits the staged changes before applying operations
+	// once the limits are reached
+	StagingCommit
+	// StagingBlock blocks operations once the limits are reached until the
+	// staged changes are committed or discarded by another goroutine
+	StagingBlock
+)
+
+// StagingLimits bound the changes an SMTWithStorage holds in memory between
+// commits: MaxKeys the number of updates and deletes, and MaxBytes the length
+// of the keys plus the length of the values updated. Zero valued limits are
+// not enforced.
+type StagingLimits struct {
+	MaxKeys  uint64
+	MaxBytes uint64
+	Mode     StagingMode
+}
+
+// StagingUsage is the number of updates and deletes and the bytes of keys and
+// values staged by a trie since its last commit.
+type StagingUsage struct {
+	Keys  uint64
+	Bytes uint64
+}
+
+// StagingError is returned when an operation is rejected as it would take the
+// staged changes of the trie over its limits. It matches ErrStagingFull with
+// errors.Is.
+type StagingError struct {
+	// Resource is the limit which would be exceeded, either "keys" or "bytes"
+	Resource string
+	// Limit is the staging limit of the resource and Requested the usage the
+	// operation would have resulted in
+	Limit     uint64
+	Requested uint64
+}
+
+// Error satisfies the error interface
+func (e *StagingError) Error() string {
+	return fmt.Sprintf("%s: %d %s requested but limit is %d", ErrStagingFull, e.Requested, e.Resource, e.Limit)
+}
+
+// Is allows the StagingError to match ErrStagingFull
+func (e *StagingError) Is(target error) bool { return target == ErrStagingFull }
+
+// SetStagingLimits bounds the changes staged in memory between commits, so
+// that ingesting faster than the trie is committed fails, triggers commits or
+// waits for them, depending on the limits' mode, rather than running out of
+// memory. Updates, deletes and their batches are subject to the limits, while
+// transactions commit their changes immediately and are not.
+//
+// StagingReject enforces the limits exactly. With StagingCommit and
+// StagingBlock operations are held back once the limits are reached, so the
+// limits are exceeded by at most the operations in flight, and a single batch
+// is never split. StagingBlock waits for other goroutines, e.g. one committing
+// periodically, to commit, so a goroutine which is the only one committing the
+// trie must not use it.
+func (smt *SMTWithStorage) SetStagingLimits(limits StagingLimits) {
+	smt.commitMu.Lock()
+	defer smt.commitMu.Unlock()
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	smt.staging = limits
+	// Operations waiting for the previous limits check the new ones
+	if smt.stagingFreed != nil {
+		close(smt.stagingFreed)
+		smt.stagingFreed = nil
+	}
+}
+
+// StagedUsage returns the size of the changes staged since the last commit
+func (smt *SMTWithStorage) StagedUsage() StagingUsage {
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	return StagingUsage{Keys: uint64(smt.trie.updates), Bytes: smt.pendingBytes}
+}
+
+// admit applies the backpressure of the staging limits before an operation,
+// committing the staged changes or waiting for them to be committed once the
+// limits are reached. The caller must not hold any of the trie's locks.
+func (smt *SMTWithStorage) admit() error {
+	for {
+		smt.trieMu.Lock()
+		// Operations on a closed trie proceed to fail with ErrClosed
+		mode, full := smt.staging.Mode, !smt.trie.closed && smt.stagingFull()
+		var freed chan struct{}
+		if full && mode == StagingBlock {
+			if smt.stagingFreed == nil {
+				smt.stagingFreed = make(chan struct{})
+			}
+			freed = smt.stagingFreed
+		}
+		smt.trieMu.Unlock()
+
+		switch {
+		case !full || mode == StagingReject:
+			return nil
+		case mode == StagingCommit:
+			return smt.commitStaged()
+		}
+		<-freed
+	}
+}
+
+// commitStaged commits the trie if its staged changes reached the limits,
+// unless another operation committed it first.
+func (smt *SMTWithStorage) commitStaged() error {
+	smt.commitMu.Lock()
+	defer smt.commitMu.Unlock()
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	if !smt.stagingFull() {
+		return nil
+	}
+	return smt.commitJournaled()
+}
+
+// stagingFull returns true if the staged changes reached the staging limits,
+// the caller must hold the trie lock.
+func (smt *SMTWithStorage) stagingFull() bool {
+	limits := smt.staging
+	return (limits.MaxKeys > 0 && uint64(smt.trie.updates) >= limits.MaxKeys) ||
+		(limits.MaxBytes > 0 && smt.pendingBytes >= limits.MaxBytes)
+}
+
+// checkStaging returns a StagingError if staging the number of keys and bytes
+// provided takes the staged changes over the limits in StagingReject mode, the
+// caller must hold the trie lock.
+func (smt *SMTWithStorage) checkStaging(keys, bytes uint64) error {
+	limits := smt.staging
+	if limits.Mode != StagingReject {
+		return nil
+	}
+	if requested := uint64(smt.trie.updates) + keys; limits.MaxKeys > 0 && requested > limits.MaxKeys {
+		return &StagingError{Resource: "keys", Limit: limits.MaxKeys, Requested: requested}
+	}
+	if requested := smt.pendingBytes + bytes; limits.MaxBytes > 0 && requested > limits.MaxBytes {
+		return &StagingError{Resource: "bytes", Limit: limits.MaxBytes, Requested: requested}
+	}
+	return nil
+}
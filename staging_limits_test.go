@@ -0,0 +1,90 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMTWithStorage_StagingReject(t *testing.T) {
+	smt := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	smt.SetStagingLimits(StagingLimits{MaxKeys: 3, MaxBytes: 24})
+
+	require.NoError(t, smt.Update([]byte("key-0"), []byte("value-0")))
+	require.NoError(t, smt.Update([]byte("key-1"), []byte("value-1")))
+	require.Equal(t, StagingUsage{Keys: 2, Bytes: 24}, smt.StagedUsage())
+
+	// The value would take the staged bytes over the limit
+	err := smt.Update([]byte("key-2"), []byte("value-2"))
+	require.ErrorIs(t, err, ErrStagingFull)
+	var stagingErr *StagingError
+	require.ErrorAs(t, err, &stagingErr)
+	require.Equal(t, "bytes", stagingErr.Resource)
+	require.Equal(t, uint64(36), stagingErr.Requested)
+	has, err := smt.Has([]byte("key-2"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// Deletes stage no bytes but count towards the keys
+	require.NoError(t, smt.Delete([]byte("key-0")))
+	err = smt.Delete([]byte("key-1"))
+	require.ErrorAs(t, err, &stagingErr)
+	require.Equal(t, "keys", stagingErr.Resource)
+	err = smt.UpdateBatch([][]byte{[]byte("key-2")}, [][]byte{[]byte("value-2")})
+	require.ErrorIs(t, err, ErrStagingFull)
+
+	// Committing frees the staging limits
+	require.NoError(t, smt.Commit())
+	require.Equal(t, StagingUsage{}, smt.StagedUsage())
+	require.NoError(t, smt.Update([]byte("key-2"), []byte("value-2")))
+}
+
+func TestSMTWithStorage_StagingCommit(t *testing.T) {
+	nodes, preimages := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	smt := NewSMTWithStorage(nodes, preimages, sha256.New())
+	smt.SetStagingLimits(StagingLimits{MaxKeys: 4, Mode: StagingCommit})
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, smt.Update([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i))))
+		require.LessOrEqual(t, smt.StagedUsage().Keys, uint64(4))
+	}
+	// The first eight updates were committed in two batches
+	require.Equal(t, uint64(2), smt.StagedUsage().Keys)
+	require.Equal(t, 16, preimages.Len())
+	value, err := smt.GetValue([]byte("key-9"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value-9"), value)
+}
+
+func TestSMTWithStorage_StagingBlock(t *testing.T) {
+	smt := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	smt.SetStagingLimits(StagingLimits{MaxKeys: 2, Mode: StagingBlock})
+	require.NoError(t, smt.Update([]byte("key-0"), []byte("value-0")))
+	require.NoError(t, smt.Update([]byte("key-1"), []byte("value-1")))
+
+	done := make(chan error)
+	go func() {
+		done <- smt.Update([]byte("key-2"), []byte("value-2"))
+	}()
+	select {
+	case <-done:
+		t.Fatal("update did not wait for the staged changes to be committed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.NoError(t, smt.Commit())
+	require.NoError(t, <-done)
+	require.Equal(t, uint64(1), smt.StagedUsage().Keys)
+
+	// Discarding the staged changes also releases waiting operations
+	require.NoError(t, smt.Update([]byte("key-3"), []byte("value-3")))
+	go func() {
+		done <- smt.Delete([]byte("key-0"))
+	}()
+	require.NoError(t, smt.Discard())
+	require.NoError(t, <-done)
+}
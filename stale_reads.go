@@ -0,0 +1,123 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// maxRootChainLength bounds the number of roots of a RootChain, clients which
+// fell further behind must resynchronise from a trusted root.
+const maxRootChainLength = 4096
+
+// ErrStaleRoot is returned when the root a client last saw cannot be linked
+// to the current root of a trie, as it is not in the trie's root history at
+// the sequence number given or is too far behind, so the client must
+// resynchronise from a trusted root rather than follow the trie.
+var ErrStaleRoot = errors.New("root cannot be linked to the current root")
+
+// RootChain is a segment of the root history of a trie, the roots recorded by
+// consecutive commits. Nothing links consecutive roots, so a chain is only
+// as trustworthy as the server returning it.
+type RootChain struct {
+	// From is the sequence number of the first root
+	From  uint64
+	Roots []MerkleRoot
+}
+
+// Latest returns the last root of the chain along with its sequence number
+func (chain *RootChain) Latest() RootUpdate {
+	return RootUpdate{Height: chain.From + uint64(len(chain.Roots)) - 1, Root: chain.Roots[len(chain.Roots)-1]}
+}
+
+// Advanced returns true if the chain extends past its first root
+func (chain *RootChain) Advanced() bool {
+	return len(chain.Roots) > 1
+}
+
+// StaleReadProof is a proof of the value of a key against the current root of
+// a trie along with the chain of roots committed since the root the client
+// last saw, so that clients caching reads can tell how far their root is
+// behind. The chain is not authenticated: a server can append any root to it,
+// so clients must check the root the proof is against with a trusted source,
+// such as a RootOracle, before relying on it.
+type StaleReadProof struct {
+	Proof *SparseMerkleProof
+	// Chain starts at the root the client last saw and ends at the root the
+	// proof is against, it holds the client's root alone if the trie's root
+	// has not advanced
+	Chain RootChain
+}
+
+// ProveSince returns a proof of the key against the current root of the trie
+// along with the chain of roots from the root the client last saw, identified
+// by its sequence number in the trie's root history (see WithRootHistory), to
+// the current root. An error wrapping ErrStaleRoot is returned if the client's
+// root is not the root recorded at its sequence number, or is more than 4096
+// commits behind. The trie must not have uncommitted changes, as their root is
+// not in the history.
+func (smt *SMT) ProveSince(key []byte, seen RootUpdate) (*StaleReadProof, error) {
+	latest, err := smt.LatestRootSeq()
+	if err != nil {
+		return nil, err
+	}
+	if seen.Height == 0 || seen.Height > latest || latest-seen.Height >= maxRootChainLength {
+		return nil, errors.Join(ErrStaleRoot, fmt.Errorf("sequence %d cannot be linked to latest sequence %d", seen.Height, latest))
+	}
+	chain := RootChain{From: seen.Height}
+	for seq := seen.Height; seq <= latest; seq++ {
+		root, err := smt.RootAt(seq)
+		if err != nil {
+			return nil, err
+		}
+		chain.Roots = append(chain.Roots, root)
+	}
+	if !bytes.Equal(chain.Roots[0], seen.Root) {
+		return nil, errors.Join(ErrStaleRoot, fmt.Errorf("root %x is not the root at sequence %d", seen.Root, seen.Height))
+	}
+	if !bytes.Equal(chain.Latest().Root, smt.Root()) {
+		return nil, errors.New("cannot prove since a root with uncommitted changes")
+	}
+	proof, err := smt.Prove(key)
+	if err != nil {
+		return nil, err
+	}
+	return &StaleReadProof{Proof: proof, Chain: chain}, nil
+}
+
+// ProveSince returns a proof of the key against the current root of the trie
+// along with the chain of roots since the root the client last saw, see
+// SMT.ProveSince.
+func (smt *SMTWithStorage) ProveSince(key []byte, seen RootUpdate) (*StaleReadProof, error) {
+	defer smt.lockKey(key)()
+
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	return smt.trie.ProveSince(key, seen)
+}
+
+// VerifyStaleReadProof verifies that the chain of the proof starts at the
+// root the client last saw and that the proof of the key's value verifies
+// against the last root of the chain, which is returned along with its
+// sequence number for the client to attach to its next read. An error
+// wrapping ErrBadProof is returned otherwise. The roots of the chain are not
+// authenticated, so this does not establish that the returned root descends
+// from the client's root: it must be checked against a trusted source.
+func VerifyStaleReadProof(proof *StaleReadProof, key, value []byte, seen RootUpdate, spec *TrieSpec) (RootUpdate, error) {
+	chain := proof.Chain
+	if len(chain.Roots) == 0 || len(chain.Roots) > maxRootChainLength {
+		return RootUpdate{}, errors.Join(ErrBadProof, fmt.Errorf("invalid root chain length %d", len(chain.Roots)))
+	}
+	if chain.From != seen.Height || !bytes.Equal(chain.Roots[0], seen.Root) {
+		return RootUpdate{}, errors.Join(ErrBadProof, fmt.Errorf("root chain does not start at root %x at sequence %d", seen.Root, seen.Height))
+	}
+	latest := chain.Latest()
+	valid, err := VerifyProof(proof.Proof, latest.Root, key, value, spec)
+	if err != nil {
+		return RootUpdate{}, err
+	}
+	if !valid {
+		return RootUpdate{}, errors.Join(ErrBadProof, fmt.Errorf("proof for key %x does not verify against root %x", key, latest.Root))
+	}
+	return latest, nil
+}
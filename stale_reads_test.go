@@ -0,0 +1,56 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_ProveSince(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithRootHistory())
+	require.NoError(t, trie.Update([]byte("key"), []byte("one")))
+	require.NoError(t, trie.Commit())
+	seen := RootUpdate{Height: 1, Root: trie.Root()}
+
+	// The chain holds the client's root alone until the root advances
+	proof, err := trie.ProveSince([]byte("key"), seen)
+	require.NoError(t, err)
+	require.False(t, proof.Chain.Advanced())
+	latest, err := VerifyStaleReadProof(proof, []byte("key"), []byte("one"), seen, trie.Spec())
+	require.NoError(t, err)
+	require.Equal(t, seen, latest)
+
+	for _, value := range []string{"two", "three"} {
+		require.NoError(t, trie.Update([]byte("key"), []byte(value)))
+		require.NoError(t, trie.Commit())
+	}
+	proof, err = trie.ProveSince([]byte("key"), seen)
+	require.NoError(t, err)
+	require.True(t, proof.Chain.Advanced())
+	require.Len(t, proof.Chain.Roots, 3)
+	latest, err = VerifyStaleReadProof(proof, []byte("key"), []byte("three"), seen, trie.Spec())
+	require.NoError(t, err)
+	require.Equal(t, RootUpdate{Height: 3, Root: trie.Root()}, latest)
+
+	// Chains not starting at the client's root are rejected
+	_, err = VerifyStaleReadProof(proof, []byte("key"), []byte("three"), RootUpdate{Height: 2, Root: seen.Root}, trie.Spec())
+	require.ErrorIs(t, err, ErrBadProof)
+	_, err = VerifyStaleReadProof(proof, []byte("key"), []byte("one"), seen, trie.Spec())
+	require.ErrorIs(t, err, ErrBadProof)
+
+	// Roots which are not in the history cannot be linked
+	_, err = trie.ProveSince([]byte("key"), RootUpdate{Height: 2, Root: seen.Root})
+	require.ErrorIs(t, err, ErrStaleRoot)
+	_, err = trie.ProveSince([]byte("key"), RootUpdate{Height: 4, Root: trie.Root()})
+	require.ErrorIs(t, err, ErrStaleRoot)
+
+	require.NoError(t, trie.Update([]byte("key"), []byte("four")))
+	_, err = trie.ProveSince([]byte("key"), seen)
+	require.ErrorContains(t, err, "uncommitted changes")
+
+	_, err = NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New()).ProveSince([]byte("key"), seen)
+	require.ErrorIs(t, err, ErrNoRootHistory)
+}
@@ -0,0 +1,56 @@
+package smt
+
+// TrieStats describes the shape and size of a trie.
+type TrieStats struct {
+	// Leaves is the number of leaves in the trie
+	Leaves uint64
+	// InnerNodes is the number of inner nodes in the trie
+	InnerNodes uint64
+	// ExtensionNodes is the number of extension nodes in the trie
+	ExtensionNodes uint64
+	// AvgLeafDepth is the average number of path bits above a leaf, or zero
+	// if the trie is empty
+	AvgLeafDepth float64
+	// MaxLeafDepth is the largest number of path bits above a leaf
+	MaxLeafDepth int
+	// Bytes is the total size of the serialised nodes of the trie, as stored
+	// in the node store once committed
+	Bytes uint64
+}
+
+// InternalNodes returns the number of nodes of the trie which are not leaves
+func (stats *TrieStats) InternalNodes() uint64 {
+	return stats.InnerNodes + stats.ExtensionNodes
+}
+
+// Stats walks the whole trie, including its uncommitted changes, and returns
+// its node counts, leaf depths and serialised size. Persisted nodes are read
+// from the node store without being cached, so computing the statistics of a
+// large trie does not load it into memory.
+func (smt *SMT) Stats() (*TrieStats, error) {
+	stats := &TrieStats{}
+	var depths uint64
+	err := smt.Walk(func(depth int, nodeType NodeType, _, data []byte) error {
+		stats.Bytes += uint64(len(data))
+		switch nodeType {
+		case NodeLeaf:
+			stats.Leaves++
+			depths += uint64(depth)
+			if depth > stats.MaxLeafDepth {
+				stats.MaxLeafDepth = depth
+			}
+		case NodeExtension:
+			stats.ExtensionNodes++
+		case NodeInner:
+			stats.InnerNodes++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if stats.Leaves > 0 {
+		stats.AvgLeafDepth = float64(depths) / float64(stats.Leaves)
+	}
+	return stats, nil
+}
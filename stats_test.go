@@ -0,0 +1,55 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_Stats(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleTrie(nodes, sha256.New())
+
+	stats, err := trie.Stats()
+	require.NoError(t, err)
+	require.Equal(t, &TrieStats{}, stats)
+
+	// A single leaf is the root
+	require.NoError(t, trie.Update([]byte("key"), []byte("value")))
+	stats, err = trie.Stats()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), stats.Leaves)
+	require.Equal(t, uint64(0), stats.InternalNodes())
+	require.Equal(t, 0, stats.MaxLeafDepth)
+	require.Equal(t, uint64(len(encodeLeafNode(trie.root.(*leafNode).path, trie.root.(*leafNode).valueHash))), stats.Bytes)
+
+	for i := 0; i < 200; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key%d", i)), []byte("value")))
+	}
+	dirty, err := trie.Stats()
+	require.NoError(t, err)
+	require.Equal(t, uint64(201), dirty.Leaves)
+	// A binary trie with n leaves has n-1 inner nodes
+	require.Equal(t, uint64(200), dirty.InnerNodes)
+	require.Greater(t, dirty.MaxLeafDepth, 0)
+	require.Greater(t, dirty.AvgLeafDepth, 0.0)
+	require.LessOrEqual(t, dirty.AvgLeafDepth, float64(dirty.MaxLeafDepth))
+
+	// The statistics of the committed trie match, and count every stored node
+	require.NoError(t, trie.Commit())
+	committed := ImportSparseMerkleTrie(nodes, sha256.New(), trie.Root())
+	stats, err = committed.Stats()
+	require.NoError(t, err)
+	require.Equal(t, dirty, stats)
+	require.Equal(t, nodes.Len(), int(stats.Leaves+stats.InternalNodes()))
+	// Walking the committed trie does not cache its nodes
+	require.IsType(t, &lazyNode{}, committed.root)
+
+	require.NoError(t, committed.Close())
+	_, err = committed.Stats()
+	require.ErrorIs(t, err, ErrClosed)
+}
@@ -0,0 +1,64 @@
+package smt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// keyPreimagePrefix prefixes the paths the keys of an SMTWithStorage are
+// stored under in its preimages store, which cannot collide with the value
+// hashes the values are stored under as long as the value hasher's size
+// differs from their length.
+var keyPreimagePrefix = reservePrefix("key preimages", []byte("smt/key/"))
+
+// keyPreimageKey returns the key the key with the given path is stored under
+// in the preimages store
+func keyPreimageKey(path []byte) []byte {
+	return append(append([]byte(nil), keyPreimagePrefix...), path...)
+}
+
+// Iterate calls fn with the key and value of every leaf of the trie in
+// ascending path order, until fn returns false. The keys are stored alongside
+// the values when they are updated, an error wrapping ErrKeyNotFound is
+// returned for leaves whose keys were not stored (e.g. when inserted through
+// the underlying SMT).
+//
+// Each key and value is read under the trie's locks, which are released
+// before fn is called, so fn may read, update and commit the trie. Other
+// operations, including commits, may run between calls to fn: to export a
+// consistent state the trie must not be updated until Iterate returns.
+func (smt *SMTWithStorage) Iterate(fn func(key, value []byte) bool) error {
+	it := smt.trie.Iterator()
+	for {
+		key, value, ok, err := smt.nextEntry(it)
+		if !ok || err != nil {
+			return err
+		}
+		if !fn(key, value) {
+			return nil
+		}
+	}
+}
+
+// nextEntry advances the iterator provided under the commit lock and returns
+// the key and value of its next leaf, or false once there are no more leaves.
+func (smt *SMTWithStorage) nextEntry(it *Iterator) (key, value []byte, ok bool, err error) {
+	smt.commitMu.RLock()
+	defer smt.commitMu.RUnlock()
+
+	smt.trieMu.Lock()
+	ok = it.Next()
+	smt.trieMu.Unlock()
+	if !ok {
+		return nil, nil, false, it.Err()
+	}
+	key, err = smt.lookupPreimage(keyPreimageKey(it.Path()))
+	if err != nil {
+		return nil, nil, false, errors.Join(ErrKeyNotFound, fmt.Errorf("no key stored for path %x", it.Path()), err)
+	}
+	value, err = smt.lookupValue(it.ValueHash())
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return key, value, true, nil
+}
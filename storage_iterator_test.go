@@ -0,0 +1,95 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMTWithStorage_Iterate(t *testing.T) {
+	nodes, preimages := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	trie := NewSMTWithStorage(nodes, preimages, sha256.New())
+	expected := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		key, value := fmt.Sprintf("key-%d", i), fmt.Sprintf("value-%d", i)
+		require.NoError(t, trie.Update([]byte(key), []byte(value)))
+		expected[key] = value
+	}
+	require.NoError(t, trie.Commit())
+	require.NoError(t, trie.UpdateBatch([][]byte{[]byte("key-20")}, [][]byte{[]byte("value-20")}))
+	expected["key-20"] = "value-20"
+	require.NoError(t, trie.Delete([]byte("key-0")))
+	delete(expected, "key-0")
+
+	// Both committed and pending leaves are iterated in path order
+	got := make(map[string]string)
+	var lastPath []byte
+	err := trie.Iterate(func(key, value []byte) bool {
+		path := trie.trie.ph.Path(key)
+		require.Positive(t, bytes.Compare(path, lastPath))
+		lastPath = path
+		got[string(key)] = string(value)
+		return true
+	})
+	require.NoError(t, err)
+	require.Equal(t, expected, got)
+
+	// Iteration stops when fn returns false
+	count := 0
+	require.NoError(t, trie.Iterate(func(_, _ []byte) bool {
+		count++
+		return count < 5
+	}))
+	require.Equal(t, 5, count)
+
+	// The keys are persisted by Commit
+	require.NoError(t, trie.Commit())
+	imported, err := ImportSMTWithStorage(nodes, preimages, sha256.New(), trie.Root())
+	require.NoError(t, err)
+	got = make(map[string]string)
+	require.NoError(t, imported.Iterate(func(key, value []byte) bool {
+		got[string(key)] = string(value)
+		return true
+	}))
+	require.Equal(t, expected, got)
+
+	// Leaves inserted without their keys cannot be iterated
+	require.NoError(t, imported.trie.Update([]byte("raw"), []byte("value")))
+	err = imported.Iterate(func(_, _ []byte) bool { return true })
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestSMTWithStorage_IterateConcurrentCommit(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	for i := 0; i < 10; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i))))
+	}
+
+	// fn reads from the trie while a commit runs, neither blocks the other
+	count := 0
+	err := trie.Iterate(func(key, value []byte) bool {
+		if count == 0 {
+			committed := make(chan error, 1)
+			go func() { committed <- trie.Commit() }()
+			select {
+			case err := <-committed:
+				require.NoError(t, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("commit blocked by Iterate")
+			}
+		}
+		got, err := trie.GetValue(key)
+		require.NoError(t, err)
+		require.Equal(t, value, got)
+		count++
+		return true
+	})
+	require.NoError(t, err)
+	require.Equal(t, 10, count)
+}
@@ -0,0 +1,116 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// defaultRingReplicas is the number of points each store is placed at on a
+// StoreRing by default
+const defaultRingReplicas = 64
+
+// StoreRing assigns the shards of a ShardedSMT to named node stores by
+// consistent hashing: every store is placed at several points of a hash ring,
+// and every shard is stored in the store at the first point following the
+// shard's hash. Adding or removing a store only moves the shards adjacent to
+// its points, roughly 1/n of the shards for n stores, rather than reassigning
+// most of them. The shards a ShardedSMT was created with are bound to their
+// stores, so shards which move must be copied to their new store before the
+// trie is reimported with the updated ring.
+//
+// A StoreRing is safe for concurrent use.
+type StoreRing struct {
+	mu       sync.RWMutex
+	replicas int
+	points   []ringPoint
+	stores   map[string]kvstore.MapStore
+}
+
+// ringPoint is a point of a store on a StoreRing
+type ringPoint struct {
+	hash uint64
+	name string
+}
+
+// NewStoreRing returns a new, empty StoreRing placing every store at the
+// number of points provided, or a default of 64 if it is not positive. More
+// points spread the shards more evenly across the stores.
+func NewStoreRing(replicas int) *StoreRing {
+	if replicas < 1 {
+		replicas = defaultRingReplicas
+	}
+	return &StoreRing{
+		replicas: replicas,
+		stores:   make(map[string]kvstore.MapStore),
+	}
+}
+
+// Add adds the store to the ring under the name provided, replacing any store
+// with the same name. Names identify stores across restarts (e.g. the path of
+// their database), so the same names must be used to locate the same shards.
+func (ring *StoreRing) Add(name string, store kvstore.MapStore) {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	if _, ok := ring.stores[name]; !ok {
+		for i := 0; i < ring.replicas; i++ {
+			ring.points = append(ring.points, ringPoint{ringHash(fmt.Sprintf("%s#%d", name, i)), name})
+		}
+		sort.Slice(ring.points, func(i, j int) bool {
+			if ring.points[i].hash != ring.points[j].hash {
+				return ring.points[i].hash < ring.points[j].hash
+			}
+			return ring.points[i].name < ring.points[j].name
+		})
+	}
+	ring.stores[name] = store
+}
+
+// Remove removes the store with the name provided from the ring
+func (ring *StoreRing) Remove(name string) {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	delete(ring.stores, name)
+	points := ring.points[:0]
+	for _, point := range ring.points {
+		if point.name != name {
+			points = append(points, point)
+		}
+	}
+	ring.points = points
+}
+
+// Locate returns the name and store the shard with the index provided is
+// assigned to, or an empty name and nil store if the ring is empty.
+func (ring *StoreRing) Locate(shard int) (string, kvstore.MapStore) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	if len(ring.points) == 0 {
+		return "", nil
+	}
+	hash := ringHash(fmt.Sprintf("shard/%d", shard))
+	i := sort.Search(len(ring.points), func(i int) bool { return ring.points[i].hash >= hash })
+	if i == len(ring.points) {
+		i = 0
+	}
+	name := ring.points[i].name
+	return name, ring.stores[name]
+}
+
+// Store returns the store the shard with the index provided is assigned to,
+// so that the ring's Store method can be used as the ShardStores of a
+// ShardedSMT.
+func (ring *StoreRing) Store(shard int) kvstore.MapStore {
+	_, store := ring.Locate(shard)
+	return store
+}
+
+// ringHash returns the position of the identifier on the ring
+func ringHash(id string) uint64 {
+	digest := sha256.Sum256([]byte(id))
+	return binary.BigEndian.Uint64(digest[:8])
+}
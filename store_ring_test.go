@@ -0,0 +1,88 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestStoreRing(t *testing.T) {
+	ring := NewStoreRing(0)
+	name, store := ring.Locate(0)
+	require.Empty(t, name)
+	require.Nil(t, store)
+	_, err := NewShardedSMTWithStores(ring.Store, 4, sha256.New)
+	require.Error(t, err)
+
+	stores := map[string]kvstore.MapStore{}
+	for i := 0; i < 4; i++ {
+		name := fmt.Sprintf("disk-%d", i)
+		stores[name] = simplemap.NewSimpleMap()
+		ring.Add(name, stores[name])
+	}
+
+	// Shards are spread across every store
+	const shards = 256
+	before := make([]string, shards)
+	counts := map[string]int{}
+	for i := range before {
+		before[i], _ = ring.Locate(i)
+		counts[before[i]]++
+	}
+	require.Len(t, counts, 4)
+
+	// Adding a store only moves shards to the new store
+	ring.Add("disk-4", simplemap.NewSimpleMap())
+	moved := 0
+	for i := range before {
+		name, _ := ring.Locate(i)
+		if name != before[i] {
+			require.Equal(t, "disk-4", name)
+			moved++
+		}
+	}
+	require.Greater(t, moved, 0)
+	require.Less(t, moved, shards/2)
+
+	// Removing it moves them back
+	ring.Remove("disk-4")
+	for i := range before {
+		name, _ := ring.Locate(i)
+		require.Equal(t, before[i], name)
+	}
+}
+
+func TestShardedSMT_StoreRing(t *testing.T) {
+	ring := NewStoreRing(0)
+	stores := map[string]kvstore.MapStore{}
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("disk-%d", i)
+		stores[name] = simplemap.NewSimpleMap()
+		ring.Add(name, stores[name])
+	}
+	trie, err := NewShardedSMTWithStores(ring.Store, 8, sha256.New)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+	}
+	require.NoError(t, trie.Commit())
+
+	// Every shard's nodes are in the store it is located in
+	for i, root := range trie.ShardRoots() {
+		name, _ := ring.Locate(i)
+		_, err := stores[name].Get(root)
+		require.NoError(t, err)
+	}
+
+	imported, err := ImportShardedSMTWithStores(ring.Store, trie.ShardRoots(), sha256.New)
+	require.NoError(t, err)
+	require.Equal(t, trie.Root(), imported.Root())
+	got, err := imported.Get([]byte("key-42"))
+	require.NoError(t, err)
+	require.Equal(t, trie.Spec().valueHash([]byte("value")), got)
+}
@@ -0,0 +1,132 @@
+package smt
+
+import (
+	"fmt"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Subtree copies the subtrie holding every leaf whose path starts with the
+// first prefixBits bits of the prefix provided into the store provided, and
+// returns a trie backed by the store holding exactly these leaves. Its root is
+// that of a trie built by inserting them alone, so a trie sharded by path
+// prefix can be split into one trie per shard without replaying its history.
+// Only the nodes of the subtrie are copied, along with an extension node
+// placing it at the root, and the trie's uncommitted changes are included.
+//
+// The returned trie has the spec of the trie, but publishes no events and
+// does not persist its metrics. Persisted nodes are read from the node store
+// without being cached.
+func (smt *SMT) Subtree(prefix []byte, prefixBits int, store kvstore.MapStore) (*SMT, error) {
+	if smt.closed {
+		return nil, ErrClosed
+	}
+	path, err := smt.prefixPath(prefix, prefixBits)
+	if err != nil {
+		return nil, err
+	}
+	root, err := smt.subtreeRoot(path, prefixBits)
+	if err != nil {
+		return nil, err
+	}
+	if err := smt.copyNodes(root, store); err != nil {
+		return nil, err
+	}
+
+	spec := smt.TrieSpec
+	spec.events = nil
+	spec.persistMetrics = false
+	spec.borrowStores = false
+	rootHash := smt.digest(root)
+	return &SMT{
+		TrieSpec: spec,
+		nodes:    store,
+		rootHash: rootHash,
+		root:     &lazyNode{rootHash},
+	}, nil
+}
+
+// Subtree copies the subtrie of the sum trie holding every leaf whose path
+// starts with the prefix provided into the store provided, see SMT.Subtree.
+func (smst *SMST) Subtree(prefix []byte, prefixBits int, store kvstore.MapStore) (*SMST, error) {
+	subtree, err := smst.SMT.Subtree(prefix, prefixBits, store)
+	if err != nil {
+		return nil, err
+	}
+	return &SMST{TrieSpec: subtree.TrieSpec, SMT: subtree}, nil
+}
+
+// prefixPath checks the prefix of prefixBits bits provided fits in a path,
+// and returns it padded to the size of a path.
+func (smt *SMT) prefixPath(prefix []byte, prefixBits int) ([]byte, error) {
+	if prefixBits < 0 || prefixBits > smt.depth() || prefixBits > len(prefix)*8 {
+		return nil, fmt.Errorf("invalid prefix of %d bits: must be between 0 and %d bits of the %d byte prefix",
+			prefixBits, smt.depth(), len(prefix))
+	}
+	path := make([]byte, smt.ph.PathSize())
+	copy(path, prefix)
+	return path, nil
+}
+
+// subtreeRoot returns the root of the trie holding only the leaves whose
+// paths share their first prefixBits bits with the path provided. The nodes
+// of the trie are left unchanged.
+func (smt *SMT) subtreeRoot(path []byte, prefixBits int) (trieNode, error) {
+	node, depth := smt.root, 0
+	for {
+		var err error
+		if node, err = smt.resolveLazy(node); err != nil {
+			return nil, err
+		}
+		switch n := node.(type) {
+		case *leafNode:
+			if countCommonPrefixBits(n.path, path, 0) < prefixBits {
+				return nil, nil
+			}
+			return n, nil
+		case *extensionNode:
+			for i := n.pathStart(); i < n.pathEnd() && i < prefixBits; i++ {
+				if getPathBit(n.path, i) != getPathBit(path, i) {
+					return nil, nil
+				}
+			}
+			if n.pathEnd() >= prefixBits {
+				return &extensionNode{path: n.path, pathBounds: [2]byte{0, n.pathBounds[1]}, child: n.child}, nil
+			}
+			node, depth = n.child, n.pathEnd()
+		case *innerNode:
+			if depth >= prefixBits {
+				return seal(path, n, depth, 0), nil
+			}
+			node = n.rightChild
+			if getPathBit(path, depth) == leftChildBit {
+				node = n.leftChild
+			}
+			depth++
+		default:
+			return nil, nil
+		}
+	}
+}
+
+// copyNodes writes the subtrie rooted at the node provided to the store
+func (smt *SMT) copyNodes(node trieNode, store kvstore.MapStore) error {
+	node, err := smt.resolveLazy(node)
+	if err != nil || node == nil {
+		return err
+	}
+	switch n := node.(type) {
+	case *extensionNode:
+		if err := smt.copyNodes(n.child, store); err != nil {
+			return err
+		}
+	case *innerNode:
+		if err := smt.copyNodes(n.leftChild, store); err != nil {
+			return err
+		}
+		if err := smt.copyNodes(n.rightChild, store); err != nil {
+			return err
+		}
+	}
+	return store.Set(smt.digest(node), smt.encode(node))
+}
@@ -0,0 +1,204 @@
+package smt
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+)
+
+func init() {
+	gob.Register(SparseMerkleSubtreeProof{})
+}
+
+// errNotSumTrie is returned when verifying a subtree proof for a trie which
+// does not commit to the counts of its subtries
+var errNotSumTrie = errors.New("subtree proofs require a sum trie")
+
+// SparseMerkleSubtreeProof is a Merkle proof for the subtrie of a
+// SparseMerkleSumTrie holding all the leaves whose paths share a prefix.
+// As every digest of a sum trie commits to the count and aggregate weight of
+// its subtrie, the proof authenticates the number of leaves under the prefix.
+type SparseMerkleSubtreeProof struct {
+	// SideNodes is an array of the sibling nodes leading up to the subtrie of
+	// the proof, from the subtrie to the root.
+	SideNodes [][]byte
+
+	// SubtreeDigest is the digest of the node at the depth of the number of
+	// side nodes, it is the placeholder if the subtrie is empty and nil if
+	// the prefix leads to a leaf.
+	SubtreeDigest []byte
+
+	// LeafData is the data of the leaf found along the prefix, if the subtrie
+	// holds a single leaf, or an unrelated leaf is found instead. It is nil
+	// otherwise.
+	LeafData []byte
+}
+
+// Marshal serialises the SparseMerkleSubtreeProof to bytes
+func (proof *SparseMerkleSubtreeProof) Marshal() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(proof); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal deserialises the SparseMerkleSubtreeProof from bytes
+func (proof *SparseMerkleSubtreeProof) Unmarshal(bz []byte) error {
+	buf := bytes.NewBuffer(bz)
+	dec := gob.NewDecoder(buf)
+	return dec.Decode(proof)
+}
+
+// validateBasic performs basic sanity checks on the proof so that a malicious
+// proof cannot cause the verifier to fatally exit.
+func (proof *SparseMerkleSubtreeProof) validateBasic(prefix []byte, spec *TrieSpec) error {
+	if !spec.sumTrie {
+		return errNotSumTrie
+	}
+	if len(prefix) > spec.ph.PathSize() {
+		return fmt.Errorf("prefix too long: got %d bytes but max is %d", len(prefix), spec.ph.PathSize())
+	}
+	if len(proof.SideNodes) > len(prefix)*8 {
+		return fmt.Errorf("too many side nodes: got %d but max is %d", len(proof.SideNodes), len(prefix)*8)
+	}
+	for _, sideNode := range proof.SideNodes {
+		if len(sideNode) != spec.hashSize() {
+			return fmt.Errorf("invalid side node size: got %d but want %d", len(sideNode), spec.hashSize())
+		}
+	}
+	if (proof.LeafData == nil) == (proof.SubtreeDigest == nil) {
+		return errors.New("exactly one of the subtree digest and leaf data must be set")
+	}
+	if proof.LeafData != nil {
+		if len(proof.LeafData) < len(leafNodePrefix)+spec.ph.PathSize()+sumSizeBytes+countSizeBytes ||
+			!isLeafNode(proof.LeafData) {
+			return fmt.Errorf("invalid leaf data: %x", proof.LeafData)
+		}
+		return nil
+	}
+	if len(proof.SubtreeDigest) != spec.hashSize() {
+		return fmt.Errorf("invalid subtree digest size: got %d but want %d", len(proof.SubtreeDigest), spec.hashSize())
+	}
+	// Subtries are only proven above the prefix's depth when they are empty
+	if len(proof.SideNodes) < len(prefix)*8 && !bytes.Equal(proof.SubtreeDigest, spec.placeholder()) {
+		return errors.New("non-empty subtree digest above the prefix depth")
+	}
+	return nil
+}
+
+// CountUnder returns the number of leaves whose paths start with the prefix
+// provided, along with a proof of the count. As keys are hashed into paths
+// the prefix applies to the paths and not the keys, unless the trie is
+// configured with a PathHasher preserving the keys' prefixes.
+func (smst *SMST) CountUnder(prefix []byte) (uint64, *SparseMerkleSubtreeProof, error) {
+	if len(prefix) > smst.ph.PathSize() {
+		return 0, nil, fmt.Errorf("prefix too long: got %d bytes but max is %d", len(prefix), smst.ph.PathSize())
+	}
+	proof, err := smst.SMT.proveSubtree(prefix)
+	if err != nil {
+		return 0, nil, err
+	}
+	if proof.LeafData != nil {
+		path, _ := smst.parseLeafNode(proof.LeafData)
+		if bytes.HasPrefix(path, prefix) {
+			return 1, proof, nil
+		}
+		return 0, proof, nil
+	}
+	_, count := parseSumAndCount(proof.SubtreeDigest)
+	return count, proof, nil
+}
+
+// proveSubtree generates a SparseMerkleSubtreeProof for the subtrie along the
+// prefix provided
+func (smt *SMT) proveSubtree(prefix []byte) (*SparseMerkleSubtreeProof, error) {
+	var siblings []trieNode
+	var err error
+	node := smt.root
+	for depth := 0; depth < len(prefix)*8; depth++ {
+		node, err = smt.resolveLazy(node)
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			break
+		}
+		if _, ok := node.(*leafNode); ok {
+			break
+		}
+		// Extension nodes are expanded as the prefix may end within them
+		if extNode, ok := node.(*extensionNode); ok {
+			node = extNode.expand()
+		}
+		inner := node.(*innerNode)
+		var sib trieNode
+		if getPathBit(prefix, depth) == leftChildBit {
+			node, sib = inner.leftChild, inner.rightChild
+		} else {
+			node, sib = inner.rightChild, inner.leftChild
+		}
+		siblings = append(siblings, sib)
+	}
+	node, err = smt.resolveLazy(node)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &SparseMerkleSubtreeProof{}
+	if leaf, ok := node.(*leafNode); ok {
+		proof.LeafData = encodeLeafNode(leaf.path, leaf.valueHash)
+	} else {
+		proof.SubtreeDigest = smt.digest(node)
+	}
+	// Hash siblings from bottom up.
+	for i := range siblings {
+		sibling, err := smt.resolveLazy(siblings[len(siblings)-i-1])
+		if err != nil {
+			return nil, err
+		}
+		proof.SideNodes = append(proof.SideNodes, smt.digest(sibling))
+	}
+	return proof, nil
+}
+
+// VerifyCountProof verifies a proof that exactly count leaves of a sum trie
+// have paths starting with the prefix provided.
+func VerifyCountProof(
+	proof *SparseMerkleSubtreeProof,
+	root, prefix []byte,
+	count uint64,
+	spec *TrieSpec,
+) (bool, error) {
+	if err := proof.validateBasic(prefix, spec); err != nil {
+		return false, errors.Join(ErrBadProof, err)
+	}
+
+	var currentHash []byte
+	var proven uint64
+	if proof.LeafData != nil {
+		path, valueHash := spec.parseLeafNode(proof.LeafData)
+		if bytes.HasPrefix(path, prefix) {
+			proven = 1
+		}
+		currentHash, _ = spec.digestLeaf(path, valueHash)
+	} else {
+		currentHash = proof.SubtreeDigest
+		_, proven = parseSumAndCount(currentHash)
+	}
+	if proven != count {
+		return false, nil
+	}
+
+	// Recompute root.
+	for i, sideNode := range proof.SideNodes {
+		if getPathBit(prefix, len(proof.SideNodes)-1-i) == leftChildBit {
+			currentHash, _ = spec.digestInnerNode(currentHash, sideNode)
+		} else {
+			currentHash, _ = spec.digestInnerNode(sideNode, currentHash)
+		}
+	}
+	return bytes.Equal(currentHash, root), nil
+}
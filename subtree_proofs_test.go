@@ -0,0 +1,77 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMST_CountUnder(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleSumTrie(nodes, sha256.New())
+	var paths [][]byte
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("validator-%d", i))
+		require.NoError(t, trie.Update(key, key, uint64(i)))
+		paths = append(paths, trie.ph.Path(key))
+	}
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+
+	// Import the trie so proofs are generated from lazily resolved nodes
+	trie = ImportSparseMerkleSumTrie(nodes, sha256.New(), root)
+
+	countPrefix := func(prefix []byte) (count uint64) {
+		for _, path := range paths {
+			if bytes.HasPrefix(path, prefix) {
+				count++
+			}
+		}
+		return count
+	}
+	prefixes := [][]byte{{}, paths[0][:2], paths[1][:3], paths[2]}
+	for b := 0; b < 256; b++ {
+		prefixes = append(prefixes, []byte{byte(b)})
+	}
+	for _, prefix := range prefixes {
+		expected := countPrefix(prefix)
+		count, proof, err := trie.CountUnder(prefix)
+		require.NoError(t, err)
+		require.Equal(t, expected, count, "prefix %x", prefix)
+
+		bz, err := proof.Marshal()
+		require.NoError(t, err)
+		decoded := new(SparseMerkleSubtreeProof)
+		require.NoError(t, decoded.Unmarshal(bz))
+
+		valid, err := VerifyCountProof(decoded, root, prefix, count, trie.Spec())
+		require.NoError(t, err)
+		require.True(t, valid, "prefix %x", prefix)
+		valid, err = VerifyCountProof(decoded, root, prefix, count+1, trie.Spec())
+		require.NoError(t, err)
+		require.False(t, valid)
+	}
+	require.Equal(t, uint64(100), countPrefix(nil))
+
+	// A proof for one prefix does not verify for a sibling prefix
+	prefix := []byte{paths[0][0]}
+	count, proof, err := trie.CountUnder(prefix)
+	require.NoError(t, err)
+	valid, err := VerifyCountProof(proof, root, []byte{prefix[0] ^ 1}, count, trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+
+	// Malformed proofs are rejected
+	_, err = VerifyCountProof(&SparseMerkleSubtreeProof{}, root, prefix, 0, trie.Spec())
+	require.ErrorIs(t, err, ErrBadProof)
+	smtSpec := NewTrieSpec(sha256.New(), false)
+	_, err = VerifyCountProof(proof, root, prefix, count, &smtSpec)
+	require.ErrorIs(t, err, ErrBadProof)
+	_, _, err = trie.CountUnder(make([]byte, 33))
+	require.Error(t, err)
+}
@@ -0,0 +1,93 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_Subtree(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	var keys [][]byte
+	values := make(map[string][]byte)
+	for i := 0; i < 300; i++ {
+		key := []byte(fmt.Sprintf("key%d", i))
+		keys = append(keys, key)
+		values[string(key)] = []byte(fmt.Sprintf("value%d", i))
+		require.NoError(t, trie.Update(key, values[string(key)]))
+	}
+	require.NoError(t, trie.Commit())
+	// Uncommitted changes are included
+	values["key0"] = []byte("updated")
+	require.NoError(t, trie.Update([]byte("key0"), values["key0"]))
+
+	// expected returns the trie built from the keys under the prefix alone
+	expected := func(prefix []byte, prefixBits int) *SMT {
+		shard := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+		for _, key := range keys {
+			if countCommonPrefixBits(trie.ph.Path(key), prefix, 0) >= prefixBits {
+				require.NoError(t, shard.Update(key, values[string(key)]))
+			}
+		}
+		return shard
+	}
+
+	path := trie.ph.Path([]byte("key0"))
+	for _, prefixBits := range []int{0, 1, 3, 8, 12, 40, 256} {
+		store := simplemap.NewSimpleMap()
+		subtree, err := trie.Subtree(path, prefixBits, store)
+		require.NoError(t, err)
+		shard := expected(path, prefixBits)
+		require.Equal(t, shard.Root(), subtree.Root(), "prefix of %d bits", prefixBits)
+
+		// The subtree is readable, provable and only holds its own nodes
+		valueHash, err := subtree.Get([]byte("key0"))
+		require.NoError(t, err)
+		require.Equal(t, trie.valueHash([]byte("updated")), valueHash)
+		proof, err := subtree.Prove([]byte("key0"))
+		require.NoError(t, err)
+		valid, err := VerifyProof(proof, subtree.Root(), []byte("key0"), []byte("updated"), subtree.Spec())
+		require.NoError(t, err)
+		require.True(t, valid)
+		stats, err := subtree.Stats()
+		require.NoError(t, err)
+		require.Equal(t, store.Len(), int(stats.Leaves+stats.InternalNodes()))
+
+		// and can be updated independently of the trie
+		require.NoError(t, subtree.Update([]byte("key0"), []byte("shard")))
+		require.NoError(t, subtree.Commit())
+		valueHash, err = trie.Get([]byte("key0"))
+		require.NoError(t, err)
+		require.Equal(t, trie.valueHash([]byte("updated")), valueHash)
+	}
+
+	// Prefixes no leaf starts with yield empty tries
+	empty := make([]byte, len(path))
+	copy(empty, path)
+	empty[len(empty)-1] ^= 1
+	subtree, err := trie.Subtree(empty, 256, simplemap.NewSimpleMap())
+	require.NoError(t, err)
+	require.True(t, IsEmptyRoot(subtree.Root(), subtree.Spec()))
+
+	_, err = trie.Subtree(path[:1], 9, simplemap.NewSimpleMap())
+	require.Error(t, err)
+
+	// Sum tries keep their sums
+	smst := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i, key := range keys {
+		require.NoError(t, smst.Update(key, []byte("value"), uint64(i)))
+	}
+	sumSubtree, err := smst.Subtree(path, 2, simplemap.NewSimpleMap())
+	require.NoError(t, err)
+	var sum uint64
+	for i, key := range keys {
+		if countCommonPrefixBits(smst.ph.Path(key), path, 0) >= 2 {
+			sum += uint64(i)
+		}
+	}
+	require.Equal(t, sum, sumSubtree.Sum())
+}
@@ -0,0 +1,110 @@
+package smt
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+)
+
+func init() {
+	gob.Register(TotalSumProof{})
+}
+
+// TotalSumProof proves the total sum and count of a SparseMerkleSumTrie, as
+// committed to by its root. The sum and count trailing a root are not covered
+// by the root's hash but by the preimage it hashes, which the proof opens.
+type TotalSumProof struct {
+	// RootPreimage is the encoded root node, an inner node or a single leaf,
+	// or nil if the trie is empty.
+	RootPreimage []byte
+}
+
+// Marshal serialises the TotalSumProof to bytes
+func (proof *TotalSumProof) Marshal() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(proof); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal deserialises the TotalSumProof from bytes
+func (proof *TotalSumProof) Unmarshal(bz []byte) error {
+	buf := bytes.NewBuffer(bz)
+	dec := gob.NewDecoder(buf)
+	return dec.Decode(proof)
+}
+
+// validateBasic performs basic sanity checks on the proof so that a malicious
+// proof cannot cause the verifier to fatally exit.
+func (proof *TotalSumProof) validateBasic(spec *TrieSpec) error {
+	if !spec.sumTrie {
+		return errNotSumTrie
+	}
+	data := proof.RootPreimage
+	if data == nil {
+		return nil
+	}
+	switch {
+	case isLeafNode(data):
+		if len(data) < len(leafNodePrefix)+spec.ph.PathSize()+sumSizeBytes+countSizeBytes {
+			return fmt.Errorf("invalid leaf preimage size: got %d bytes", len(data))
+		}
+	case isInnerNode(data):
+		if len(data) != len(innerNodePrefix)+2*spec.hashSize()+sumSizeBytes+countSizeBytes {
+			return fmt.Errorf("invalid inner node preimage size: got %d bytes", len(data))
+		}
+	default:
+		return fmt.Errorf("invalid root preimage: %x", data)
+	}
+	return nil
+}
+
+// ProveTotalSum generates a TotalSumProof for the current root of the trie
+func (smst *SMST) ProveTotalSum() (*TotalSumProof, error) {
+	if smst.closed {
+		return nil, ErrClosed
+	}
+	root, err := smst.resolveLazy(smst.root)
+	if err != nil {
+		return nil, err
+	}
+	smst.root = root
+	if root == nil {
+		return &TotalSumProof{}, nil
+	}
+	// The digest of an extension node is that of the inner nodes it compacts
+	if ext, ok := root.(*extensionNode); ok {
+		root = ext.expand()
+	}
+	return &TotalSumProof{RootPreimage: smst.encodeSumNode(root)}, nil
+}
+
+// VerifyTotalSumProof verifies the proof that the root provided commits to a
+// total sum and count of the sum and count given. For inner nodes the sum is
+// checked to be the aggregate of the sums of the root's children.
+func VerifyTotalSumProof(proof *TotalSumProof, root []byte, sum, count uint64, spec *TrieSpec) (bool, error) {
+	if err := proof.validateBasic(spec); err != nil {
+		return false, errors.Join(ErrBadProof, err)
+	}
+	data := proof.RootPreimage
+	if data == nil {
+		return bytes.Equal(root, spec.placeholder()) && sum == 0 && count == 0, nil
+	}
+	if isInnerNode(data) {
+		left, right, _, _ := spec.th.parseSumInnerNode(data)
+		if !bytes.Equal(data, encodeSumInnerNode(spec.agg, left, right)) {
+			return false, nil
+		}
+	}
+	provenSum, provenCount := parseSumAndCount(data)
+	if isLeafNode(data) && provenCount != 1 {
+		return false, nil
+	}
+	if provenSum != sum || provenCount != count {
+		return false, nil
+	}
+	return bytes.Equal(root, spec.hashSumSerialization(data)), nil
+}
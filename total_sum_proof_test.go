@@ -0,0 +1,85 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMST_ProveTotalSum(t *testing.T) {
+	trie := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+
+	// Empty tries prove a zero total
+	proof, err := trie.ProveTotalSum()
+	require.NoError(t, err)
+	valid, err := VerifyTotalSumProof(proof, trie.Root(), 0, 0, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// A single leaf is the root itself
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar"), 5))
+	proof, err = trie.ProveTotalSum()
+	require.NoError(t, err)
+	valid, err = VerifyTotalSumProof(proof, trie.Root(), 5, 1, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	require.NoError(t, trie.Update([]byte("baz"), []byte("qux"), 10))
+	require.NoError(t, trie.Update([]byte("bin"), []byte("bob"), 20))
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+	proof, err = trie.ProveTotalSum()
+	require.NoError(t, err)
+	bz, err := proof.Marshal()
+	require.NoError(t, err)
+	decoded := new(TotalSumProof)
+	require.NoError(t, decoded.Unmarshal(bz))
+
+	valid, err = VerifyTotalSumProof(decoded, root, 35, 3, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+	valid, err = VerifyTotalSumProof(decoded, root, 36, 3, trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+
+	// A root whose trailing sum was tampered with does not match its preimage
+	tampered := append(MerkleRoot(nil), root...)
+	firstSumByteIdx, _ := getFirstMetaByteIdx(tampered)
+	binary.BigEndian.PutUint64(tampered[firstSumByteIdx:], 1000)
+	require.Equal(t, uint64(1000), tampered.Sum())
+	valid, err = VerifyTotalSumProof(decoded, tampered, 1000, 3, trie.Spec())
+	require.NoError(t, err)
+	require.False(t, valid)
+
+	// Proofs of imported tries resolve their root from the store
+	imported := ImportSparseMerkleSumTrie(trie.nodes, sha256.New(), root)
+	proof, err = imported.ProveTotalSum()
+	require.NoError(t, err)
+	require.Equal(t, decoded, proof)
+
+	// Sum proofs are meaningless for tries without sums
+	smtSpec := NewTrieSpec(sha256.New(), false)
+	_, err = VerifyTotalSumProof(proof, root, 35, 3, &smtSpec)
+	require.ErrorIs(t, err, ErrBadProof)
+}
+
+func TestSMST_ProveTotalSumExtensionRoot(t *testing.T) {
+	trie := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New(),
+		WithPathHasher(NewIndexPathHasher(8)))
+	// Adjacent indices share all but their last bit, so the root is an
+	// extension node
+	require.NoError(t, trie.Update(IndexKey(0, 8), []byte("a"), 1))
+	require.NoError(t, trie.Update(IndexKey(1, 8), []byte("b"), 2))
+	_, ok := trie.root.(*extensionNode)
+	require.True(t, ok)
+
+	proof, err := trie.ProveTotalSum()
+	require.NoError(t, err)
+	valid, err := VerifyTotalSumProof(proof, trie.Root(), 3, 2, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+}
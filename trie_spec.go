@@ -12,6 +12,41 @@ type TrieSpec struct {
 	ph      PathHasher
 	vh      ValueHasher
 	sumTrie bool
+	// agg combines the weights of inner nodes' children in a sum trie, if nil
+	// the weights are summed
+	agg AggregateFunc
+
+	// events is the optional EventBus the trie publishes its events to
+	events *EventBus
+	// tracker is the optional AccessTracker recording the paths accessed
+	tracker *AccessTracker
+	// depthLimit is the maximum depth leaves can be inserted at, if positive
+	depthLimit int
+	// depthAlarm decides whether to allow updates exceeding the depth limit
+	depthAlarm DepthAlarm
+	// borrowStores is true if the trie's stores are left open on Close
+	borrowStores bool
+	// hasherImpl is the hasher implementation selected for the trie, if any
+	hasherImpl string
+	// maxNodesPerUpdate is the anomaly threshold of commits, if positive
+	maxNodesPerUpdate int
+	// persistMetrics is true if the trie's metrics are persisted on Close
+	persistMetrics bool
+	// metricsNamespace is the namespace the trie's metrics are persisted
+	// under, if any
+	metricsNamespace string
+	// verifyNodes is true if the nodes read from the node store are checked
+	// against their digests
+	verifyNodes bool
+	// rootHistory is true if the trie's committed roots are logged to its
+	// node store
+	rootHistory bool
+	// importWorkers is the number of workers building subtries concurrently
+	// when bulk loading the trie, if greater than one
+	importWorkers int
+	// clock is the source of time of the trie's time-based features, the
+	// SystemClock if nil
+	clock Clock
 }
 
 // NewTrieSpec returns a new TrieSpec with the given hasher and sumTrie flag
@@ -71,7 +106,7 @@ func (spec *TrieSpec) digestLeaf(path, value []byte) ([]byte, []byte) {
 // digestNode returns the hash and preimage of a node depending on the trie type
 func (spec *TrieSpec) digestInnerNode(left, right []byte) ([]byte, []byte) {
 	if spec.sumTrie {
-		return spec.th.digestSumInnerNode(left, right)
+		return spec.th.digestSumInnerNode(spec.agg, left, right)
 	}
 	return spec.th.digestInnerNode(left, right)
 }
@@ -198,7 +233,7 @@ func (spec *TrieSpec) encodeSumNode(node trieNode) (preImage []byte) {
 	case *innerNode:
 		leftChild := spec.digestSumNode(n.leftChild)
 		rightChild := spec.digestSumNode(n.rightChild)
-		return encodeSumInnerNode(leftChild, rightChild)
+		return encodeSumInnerNode(spec.agg, leftChild, rightChild)
 	case *extensionNode:
 		child := spec.digestSumNode(n.child)
 		return encodeSumExtensionNode(n.pathBounds, n.path, child)
@@ -0,0 +1,179 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrTxnDone is returned when a transaction is used after it was committed or
+// rolled back.
+var ErrTxnDone = errors.New("transaction already committed or rolled back")
+
+// Txn is a group of updates and deletes of an SMTWithStorage which are staged
+// in memory and applied to the trie and committed to both of its stores
+// atomically by Commit, or not at all. A Txn is not safe for concurrent use,
+// but any number of transactions may be staged concurrently on the same trie.
+type Txn struct {
+	smt  *SMTWithStorage
+	ops  []txnOp
+	done bool
+}
+
+// txnOp is an update or delete staged by a transaction
+type txnOp struct {
+	key, value []byte
+	delete     bool
+}
+
+// Txn returns a new transaction staging changes to the trie, see Txn.
+func (smt *SMTWithStorage) Txn() *Txn {
+	return &Txn{smt: smt}
+}
+
+// Update stages the update of the key with the value provided. The value is
+// only checked against its namespace's validator and quota on Commit.
+func (txn *Txn) Update(key, value []byte) error {
+	if txn.done {
+		return ErrTxnDone
+	}
+	txn.ops = append(txn.ops, txnOp{key: bytes.Clone(key), value: bytes.Clone(value)})
+	return nil
+}
+
+// Delete stages the deletion of the key provided, which must be present when
+// the transaction is committed.
+func (txn *Txn) Delete(key []byte) error {
+	if txn.done {
+		return ErrTxnDone
+	}
+	txn.ops = append(txn.ops, txnOp{key: bytes.Clone(key), delete: true})
+	return nil
+}
+
+// Len returns the number of changes staged by the transaction
+func (txn *Txn) Len() int {
+	return len(txn.ops)
+}
+
+// Rollback discards the changes staged by the transaction, leaving the trie
+// unchanged.
+func (txn *Txn) Rollback() {
+	txn.ops, txn.done = nil, true
+}
+
+// Commit applies the staged changes to the trie in the order they were staged
+// and commits them, writing the trie's nodes and the values atomically as for
+// SMTWithStorage.Commit. Every value is checked against its namespace's
+// validator and quota before the trie is modified. If any change fails, e.g.
+// deleting a key which is not present, or the commit fails, the trie is
+// reverted to the root it had before the transaction and the error returned,
+// and neither store holds any of the transaction's changes (unless the commit
+// was interrupted after its journal was written, see RecoverSMTWithStorage).
+//
+// Changes made to the trie outside the transaction since its last commit are
+// committed first, so that rolling the transaction back never discards them.
+// Events of the staged changes are published as they are applied, even if the
+// transaction is then rolled back. The transaction holds the commit lock
+// exclusively, so it never interleaves with other operations, and cannot be
+// used once committed.
+func (txn *Txn) Commit() error {
+	if txn.done {
+		return ErrTxnDone
+	}
+	txn.done = true
+	smt := txn.smt
+	smt.commitMu.Lock()
+	defer smt.commitMu.Unlock()
+
+	usages, err := txn.checkQuotas()
+	if err != nil {
+		return err
+	}
+
+	smt.trieMu.Lock()
+	defer smt.trieMu.Unlock()
+	if err := smt.commitJournaled(); err != nil {
+		return err
+	}
+	committed := smt.trie.rootHash
+	if err = txn.apply(); err == nil {
+		err = smt.commitJournaled()
+	}
+	if err != nil {
+		smt.trie.rootHash = committed
+		if discardErr := smt.trie.Discard(); discardErr != nil {
+			return errors.Join(err, discardErr)
+		}
+		smt.clearPending()
+		return err
+	}
+	for ns, usage := range usages {
+		ns.usage = usage
+	}
+	return nil
+}
+
+// checkQuotas validates the staged values and returns the usage of every
+// namespace once the transaction is applied, or an error if any value is
+// rejected or a namespace would exceed its quota. The caller must hold the
+// commit lock exclusively.
+func (txn *Txn) checkQuotas() (map[*Namespace]NamespaceUsage, error) {
+	smt := txn.smt
+	// Only the last change of each key counts towards its namespace's quota
+	last := make(map[string]int, len(txn.ops))
+	for i, op := range txn.ops {
+		last[string(op.key)] = i
+	}
+	usages := make(map[*Namespace]NamespaceUsage)
+	for i, op := range txn.ops {
+		var value []byte
+		if !op.delete {
+			if err := smt.validate(op.key, op.value); err != nil {
+				return nil, err
+			}
+			// A nil value would be taken for a deletion
+			value = op.value
+			if value == nil {
+				value = []byte{}
+			}
+		}
+		if last[string(op.key)] != i {
+			continue
+		}
+		ns, before, after, err := smt.namespaceDelta(op.key, value)
+		if err != nil {
+			return nil, err
+		}
+		if ns == nil {
+			continue
+		}
+		usage, ok := usages[ns]
+		if !ok {
+			usage = ns.usage
+		}
+		if usages[ns], err = ns.checkQuota(op.key, usage, before, after); err != nil {
+			return nil, err
+		}
+	}
+	return usages, nil
+}
+
+// apply applies the staged changes to the trie, the caller must hold the
+// commit and trie locks.
+func (txn *Txn) apply() error {
+	smt := txn.smt
+	for _, op := range txn.ops {
+		if op.delete {
+			if err := smt.trie.Delete(op.key); err != nil {
+				return err
+			}
+			continue
+		}
+		leafValue := smt.leafValue(op.key, op.value)
+		if err := smt.trie.Update(op.key, leafValue); err != nil {
+			return err
+		}
+		smt.addPending(op.key, leafValue, op.value)
+	}
+	return nil
+}
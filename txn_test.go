@@ -0,0 +1,95 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMTWithStorage_Txn(t *testing.T) {
+	nodes, preimages := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	smt := NewSMTWithStorage(nodes, preimages, sha256.New())
+	require.NoError(t, smt.Update([]byte("key-0"), []byte("value-0")))
+	require.NoError(t, smt.Commit())
+	root := smt.Root()
+
+	txn := smt.Txn()
+	for i := 1; i < 5; i++ {
+		require.NoError(t, txn.Update([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i))))
+	}
+	require.NoError(t, txn.Delete([]byte("key-0")))
+	require.Equal(t, 5, txn.Len())
+	// Staged changes are not applied until the transaction is committed
+	require.Equal(t, root, smt.Root())
+	has, err := smt.Has([]byte("key-1"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	require.NoError(t, txn.Commit())
+	require.ErrorIs(t, txn.Commit(), ErrTxnDone)
+	require.ErrorIs(t, txn.Update([]byte("key-5"), []byte("value-5")), ErrTxnDone)
+	has, err = smt.Has([]byte("key-0"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// The changes are committed to both stores
+	smt, err = ImportSMTWithStorage(nodes, preimages, sha256.New(), smt.Root())
+	require.NoError(t, err)
+	for i := 1; i < 5; i++ {
+		value, err := smt.GetValue([]byte(fmt.Sprintf("key-%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value-%d", i)), value)
+	}
+
+	// A rolled back transaction leaves the trie unchanged
+	root = smt.Root()
+	txn = smt.Txn()
+	require.NoError(t, txn.Update([]byte("key-5"), []byte("value-5")))
+	txn.Rollback()
+	require.ErrorIs(t, txn.Commit(), ErrTxnDone)
+	require.Equal(t, root, smt.Root())
+}
+
+func TestSMTWithStorage_TxnRollsBackOnError(t *testing.T) {
+	nodes, preimages := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	smt := NewSMTWithStorage(nodes, preimages, sha256.New())
+	require.NoError(t, smt.Update([]byte("key-0"), []byte("value-0")))
+	require.NoError(t, smt.Commit())
+	// Changes made outside the transaction are kept when it is rolled back
+	require.NoError(t, smt.Update([]byte("key-1"), []byte("value-1")))
+	expected := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, expected.Update([]byte("key-0"), []byte("value-0")))
+	require.NoError(t, expected.Update([]byte("key-1"), []byte("value-1")))
+
+	// Deleting a missing key fails after the first update was applied
+	txn := smt.Txn()
+	require.NoError(t, txn.Update([]byte("key-2"), []byte("value-2")))
+	require.NoError(t, txn.Delete([]byte("missing")))
+	require.ErrorIs(t, txn.Commit(), ErrKeyNotFound)
+	require.Equal(t, expected.Root(), smt.Root())
+	has, err := smt.Has([]byte("key-2"))
+	require.NoError(t, err)
+	require.False(t, has)
+	value, err := smt.GetValue([]byte("key-1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value-1"), value)
+	require.Equal(t, 4, preimages.Len())
+
+	// A failing store rolls back the commit of the transaction
+	root := smt.Root()
+	crashingPreimages := &crashingStore{MapStore: preimages}
+	smt, err = ImportSMTWithStorage(nodes, crashingPreimages, sha256.New(), root)
+	require.NoError(t, err)
+	txn = smt.Txn()
+	require.NoError(t, txn.Update([]byte("key-2"), []byte("value-2")))
+	require.ErrorIs(t, txn.Commit(), errCrash)
+	require.Equal(t, root, smt.Root())
+	has, err = smt.Has([]byte("key-2"))
+	require.NoError(t, err)
+	require.False(t, has)
+	require.Equal(t, 4, preimages.Len())
+}
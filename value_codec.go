@@ -0,0 +1,117 @@
+package smt
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Ensure the built-in codecs satisfy the ValueCodec interface
+var (
+	_ ValueCodec = GobCodec{}
+	_ ValueCodec = JSONCodec{}
+)
+
+// ValueCodec defines how typed values are (de)serialised before being stored
+// in an SMTWithStorage. Implementations (e.g. for protobuf messages) must be
+// deterministic, as the serialised bytes are what get hashed into the trie:
+// encoding the same value twice must produce the same bytes.
+type ValueCodec interface {
+	// Marshal serialises the value provided
+	Marshal(v any) ([]byte, error)
+	// Unmarshal deserialises the data provided into the value pointed to by v
+	Unmarshal(data []byte, v any) error
+}
+
+// GobCodec is a ValueCodec using encoding/gob, the same encoding used to
+// serialise proofs. It is the default codec of an SMTWithStorage.
+// NB: gob does not encode maps deterministically, so values containing maps
+// should use a different codec.
+type GobCodec struct{}
+
+// Marshal satisfies the ValueCodec#Marshal interface
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal satisfies the ValueCodec#Unmarshal interface
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewBuffer(data)).Decode(v)
+}
+
+// JSONCodec is a ValueCodec using encoding/json, which sorts map keys and so
+// encodes maps deterministically.
+type JSONCodec struct{}
+
+// Marshal satisfies the ValueCodec#Marshal interface
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal satisfies the ValueCodec#Unmarshal interface
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// SetValueCodec sets the codec used by UpdateTyped and GetTyped, replacing
+// the default GobCodec.
+func (smt *SMTWithStorage) SetValueCodec(codec ValueCodec) {
+	smt.commitMu.Lock()
+	defer smt.commitMu.Unlock()
+	smt.codec = codec
+}
+
+// UpdateTyped serialises the value provided with the ValueCodec of the key's
+// namespace, or the trie's ValueCodec, and stores it at the given key.
+func (smt *SMTWithStorage) UpdateTyped(key []byte, v any) error {
+	value, err := smt.valueCodec(key).Marshal(v)
+	if err != nil {
+		return err
+	}
+	return smt.Update(key, value)
+}
+
+// GetTyped retrieves the value stored at the given key and deserialises it
+// with the ValueCodec of the key's namespace, or the trie's ValueCodec, into
+// the value pointed to by out. ErrKeyNotFound is returned if the key is not
+// present in the trie.
+func (smt *SMTWithStorage) GetTyped(key []byte, out any) error {
+	value, err := smt.getPresentValue(key)
+	if err != nil {
+		return err
+	}
+	return smt.valueCodec(key).Unmarshal(value, out)
+}
+
+// getPresentValue returns the value for the key, or ErrKeyNotFound if the key
+// is not present in the trie. Unlike GetValue, a key stored with an empty
+// value is told apart from an absent key.
+func (smt *SMTWithStorage) getPresentValue(key []byte) ([]byte, error) {
+	defer smt.lockKey(key)()
+	valueHash, err := smt.getValueHash(key)
+	if err != nil {
+		return nil, err
+	}
+	if valueHash == nil {
+		return nil, ErrKeyNotFound
+	}
+	return smt.lookupValue(valueHash)
+}
+
+// valueCodec returns the ValueCodec for the key provided, using the codec of
+// its namespace if set and otherwise the trie's, defaulting to GobCodec
+func (smt *SMTWithStorage) valueCodec(key []byte) ValueCodec {
+	smt.commitMu.RLock()
+	defer smt.commitMu.RUnlock()
+	if ns := smt.namespace(key); ns != nil && ns.Codec != nil {
+		return ns.Codec
+	}
+	if smt.codec == nil {
+		return GobCodec{}
+	}
+	return smt.codec
+}
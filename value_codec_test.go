@@ -0,0 +1,91 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+type testAccount struct {
+	Owner   string
+	Balance uint64
+	Tags    map[string]string
+}
+
+func TestSMTWithStorage_TypedValues(t *testing.T) {
+	tests := []struct {
+		desc  string
+		codec ValueCodec
+	}{
+		{desc: "default codec", codec: nil},
+		{desc: "gob codec", codec: GobCodec{}},
+		{desc: "json codec", codec: JSONCodec{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+			if tt.codec != nil {
+				trie.SetValueCodec(tt.codec)
+			}
+			account := testAccount{Owner: "alice", Balance: 42}
+			require.NoError(t, trie.UpdateTyped([]byte("alice"), account))
+
+			var got testAccount
+			require.NoError(t, trie.GetTyped([]byte("alice"), &got))
+			require.Equal(t, account, got)
+
+			// The stored value is the codec's encoding, so proofs can be
+			// verified against the encoded bytes.
+			encoded, err := trie.valueCodec([]byte("alice")).Marshal(account)
+			require.NoError(t, err)
+			proof, err := trie.Prove([]byte("alice"))
+			require.NoError(t, err)
+			valid, err := VerifyProof(proof, trie.Root(), []byte("alice"), encoded, trie.Spec())
+			require.NoError(t, err)
+			require.True(t, valid)
+
+			require.ErrorIs(t, trie.GetTyped([]byte("bob"), &got), ErrKeyNotFound)
+		})
+	}
+}
+
+// rawCodec is a ValueCodec storing byte slices as they are
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) { return v.([]byte), nil }
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	*v.(*[]byte) = append([]byte{}, data...)
+	return nil
+}
+
+func TestSMTWithStorage_TypedEmptyValue(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	trie.SetValueCodec(rawCodec{})
+
+	// A value encoded as no bytes is present, unlike an absent key
+	require.NoError(t, trie.UpdateTyped([]byte("empty"), []byte{}))
+	for _, commit := range []bool{false, true} {
+		if commit {
+			require.NoError(t, trie.Commit())
+		}
+		got := []byte("stale")
+		require.NoError(t, trie.GetTyped([]byte("empty"), &got))
+		require.Empty(t, got)
+		require.ErrorIs(t, trie.GetTyped([]byte("absent"), &got), ErrKeyNotFound)
+	}
+}
+
+func TestJSONCodec_Deterministic(t *testing.T) {
+	account := testAccount{Tags: map[string]string{"b": "2", "a": "1", "c": "3"}}
+	first, err := JSONCodec{}.Marshal(account)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		again, err := JSONCodec{}.Marshal(account)
+		require.NoError(t, err)
+		require.Equal(t, first, again)
+	}
+}
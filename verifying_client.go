@@ -0,0 +1,93 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Ensure ProofSourceFunc satisfies the ProofSource interface
+var _ ProofSource = (ProofSourceFunc)(nil)
+
+// ProofSource defines how a VerifyingClient retrieves values and proofs from
+// an untrusted proof server. The transport (HTTP, gRPC, in-process, ...) is
+// left to the implementation.
+type ProofSource interface {
+	// FetchProof returns the value stored at the key provided along with a
+	// proof of its membership (or non-membership if the value is empty)
+	// against the root provided.
+	FetchProof(key []byte, root MerkleRoot) (value []byte, proof *SparseMerkleProof, err error)
+}
+
+// ProofSourceFunc is an adapter allowing ordinary functions to be used as
+// a ProofSource.
+type ProofSourceFunc func(key []byte, root MerkleRoot) ([]byte, *SparseMerkleProof, error)
+
+// FetchProof satisfies the ProofSource#FetchProof interface
+func (fn ProofSourceFunc) FetchProof(key []byte, root MerkleRoot) ([]byte, *SparseMerkleProof, error) {
+	return fn(key, root)
+}
+
+// TrustedRootFunc returns the root the client currently trusts. It is
+// invoked on every read so that the trusted root can advance over time.
+type TrustedRootFunc func() (MerkleRoot, error)
+
+// VerifyingClient is a light client over an untrusted ProofSource. Every
+// value it returns has been verified against the trusted root, so callers
+// never need to handle proofs themselves.
+//
+// Only plain (non-sum) tries are supported, as the SMST requires the leaf
+// weight to verify a proof.
+type VerifyingClient struct {
+	source      ProofSource
+	trustedRoot TrustedRootFunc
+	spec        *TrieSpec
+}
+
+// NewVerifyingClient returns a new VerifyingClient fetching proofs from the
+// source provided and verifying them against the roots returned by the
+// trustedRoot function using the spec of the remote trie.
+func NewVerifyingClient(
+	source ProofSource,
+	trustedRoot TrustedRootFunc,
+	spec *TrieSpec,
+) *VerifyingClient {
+	return &VerifyingClient{
+		source:      source,
+		trustedRoot: trustedRoot,
+		spec:        spec,
+	}
+}
+
+// Get returns the value stored at the given key once its proof has been
+// verified against the trusted root. If the key is not present in the trie
+// the default empty value is returned, after verifying the non-membership
+// proof. An error wrapping ErrBadProof is returned if the proof provided by
+// the source does not verify.
+func (c *VerifyingClient) Get(key []byte) ([]byte, error) {
+	if c.spec.sumTrie {
+		return nil, errors.New("verifying client: sum tries are not supported")
+	}
+	root, err := c.trustedRoot()
+	if err != nil {
+		return nil, err
+	}
+	value, proof, err := c.source.FetchProof(key, root)
+	if err != nil {
+		return nil, err
+	}
+	if proof == nil {
+		return nil, errors.Join(ErrBadProof, errors.New("no proof provided"))
+	}
+	valid, err := VerifyProof(proof, root, key, value, c.spec)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errors.Join(ErrBadProof, fmt.Errorf("proof for key %x does not verify against root %x", key, root))
+	}
+	if bytes.Equal(value, defaultEmptyValue) {
+		return defaultEmptyValue, nil
+	}
+	return value, nil
+}
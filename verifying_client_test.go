@@ -0,0 +1,63 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestVerifyingClient_Get(t *testing.T) {
+	trie := NewSMTWithStorage(simplemap.NewSimpleMap(), simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, trie.Update([]byte("baz"), []byte("qux")))
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+
+	honest := ProofSourceFunc(func(key []byte, _ MerkleRoot) ([]byte, *SparseMerkleProof, error) {
+		value, err := trie.GetValue(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		proof, err := trie.Prove(key)
+		return value, proof, err
+	})
+	trusted := func() (MerkleRoot, error) { return root, nil }
+
+	client := NewVerifyingClient(honest, trusted, trie.Spec())
+
+	value, err := client.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), value)
+
+	value, err = client.Get([]byte("missing"))
+	require.NoError(t, err)
+	require.Equal(t, defaultEmptyValue, value)
+
+	// A source lying about the value must be rejected
+	lying := ProofSourceFunc(func(key []byte, root MerkleRoot) ([]byte, *SparseMerkleProof, error) {
+		_, proof, err := honest(key, root)
+		return []byte("forged"), proof, err
+	})
+	client = NewVerifyingClient(lying, trusted, trie.Spec())
+	_, err = client.Get([]byte("foo"))
+	require.ErrorIs(t, err, ErrBadProof)
+
+	// A source hiding a key behind a non-membership claim must be rejected
+	hiding := ProofSourceFunc(func(key []byte, root MerkleRoot) ([]byte, *SparseMerkleProof, error) {
+		_, proof, err := honest(key, root)
+		return nil, proof, err
+	})
+	client = NewVerifyingClient(hiding, trusted, trie.Spec())
+	_, err = client.Get([]byte("foo"))
+	require.ErrorIs(t, err, ErrBadProof)
+
+	// Errors from the trusted root supplier are propagated
+	errNoRoot := errors.New("no root")
+	client = NewVerifyingClient(honest, func() (MerkleRoot, error) { return nil, errNoRoot }, trie.Spec())
+	_, err = client.Get([]byte("foo"))
+	require.ErrorIs(t, err, errNoRoot)
+}
@@ -0,0 +1,65 @@
+package smt
+
+// SaveVersion commits the trie, see Commit, and returns the version and root
+// of the snapshot retaining it. Versions are the heights of the snapshots, so
+// the first version saved is 1. If the root is unchanged since the latest
+// version, that version is returned rather than a new one.
+func (trie *SMTWithSnapshots) SaveVersion() (uint64, MerkleRoot, error) {
+	if err := trie.Commit(); err != nil {
+		return 0, nil, err
+	}
+	latest := trie.state.Snapshots[len(trie.state.Snapshots)-1]
+	return latest.Height, latest.Root, nil
+}
+
+// LatestVersion returns the latest version saved, or zero if no version has
+// been saved.
+func (trie *SMTWithSnapshots) LatestVersion() uint64 {
+	if n := len(trie.state.Snapshots); n > 0 {
+		return trie.state.Snapshots[n-1].Height
+	}
+	return 0
+}
+
+// VersionRoot returns the root of the version provided, returning
+// ErrSnapshotNotFound if the version was never saved or has been pruned.
+func (trie *SMTWithSnapshots) VersionRoot(version uint64) (MerkleRoot, error) {
+	for _, snapshot := range trie.state.Snapshots {
+		if snapshot.Height == version {
+			return snapshot.Root, nil
+		}
+	}
+	return nil, ErrSnapshotNotFound
+}
+
+// GetVersion returns the value hash stored at the key in the version provided,
+// or the default empty value if the key was not present.
+func (trie *SMTWithSnapshots) GetVersion(version uint64, key []byte) ([]byte, error) {
+	snapshot, err := trie.version(version)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.Get(key)
+}
+
+// ProveAtVersion generates a SparseMerkleProof for the key in the version
+// provided, verifiable against the root returned by VersionRoot.
+func (trie *SMTWithSnapshots) ProveAtVersion(version uint64, key []byte) (*SparseMerkleProof, error) {
+	snapshot, err := trie.version(version)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.Prove(key)
+}
+
+// version returns a read-only view of the trie at the version provided
+func (trie *SMTWithSnapshots) version(version uint64) (*SMT, error) {
+	if trie.closed {
+		return nil, ErrClosed
+	}
+	root, err := trie.VersionRoot(version)
+	if err != nil {
+		return nil, err
+	}
+	return trie.Snapshot(root)
+}
@@ -0,0 +1,70 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMTWithSnapshots_Versions(t *testing.T) {
+	nodes, meta := simplemap.NewSimpleMap(), simplemap.NewSimpleMap()
+	trie := NewSMTWithSnapshots(nodes, meta, sha256.New())
+	now := time.Unix(0, 0)
+	trie.now = func() time.Time { return now }
+	require.Equal(t, uint64(0), trie.LatestVersion())
+
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v1")))
+	v1, root1, err := trie.SaveVersion()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), v1)
+	require.Equal(t, trie.Root(), root1)
+
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v2")))
+	require.NoError(t, trie.Update([]byte("bar"), []byte("v2")))
+	v2, root2, err := trie.SaveVersion()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), v2)
+	require.Equal(t, v2, trie.LatestVersion())
+
+	// Saving an unchanged trie returns the latest version
+	v, root, err := trie.SaveVersion()
+	require.NoError(t, err)
+	require.Equal(t, v2, v)
+	require.Equal(t, root2, root)
+
+	// Historical versions are readable and provable
+	got, err := trie.GetVersion(v1, []byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, trie.valueHash([]byte("v1")), got)
+	got, err = trie.GetVersion(v1, []byte("bar"))
+	require.NoError(t, err)
+	require.Equal(t, defaultEmptyValue, got)
+	proof, err := trie.ProveAtVersion(v1, []byte("foo"))
+	require.NoError(t, err)
+	versionRoot, err := trie.VersionRoot(v1)
+	require.NoError(t, err)
+	valid, err := VerifyProof(proof, versionRoot, []byte("foo"), []byte("v1"), trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// Pruned and unknown versions cannot be read
+	now = now.Add(time.Hour)
+	_, err = trie.Prune(PrunePolicy{MaxAge: time.Minute})
+	require.NoError(t, err)
+	_, err = trie.GetVersion(v1, []byte("foo"))
+	require.ErrorIs(t, err, ErrSnapshotNotFound)
+	_, err = trie.ProveAtVersion(3, []byte("foo"))
+	require.ErrorIs(t, err, ErrSnapshotNotFound)
+
+	// Versions survive a reimport
+	imported, err := ImportSMTWithSnapshots(nodes, meta, sha256.New())
+	require.NoError(t, err)
+	require.Equal(t, v2, imported.LatestVersion())
+	got, err = imported.GetVersion(v2, []byte("bar"))
+	require.NoError(t, err)
+	require.Equal(t, trie.valueHash([]byte("v2")), got)
+}
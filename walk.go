@@ -0,0 +1,71 @@
+package smt
+
+import "fmt"
+
+// NodeType is the type of a node of a trie
+type NodeType int
+
+const (
+	// NodeInner is an inner node, with a left and a right child
+	NodeInner NodeType = iota + 1
+	// NodeLeaf is a leaf node, holding the path and value hash of a key
+	NodeLeaf
+	// NodeExtension is an extension node, spanning several path bits along
+	// which its subtrie has a single child
+	NodeExtension
+)
+
+// String returns the name of the node type
+func (nodeType NodeType) String() string {
+	switch nodeType {
+	case NodeInner:
+		return "inner"
+	case NodeLeaf:
+		return "leaf"
+	case NodeExtension:
+		return "extension"
+	}
+	return fmt.Sprintf("unknown node type %d", int(nodeType))
+}
+
+// Walk calls fn with every node reachable from the root of the trie,
+// including its uncommitted changes, parents before their children and left
+// children before right ones, until fn returns an error, which Walk returns.
+// Each node is passed with its depth in path bits, its type, its digest and
+// its serialised data, as stored in the node store under the digest once
+// committed, so tools can inspect a trie without decoding its nodes. Empty
+// subtries are not visited. Persisted nodes are read from the node store
+// without being cached.
+func (smt *SMT) Walk(fn func(depth int, nodeType NodeType, hash, data []byte) error) error {
+	if smt.closed {
+		return ErrClosed
+	}
+	return smt.walk(smt.root, 0, fn)
+}
+
+// walk calls fn with every node of the subtrie rooted at the node provided,
+// at the given depth
+func (smt *SMT) walk(node trieNode, depth int, fn func(depth int, nodeType NodeType, hash, data []byte) error) error {
+	node, err := smt.resolveLazy(node)
+	if err != nil || node == nil {
+		return err
+	}
+	switch n := node.(type) {
+	case *leafNode:
+		return fn(depth, NodeLeaf, smt.digest(n), smt.encode(n))
+	case *extensionNode:
+		if err := fn(depth, NodeExtension, smt.digest(n), smt.encode(n)); err != nil {
+			return err
+		}
+		return smt.walk(n.child, depth+n.length(), fn)
+	case *innerNode:
+		if err := fn(depth, NodeInner, smt.digest(n), smt.encode(n)); err != nil {
+			return err
+		}
+		if err := smt.walk(n.leftChild, depth+1, fn); err != nil {
+			return err
+		}
+		return smt.walk(n.rightChild, depth+1, fn)
+	}
+	return nil
+}
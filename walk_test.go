@@ -0,0 +1,70 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_Walk(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleTrie(nodes, sha256.New())
+	require.NoError(t, trie.Walk(func(int, NodeType, []byte, []byte) error {
+		t.Fatal("empty tries have no nodes")
+		return nil
+	}))
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key%d", i)), []byte("value")))
+	}
+	require.NoError(t, trie.Commit())
+
+	// Every node is visited once, with the data stored under its digest
+	visited := make(map[string]bool)
+	counts := make(map[NodeType]int)
+	first := true
+	require.NoError(t, trie.Walk(func(depth int, nodeType NodeType, hash, data []byte) error {
+		if first {
+			require.Equal(t, 0, depth)
+			require.Equal(t, []byte(trie.Root()), hash)
+			first = false
+		}
+		require.False(t, visited[string(hash)])
+		visited[string(hash)] = true
+		counts[nodeType]++
+		stored, err := nodes.Get(hash)
+		require.NoError(t, err)
+		require.Equal(t, stored, data)
+		require.Equal(t, hash, trie.hashPreimage(data))
+		switch nodeType {
+		case NodeLeaf:
+			require.True(t, isLeafNode(data))
+		case NodeExtension:
+			require.True(t, isExtNode(data))
+		case NodeInner:
+			require.True(t, isInnerNode(data))
+		}
+		return nil
+	}))
+	require.Equal(t, nodes.Len(), len(visited))
+	require.Equal(t, 100, counts[NodeLeaf])
+	require.Equal(t, 99, counts[NodeInner])
+	require.Equal(t, "extension", NodeExtension.String())
+
+	// Errors returned by fn stop the walk
+	stop := errors.New("stop")
+	visits := 0
+	err := trie.Walk(func(int, NodeType, []byte, []byte) error {
+		if visits++; visits == 10 {
+			return stop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, stop)
+	require.Equal(t, 10, visits)
+}